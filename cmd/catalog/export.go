@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/service"
+)
+
+// cmdExport executes the `export` subcommand. It is read-only.
+//
+//	go run ./cmd/catalog export -o catalog.yaml
+func cmdExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	format := fs.String("format", "", "Catalog format: yaml or json (default: from -o extension, else yaml)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	_ = fs.Parse(args)
+
+	f, err := resolveFormat(*format, *output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	config.LoadConfig(*configPath)
+	songService := newSongService()
+
+	catalog, err := songService.ExportCatalog(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export catalog: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := service.EncodeCatalog(catalog, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode catalog: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d songs to %s\n", len(catalog.Songs), *output)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/service"
+)
+
+// cmdImport executes the `import` subcommand. Without -apply it only prints
+// the plan; the import transaction is always rolled back.
+//
+//	go run ./cmd/catalog import -f catalog.yaml           # plan only
+//	go run ./cmd/catalog import -f catalog.yaml -apply    # commit
+func cmdImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	file := fs.String("f", "", "Catalog file to import (required)")
+	format := fs.String("format", "", "Catalog format: yaml or json (default: from -f extension, else yaml)")
+	apply := fs.Bool("apply", false, "Commit the plan (default: print it and roll back)")
+	prune := fs.Bool("prune", false, "Retire live songs that are missing from the catalog")
+	user := fs.String("user", "catalog", "Name recorded as changed_by in the chart history")
+	asJSON := fs.Bool("json", false, "Print the plan as JSON instead of a table")
+	_ = fs.Parse(args)
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "error: -f is required")
+		os.Exit(2)
+	}
+
+	f, err := resolveFormat(*format, *file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read %s: %v\n", *file, err)
+		os.Exit(1)
+	}
+	catalog, err := service.DecodeCatalog(data, f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	config.LoadConfig(*configPath)
+	songService := newSongService()
+
+	plan, err := songService.ImportCatalog(context.Background(), catalog,
+		service.CatalogImportOptions{Apply: *apply, Prune: *prune}, *user)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed, nothing was changed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(plan)
+		return
+	}
+	printPlan(os.Stdout, plan)
+}
+
+// printPlan renders the plan as a table followed by a one-line summary.
+func printPlan(w io.Writer, plan *model.CatalogPlan) {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(w, "Catalog is up to date, nothing to do.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tWIKI_ID\tDIFFICULTY\tFIELD\tOLD\tNEW")
+	counts := make(map[string]int)
+	for _, c := range plan.Changes {
+		counts[c.Action]++
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			c.Action, c.WikiID, orDash(string(c.Difficulty)), orDash(c.Field),
+			orDash(deref(c.OldValue)), orDash(deref(c.NewValue)))
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\n%d create, %d update, %d level change, %d retire\n",
+		counts[model.CatalogActionCreate], counts[model.CatalogActionUpdate],
+		counts[model.CatalogActionLevelChange], counts[model.CatalogActionRetire])
+	if plan.Applied {
+		fmt.Fprintln(w, "Applied.")
+	} else {
+		fmt.Fprintln(w, "Plan only, nothing was changed. Re-run with -apply to commit.")
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
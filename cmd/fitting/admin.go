@@ -0,0 +1,195 @@
+package main
+
+// The admin server is an optional HTTP control plane for `fitting run` in
+// continuous mode, enabled by config.fitting.admin_addr. Like the probe
+// server's metrics server it is meant for a private port:
+//
+//	GET  /healthz      liveness, with a database ping (503 when it fails)
+//	GET  /status       leadership, current run progress, last run report, next tick
+//	POST /runs         run now (?full=true for a full sweep); 409 while a run is in progress or on standby
+//	POST /runs/cancel  cancel the run in progress; 409 when idle
+//	GET  /metrics      Prometheus metrics, including fitting_run_* (internal/metrics)
+//
+// With config.fitting.admin_token set, the POST endpoints require
+// "Authorization: Bearer <token>".
+//
+// An instance on standby, waiting for the fitting lease held by another
+// one, serves the same endpoints with "leader": false in /status.
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/fitting"
+	"paradigm-reboot-prober-go/internal/metrics"
+
+	"gorm.io/gorm"
+)
+
+// adminReportJSON is the layout of a RunReport in admin responses.
+type adminReportJSON struct {
+	RunID             int        `json:"run_id,omitempty"`
+	StartedAt         time.Time  `json:"started_at"`
+	CompletedAt       time.Time  `json:"completed_at"`
+	DurationMs        int64      `json:"duration_ms"`
+	Incremental       bool       `json:"incremental"`
+	Watermark         *time.Time `json:"watermark,omitempty"`
+	PlayersConsidered int        `json:"players_considered"`
+	PlayersChanged    int        `json:"players_changed"`
+	ChartsTotal       int        `json:"charts_total"`
+	ChartsProcessed   int        `json:"charts_processed"`
+	ChartsPublished   int        `json:"charts_published"`
+	ChartsAbstained   int        `json:"charts_abstained"`
+	ChartsEmpty       int        `json:"charts_empty"`
+	ChartsSkipped     int        `json:"charts_skipped"`
+	OutliersReported  int        `json:"outliers_reported"`
+	ErrorsEncountered int        `json:"errors_encountered"`
+}
+
+// adminRunJSON is the layout of a fitting.ScheduledRun in admin responses.
+type adminRunJSON struct {
+	Trigger   string           `json:"trigger"`
+	Full      bool             `json:"full"`
+	StartedAt time.Time        `json:"started_at"`
+	Error     string           `json:"error,omitempty"`
+	Cancelled bool             `json:"cancelled,omitempty"`
+	Report    *adminReportJSON `json:"report,omitempty"` // finished runs only
+}
+
+// adminStatusJSON is the layout of GET /status.
+type adminStatusJSON struct {
+	Leader          bool          `json:"leader"` // holds the fitting lease; false on standby
+	Holder          string        `json:"holder"` // this instance's name in the lease
+	Running         bool          `json:"running"`
+	Current         *adminRunJSON `json:"current,omitempty"`
+	ChartsProcessed int           `json:"charts_processed"` // of the current run
+	ChartsTotal     int           `json:"charts_total"`     // of the current run; 0 until known
+	LastRun         *adminRunJSON `json:"last_run,omitempty"`
+	NextTick        *time.Time    `json:"next_tick,omitempty"`
+	Interval        string        `json:"interval"`
+}
+
+func adminRun(run *fitting.ScheduledRun, finished bool) *adminRunJSON {
+	if run == nil {
+		return nil
+	}
+	out := &adminRunJSON{Trigger: run.Trigger, Full: run.Full, StartedAt: run.Started, Cancelled: run.Cancelled}
+	if run.Err != nil {
+		out.Error = run.Err.Error()
+	}
+	if finished {
+		r := run.Report
+		out.Report = &adminReportJSON{
+			RunID:             r.RunID,
+			StartedAt:         r.Started,
+			CompletedAt:       r.Completed,
+			DurationMs:        r.Duration.Milliseconds(),
+			Incremental:       r.Incremental,
+			Watermark:         r.Watermark,
+			PlayersConsidered: r.PlayersConsidered,
+			PlayersChanged:    r.PlayersChanged,
+			ChartsTotal:       r.ChartsTotal,
+			ChartsProcessed:   r.ChartsProcessed,
+			ChartsPublished:   r.ChartsPublished,
+			ChartsAbstained:   r.ChartsAbstained,
+			ChartsEmpty:       r.ChartsEmpty,
+			ChartsSkipped:     r.ChartsSkipped,
+			OutliersReported:  r.OutliersReported,
+			ErrorsEncountered: r.ErrorsEncountered,
+		}
+	}
+	return out
+}
+
+// newAdminHandler builds the admin server's routes.
+func newAdminHandler(s *fitting.Scheduler, lease *fitting.Lease, db *gorm.DB, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			adminJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+			return
+		}
+		adminJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		st := s.Status()
+		out := adminStatusJSON{
+			Leader:          lease.Held(),
+			Holder:          lease.Holder(),
+			Running:         st.Current != nil,
+			Current:         adminRun(st.Current, false),
+			ChartsProcessed: st.ChartsProcessed,
+			ChartsTotal:     st.ChartsTotal,
+			LastRun:         adminRun(st.Last, true),
+			Interval:        st.Interval.String(),
+		}
+		if !st.NextTick.IsZero() {
+			out.NextTick = &st.NextTick
+		}
+		adminJSON(w, http.StatusOK, out)
+	})
+	mux.HandleFunc("POST /runs", adminAuth(token, func(w http.ResponseWriter, r *http.Request) {
+		full := false
+		if v := r.URL.Query().Get("full"); v != "" {
+			var err error
+			if full, err = strconv.ParseBool(v); err != nil {
+				adminJSON(w, http.StatusBadRequest, map[string]string{"error": "full must be a boolean"})
+				return
+			}
+		}
+		if !lease.Held() {
+			adminJSON(w, http.StatusConflict, map[string]string{"error": "this instance is on standby; another one holds the fitting lease"})
+			return
+		}
+		if err := s.Trigger(full); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, fitting.ErrRunInProgress) {
+				status = http.StatusConflict
+			}
+			adminJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		adminJSON(w, http.StatusAccepted, map[string]any{"status": "queued", "full": full})
+	}))
+	mux.HandleFunc("POST /runs/cancel", adminAuth(token, func(w http.ResponseWriter, r *http.Request) {
+		if !s.Cancel() {
+			adminJSON(w, http.StatusConflict, map[string]string{"error": "no fitting run in progress"})
+			return
+		}
+		adminJSON(w, http.StatusAccepted, map[string]string{"status": "cancelling"})
+	}))
+	mux.Handle("GET /metrics", metrics.Handler())
+	return mux
+}
+
+// adminAuth requires the bearer token on next when token is set.
+func adminAuth(token string, next http.HandlerFunc) http.HandlerFunc {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			adminJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid admin token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func adminJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
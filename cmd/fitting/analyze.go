@@ -15,6 +15,27 @@ package main
 //  2. The output of fitting.ComputeFitting under several diagnostic Params
 //     configurations (status quo vs candidate fixes), side-by-side.
 //
+// With -votes it instead compares the community level votes (chart_votes)
+// with the stored chart_statistics of every voted chart, or of -chart only:
+//
+//	go run ./cmd/fitting analyze -votes -min-votes 5
+//
+// With -estimators it fits every chart with each fitting.Estimator (the
+// per-chart robust one and the joint player–chart one) and prints where
+// they disagree most, or their results for -chart only:
+//
+//	go run ./cmd/fitting analyze -estimators -top 30
+//
+// With -format json or csv it instead exports every sample of the -chart
+// list, or of every chart within -level-min / -level-max, with its inferred
+// level and each weight component (proximity, volume, score quality, age,
+// Tukey), built the way a run builds them:
+//
+//	go run ./cmd/fitting analyze -format csv -level-min 15 -level-max 16 -report samples.csv
+//
+// Every mode honours -params, a YAML file of config.fitting keys overriding
+// config.yaml (as for `run -dry-run`).
+//
 // The subcommand writes nothing back to the database. It is safe to run
 // against production. It is intentionally not driven by any scheduler — it
 // exists to debug distribution problems uncovered during tuning. If the
@@ -23,11 +44,16 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 
 	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/fitting"
@@ -38,30 +64,85 @@ import (
 func cmdAnalyze(args []string) {
 	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
 	configPath := fs.String("config", "config/config.yaml", "Path to config file")
-	chartID := fs.Int("chart", 0, "Chart ID to analyze (required)")
+	chartList := fs.String("chart", "", "Chart ID to analyze (required unless -votes, -estimators or a level range); a comma-separated list with -format json|csv")
+	votes := fs.Bool("votes", false, "Compare community level votes with chart_statistics instead")
+	minVotes := fs.Int("min-votes", 3, "With -votes, skip charts with fewer votes")
+	compare := fs.Bool("estimators", false, "Compare the levels of every fitting estimator instead")
+	top := fs.Int("top", 30, "With -estimators, number of charts with the largest gaps to print")
+	paramsPath := fs.String("params", "", "YAML file of config.fitting keys overriding config.yaml")
+	format := fs.String("format", "text", "Output: text, or json / csv with the weights of every sample")
+	reportPath := fs.String("report", "", "With -format json|csv, write to this file (default stdout)")
+	levelMin := fs.Float64("level-min", 0, "With -format json|csv, only charts with an official level >= this")
+	levelMax := fs.Float64("level-max", 0, "With -format json|csv, only charts with an official level <= this (0 = no upper bound)")
 	_ = fs.Parse(args)
-	if *chartID == 0 {
+
+	chartIDs, err := analyzeChartIDs(*chartList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	levelRange := *levelMin > 0 || *levelMax > 0
+	export := *format == "json" || *format == "csv"
+	switch {
+	case !export && *format != "text":
+		fmt.Fprintf(os.Stderr, "error: unknown format %q: must be text, json or csv\n", *format)
+		os.Exit(2)
+	case export && (*votes || *compare):
+		fmt.Fprintln(os.Stderr, "error: -format json|csv cannot be combined with -votes or -estimators")
+		os.Exit(2)
+	case export && len(chartIDs) == 0 && !levelRange:
+		fmt.Fprintln(os.Stderr, "error: -chart or -level-min/-level-max is required")
+		os.Exit(2)
+	case !export && (levelRange || *reportPath != ""):
+		fmt.Fprintln(os.Stderr, "error: -level-min, -level-max and -report require -format json or csv")
+		os.Exit(2)
+	case !export && len(chartIDs) > 1:
+		fmt.Fprintln(os.Stderr, "error: a list of charts requires -format json or csv")
+		os.Exit(2)
+	case !export && len(chartIDs) == 0 && !*votes && !*compare:
 		fmt.Fprintln(os.Stderr, "error: -chart is required")
 		os.Exit(2)
 	}
 
 	config.LoadConfig(*configPath)
+	if *paramsPath != "" {
+		if err := overrideFittingParams(*paramsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+	}
 	util.InitDB()
 
 	ctx := context.Background()
+	if export {
+		analyzeExport(ctx, chartIDs, *levelMin, *levelMax, *format, *reportPath)
+		return
+	}
+	chartID := 0
+	if len(chartIDs) == 1 {
+		chartID = chartIDs[0]
+	}
+	if *votes {
+		analyzeVotes(ctx, chartID, *minVotes)
+		return
+	}
+	if *compare {
+		analyzeEstimators(ctx, chartID, *top)
+		return
+	}
 
 	// 1. Load chart metadata.
 	var chart model.Chart
 	if err := util.DB.WithContext(ctx).
 		Select("id, level, song_id, difficulty").
-		First(&chart, *chartID).Error; err != nil {
-		fmt.Fprintf(os.Stderr, "failed to load chart %d: %v\n", *chartID, err)
+		First(&chart, chartID).Error; err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load chart %d: %v\n", chartID, err)
 		os.Exit(1)
 	}
 	fmt.Printf("=== chart %d | level=%.1f | difficulty=%s ===\n\n", chart.ID, chart.Level, chart.Difficulty)
 
 	// 2. Load samples and skills (inline queries — we don't need paging for a single chart).
-	samples := analyzeLoadSamples(ctx, *chartID)
+	samples := analyzeLoadSamples(ctx, chartID)
 	fmt.Printf("total raw samples: %d\n\n", len(samples))
 
 	// 3. Bucket breakdown: who's playing, what they're scoring, what their skill is.
@@ -74,27 +155,10 @@ func cmdAnalyze(args []string) {
 		filter func(fitting.Sample) bool // optional pre-filter applied BEFORE ComputeFitting
 	}
 	// The "base" Params below are pulled from the loaded config (same values the
-	// `run` subcommand uses in production), so the diagnostic reflects the
-	// shipping algorithm. To probe knob changes, derive a Params from `base` and
+	// `run` subcommand uses in production, after -params overrides), so the
+	// diagnostic reflects the shipping algorithm. To probe knob changes, derive a Params from `base` and
 	// override just the field(s) under investigation.
-	fp := config.GlobalConfig.Fitting
-	base := fitting.Params{
-		MinEffectiveSamples: fp.MinSamples,
-		SkillTopK:           fp.SkillTopK,
-		SampleHalflifeDays:  fp.SampleHalflifeDays,
-		ProximitySigma:      fp.ProximitySigma,
-		HighSkillSigmaRatio: fp.HighSkillSigmaRatio,
-		VolumeFullAt:        fp.VolumeFullAt,
-		PriorStrength:       fp.PriorStrength,
-		DeviationPenalty:    fp.DeviationPenalty,
-		MaxDeviation:        fp.MaxDeviation,
-		MaxDeviationLow:     fp.MaxDeviationLow,
-		MaxDeviationLowAt:   fp.MaxDeviationLowAt,
-		MaxDeviationHighAt:  fp.MaxDeviationHighAt,
-		MinScore:            fp.MinScore,
-		TukeyK:              fp.TukeyK,
-		MinPlayerRecords:    fp.MinPlayerRecords,
-	}
+	base := fittingParams()
 	withRatio := func(p fitting.Params, r float64) fitting.Params { p.HighSkillSigmaRatio = r; return p }
 	flatCap := base // pre-ramp behaviour (flat ±MaxDeviation); useful for before/after comparison
 	flatCap.MaxDeviationLow = 0
@@ -306,6 +370,476 @@ func analyzePrintBuckets(official float64, samples []fitting.Sample) {
 	}
 }
 
+// analyzeVotes prints, per chart with at least minVotes community votes, the
+// community level (official + mean vote) next to the fitted level stored in
+// chart_statistics, followed by how well the two agree overall. Agreement is
+// measured on the deviations from the official level, which is what both
+// signals estimate.
+func analyzeVotes(ctx context.Context, chartID, minVotes int) {
+	type row struct {
+		ChartID      int
+		Title        string
+		Difficulty   string
+		Level        float64
+		VoteCount    int
+		MeanDelta    float64
+		FittingLevel *float64
+		SampleCount  *int
+	}
+	q := util.DB.WithContext(ctx).
+		Table("chart_votes").
+		Select("charts.id AS chart_id, songs.title AS title, charts.difficulty AS difficulty, charts.level AS level, "+
+			"COUNT(*) AS vote_count, AVG(chart_votes.delta) AS mean_delta, "+
+			"chart_statistics.fitting_level AS fitting_level, chart_statistics.sample_count AS sample_count").
+		Joins("JOIN charts ON charts.id = chart_votes.chart_id").
+		Joins("JOIN songs ON songs.id = charts.song_id").
+		Joins("LEFT JOIN chart_statistics ON chart_statistics.chart_id = charts.id").
+		Where("charts.deleted_at IS NULL AND songs.deleted_at IS NULL").
+		Group("charts.id, songs.title, charts.difficulty, charts.level, chart_statistics.fitting_level, chart_statistics.sample_count").
+		Having("COUNT(*) >= ?", minVotes).
+		Order("charts.level DESC, charts.id")
+	if chartID != 0 {
+		q = q.Where("charts.id = ?", chartID)
+	}
+	var rows []row
+	if err := q.Scan(&rows).Error; err != nil {
+		fmt.Fprintf(os.Stderr, "fetch votes failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("=== community votes vs fitting (charts with >= %d votes) ===\n\n", minVotes)
+	fmt.Printf("%-6s %-28s %-9s %-6s %-6s %-8s %-9s %-8s %-8s\n",
+		"chart", "title", "diff", "level", "votes", "vote_d", "community", "fit", "gap")
+	fmt.Println(analyzeRepeat("-", 96))
+
+	var voteDev, fitDev []float64
+	for _, r := range rows {
+		title := []rune(r.Title)
+		if len(title) > 28 {
+			title = append(title[:27], '…')
+		}
+		fit, gap := "nil", "-"
+		if r.FittingLevel != nil {
+			fit = fmt.Sprintf("%.3f", *r.FittingLevel)
+			gap = fmt.Sprintf("%+.3f", r.Level+r.MeanDelta-*r.FittingLevel)
+			voteDev = append(voteDev, r.MeanDelta)
+			fitDev = append(fitDev, *r.FittingLevel-r.Level)
+		}
+		fmt.Printf("%-6d %-28s %-9s %-6.1f %-6d %-+8.3f %-9.3f %-8s %-8s\n",
+			r.ChartID, string(title), r.Difficulty, r.Level, r.VoteCount, r.MeanDelta, r.Level+r.MeanDelta, fit, gap)
+	}
+
+	fmt.Printf("\ncharts with votes: %d, with a fitted level: %d\n", len(rows), len(voteDev))
+	if len(voteDev) == 0 {
+		return
+	}
+	var sumAbs, sumSigned float64
+	for i := range voteDev {
+		d := voteDev[i] - fitDev[i]
+		sumAbs += math.Abs(d)
+		sumSigned += d
+	}
+	n := float64(len(voteDev))
+	fmt.Printf("mean |community - fit|: %.3f\n", sumAbs/n)
+	fmt.Printf("mean (community - fit): %+.3f  (positive: players find charts harder than fitting says)\n", sumSigned/n)
+	if r, ok := analyzePearson(voteDev, fitDev); ok {
+		fmt.Printf("correlation of deviations from official level: %.3f\n", r)
+	}
+}
+
+// analyzeEstimators fits every chart with each fitting.Estimator using the
+// configured Params and compares the other estimators with the robust one:
+// the charts where they differ most (or chartID only, with the sample size
+// and interval of each), then how much they agree overall.
+func analyzeEstimators(ctx context.Context, chartID, top int) {
+	type chart struct {
+		ID         int
+		Title      string
+		Difficulty string
+		Level      float64
+	}
+	var charts []chart
+	q := util.DB.WithContext(ctx).
+		Table("charts").
+		Select("charts.id AS id, songs.title AS title, charts.difficulty AS difficulty, charts.level AS level").
+		Joins("JOIN songs ON songs.id = charts.song_id").
+		Where("charts.deleted_at IS NULL AND songs.deleted_at IS NULL").
+		Order("charts.id")
+	if chartID != 0 {
+		q = q.Where("charts.id = ?", chartID)
+	}
+	if err := q.Scan(&charts).Error; err != nil {
+		fmt.Fprintf(os.Stderr, "fetch charts failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	params := fittingParams()
+	data, err := fitting.LoadDataset(ctx, util.DB, fitting.RunnerConfig{ChartBatchSize: config.GlobalConfig.Fitting.ChartBatchSize})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load best records failed: %v\n", err)
+		os.Exit(1)
+	}
+	ests := fitting.Estimators()
+	results := make(map[string]map[int]fitting.Result, len(ests))
+	for _, est := range ests {
+		if results[est.Name()], err = est.Fit(ctx, data, params); err != nil {
+			fmt.Fprintf(os.Stderr, "%s estimator failed: %v\n", est.Name(), err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("=== estimators (%d best records, published: %s) ===\n\n", data.Records(), params.Estimator)
+
+	if chartID != 0 {
+		if len(charts) == 0 {
+			fmt.Fprintf(os.Stderr, "chart %d not found\n", chartID)
+			os.Exit(1)
+		}
+		c := charts[0]
+		fmt.Printf("chart %d | %s | %s | level=%.1f\n\n", c.ID, c.Title, c.Difficulty, c.Level)
+		fmt.Printf("%-8s %-8s %-8s %-8s %-8s %-8s %-8s\n", "estim.", "raw", "nEff", "sd", "fit", "lower", "upper")
+		fmt.Println(analyzeRepeat("-", 60))
+		for _, est := range ests {
+			r := results[est.Name()][c.ID]
+			fmt.Printf("%-8s %-8d %-8.1f %-8.3f %-8s %-8s %-8s\n", est.Name(), r.SampleCount, r.EffectiveSampleSize,
+				r.StdDev, analyzeLevel(r.FittingLevel), analyzeLevel(r.FittingLevelLower), analyzeLevel(r.FittingLevelUpper))
+		}
+		return
+	}
+
+	for _, est := range ests {
+		if est.Name() == fitting.EstimatorRobust {
+			continue
+		}
+		name := est.Name()
+		type gap struct {
+			chart         chart
+			robust, other float64
+		}
+		var gaps []gap
+		var robustDev, otherDev []float64
+		onlyRobust, onlyOther := 0, 0
+		for _, c := range charts {
+			r, o := results[fitting.EstimatorRobust][c.ID].FittingLevel, results[name][c.ID].FittingLevel
+			switch {
+			case r != nil && o != nil:
+				gaps = append(gaps, gap{chart: c, robust: *r, other: *o})
+				robustDev = append(robustDev, *r-c.Level)
+				otherDev = append(otherDev, *o-c.Level)
+			case r != nil:
+				onlyRobust++
+			case o != nil:
+				onlyOther++
+			}
+		}
+		sort.SliceStable(gaps, func(i, j int) bool {
+			return math.Abs(gaps[i].other-gaps[i].robust) > math.Abs(gaps[j].other-gaps[j].robust)
+		})
+
+		fmt.Printf("--- %s vs %s: largest gaps ---\n\n", name, fitting.EstimatorRobust)
+		fmt.Printf("%-6s %-28s %-9s %-6s %-8s %-8s %-8s\n", "chart", "title", "diff", "level", "robust", name, "gap")
+		fmt.Println(analyzeRepeat("-", 80))
+		for _, g := range gaps[:min(top, len(gaps))] {
+			title := []rune(g.chart.Title)
+			if len(title) > 28 {
+				title = append(title[:27], '…')
+			}
+			fmt.Printf("%-6d %-28s %-9s %-6.1f %-8.3f %-8.3f %-+8.3f\n",
+				g.chart.ID, string(title), g.chart.Difficulty, g.chart.Level, g.robust, g.other, g.other-g.robust)
+		}
+
+		fmt.Printf("\ncharts fitted by both: %d, by %s only: %d, by %s only: %d\n",
+			len(gaps), fitting.EstimatorRobust, onlyRobust, name, onlyOther)
+		if len(gaps) == 0 {
+			fmt.Println()
+			continue
+		}
+		var sumAbs, sumSigned, maxAbs float64
+		for _, g := range gaps {
+			d := g.other - g.robust
+			sumAbs += math.Abs(d)
+			sumSigned += d
+			maxAbs = math.Max(maxAbs, math.Abs(d))
+		}
+		n := float64(len(gaps))
+		fmt.Printf("mean |%s - robust|: %.3f, max: %.3f\n", name, sumAbs/n, maxAbs)
+		fmt.Printf("mean (%s - robust): %+.3f\n", name, sumSigned/n)
+		if r, ok := analyzePearson(robustDev, otherDev); ok {
+			fmt.Printf("correlation of deviations from official level: %.3f\n", r)
+		}
+		fmt.Println()
+	}
+}
+
+// analyzeChartIDs parses the -chart flag: empty, one ID or a comma-separated
+// list of IDs.
+func analyzeChartIDs(list string) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var ids []int
+	for _, f := range strings.Split(list, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid chart ID %q in -chart", f)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// analyzeSampleJSON is the layout of one sample in the JSON export; the
+// weight components are described by fitting.SampleWeights.
+type analyzeSampleJSON struct {
+	Username      string   `json:"username"`
+	Score         int      `json:"score"`
+	PlayerSkill   float64  `json:"player_skill"`
+	PlayerRecords int      `json:"player_records"`
+	AgeDays       float64  `json:"age_days"`
+	Status        string   `json:"status"`
+	InferredLevel *float64 `json:"inferred_level"` // null when the score could not be inverted
+	Proximity     float64  `json:"proximity"`
+	Volume        float64  `json:"volume"`
+	ScoreQuality  float64  `json:"score_quality"`
+	Age           float64  `json:"age"`
+	PreWeight     float64  `json:"pre_weight"`
+	Tukey         float64  `json:"tukey"`
+	Weight        float64  `json:"weight"`
+}
+
+// analyzeChartJSON is the layout of one chart in the JSON export.
+type analyzeChartJSON struct {
+	ChartID             int                 `json:"chart_id"`
+	Title               string              `json:"title"`
+	Difficulty          string              `json:"difficulty"`
+	Level               float64             `json:"level"`
+	PublishedLevel      *float64            `json:"published_level"`
+	FittingLevel        *float64            `json:"fitting_level"`
+	FittingLevelLower   *float64            `json:"fitting_level_lower"`
+	FittingLevelUpper   *float64            `json:"fitting_level_upper"`
+	SampleCount         int                 `json:"sample_count"`
+	EffectiveSampleSize float64             `json:"effective_sample_size"`
+	WeightedMean        float64             `json:"weighted_mean"`
+	WeightedMedian      float64             `json:"weighted_median"`
+	StdDev              float64             `json:"std_dev"`
+	MAD                 float64             `json:"mad"`
+	Samples             []analyzeSampleJSON `json:"samples"`
+}
+
+// analyzeJSON is the layout of the JSON export.
+type analyzeJSON struct {
+	Params  fitting.Params     `json:"params"`
+	Records int                `json:"records"` // best records loaded to compute player skills
+	Charts  []analyzeChartJSON `json:"charts"`
+}
+
+// analyzeChartMeta is the song title and difficulty of an exported chart.
+type analyzeChartMeta struct {
+	ID         int
+	Title      string
+	Difficulty string
+}
+
+// analyzeExport writes every sample of the selected charts — chartIDs, or
+// every chart when empty, within [levelMin, levelMax] — with its inferred
+// level and weight components, as JSON or CSV. Samples are built the way a
+// run builds them (fitting.Dataset.Explain), with the configured Params.
+func analyzeExport(ctx context.Context, chartIDs []int, levelMin, levelMax float64, format, reportPath string) {
+	// Open the report first so a bad path fails before loading every record.
+	out := io.Writer(os.Stdout)
+	if reportPath != "" {
+		f, err := os.Create(reportPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	params := fittingParams()
+	data, err := fitting.LoadDataset(ctx, util.DB, fitting.RunnerConfig{ChartBatchSize: config.GlobalConfig.Fitting.ChartBatchSize})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load best records failed: %v\n", err)
+		os.Exit(1)
+	}
+	wanted := make(map[int]bool, len(chartIDs))
+	for _, id := range chartIDs {
+		wanted[id] = true
+	}
+	charts := data.Explain(params, func(id int, level float64) bool {
+		if len(wanted) > 0 && !wanted[id] {
+			return false
+		}
+		return level >= levelMin && (levelMax <= 0 || level <= levelMax)
+	})
+	for _, c := range charts {
+		delete(wanted, c.ChartID)
+	}
+	for _, id := range chartIDs {
+		if wanted[id] {
+			fmt.Fprintf(os.Stderr, "warning: chart %d not found or outside the level range\n", id)
+		}
+	}
+
+	ids := make([]int, len(charts))
+	for i, c := range charts {
+		ids[i] = c.ChartID
+	}
+	var metas []analyzeChartMeta
+	if len(ids) > 0 {
+		if err := util.DB.WithContext(ctx).
+			Table("charts").
+			Select("charts.id AS id, songs.title AS title, charts.difficulty AS difficulty").
+			Joins("JOIN songs ON songs.id = charts.song_id").
+			Where("charts.id IN ?", ids).
+			Scan(&metas).Error; err != nil {
+			fmt.Fprintf(os.Stderr, "fetch charts failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	meta := make(map[int]analyzeChartMeta, len(metas))
+	for _, m := range metas {
+		meta[m.ID] = m
+	}
+
+	if format == "csv" {
+		err = writeAnalyzeCSV(out, charts, meta)
+	} else {
+		err = writeAnalyzeJSON(out, params, data.Records(), charts, meta)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write report failed: %v\n", err)
+		os.Exit(1)
+	}
+	samples := 0
+	for _, c := range charts {
+		samples += len(c.Samples)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d charts, %d samples\n", len(charts), samples)
+}
+
+func writeAnalyzeJSON(w io.Writer, params fitting.Params, records int, charts []fitting.ChartExplanation, meta map[int]analyzeChartMeta) error {
+	doc := analyzeJSON{Params: params, Records: records, Charts: make([]analyzeChartJSON, 0, len(charts))}
+	for _, c := range charts {
+		r := c.Result
+		out := analyzeChartJSON{
+			ChartID:             c.ChartID,
+			Title:               meta[c.ChartID].Title,
+			Difficulty:          meta[c.ChartID].Difficulty,
+			Level:               c.Level,
+			PublishedLevel:      c.FittingLevel,
+			FittingLevel:        r.FittingLevel,
+			FittingLevelLower:   r.FittingLevelLower,
+			FittingLevelUpper:   r.FittingLevelUpper,
+			SampleCount:         r.SampleCount,
+			EffectiveSampleSize: r.EffectiveSampleSize,
+			WeightedMean:        r.WeightedMean,
+			WeightedMedian:      r.WeightedMedian,
+			StdDev:              r.StdDev,
+			MAD:                 r.MAD,
+			Samples:             make([]analyzeSampleJSON, 0, len(c.Samples)),
+		}
+		for _, s := range c.Samples {
+			out.Samples = append(out.Samples, analyzeSampleJSON{
+				Username:      s.Username,
+				Score:         s.Score,
+				PlayerSkill:   s.PlayerSkill,
+				PlayerRecords: s.PlayerRecords,
+				AgeDays:       s.AgeDays,
+				Status:        s.Status,
+				InferredLevel: analyzeInferred(s),
+				Proximity:     s.Proximity,
+				Volume:        s.Volume,
+				ScoreQuality:  s.ScoreQuality,
+				Age:           s.Age,
+				PreWeight:     s.PreWeight,
+				Tukey:         s.Tukey,
+				Weight:        s.Weight,
+			})
+		}
+		doc.Charts = append(doc.Charts, out)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeAnalyzeCSV writes one row per sample, with its chart's columns
+// repeated. Missing levels are empty cells.
+func writeAnalyzeCSV(w io.Writer, charts []fitting.ChartExplanation, meta map[int]analyzeChartMeta) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"chart_id", "title", "difficulty", "level", "published_level", "fitting_level",
+		"username", "score", "player_skill", "player_records", "age_days", "status",
+		"inferred_level", "proximity", "volume", "score_quality", "age", "pre_weight", "tukey", "weight",
+	})
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	opt := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return num(*v)
+	}
+	for _, c := range charts {
+		for _, s := range c.Samples {
+			_ = cw.Write([]string{
+				strconv.Itoa(c.ChartID), meta[c.ChartID].Title, meta[c.ChartID].Difficulty,
+				num(c.Level), opt(c.FittingLevel), opt(c.Result.FittingLevel),
+				s.Username, strconv.Itoa(s.Score), num(s.PlayerSkill), strconv.Itoa(s.PlayerRecords), num(s.AgeDays), s.Status,
+				opt(analyzeInferred(s)), num(s.Proximity), num(s.Volume), num(s.ScoreQuality), num(s.Age),
+				num(s.PreWeight), num(s.Tukey), num(s.Weight),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// analyzeInferred returns the inferred level of a sample, nil when its
+// score was not inverted.
+func analyzeInferred(s fitting.SampleWeights) *float64 {
+	switch s.Status {
+	case fitting.SampleBelowMinScore, fitting.SampleNotInvertible, fitting.SampleFewRecords:
+		return nil
+	}
+	level := s.InferredLevel
+	return &level
+}
+
+// analyzeLevel formats an optional level.
+func analyzeLevel(level *float64) string {
+	if level == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%.3f", *level)
+}
+
+// analyzePearson returns the Pearson correlation of xs and ys; ok is false
+// when it is undefined (fewer than two points or zero variance).
+func analyzePearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 {
+		return 0, false
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, false
+	}
+	return sxy / math.Sqrt(sxx*syy), true
+}
+
 func analyzeRepeat(s string, n int) string {
 	out := make([]byte, 0, len(s)*n)
 	for i := 0; i < n; i++ {
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/fitting"
+	"paradigm-reboot-prober-go/internal/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+// evalCandidate is one point of a grid search: the config.fitting keys it
+// overrides, and the resulting Params.
+type evalCandidate struct {
+	label  string
+	params fitting.Params
+	result fitting.EvalResult
+}
+
+// cmdEvaluate executes the `evaluate` subcommand: it cross-validates the
+// configured Params (or every point of a -grid) on best_play_records and
+// prints the error of the fitted levels as skill predictors. Read-only.
+func cmdEvaluate(args []string) {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	paramsPath := fs.String("params", "", "YAML file of config.fitting keys overriding config.yaml")
+	gridPath := fs.String("grid", "", "YAML file mapping config.fitting keys to lists of values; every combination is evaluated")
+	folds := fs.Int("folds", 5, "Number of cross-validation folds")
+	holdout := fs.Float64("holdout", 0, "Hold out this fraction of the records once instead of cross-validating")
+	seed := fs.Uint64("seed", 1, "Seed of the train/test split")
+	top := fs.Int("top", 20, "With -grid, number of ranked candidates to print")
+	_ = fs.Parse(args)
+	if *holdout < 0 || *holdout >= 1 {
+		fmt.Fprintln(os.Stderr, "error: -holdout must be in [0, 1)")
+		os.Exit(2)
+	}
+	if *holdout == 0 && *folds < 2 {
+		fmt.Fprintln(os.Stderr, "error: -folds must be at least 2")
+		os.Exit(2)
+	}
+
+	config.LoadConfig(*configPath)
+	if *paramsPath != "" {
+		if err := overrideFittingParams(*paramsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	var grid []evalCandidate
+	if *gridPath != "" {
+		var err error
+		if grid, err = loadEvalGrid(*gridPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	util.InitDB()
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// The scored records are picked with the base config, so every candidate
+	// of a grid is measured on the same held-out set.
+	fp := config.GlobalConfig.Fitting
+	evalCfg := fitting.EvalConfig{
+		Folds:            *folds,
+		Holdout:          *holdout,
+		Seed:             *seed,
+		MinScore:         fp.MinScore,
+		MinPlayerRecords: fp.MinPlayerRecords,
+	}
+	data, err := fitting.LoadDataset(ctx, util.DB, fitting.RunnerConfig{ChartBatchSize: fp.ChartBatchSize})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load best records failed: %v\n", err)
+		os.Exit(1)
+	}
+	split := fmt.Sprintf("%d-fold cross-validation", *folds)
+	if *holdout > 0 {
+		split = fmt.Sprintf("%.0f%% holdout", *holdout*100)
+	}
+	fmt.Printf("=== %d best records | %s | seed %d ===\n\n", data.Records(), split, *seed)
+
+	if grid == nil {
+		grid = []evalCandidate{{label: "config", params: fittingParams()}}
+	}
+	for i := range grid {
+		res, err := data.Evaluate(ctx, grid[i].params, evalCfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "evaluate %s failed: %v\n", grid[i].label, err)
+			os.Exit(1)
+		}
+		grid[i].result = res
+		if len(grid) > 1 {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: mae %.3f\n", i+1, len(grid), grid[i].label, res.Overall.Fitted.MAE)
+		}
+	}
+	sort.SliceStable(grid, func(i, j int) bool {
+		a, b := grid[i].result.Overall.Fitted, grid[j].result.Overall.Fitted
+		if a.MAE != b.MAE {
+			return a.MAE < b.MAE
+		}
+		return a.RMSE < b.RMSE
+	})
+
+	if len(grid) > 1 {
+		fmt.Printf("%-5s %-8s %-8s %-8s %-9s %-9s %s\n", "rank", "mae", "rmse", "bias", "coverage", "Δmae", "params")
+		fmt.Println(analyzeRepeat("-", 84))
+		for i, c := range grid[:min(*top, len(grid))] {
+			o := c.result.Overall
+			fmt.Printf("%-5d %-8.3f %-8.3f %-+8.3f %-9.1f %-+9.3f %s\n",
+				i+1, o.Fitted.MAE, o.Fitted.RMSE, o.Fitted.Bias, o.Coverage*100, o.Fitted.MAE-o.Official.MAE, c.label)
+		}
+		fmt.Printf("\n=== per level band, rank 1 (%s) ===\n\n", grid[0].label)
+	}
+	evaluatePrintBands(grid[0].result)
+}
+
+// evaluatePrintBands prints the fitted and official errors per level band.
+func evaluatePrintBands(res fitting.EvalResult) {
+	fmt.Printf("%-6s %-8s %-9s %-8s %-8s %-8s %-8s %-8s %-8s\n",
+		"band", "records", "coverage", "fit_mae", "fit_rmse", "fit_bias", "off_mae", "off_rmse", "off_bias")
+	fmt.Println(analyzeRepeat("-", 80))
+	row := func(label string, b fitting.EvalBand) {
+		fmt.Printf("%-6s %-8d %-9.1f %-8.3f %-8.3f %-+8.3f %-8.3f %-8.3f %-+8.3f\n",
+			label, b.Records, b.Coverage*100, b.Fitted.MAE, b.Fitted.RMSE, b.Fitted.Bias,
+			b.Official.MAE, b.Official.RMSE, b.Official.Bias)
+	}
+	for _, b := range res.Bands {
+		row(fmt.Sprintf("%d", b.Band), b)
+	}
+	row("all", res.Overall)
+	fmt.Println("\nerror = SingleRating(level, score) − player skill (top-K average of training records);")
+	fmt.Println("coverage = % of records on charts with a fitted level (the rest fall back to the official level)")
+}
+
+// loadEvalGrid reads a grid file, a YAML mapping of config.fitting keys to
+// lists of values, and expands it into one candidate per combination. Each
+// candidate's Params are built from config.GlobalConfig.Fitting with its
+// values overlaid, and must pass config.ValidateFitting; the global config
+// is left as it was.
+func loadEvalGrid(path string) ([]evalCandidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping of config.fitting keys to lists of values", path)
+	}
+	known := fittingKeys()
+	type axis struct {
+		key    *yaml.Node
+		values []*yaml.Node
+	}
+	var axes []axis
+	pairs := doc.Content[0].Content
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key, values := pairs[i], pairs[i+1]
+		if !known[key.Value] {
+			return nil, fmt.Errorf("%s: unknown config.fitting key %q", path, key.Value)
+		}
+		if values.Kind != yaml.SequenceNode || len(values.Content) == 0 {
+			return nil, fmt.Errorf("%s: %s must be a non-empty list", path, key.Value)
+		}
+		axes = append(axes, axis{key: key, values: values.Content})
+	}
+	if len(axes) == 0 {
+		return nil, fmt.Errorf("%s: empty grid", path)
+	}
+
+	base := config.GlobalConfig.Fitting
+	defer func() { config.GlobalConfig.Fitting = base }()
+	var out []evalCandidate
+	pick := make([]int, len(axes))
+	for {
+		config.GlobalConfig.Fitting = base
+		overlay := &yaml.Node{Kind: yaml.MappingNode}
+		labels := make([]string, len(axes))
+		for i, a := range axes {
+			v := a.values[pick[i]]
+			overlay.Content = append(overlay.Content, a.key, v)
+			labels[i] = a.key.Value + "=" + v.Value
+		}
+		if err := overlay.Decode(&config.GlobalConfig.Fitting); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, strings.Join(labels, " "), err)
+		}
+		if err := config.ValidateFitting(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, strings.Join(labels, " "), err)
+		}
+		out = append(out, evalCandidate{label: strings.Join(labels, " "), params: fittingParams()})
+
+		// Advance the odometer, last axis fastest.
+		i := len(axes) - 1
+		for ; i >= 0; i-- {
+			if pick[i]++; pick[i] < len(axes[i].values) {
+				break
+			}
+			pick[i] = 0
+		}
+		if i < 0 {
+			return out, nil
+		}
+	}
+}
+
+// fittingKeys returns the YAML keys of config.fitting.
+func fittingKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(config.GlobalConfig.Fitting)
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
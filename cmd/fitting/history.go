@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/fitting"
+	"paradigm-reboot-prober-go/internal/util"
+)
+
+// cmdHistory executes the `history` subcommand: without -chart it lists the
+// most recent fitting runs; with -chart it shows how that chart's published
+// level moved from run to run. Read-only.
+func cmdHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	chartID := fs.Int("chart", 0, "Chart ID whose level history to show (default: list runs)")
+	limit := fs.Int("limit", 20, "Number of runs to show")
+	_ = fs.Parse(args)
+
+	config.LoadConfig(*configPath)
+	util.InitDB()
+	ctx := context.Background()
+
+	if *chartID == 0 {
+		runs, err := fitting.RecentRuns(ctx, util.DB, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch runs failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%-6s %-12s %-10s %-20s %-9s %-9s %-9s %-7s %-12s\n",
+			"run", "kind", "status", "started", "duration", "published", "abstained", "errors", "config")
+		fmt.Println(analyzeRepeat("-", 103))
+		for _, run := range runs {
+			kind := run.Kind
+			if run.Incremental {
+				kind = "incremental"
+			}
+			if run.RollbackOf != nil {
+				kind = fmt.Sprintf("rollback→%d", *run.RollbackOf)
+			}
+			fmt.Printf("%-6d %-12s %-10s %-20s %-9s %-9d %-9d %-7d %-12s\n",
+				run.ID, kind, run.Status, run.StartedAt.Local().Format(time.DateTime),
+				(time.Duration(run.DurationMs) * time.Millisecond).String(),
+				run.ChartsPublished, run.ChartsAbstained, run.ErrorsEncountered, run.ConfigHash[:min(12, len(run.ConfigHash))])
+			if run.Error != "" {
+				fmt.Printf("       error: %s\n", run.Error)
+			}
+		}
+		return
+	}
+
+	entries, err := fitting.ChartRunHistory(ctx, util.DB, *chartID, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fetch chart history failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("=== chart %d: fitting level per run (newest first) ===\n\n", *chartID)
+	fmt.Printf("%-6s %-9s %-20s %-6s %-8s %-8s %-8s %-7s %-7s %-6s\n",
+		"run", "kind", "started", "level", "before", "after", "change", "n_eff", "n", "std")
+	fmt.Println(analyzeRepeat("-", 90))
+	for _, e := range entries {
+		change := "-"
+		if e.PreviousLevel != nil && e.FittingLevel != nil {
+			change = fmt.Sprintf("%+.3f", *e.FittingLevel-*e.PreviousLevel)
+		} else if e.PreviousLevel != nil || e.FittingLevel != nil {
+			change = "nil↔"
+		}
+		fmt.Printf("%-6d %-9s %-20s %-6.1f %-8s %-8s %-8s %-7.2f %-7d %-6.3f\n",
+			e.RunID, e.Kind, e.StartedAt.Local().Format(time.DateTime), e.OfficialLevel,
+			historyLevel(e.PreviousLevel), historyLevel(e.FittingLevel), change,
+			e.EffectiveSampleSize, e.SampleCount, e.StdDev)
+	}
+	if len(entries) == 0 {
+		fmt.Println("(no snapshots; the chart was not part of any recorded run)")
+	}
+}
+
+func historyLevel(level *float64) string {
+	if level == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%.3f", *level)
+}
+
+// cmdRollback executes the `rollback` subcommand: it restores the published
+// levels written by an earlier run. The rollback is itself recorded as a run.
+func cmdRollback(args []string) {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	runID := fs.Int("run", 0, "ID of the run whose levels to restore (required; see `fitting history`)")
+	dryRun := fs.Bool("dry-run", false, "Only report what would change")
+	_ = fs.Parse(args)
+	if *runID == 0 {
+		fmt.Fprintln(os.Stderr, "error: -run is required")
+		os.Exit(2)
+	}
+
+	config.LoadConfig(*configPath)
+	util.InitDB()
+
+	report, err := fitting.Rollback(context.Background(), util.DB, *runID, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rollback failed: %v\n", err)
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Printf("dry run: rolling back to run %d would restore %d charts (%d unchanged, %d skipped)\n",
+			*runID, report.Restored, report.Unchanged, report.Skipped)
+		return
+	}
+	fmt.Printf("rolled back to run %d as run %d: %d charts restored, %d unchanged, %d skipped\n",
+		*runID, report.RunID, report.Restored, report.Unchanged, report.Skipped)
+	fmt.Println("note: the next fitting run recomputes all levels; fix its params first if they caused the bad run")
+}
@@ -9,16 +9,30 @@
 //     dependency on internal/service or internal/repository (no HTTP
 //     handlers, no caches, no auth logic).
 //   - Runs on a configurable ticker interval (config.fitting.interval,
-//     typically hours) or once with the `run --once` flag.
+//     typically hours) or once with the `run --once` flag. In continuous
+//     mode an optional admin HTTP server (config.fitting.admin_addr) shows
+//     the status and lets operators trigger or cancel runs (see admin.go).
 //   - Persists results into charts.fitting_level and a dedicated
-//     chart_statistics table for offline analysis.
+//     chart_statistics table for offline analysis, and records every run
+//     with per-chart snapshots in fitting_runs / fitting_run_charts.
 //
 // # Subcommands
 //
 // The binary dispatches on the first positional argument:
 //
-//	fitting run [flags]       continuous or one-shot calculation
-//	fitting analyze [flags]   read-only diagnostic for one chart
+//	fitting run [flags]       continuous or one-shot calculation, or with
+//	                          -dry-run a shadow pass that publishes nothing
+//	fitting analyze [flags]   read-only diagnostic for one chart, or with
+//	                          -votes a comparison with community votes,
+//	                          or with -estimators a comparison of estimators,
+//	                          or with -format json|csv per-sample weights
+//	fitting evaluate [flags]  cross-validate Params on held-out best
+//	                          records, or rank a -grid of them
+//	fitting history [flags]   recent runs, or one chart's level per run
+//	fitting rollback [flags]  restore the levels published by an earlier run
+//	fitting report outliers [flags]
+//	                          players far from the model across charts,
+//	                          as ranked by the latest full run
 //
 // When no subcommand is given, `run` is assumed so that existing
 // invocations such as `./fitting`, `./fitting --once`, or
@@ -27,7 +41,7 @@
 //
 // NOTE: `go run cmd/fitting/main.go …` (single-file path) no longer
 // compiles because this `main` package now spans multiple files
-// (main.go + run.go + analyze.go). Always use the package path
+// (main.go + run.go + admin.go + shadow.go + analyze.go + evaluate.go + history.go + report.go). Always use the package path
 // `./cmd/fitting` for `go run` / `go build`, and the same applies
 // inside Dockerfile build steps.
 package main
@@ -52,6 +66,18 @@ func main() {
 		case "analyze":
 			cmdAnalyze(os.Args[2:])
 			return
+		case "evaluate":
+			cmdEvaluate(os.Args[2:])
+			return
+		case "history":
+			cmdHistory(os.Args[2:])
+			return
+		case "rollback":
+			cmdRollback(os.Args[2:])
+			return
+		case "report":
+			cmdReport(os.Args[2:])
+			return
 		case "-h", "--help", "help":
 			printUsage()
 			return
@@ -64,8 +90,18 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "Usage: fitting [subcommand] [flags]")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Subcommands:")
-	fmt.Fprintln(os.Stderr, "  run      (default) run the fitting calculator in continuous or --once mode")
-	fmt.Fprintln(os.Stderr, "  analyze  read-only diagnostic for one chart (prints bucket breakdown + config sweep)")
+	fmt.Fprintln(os.Stderr, "  run      (default) run the fitting calculator in continuous or --once mode,")
+	fmt.Fprintln(os.Stderr, "           or with -dry-run compare alternate -params with the published levels")
+	fmt.Fprintln(os.Stderr, "  analyze  read-only diagnostic for one chart (prints bucket breakdown + config sweep),")
+	fmt.Fprintln(os.Stderr, "           or with -votes a comparison of community level votes with chart_statistics,")
+	fmt.Fprintln(os.Stderr, "           or with -estimators the levels of the robust and joint estimators side by side,")
+	fmt.Fprintln(os.Stderr, "           or with -format json|csv every sample's weights for a -chart list or level range")
+	fmt.Fprintln(os.Stderr, "  evaluate cross-validate the fitting params on held-out best records,")
+	fmt.Fprintln(os.Stderr, "           or with -grid rank every combination of a params grid")
+	fmt.Fprintln(os.Stderr, "  history  list recent fitting runs, or with -chart N that chart's level per run")
+	fmt.Fprintln(os.Stderr, "  rollback restore the levels published by run -run ID (recorded as a new run)")
+	fmt.Fprintln(os.Stderr, "  report   `report outliers`: players whose records sit far from the model across")
+	fmt.Fprintln(os.Stderr, "           many charts, as ranked by the latest full run (or -run ID, or -user NAME)")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "Run `fitting <subcommand> --help` to see flags for each subcommand.")
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/fitting"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/util"
+)
+
+// cmdReport executes the `report` subcommand, which prints the reports that
+// runs store next to the levels. Read-only.
+func cmdReport(args []string) {
+	if len(args) == 0 || args[0] != "outliers" {
+		fmt.Fprintln(os.Stderr, "Usage: fitting report outliers [flags]")
+		os.Exit(2)
+	}
+	cmdReportOutliers(args[1:])
+}
+
+// cmdReportOutliers prints the outlier report of a full run: the players
+// whose samples sit furthest from the model across their charts, for admins
+// to review. With -user it lists that player's entries across runs instead.
+func cmdReportOutliers(args []string) {
+	fs := flag.NewFlagSet("report outliers", flag.ExitOnError)
+	configPath := fs.String("config", "config/config.yaml", "Path to config file")
+	runID := fs.Int("run", 0, "ID of the run whose report to show (default: the latest run with one)")
+	username := fs.String("user", "", "Show this player's entries across the retained runs instead")
+	limit := fs.Int("limit", 50, "Number of players (or, with -user, runs) to show")
+	format := fs.String("format", "text", "Output format: text or json")
+	_ = fs.Parse(args)
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "error: invalid -format %q: must be text or json\n", *format)
+		os.Exit(2)
+	}
+
+	config.LoadConfig(*configPath)
+	util.InitDB()
+	ctx := context.Background()
+
+	if *username != "" {
+		rows, err := fitting.PlayerOutlierHistory(ctx, util.DB, *username, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fetch outlier history failed: %v\n", err)
+			os.Exit(1)
+		}
+		if *format == "json" {
+			if rows == nil {
+				rows = []model.FittingRunOutlier{}
+			}
+			writeReportJSON(rows)
+			return
+		}
+		fmt.Printf("=== %s in the outlier reports (newest run first) ===\n\n", *username)
+		printOutliers(rows, true)
+		if len(rows) == 0 {
+			fmt.Println("(not in any retained report)")
+		}
+		return
+	}
+
+	run, rows, err := fitting.RunOutliers(ctx, util.DB, *runID, *limit)
+	if errors.Is(err, fitting.ErrRunNotFound) {
+		fmt.Fprintf(os.Stderr, "run %d not found\n", *runID)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fetch outlier report failed: %v\n", err)
+		os.Exit(1)
+	}
+	if *format == "json" {
+		if rows == nil {
+			rows = []model.FittingRunOutlier{}
+		}
+		writeReportJSON(struct {
+			Run      *model.FittingRun         `json:"run"`
+			Outliers []model.FittingRunOutlier `json:"outliers"`
+		}{run, rows})
+		return
+	}
+	if run == nil {
+		fmt.Println("(no outlier report yet; full sweeps write one when fitting.outlier_report_size > 0)")
+		return
+	}
+	fmt.Printf("=== outliers of run %d (%s, %d ranked) ===\n", run.ID, run.StartedAt.Local().Format(time.DateTime), run.OutliersReported)
+	fmt.Println("residual u = (inferred level − chart median) / Tukey scale; |u| ≥ 1 is trimmed; mean u < 0 scores above the player's skill")
+	fmt.Println()
+	printOutliers(rows, false)
+	if len(rows) == 0 {
+		fmt.Println("(the run has no report; incremental runs do not write one)")
+	}
+}
+
+// printOutliers prints report rows, with their run instead of the rank when
+// byRun is set.
+func printOutliers(rows []model.FittingRunOutlier, byRun bool) {
+	first := "rank"
+	if byRun {
+		first = "run"
+	}
+	fmt.Printf("%-6s %-24s %-7s %-8s %-8s %-10s %-9s\n",
+		first, "username", "charts", "trimmed", "trim%", "median|u|", "mean u")
+	fmt.Println(analyzeRepeat("-", 78))
+	for _, o := range rows {
+		key := o.Rank
+		if byRun {
+			key = o.RunID
+		}
+		fmt.Printf("%-6d %-24s %-7d %-8d %-8.1f %-10.3f %+-9.3f\n",
+			key, o.Username, o.Charts, o.Trimmed, 100*float64(o.Trimmed)/float64(o.Charts),
+			o.MedianAbsResidual, o.MeanResidual)
+	}
+}
+
+func writeReportJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "write report failed: %v\n", err)
+		os.Exit(1)
+	}
+}
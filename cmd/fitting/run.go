@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -21,14 +25,34 @@ import (
 // is present on the command line, so `./fitting`, `./fitting --once`, and
 // `go run ./cmd/fitting --config foo.yaml` all route here unchanged from
 // the pre-subcommand behaviour.
+//
+// With -dry-run it makes a single shadow pass instead (see shadow.go):
+// nothing is published, and a comparison report is written.
 func cmdRun(args []string) {
 	fs := flag.NewFlagSet("run", flag.ExitOnError)
 	configPath := fs.String("config", "config/config.yaml", "Path to config file")
 	once := fs.Bool("once", false, "Run the calculator once and exit (ignores the ticker loop)")
+	full := fs.Bool("full", false, "With --once, recompute every chart even when an incremental run would do")
+	dryRun := fs.Bool("dry-run", false, "Shadow mode: compute once and write a comparison with the published levels instead of persisting")
+	var shadow shadowOptions
+	fs.StringVar(&shadow.paramsPath, "params", "", "With -dry-run, YAML file of config.fitting keys overriding config.yaml")
+	fs.StringVar(&shadow.reportPath, "report", "", "With -dry-run, write the report to this file (default stdout)")
+	fs.StringVar(&shadow.format, "format", "", "With -dry-run, report format: json or csv (default from the -report extension, else json)")
+	fs.BoolVar(&shadow.record, "record", false, "With -dry-run, also store the shadow run and its chart snapshots in fitting_runs")
 	_ = fs.Parse(args)
 
 	// 1. Shared config (same file as cmd/server).
 	config.LoadConfig(*configPath)
+	if shadow.paramsPath != "" {
+		if !*dryRun {
+			fmt.Fprintln(os.Stderr, "error: -params requires -dry-run")
+			os.Exit(2)
+		}
+		if err := overrideFittingParams(shadow.paramsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+	}
 
 	// 2. Shared structured logging.
 	logCloser, err := logging.Setup(
@@ -49,7 +73,7 @@ func cmdRun(args []string) {
 	// 3. Master switch: when disabled we still initialize the DB so
 	//    AutoMigrate keeps chart_statistics in sync with the schema, but we
 	//    skip all actual work.
-	if !config.GlobalConfig.Fitting.Enabled && !*once {
+	if !config.GlobalConfig.Fitting.Enabled && !*once && !*dryRun {
 		slog.InfoContext(baseCtx, "fitting disabled by config.fitting.enabled; exiting")
 		return
 	}
@@ -59,50 +83,66 @@ func cmdRun(args []string) {
 
 	// 5. Build the runner.
 	fp := config.GlobalConfig.Fitting
-	params := fitting.Params{
-		MinEffectiveSamples: fp.MinSamples,
-		SkillTopK:           fp.SkillTopK,
-		SampleHalflifeDays:  fp.SampleHalflifeDays,
-		ProximitySigma:      fp.ProximitySigma,
-		HighSkillSigmaRatio: fp.HighSkillSigmaRatio,
-		VolumeFullAt:        fp.VolumeFullAt,
-		PriorStrength:       fp.PriorStrength,
-		DeviationPenalty:    fp.DeviationPenalty,
-		MaxDeviation:        fp.MaxDeviation,
-		MaxDeviationLow:     fp.MaxDeviationLow,
-		MaxDeviationLowAt:   fp.MaxDeviationLowAt,
-		MaxDeviationHighAt:  fp.MaxDeviationHighAt,
-		MinScore:            fp.MinScore,
-		ScoreFloorAt:        fp.ScoreFloorAt,
-		ScoreGoodAt:         fp.ScoreGoodAt,
-		ScoreFullAt:         fp.ScoreFullAt,
-		ScoreGoodWeight:     fp.ScoreGoodWeight,
-		TukeyK:              fp.TukeyK,
-		MinPlayerRecords:    fp.MinPlayerRecords,
-	}
+	params := fittingParams()
 	cfg := fitting.RunnerConfig{
-		ChartBatchSize:  fp.ChartBatchSize,
-		PlayerBatchSize: fp.PlayerBatchSize,
-		BatchPause:      config.FittingBatchPauseDuration,
+		ChartBatchSize:    fp.ChartBatchSize,
+		PlayerBatchSize:   fp.PlayerBatchSize,
+		BatchPause:        config.FittingBatchPauseDuration,
+		KeepRuns:          fp.KeepRuns,
+		FullSweepInterval: config.FittingFullSweepDuration,
+		OutlierReportSize: fp.OutlierReportSize,
+		OutlierMinCharts:  fp.OutlierMinCharts,
 	}
 	runner := fitting.NewRunner(util.DB, params, cfg)
 
-	// 6. --once: run a single pass and exit with status 0 on success.
+	// 6. -dry-run: one shadow pass, nothing published.
+	if *dryRun {
+		runCtx, cancel := signal.NotifyContext(baseCtx, syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		if err := runShadow(runCtx, runner, params, shadow); err != nil {
+			fmt.Fprintf(os.Stderr, "shadow run failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Only one instance publishes at a time: the others wait on the lease
+	// (or exit, with lease_wait off). Shadow passes above publish nothing
+	// and need no lease.
+	lease := fitting.NewLease(util.DB, fitting.LeaseConfig{
+		TTL:  config.FittingLeaseTTLDuration,
+		Wait: fp.LeaseWait,
+	})
+
+	// 7. --once: run a single pass and exit with status 0 on success.
 	if *once {
 		runCtx, cancel := signal.NotifyContext(baseCtx, syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
-		report, err := runner.Run(runCtx)
+		leaseCtx, ok := acquireLease(runCtx, lease)
+		if !ok {
+			return
+		}
+		defer lease.Release()
+		run := runner.Run
+		if *full {
+			run = runner.RunFull
+		}
+		report, err := run(leaseCtx)
 		if err != nil {
 			slog.ErrorContext(runCtx, "fitting run failed",
 				"err", err,
 				"duration_ms", report.Duration.Milliseconds(),
 			)
+			lease.Release()
 			panic(err)
 		}
 		return
 	}
 
-	// 7. Continuous mode: run once immediately, then every Fitting.Interval.
+	// 8. Continuous mode: run once immediately, then every Fitting.Interval,
+	//    with the optional admin server to watch, trigger and cancel runs.
+	//    The schedule only runs while this instance holds the lease; when
+	//    the lease is lost it goes back to waiting for it.
 	loopCtx, stop := signal.NotifyContext(baseCtx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -110,34 +150,87 @@ func cmdRun(args []string) {
 	slog.InfoContext(loopCtx, "fitting loop starting",
 		"interval", interval.String(),
 	)
+	scheduler := fitting.NewScheduler(runner, interval)
+
+	var adminSrv *http.Server
+	if fp.AdminAddr != "" {
+		adminSrv = &http.Server{
+			Addr:              fp.AdminAddr,
+			Handler:           newAdminHandler(scheduler, lease, util.DB, fp.AdminToken),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.InfoContext(loopCtx, "fitting admin server starting", "addr", adminSrv.Addr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.ErrorContext(loopCtx, "failed to start fitting admin server", "error", err)
+				panic(err)
+			}
+		}()
+	}
 
-	// Immediate first run so there is no long initial idle delay after boot.
-	runTick(loopCtx, runner)
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-loopCtx.Done():
-			slog.InfoContext(loopCtx, "fitting loop shutting down")
-			return
-		case <-ticker.C:
-			runTick(loopCtx, runner)
+	for loopCtx.Err() == nil {
+		leaseCtx, ok := acquireLease(loopCtx, lease)
+		if !ok {
+			break
+		}
+		scheduler.Run(leaseCtx)
+		lease.Release()
+	}
+	slog.InfoContext(loopCtx, "fitting loop shutting down")
+	if adminSrv != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			slog.ErrorContext(loopCtx, "fitting admin server forced shutdown", "error", err)
 		}
 	}
 }
 
-// runTick invokes one fitting pass and logs any error, never panicking in the
-// continuous loop — a single transient DB hiccup should not kill the
-// microservice.
-func runTick(ctx context.Context, runner *fitting.Runner) {
-	report, err := runner.Run(ctx)
-	if err != nil {
-		slog.ErrorContext(ctx, "fitting run failed",
-			"err", err,
-			"duration_ms", report.Duration.Milliseconds(),
-		)
-		return
+// acquireLease takes the fitting lease for ctx. It reports false when the
+// caller should stop: ctx is done, or the lease is held elsewhere and
+// config.fitting.lease_wait is off. Database errors exit the process.
+func acquireLease(ctx context.Context, lease *fitting.Lease) (context.Context, bool) {
+	leaseCtx, err := lease.Acquire(ctx)
+	switch {
+	case err == nil:
+		return leaseCtx, true
+	case errors.Is(err, fitting.ErrLeaseHeld):
+		slog.InfoContext(ctx, "another fitting instance holds the lease; exiting")
+	case ctx.Err() == nil:
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return nil, false
+}
+
+// fittingParams builds the calculator Params from config.GlobalConfig.Fitting.
+func fittingParams() fitting.Params {
+	fp := config.GlobalConfig.Fitting
+	return fitting.Params{
+		MinEffectiveSamples: fp.MinSamples,
+		SkillTopK:           fp.SkillTopK,
+		SampleHalflifeDays:  fp.SampleHalflifeDays,
+		ProximitySigma:      fp.ProximitySigma,
+		HighSkillSigmaRatio: fp.HighSkillSigmaRatio,
+		VolumeFullAt:        fp.VolumeFullAt,
+		PriorStrength:       fp.PriorStrength,
+		DeviationPenalty:    fp.DeviationPenalty,
+		MaxDeviation:        fp.MaxDeviation,
+		MaxDeviationLow:     fp.MaxDeviationLow,
+		MaxDeviationLowAt:   fp.MaxDeviationLowAt,
+		MaxDeviationHighAt:  fp.MaxDeviationHighAt,
+		MinScore:            fp.MinScore,
+		ScoreFloorAt:        fp.ScoreFloorAt,
+		ScoreGoodAt:         fp.ScoreGoodAt,
+		ScoreFullAt:         fp.ScoreFullAt,
+		ScoreGoodWeight:     fp.ScoreGoodWeight,
+		TukeyK:              fp.TukeyK,
+		MinPlayerRecords:    fp.MinPlayerRecords,
+		BootstrapReplicates: fp.BootstrapReplicates,
+		BootstrapSeed:       fp.BootstrapSeed,
+		ConfidenceLevel:     fp.ConfidenceLevel,
+		Estimator:           fp.Estimator,
+		JointMaxIterations:  fp.JointMaxIterations,
+		JointTolerance:      fp.JointTolerance,
 	}
 }
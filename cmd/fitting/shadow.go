@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/fitting"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Shadow mode (`fitting run -dry-run`) computes every chart with the
+// configured Params — optionally overridden by a -params file — and writes a
+// comparison with the published levels instead of persisting anything:
+//
+//	go run ./cmd/fitting run -dry-run -params trial.yaml -report shadow.csv
+//
+// The -params file holds keys of the `fitting:` config section, e.g.
+//
+//	prior_strength: 8
+//	high_skill_sigma_ratio: 0.3
+//
+// and only the keys present override config.yaml.
+
+// shadowOptions are the `run` flags that only apply with -dry-run.
+type shadowOptions struct {
+	paramsPath string // YAML overrides of config.fitting; empty uses config.yaml as is
+	reportPath string // empty writes the report to stdout
+	format     string // "json" or "csv"; empty infers it from reportPath
+	record     bool   // store the shadow run in fitting_runs
+}
+
+// overrideFittingParams overlays the keys of a YAML file onto
+// config.GlobalConfig.Fitting and validates the result.
+func overrideFittingParams(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &config.GlobalConfig.Fitting); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := config.ValidateFitting(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// shadowFormat resolves the report format from -format and the report path.
+func shadowFormat(opts shadowOptions) (string, error) {
+	format := strings.ToLower(opts.format)
+	if format == "" {
+		format = "json"
+		if strings.EqualFold(filepath.Ext(opts.reportPath), ".csv") {
+			format = "csv"
+		}
+	}
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("unknown report format %q: must be json or csv", opts.format)
+	}
+	return format, nil
+}
+
+// runShadow executes one shadow pass, writes the report and prints a
+// human-readable summary to stderr.
+func runShadow(ctx context.Context, runner *fitting.Runner, params fitting.Params, opts shadowOptions) error {
+	format, err := shadowFormat(opts)
+	if err != nil {
+		return err
+	}
+	// Open the report first so a bad path fails before the long pass.
+	out := io.Writer(os.Stdout)
+	if opts.reportPath != "" {
+		f, err := os.Create(opts.reportPath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	report, err := runner.Shadow(ctx, opts.record)
+	if err != nil {
+		return err
+	}
+	if format == "csv" {
+		err = writeShadowCSV(out, report.Charts)
+	} else {
+		err = writeShadowJSON(out, params, report)
+	}
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	printShadowSummary(os.Stderr, report)
+	return nil
+}
+
+// shadowJSON is the layout of the JSON report.
+type shadowJSON struct {
+	StartedAt         time.Time             `json:"started_at"`
+	DurationMs        int64                 `json:"duration_ms"`
+	RunID             int                   `json:"run_id,omitempty"`
+	Params            fitting.Params        `json:"params"`
+	PlayersConsidered int                   `json:"players_considered"`
+	ErrorsEncountered int                   `json:"errors_encountered"`
+	Summary           fitting.ShadowSummary `json:"summary"`
+	Charts            []fitting.ShadowChart `json:"charts"`
+}
+
+func writeShadowJSON(w io.Writer, params fitting.Params, report fitting.ShadowReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(shadowJSON{
+		StartedAt:         report.Started,
+		DurationMs:        report.Duration.Milliseconds(),
+		RunID:             report.RunID,
+		Params:            params,
+		PlayersConsidered: report.PlayersConsidered,
+		ErrorsEncountered: report.ErrorsEncountered,
+		Summary:           report.Summary,
+		Charts:            report.Charts,
+	})
+}
+
+// writeShadowCSV writes one row per chart. Unpublished levels and missing
+// deltas are empty cells.
+func writeShadowCSV(w io.Writer, charts []fitting.ShadowChart) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"chart_id", "official_level", "old_level", "new_level", "delta", "change", "sample_count", "effective_sample_size"})
+	for _, c := range charts {
+		_ = cw.Write([]string{
+			strconv.Itoa(c.ChartID),
+			strconv.FormatFloat(c.OfficialLevel, 'f', 1, 64),
+			shadowCell(c.OldLevel),
+			shadowCell(c.NewLevel),
+			shadowCell(c.Delta),
+			c.Change,
+			strconv.Itoa(c.SampleCount),
+			strconv.FormatFloat(c.EffectiveSampleSize, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func shadowCell(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 4, 64)
+}
+
+// printShadowSummary prints the counts, the delta histogram and the charts
+// that moved the most.
+func printShadowSummary(w io.Writer, report fitting.ShadowReport) {
+	s := report.Summary
+	fmt.Fprintf(w, "=== shadow run: %d charts compared in %s ===\n\n", s.Charts, report.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "unchanged %d | moved %d | abstentions gained %d | abstentions lost %d\n",
+		s.Unchanged, s.Moved, s.AbstentionsGained, s.AbstentionsLost)
+	fmt.Fprintf(w, "delta: mean %+.4f | mean |d| %.4f | max |d| %.4f\n\n", s.MeanDelta, s.MeanAbsDelta, s.MaxAbsDelta)
+
+	largest := 0
+	for _, b := range s.Histogram {
+		largest = max(largest, b.Count)
+	}
+	for _, b := range s.Histogram {
+		bar := 0
+		if largest > 0 {
+			bar = int(math.Ceil(40 * float64(b.Count) / float64(largest)))
+		}
+		fmt.Fprintf(w, "%-16s %6d %s\n", shadowBucketLabel(b), b.Count, analyzeRepeat("#", bar))
+	}
+
+	moved := make([]fitting.ShadowChart, 0, len(report.Charts))
+	for _, c := range report.Charts {
+		if c.Change != fitting.ShadowUnchanged {
+			moved = append(moved, c)
+		}
+	}
+	if len(moved) == 0 {
+		return
+	}
+	// Abstention changes first, then by |delta|.
+	sort.SliceStable(moved, func(i, j int) bool {
+		if (moved[i].Delta == nil) != (moved[j].Delta == nil) {
+			return moved[i].Delta == nil
+		}
+		if moved[i].Delta == nil {
+			return false
+		}
+		return math.Abs(*moved[i].Delta) > math.Abs(*moved[j].Delta)
+	})
+	fmt.Fprintf(w, "\n%-8s %-6s %-8s %-8s %-8s %-18s %-7s\n", "chart", "level", "old", "new", "delta", "change", "n_eff")
+	fmt.Fprintln(w, analyzeRepeat("-", 70))
+	for _, c := range moved[:min(20, len(moved))] {
+		delta := "-"
+		if c.Delta != nil {
+			delta = fmt.Sprintf("%+.3f", *c.Delta)
+		}
+		fmt.Fprintf(w, "%-8d %-6.1f %-8s %-8s %-8s %-18s %-7.2f\n",
+			c.ChartID, c.OfficialLevel, historyLevel(c.OldLevel), historyLevel(c.NewLevel), delta, c.Change, c.EffectiveSampleSize)
+	}
+}
+
+func shadowBucketLabel(b fitting.DeltaBucket) string {
+	switch {
+	case b.Min == nil:
+		return fmt.Sprintf("< %+.2f", *b.Max)
+	case b.Max == nil:
+		return fmt.Sprintf(">= %+.2f", *b.Min)
+	default:
+		return fmt.Sprintf("[%+.2f, %+.2f)", *b.Min, *b.Max)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/logging"
 	"paradigm-reboot-prober-go/internal/metrics"
+	"paradigm-reboot-prober-go/internal/repository"
 	"paradigm-reboot-prober-go/internal/router"
 	"paradigm-reboot-prober-go/internal/util"
 	"syscall"
@@ -53,6 +54,13 @@ func main() {
 	// Initialize Database
 	util.InitDB()
 
+	// Initialize the repository cache (shared between replicas with the redis backend)
+	if err := repository.InitCache(util.DB); err != nil {
+		slog.Error("failed to initialize cache", "error", err)
+		panic(err)
+	}
+	defer func() { _ = repository.CloseCache() }()
+
 	r := router.SetupRouter(util.DB)
 
 	srv := &http.Server{
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"regexp"
@@ -24,6 +25,9 @@ type Config struct {
 		Password string `yaml:"password"`
 		DBName   string `yaml:"dbname"`
 		SSLMode  string `yaml:"sslmode"`
+
+		ReadTimeout  string `yaml:"read_timeout"`  // Go duration string; bounds each repository read, "0s" disables
+		WriteTimeout string `yaml:"write_timeout"` // Go duration string; bounds each repository write or transaction, "0s" disables
 	} `yaml:"database"`
 	Auth struct {
 		SecretKey              string `yaml:"secret_key"`
@@ -57,6 +61,8 @@ type Config struct {
 	Fitting struct {
 		Enabled             bool    `yaml:"enabled"`                // master switch for the fitting-calculator microservice
 		Interval            string  `yaml:"interval"`               // Go duration string (e.g. "6h"); run continuously via ticker
+		AdminAddr           string  `yaml:"admin_addr"`             // listen address of the admin HTTP server of `fitting run` (status, trigger, cancel, health, metrics), e.g. ":9091"; "" disables it
+		AdminToken          string  `yaml:"admin_token"`            // when set, the admin server's POST endpoints require "Authorization: Bearer <token>"
 		MinSamples          float64 `yaml:"min_samples"`            // minimum effective sample size required to publish FittingLevel
 		MinPlayerRecords    int     `yaml:"min_player_records"`     // a player needs at least this many best_play_records to contribute
 		SkillTopK           int     `yaml:"skill_top_k"`            // number of top-rating best-records used to compute a player's skill proxy B_p; must be ≥ 1 (historically 50)
@@ -76,10 +82,42 @@ type Config struct {
 		ScoreFullAt         int     `yaml:"score_full_at"`          // score at which score-quality weight saturates to 1.0 ("高分" threshold)
 		ScoreGoodWeight     float64 `yaml:"score_good_weight"`      // score-quality weight at ScoreGoodAt; must be in (0, 1)
 		TukeyK              float64 `yaml:"tukey_k"`                // Tukey biweight tuning constant (usually 4.685)
+		BootstrapReplicates int     `yaml:"bootstrap_replicates"`   // bootstrap resamples per chart for the fitting level's confidence interval; 0 = no interval
+		BootstrapSeed       uint64  `yaml:"bootstrap_seed"`         // seed of the bootstrap resampling, so intervals are reproducible
+		ConfidenceLevel     float64 `yaml:"confidence_level"`       // coverage of the bootstrap interval, in (0, 1) (e.g. 0.9)
+		Estimator           string  `yaml:"estimator"`              // estimator whose levels are published: "robust" (per chart) or "joint" (player skills and chart levels fitted together)
+		JointMaxIterations  int     `yaml:"joint_max_iterations"`   // cap on the alternating passes of the joint estimator
+		JointTolerance      float64 `yaml:"joint_tolerance"`        // the joint estimator stops once no level moves more than this in a pass
 		ChartBatchSize      int     `yaml:"chart_batch_size"`       // number of charts processed per DB batch
 		PlayerBatchSize     int     `yaml:"player_batch_size"`      // number of users fetched per page during skill collection
 		BatchPause          string  `yaml:"batch_pause"`            // Go duration string; sleep between chart batches to ease DB load
+		KeepRuns            int     `yaml:"keep_runs"`              // number of most recent fitting_runs (and their chart snapshots) kept; 0 keeps all
+		FullSweepInterval   string  `yaml:"full_sweep_interval"`    // Go duration string; runs in between only recompute charts with new evidence; "0" makes every run a full sweep
+		LeaseTTL            string  `yaml:"lease_ttl"`              // Go duration string; the fitting lease row (SQLite) expires this long after its last renewal, renewed every third of it
+		LeaseWait           bool    `yaml:"lease_wait"`             // when another instance holds the fitting lease: true waits for it (standby), false exits
+		OutlierReportSize   int     `yaml:"outlier_report_size"`    // players kept in the outlier report of each full sweep (fitting_run_outliers); 0 disables the report
+		OutlierMinCharts    int     `yaml:"outlier_min_charts"`     // charts with a residual a player needs to be ranked in the outlier report
 	} `yaml:"fitting"`
+	Wiki struct {
+		Provider    string `yaml:"provider"`     // "" (disabled), "http" or "file"
+		BaseURL     string `yaml:"base_url"`     // http: song metadata URL template; "{wiki_id}" is replaced by the song's wiki_id
+		Timeout     string `yaml:"timeout"`      // http: request timeout (Go duration string)
+		FixtureFile string `yaml:"fixture_file"` // file: YAML/JSON file with a top-level `songs` list, for tests and offline use
+	} `yaml:"wiki"`
+	Cache struct {
+		Backend              string `yaml:"backend"`                // "memory" (default; each process caches on its own) or "redis" (shared by every replica)
+		Addr                 string `yaml:"addr"`                   // redis: server address, e.g. "localhost:6379"
+		Password             string `yaml:"password"`               // redis: AUTH password; "" sends none
+		DB                   int    `yaml:"db"`                     // redis: database selected after connecting
+		Prefix               string `yaml:"prefix"`                 // redis: prepended to every key, so deployments can share a server
+		Timeout              string `yaml:"timeout"`                // redis: Go duration string; dial and per-command timeout
+		NotifyChannel        string `yaml:"notify_channel"`         // memory on PostgreSQL: channel on which replicas LISTEN/NOTIFY each other's invalidations; "" keeps them local
+		StaleWhileRevalidate string `yaml:"stale_while_revalidate"` // Go duration string; how long an expired entry is still served while one request refreshes it; "0s" disables
+	} `yaml:"cache"`
+	Stats struct {
+		PassScore  int `yaml:"pass_score"`  // best scores at or above this count as passes in chart score distributions
+		MinPlayers int `yaml:"min_players"` // charts with fewer players get no score distribution, so single players cannot be singled out
+	} `yaml:"stats"`
 }
 
 var GlobalConfig Config
@@ -91,6 +129,13 @@ var (
 	UsernameRegex                  *regexp.Regexp
 	FittingIntervalDuration        time.Duration
 	FittingBatchPauseDuration      time.Duration
+	FittingFullSweepDuration       time.Duration
+	FittingLeaseTTLDuration        time.Duration
+	WikiTimeoutDuration            time.Duration
+	CacheTimeoutDuration           time.Duration
+	CacheStaleDuration             time.Duration
+	DatabaseReadTimeoutDuration    time.Duration
+	DatabaseWriteTimeoutDuration   time.Duration
 )
 
 // InitDefaults sets all config fields to their default values and parses derived values.
@@ -99,6 +144,8 @@ func InitDefaults() {
 	GlobalConfig.Server.Port = ":8080"
 	GlobalConfig.Database.Type = "sqlite"
 	GlobalConfig.Database.DSN = "prober.db"
+	GlobalConfig.Database.ReadTimeout = "5s"
+	GlobalConfig.Database.WriteTimeout = "30s"
 	GlobalConfig.Auth.SecretKey = "your_secret_key_here"
 	GlobalConfig.Auth.JWTAlgorithm = "HS256"
 	GlobalConfig.Auth.JWTExpiration = "30m"
@@ -143,9 +190,35 @@ func InitDefaults() {
 	GlobalConfig.Fitting.ScoreFullAt = 0
 	GlobalConfig.Fitting.ScoreGoodWeight = 0
 	GlobalConfig.Fitting.TukeyK = 4.685
+	GlobalConfig.Fitting.BootstrapReplicates = 200
+	GlobalConfig.Fitting.BootstrapSeed = 1
+	GlobalConfig.Fitting.ConfidenceLevel = 0.9
+	GlobalConfig.Fitting.Estimator = "robust"
+	GlobalConfig.Fitting.JointMaxIterations = 10
+	GlobalConfig.Fitting.JointTolerance = 0.001
 	GlobalConfig.Fitting.ChartBatchSize = 200
 	GlobalConfig.Fitting.PlayerBatchSize = 500
 	GlobalConfig.Fitting.BatchPause = "50ms"
+	GlobalConfig.Fitting.KeepRuns = 120
+	GlobalConfig.Fitting.FullSweepInterval = "24h"
+	GlobalConfig.Fitting.LeaseTTL = "60s"
+	GlobalConfig.Fitting.LeaseWait = true
+	GlobalConfig.Fitting.OutlierReportSize = 100
+	GlobalConfig.Fitting.OutlierMinCharts = 10
+	GlobalConfig.Wiki.Provider = ""
+	GlobalConfig.Wiki.BaseURL = ""
+	GlobalConfig.Wiki.Timeout = "10s"
+	GlobalConfig.Wiki.FixtureFile = ""
+	GlobalConfig.Cache.Backend = "memory"
+	GlobalConfig.Cache.Addr = ""
+	GlobalConfig.Cache.Password = ""
+	GlobalConfig.Cache.DB = 0
+	GlobalConfig.Cache.Prefix = "prober:"
+	GlobalConfig.Cache.Timeout = "500ms"
+	GlobalConfig.Cache.NotifyChannel = "prober_cache"
+	GlobalConfig.Cache.StaleWhileRevalidate = "0s"
+	GlobalConfig.Stats.PassScore = 1000000
+	GlobalConfig.Stats.MinPlayers = 5
 
 	// Parse derived values (defaults are always valid, no error expected)
 	JWTExpirationDuration, _ = time.ParseDuration(GlobalConfig.Auth.JWTExpiration)
@@ -153,6 +226,13 @@ func InitDefaults() {
 	UsernameRegex = regexp.MustCompile(GlobalConfig.Auth.UsernamePattern)
 	FittingIntervalDuration, _ = time.ParseDuration(GlobalConfig.Fitting.Interval)
 	FittingBatchPauseDuration, _ = time.ParseDuration(GlobalConfig.Fitting.BatchPause)
+	FittingFullSweepDuration, _ = time.ParseDuration(GlobalConfig.Fitting.FullSweepInterval)
+	FittingLeaseTTLDuration, _ = time.ParseDuration(GlobalConfig.Fitting.LeaseTTL)
+	WikiTimeoutDuration, _ = time.ParseDuration(GlobalConfig.Wiki.Timeout)
+	CacheTimeoutDuration, _ = time.ParseDuration(GlobalConfig.Cache.Timeout)
+	CacheStaleDuration, _ = time.ParseDuration(GlobalConfig.Cache.StaleWhileRevalidate)
+	DatabaseReadTimeoutDuration, _ = time.ParseDuration(GlobalConfig.Database.ReadTimeout)
+	DatabaseWriteTimeoutDuration, _ = time.ParseDuration(GlobalConfig.Database.WriteTimeout)
 }
 
 func LoadConfig(configPath string) {
@@ -255,9 +335,33 @@ func LoadConfig(configPath string) {
 	if v := os.Getenv("FITTING_INTERVAL"); v != "" {
 		GlobalConfig.Fitting.Interval = v
 	}
+	if v := os.Getenv("FITTING_ADMIN_ADDR"); v != "" {
+		GlobalConfig.Fitting.AdminAddr = v
+	}
+	if v := os.Getenv("FITTING_ADMIN_TOKEN"); v != "" {
+		GlobalConfig.Fitting.AdminToken = v
+	}
 	if v := os.Getenv("FITTING_BATCH_PAUSE"); v != "" {
 		GlobalConfig.Fitting.BatchPause = v
 	}
+	if v := os.Getenv("WIKI_PROVIDER"); v != "" {
+		GlobalConfig.Wiki.Provider = v
+	}
+	if v := os.Getenv("WIKI_BASE_URL"); v != "" {
+		GlobalConfig.Wiki.BaseURL = v
+	}
+	if v := os.Getenv("WIKI_FIXTURE_FILE"); v != "" {
+		GlobalConfig.Wiki.FixtureFile = v
+	}
+	if v := os.Getenv("CACHE_BACKEND"); v != "" {
+		GlobalConfig.Cache.Backend = v
+	}
+	if v := os.Getenv("CACHE_ADDR"); v != "" {
+		GlobalConfig.Cache.Addr = v
+	}
+	if v := os.Getenv("CACHE_PASSWORD"); v != "" {
+		GlobalConfig.Cache.Password = v
+	}
 	// Re-parse derived values after file/env overrides
 	JWTExpirationDuration, err = time.ParseDuration(GlobalConfig.Auth.JWTExpiration)
 	if err != nil {
@@ -274,6 +378,22 @@ func LoadConfig(configPath string) {
 		log.Fatalf("Invalid username_pattern %q: %v", GlobalConfig.Auth.UsernamePattern, err)
 	}
 
+	// Validate + parse database query timeouts
+	DatabaseReadTimeoutDuration, err = time.ParseDuration(GlobalConfig.Database.ReadTimeout)
+	if err != nil {
+		log.Fatalf("Invalid database.read_timeout %q: %v", GlobalConfig.Database.ReadTimeout, err)
+	}
+	if DatabaseReadTimeoutDuration < 0 {
+		log.Fatalf("database.read_timeout must be ≥ 0, got %q", GlobalConfig.Database.ReadTimeout)
+	}
+	DatabaseWriteTimeoutDuration, err = time.ParseDuration(GlobalConfig.Database.WriteTimeout)
+	if err != nil {
+		log.Fatalf("Invalid database.write_timeout %q: %v", GlobalConfig.Database.WriteTimeout, err)
+	}
+	if DatabaseWriteTimeoutDuration < 0 {
+		log.Fatalf("database.write_timeout must be ≥ 0, got %q", GlobalConfig.Database.WriteTimeout)
+	}
+
 	// Validate bcrypt cost
 	if GlobalConfig.Auth.BcryptCost < 4 || GlobalConfig.Auth.BcryptCost > 31 {
 		log.Fatalf("Invalid bcrypt_cost %d: must be between 4 and 31", GlobalConfig.Auth.BcryptCost)
@@ -329,47 +449,142 @@ func LoadConfig(configPath string) {
 	if FittingBatchPauseDuration < 0 {
 		log.Fatalf("fitting.batch_pause must be ≥ 0, got %q", GlobalConfig.Fitting.BatchPause)
 	}
+	FittingFullSweepDuration, err = time.ParseDuration(GlobalConfig.Fitting.FullSweepInterval)
+	if err != nil {
+		log.Fatalf("Invalid fitting.full_sweep_interval %q: %v", GlobalConfig.Fitting.FullSweepInterval, err)
+	}
+	if FittingFullSweepDuration < 0 {
+		log.Fatalf("fitting.full_sweep_interval must be ≥ 0, got %q", GlobalConfig.Fitting.FullSweepInterval)
+	}
+	FittingLeaseTTLDuration, err = time.ParseDuration(GlobalConfig.Fitting.LeaseTTL)
+	if err != nil {
+		log.Fatalf("Invalid fitting.lease_ttl %q: %v", GlobalConfig.Fitting.LeaseTTL, err)
+	}
+	if FittingLeaseTTLDuration <= 0 {
+		log.Fatalf("fitting.lease_ttl must be > 0, got %q", GlobalConfig.Fitting.LeaseTTL)
+	}
+	if err := ValidateFitting(); err != nil {
+		log.Fatalf("%v", err)
+	}
+	// Validate wiki metadata provider
+	switch GlobalConfig.Wiki.Provider {
+	case "":
+		// disabled: src=wiki and wiki sync return an error
+	case "http":
+		if !strings.Contains(GlobalConfig.Wiki.BaseURL, "{wiki_id}") {
+			log.Fatalf("wiki.provider=http requires wiki.base_url to contain the {wiki_id} placeholder, got %q", GlobalConfig.Wiki.BaseURL)
+		}
+	case "file":
+		if strings.TrimSpace(GlobalConfig.Wiki.FixtureFile) == "" {
+			log.Fatalf("wiki.provider=file requires wiki.fixture_file to be set")
+		}
+	default:
+		log.Fatalf("Invalid wiki.provider %q: must be one of \"\", http, file", GlobalConfig.Wiki.Provider)
+	}
+	WikiTimeoutDuration, err = time.ParseDuration(GlobalConfig.Wiki.Timeout)
+	if err != nil {
+		log.Fatalf("Invalid wiki.timeout %q: %v", GlobalConfig.Wiki.Timeout, err)
+	}
+	if WikiTimeoutDuration <= 0 {
+		log.Fatalf("wiki.timeout must be > 0, got %q", GlobalConfig.Wiki.Timeout)
+	}
+	// Validate the repository cache backend
+	switch GlobalConfig.Cache.Backend {
+	case "memory":
+		// ok
+	case "redis":
+		if strings.TrimSpace(GlobalConfig.Cache.Addr) == "" {
+			log.Fatalf("cache.backend=redis requires cache.addr to be set")
+		}
+	default:
+		log.Fatalf("Invalid cache.backend %q: must be one of memory, redis", GlobalConfig.Cache.Backend)
+	}
+	if GlobalConfig.Cache.DB < 0 {
+		log.Fatalf("cache.db must be ≥ 0, got %d", GlobalConfig.Cache.DB)
+	}
+	CacheTimeoutDuration, err = time.ParseDuration(GlobalConfig.Cache.Timeout)
+	if err != nil {
+		log.Fatalf("Invalid cache.timeout %q: %v", GlobalConfig.Cache.Timeout, err)
+	}
+	if CacheTimeoutDuration <= 0 {
+		log.Fatalf("cache.timeout must be > 0, got %q", GlobalConfig.Cache.Timeout)
+	}
+	CacheStaleDuration, err = time.ParseDuration(GlobalConfig.Cache.StaleWhileRevalidate)
+	if err != nil {
+		log.Fatalf("Invalid cache.stale_while_revalidate %q: %v", GlobalConfig.Cache.StaleWhileRevalidate, err)
+	}
+	if CacheStaleDuration < 0 {
+		log.Fatalf("cache.stale_while_revalidate must be ≥ 0, got %q", GlobalConfig.Cache.StaleWhileRevalidate)
+	}
+	if GlobalConfig.Stats.PassScore <= 0 {
+		log.Fatalf("stats.pass_score must be > 0, got %d", GlobalConfig.Stats.PassScore)
+	}
+	if GlobalConfig.Stats.MinPlayers < 1 {
+		log.Fatalf("stats.min_players must be ≥ 1, got %d", GlobalConfig.Stats.MinPlayers)
+	}
+}
+
+// ValidateFitting checks the fitting parameters of GlobalConfig. LoadConfig
+// calls it; call it again after overlaying parameters on a loaded config
+// (fitting run -params, fitting evaluate -grid).
+func ValidateFitting() error {
 	if GlobalConfig.Fitting.ProximitySigma <= 0 {
-		log.Fatalf("fitting.proximity_sigma must be > 0, got %f", GlobalConfig.Fitting.ProximitySigma)
+		return fmt.Errorf("fitting.proximity_sigma must be > 0, got %f", GlobalConfig.Fitting.ProximitySigma)
 	}
 	if GlobalConfig.Fitting.SkillTopK < 1 {
-		log.Fatalf("fitting.skill_top_k must be ≥ 1, got %d", GlobalConfig.Fitting.SkillTopK)
+		return fmt.Errorf("fitting.skill_top_k must be ≥ 1, got %d", GlobalConfig.Fitting.SkillTopK)
 	}
 	if GlobalConfig.Fitting.SampleHalflifeDays < 0 {
-		log.Fatalf("fitting.sample_halflife_days must be ≥ 0, got %f", GlobalConfig.Fitting.SampleHalflifeDays)
+		return fmt.Errorf("fitting.sample_halflife_days must be ≥ 0, got %f", GlobalConfig.Fitting.SampleHalflifeDays)
 	}
 	if GlobalConfig.Fitting.TukeyK <= 0 {
-		log.Fatalf("fitting.tukey_k must be > 0, got %f", GlobalConfig.Fitting.TukeyK)
+		return fmt.Errorf("fitting.tukey_k must be > 0, got %f", GlobalConfig.Fitting.TukeyK)
+	}
+	if GlobalConfig.Fitting.BootstrapReplicates < 0 {
+		return fmt.Errorf("fitting.bootstrap_replicates must be ≥ 0, got %d", GlobalConfig.Fitting.BootstrapReplicates)
+	}
+	if GlobalConfig.Fitting.BootstrapReplicates > 0 &&
+		(GlobalConfig.Fitting.ConfidenceLevel <= 0 || GlobalConfig.Fitting.ConfidenceLevel >= 1) {
+		return fmt.Errorf("fitting.confidence_level must be in (0, 1), got %f", GlobalConfig.Fitting.ConfidenceLevel)
+	}
+	if e := GlobalConfig.Fitting.Estimator; e != "robust" && e != "joint" {
+		return fmt.Errorf("fitting.estimator must be \"robust\" or \"joint\", got %q", e)
+	}
+	if GlobalConfig.Fitting.JointMaxIterations < 1 {
+		return fmt.Errorf("fitting.joint_max_iterations must be ≥ 1, got %d", GlobalConfig.Fitting.JointMaxIterations)
+	}
+	if GlobalConfig.Fitting.JointTolerance <= 0 {
+		return fmt.Errorf("fitting.joint_tolerance must be > 0, got %f", GlobalConfig.Fitting.JointTolerance)
 	}
 	if GlobalConfig.Fitting.PriorStrength < 0 {
-		log.Fatalf("fitting.prior_strength must be ≥ 0, got %f", GlobalConfig.Fitting.PriorStrength)
+		return fmt.Errorf("fitting.prior_strength must be ≥ 0, got %f", GlobalConfig.Fitting.PriorStrength)
 	}
 	if GlobalConfig.Fitting.DeviationPenalty < 0 {
-		log.Fatalf("fitting.deviation_penalty must be ≥ 0, got %f", GlobalConfig.Fitting.DeviationPenalty)
+		return fmt.Errorf("fitting.deviation_penalty must be ≥ 0, got %f", GlobalConfig.Fitting.DeviationPenalty)
 	}
 	if GlobalConfig.Fitting.HighSkillSigmaRatio < 0 {
-		log.Fatalf("fitting.high_skill_sigma_ratio must be ≥ 0, got %f", GlobalConfig.Fitting.HighSkillSigmaRatio)
+		return fmt.Errorf("fitting.high_skill_sigma_ratio must be ≥ 0, got %f", GlobalConfig.Fitting.HighSkillSigmaRatio)
 	}
 	if GlobalConfig.Fitting.MaxDeviation < 0 {
-		log.Fatalf("fitting.max_deviation must be ≥ 0, got %f", GlobalConfig.Fitting.MaxDeviation)
+		return fmt.Errorf("fitting.max_deviation must be ≥ 0, got %f", GlobalConfig.Fitting.MaxDeviation)
 	}
 	// Level-dependent cap ramp: only validated when enabled (MaxDeviationLow > 0). Consistency with
 	// effectiveMaxDeviation(): if any endpoint is misconfigured we fall back silently to the flat cap,
-	// but we still surface the most likely-to-be-wrong configurations as fatal at startup.
+	// but we still reject the most likely-to-be-wrong configurations.
 	if GlobalConfig.Fitting.MaxDeviationLow < 0 {
-		log.Fatalf("fitting.max_deviation_low must be ≥ 0, got %f", GlobalConfig.Fitting.MaxDeviationLow)
+		return fmt.Errorf("fitting.max_deviation_low must be ≥ 0, got %f", GlobalConfig.Fitting.MaxDeviationLow)
 	}
 	if GlobalConfig.Fitting.MaxDeviationLow > 0 {
 		if GlobalConfig.Fitting.MaxDeviationLow > GlobalConfig.Fitting.MaxDeviation {
-			log.Fatalf("fitting.max_deviation_low (%f) must be ≤ fitting.max_deviation (%f)",
+			return fmt.Errorf("fitting.max_deviation_low (%f) must be ≤ fitting.max_deviation (%f)",
 				GlobalConfig.Fitting.MaxDeviationLow, GlobalConfig.Fitting.MaxDeviation)
 		}
 		if GlobalConfig.Fitting.MaxDeviationLowAt <= 0 {
-			log.Fatalf("fitting.max_deviation_low_at must be > 0 when fitting.max_deviation_low > 0, got %f",
+			return fmt.Errorf("fitting.max_deviation_low_at must be > 0 when fitting.max_deviation_low > 0, got %f",
 				GlobalConfig.Fitting.MaxDeviationLowAt)
 		}
 		if GlobalConfig.Fitting.MaxDeviationHighAt <= GlobalConfig.Fitting.MaxDeviationLowAt {
-			log.Fatalf("fitting.max_deviation_high_at (%f) must be > fitting.max_deviation_low_at (%f)",
+			return fmt.Errorf("fitting.max_deviation_high_at (%f) must be > fitting.max_deviation_low_at (%f)",
 				GlobalConfig.Fitting.MaxDeviationHighAt, GlobalConfig.Fitting.MaxDeviationLowAt)
 		}
 	}
@@ -379,25 +594,35 @@ func LoadConfig(configPath string) {
 		GlobalConfig.Fitting.ScoreGoodAt > 0 ||
 		GlobalConfig.Fitting.ScoreFullAt > 0 {
 		if GlobalConfig.Fitting.ScoreFloorAt <= 0 {
-			log.Fatalf("fitting.score_floor_at must be > 0 when any score-quality anchor is set, got %d", GlobalConfig.Fitting.ScoreFloorAt)
+			return fmt.Errorf("fitting.score_floor_at must be > 0 when any score-quality anchor is set, got %d", GlobalConfig.Fitting.ScoreFloorAt)
 		}
 		if GlobalConfig.Fitting.ScoreGoodAt <= GlobalConfig.Fitting.ScoreFloorAt {
-			log.Fatalf("fitting.score_good_at (%d) must be > fitting.score_floor_at (%d)",
+			return fmt.Errorf("fitting.score_good_at (%d) must be > fitting.score_floor_at (%d)",
 				GlobalConfig.Fitting.ScoreGoodAt, GlobalConfig.Fitting.ScoreFloorAt)
 		}
 		if GlobalConfig.Fitting.ScoreFullAt <= GlobalConfig.Fitting.ScoreGoodAt {
-			log.Fatalf("fitting.score_full_at (%d) must be > fitting.score_good_at (%d)",
+			return fmt.Errorf("fitting.score_full_at (%d) must be > fitting.score_good_at (%d)",
 				GlobalConfig.Fitting.ScoreFullAt, GlobalConfig.Fitting.ScoreGoodAt)
 		}
 		if GlobalConfig.Fitting.ScoreGoodWeight <= 0 || GlobalConfig.Fitting.ScoreGoodWeight >= 1 {
-			log.Fatalf("fitting.score_good_weight must be in (0, 1) when score-quality weighting is enabled, got %f",
+			return fmt.Errorf("fitting.score_good_weight must be in (0, 1) when score-quality weighting is enabled, got %f",
 				GlobalConfig.Fitting.ScoreGoodWeight)
 		}
 	}
 	if GlobalConfig.Fitting.ChartBatchSize <= 0 {
-		log.Fatalf("fitting.chart_batch_size must be > 0, got %d", GlobalConfig.Fitting.ChartBatchSize)
+		return fmt.Errorf("fitting.chart_batch_size must be > 0, got %d", GlobalConfig.Fitting.ChartBatchSize)
 	}
 	if GlobalConfig.Fitting.PlayerBatchSize <= 0 {
-		log.Fatalf("fitting.player_batch_size must be > 0, got %d", GlobalConfig.Fitting.PlayerBatchSize)
+		return fmt.Errorf("fitting.player_batch_size must be > 0, got %d", GlobalConfig.Fitting.PlayerBatchSize)
+	}
+	if GlobalConfig.Fitting.KeepRuns < 0 {
+		return fmt.Errorf("fitting.keep_runs must be ≥ 0, got %d", GlobalConfig.Fitting.KeepRuns)
+	}
+	if GlobalConfig.Fitting.OutlierReportSize < 0 {
+		return fmt.Errorf("fitting.outlier_report_size must be ≥ 0, got %d", GlobalConfig.Fitting.OutlierReportSize)
+	}
+	if GlobalConfig.Fitting.OutlierMinCharts < 1 {
+		return fmt.Errorf("fitting.outlier_min_charts must be ≥ 1, got %d", GlobalConfig.Fitting.OutlierMinCharts)
 	}
+	return nil
 }
@@ -24,100 +24,40 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/records/{username}": {
+        "/catalog": {
             "get": {
-                "description": "Retrieve play records for a user based on scope (b50, best, all, all-charts)",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export every live song and chart as a catalog file keyed by wiki_id (Admin only)",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "record"
+                    "catalog"
                 ],
-                "summary": "Get play records",
+                "summary": "Export the song catalog",
                 "parameters": [
                     {
+                        "enum": [
+                            "yaml",
+                            "json"
+                        ],
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "b50",
-                        "description": "Scope (b50, best, all, all-charts)",
-                        "name": "scope",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Underflow for b50",
-                        "name": "underflow",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Page size",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page index",
-                        "name": "page_index",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "rating",
-                        "description": "Sort by (rating, score, record_time, etc.)",
-                        "name": "sort_by",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Order (desc or asc)",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Minimum chart level (inclusive)",
-                        "name": "min_level",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "Maximum chart level (inclusive)",
-                        "name": "max_level",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Filter by difficulty (detected, invaded, massive, reboot)",
-                        "name": "difficulty",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Filter by season: true = new (B15), false = old (B35)",
-                        "name": "b15",
+                        "default": "json",
+                        "description": "Catalog format",
+                        "name": "format",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "all-charts scope",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.AllChartsResponse"
+                            "$ref": "#/definitions/model.Catalog"
                         }
                     },
                     "400": {
@@ -126,14 +66,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/model.Response"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -141,43 +75,64 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Batch upload play records for a user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Diff a catalog file against the database and return the plan (create, update, level_change, retire).\nWith apply=true the plan is committed in a single transaction, reusing the song update\nvalidation, history and rating recalculation. With prune=true live songs missing from\nthe catalog are retired. (Admin only)",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/yaml"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "record"
+                    "catalog"
                 ],
-                "summary": "Upload play records",
+                "summary": "Import the song catalog",
                 "parameters": [
                     {
+                        "enum": [
+                            "yaml",
+                            "json"
+                        ],
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
+                        "default": "json",
+                        "description": "Catalog format",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "Play records upload info",
-                        "name": "record",
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Commit the plan instead of only computing it",
+                        "name": "apply",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Retire songs missing from the catalog",
+                        "name": "prune",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Catalog file",
+                        "name": "catalog",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.BatchCreatePlayRecordRequest"
+                            "$ref": "#/definitions/model.Catalog"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.PlayRecord"
-                            }
+                            "$ref": "#/definitions/model.CatalogPlan"
                         }
                     },
                     "400": {
@@ -186,8 +141,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -195,64 +150,69 @@ const docTemplate = `{
                 }
             }
         },
-        "/records/{username}/chart/{chart_addr}": {
+        "/charts/stats": {
             "get": {
-                "description": "Retrieve play records for a user scoped to a specific chart. chart_addr can be numeric chart_id or wiki_id:difficulty (e.g. felys:massive).",
+                "description": "Retrieve an overview of every live chart: its fitting sample size and spread, its number of\nplayers and its pass rate (omitted for charts with too few players), hardest level first.\nStatistics are cached and may be a few minutes old.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "record"
+                    "stats"
                 ],
-                "summary": "Get play records for a specific chart",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Chart address (numeric chart_id or wiki_id:difficulty)",
-                        "name": "chart_addr",
-                        "in": "path",
-                        "required": true
+                "summary": "Get statistics of all charts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ChartStatsSummary"
+                            }
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/charts/tiers": {
+            "get": {
+                "description": "Charts with community level votes, grouped by official level (hardest first). Within a level,\ncharts are ordered by community level (official level + mean vote), hardest-feeling first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vote"
+                ],
+                "summary": "Get the community difficulty tier list",
+                "parameters": [
                     {
-                        "type": "string",
-                        "default": "best",
-                        "description": "Scope (best, all)",
-                        "name": "scope",
+                        "type": "number",
+                        "description": "Minimum official level (inclusive)",
+                        "name": "min_level",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Page size (scope=all only)",
-                        "name": "page_size",
+                        "type": "number",
+                        "description": "Maximum official level (inclusive)",
+                        "name": "max_level",
                         "in": "query"
                     },
                     {
                         "type": "integer",
                         "default": 1,
-                        "description": "Page index (scope=all only)",
-                        "name": "page_index",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "rating",
-                        "description": "Sort by (rating, score, record_time)",
-                        "name": "sort_by",
+                        "description": "Minimum number of votes",
+                        "name": "min_votes",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "default": "desc",
-                        "description": "Order (desc or asc)",
-                        "name": "order",
+                        "description": "Only charts carrying this tag",
+                        "name": "tag",
                         "in": "query"
                     }
                 ],
@@ -260,7 +220,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PlayRecordResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TierListGroup"
+                            }
                         }
                     },
                     "400": {
@@ -268,9 +231,37 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    }
+                }
+            }
+        },
+        "/charts/{chart_addr}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retire (soft-delete) a single chart (Admin only). The chart is hidden from /songs and excluded\nfrom B50, but players' records on it remain visible in record history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Retire a chart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -280,76 +271,67 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
                     }
                 }
-            }
-        },
-        "/records/{username}/song/{song_addr}": {
-            "get": {
-                "description": "Retrieve play records for a user scoped to a specific song. song_addr can be numeric song_id or wiki_id.",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change only the supplied fields of a chart (Admin only); an empty override field clears the\noverride. Send the chart's updated_at (or the ETag of a previous PATCH) as If-Match to refuse\nthe write with 412 if someone else changed the chart in the meantime. Every change is recorded\nin the song's history and ratings are recalculated only when the level changes.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "record"
+                    "song"
                 ],
-                "summary": "Get play records for a specific song",
+                "summary": "Update a single chart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Song address (numeric song_id or wiki_id)",
-                        "name": "song_addr",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "best",
-                        "description": "Scope (best, all)",
-                        "name": "scope",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Page size (scope=all only)",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page index (scope=all only)",
-                        "name": "page_index",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "default": "rating",
-                        "description": "Sort by (rating, score, record_time)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "description": "updated_at of the chart as last read",
+                        "name": "If-Match",
+                        "in": "header"
                     },
                     {
-                        "type": "string",
-                        "default": "desc",
-                        "description": "Order (desc or asc)",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Fields to change",
+                        "name": "chart",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ChartPatch"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.PlayRecordResponse"
+                            "$ref": "#/definitions/model.ChartInfo"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send as If-Match on the next update"
+                            }
                         }
                     },
                     "400": {
@@ -358,91 +340,50 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/model.Response"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/songs": {
-            "get": {
-                "description": "Retrieve a list of all charts with their details",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "song"
-                ],
-                "summary": "Get all charts",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.ChartInfo"
-                            }
-                        }
-                    },
-                    "304": {
-                        "description": "Not Modified",
-                        "schema": {
-                            "type": "string"
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/charts/{chart_addr}/restore": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update song details and its charts (Admin only)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Restore a previously retired chart (Admin only). Fails if the song already has a live chart\nof the same difficulty. With wiki_id:difficulty the most recently retired chart is restored.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "song"
                 ],
-                "summary": "Update an existing song",
+                "summary": "Restore a retired chart",
                 "parameters": [
                     {
-                        "description": "Song update info",
-                        "name": "song",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.UpdateSongRequest"
-                        }
+                        "type": "string",
+                        "description": "Retired chart ID or wiki_id:difficulty",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.ChartInfo"
-                            }
+                            "$ref": "#/definitions/model.Response"
                         }
                     },
                     "400": {
@@ -450,51 +391,56 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
                     }
                 }
             }
         },
-        "/songs/{song_id}": {
+        "/charts/{chart_addr}/stats": {
             "get": {
-                "description": "Retrieve detailed information about a single song by ID",
+                "description": "Retrieve the fitting statistics of a chart and the distribution of players' best scores on it\n(pass rate, percentiles, histogram). The distribution is omitted for charts with too few players.\nFor authenticated callers with a best record on the chart, the response also contains their rank.\nStatistics are cached and may be a few minutes old.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "song"
+                    "stats"
                 ],
-                "summary": "Get single song info",
+                "summary": "Get statistics of a chart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Song ID",
-                        "name": "song_id",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "prp",
-                        "description": "Source (prp or wiki)",
-                        "name": "src",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Song"
+                            "$ref": "#/definitions/model.ChartStats"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -502,88 +448,78 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/login": {
-            "post": {
-                "description": "Authenticate user and return access and refresh JWT tokens",
+        "/charts/{chart_addr}/tags": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the caller's tags on a chart (at most 5, e.g. \"stamina\", \"tech\", \"sight-read-trap\"); an\nempty list removes them. Tags are lowercased and their words joined with hyphens. Only players\nwith a best record on the chart may tag it.",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "vote"
                 ],
-                "summary": "Login user",
+                "summary": "Tag a chart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "formData",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Password",
-                        "name": "password",
-                        "in": "formData",
-                        "required": true
+                        "description": "Tags",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ChartTagsRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Token"
+                            "$ref": "#/definitions/model.ChartVoteSummary"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/user/me": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get the profile of the currently authenticated user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "user"
-                ],
-                "summary": "Get current user info",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/charts/{chart_addr}/vote": {
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update the profile of the currently authenticated user",
+                "description": "Record how much harder (positive) or easier (negative) a chart feels than its official level,\nwithin ±1.5. Each player has one vote per chart, which this replaces, and only players with a\nbest record on the chart may vote.",
                 "consumes": [
                     "application/json"
                 ],
@@ -591,17 +527,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "vote"
                 ],
-                "summary": "Update current user info",
+                "summary": "Vote on a chart's level",
                 "parameters": [
                     {
-                        "description": "User update info",
-                        "name": "user",
+                        "type": "string",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Vote",
+                        "name": "vote",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.UpdateUserRequest"
+                            "$ref": "#/definitions/request.ChartVoteRequest"
                         }
                     }
                 ],
@@ -609,7 +552,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/model.ChartVoteSummary"
                         }
                     },
                     "400": {
@@ -617,37 +560,42 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
                     }
                 }
-            }
-        },
-        "/user/me/password": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Change the current user's password",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Remove the caller's level vote on a chart",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "vote"
                 ],
-                "summary": "Change password",
+                "summary": "Withdraw a level vote",
                 "parameters": [
                     {
-                        "description": "Password change info",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.ChangePasswordRequest"
-                        }
+                        "type": "string",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -657,14 +605,1434 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                    }
+                }
+            }
+        },
+        "/charts/{chart_addr}/votes": {
+            "get": {
+                "description": "Retrieve the community level votes and tags of a chart. For authenticated callers the response\nalso contains their own vote and tags.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vote"
+                ],
+                "summary": "Get community votes of a chart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chart address (ID or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChartVoteSummary"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/records/{username}": {
+            "get": {
+                "description": "Retrieve play records for a user based on scope (b50, best, all, all-charts)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "record"
+                ],
+                "summary": "Get play records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "b50",
+                        "description": "Scope (b50, best, all, all-charts)",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Underflow for b50",
+                        "name": "underflow",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page index",
+                        "name": "page_index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "rating",
+                        "description": "Sort by (rating, score, record_time, etc.)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order (desc or asc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum chart level (inclusive)",
+                        "name": "min_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum chart level (inclusive)",
+                        "name": "max_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by difficulty (detected, invaded, massive, reboot)",
+                        "name": "difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by season: true = new (B15), false = old (B35)",
+                        "name": "b15",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Season (ID, name or \\",
+                        "name": "season",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "all-charts scope",
+                        "schema": {
+                            "$ref": "#/definitions/model.AllChartsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Batch upload play records for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "record"
+                ],
+                "summary": "Upload play records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Play records upload info",
+                        "name": "record",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.BatchCreatePlayRecordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.PlayRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/records/{username}/chart/{chart_addr}": {
+            "get": {
+                "description": "Retrieve play records for a user scoped to a specific chart. chart_addr can be numeric chart_id or wiki_id:difficulty (e.g. felys:massive).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "record"
+                ],
+                "summary": "Get play records for a specific chart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chart address (numeric chart_id or wiki_id:difficulty)",
+                        "name": "chart_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "best",
+                        "description": "Scope (best, all)",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (scope=all only)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page index (scope=all only)",
+                        "name": "page_index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "rating",
+                        "description": "Sort by (rating, score, record_time)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order (desc or asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PlayRecordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/records/{username}/song/{song_addr}": {
+            "get": {
+                "description": "Retrieve play records for a user scoped to a specific song. song_addr can be numeric song_id or wiki_id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "record"
+                ],
+                "summary": "Get play records for a specific song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song address (numeric song_id or wiki_id)",
+                        "name": "song_addr",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "best",
+                        "description": "Scope (best, all)",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size (scope=all only)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page index (scope=all only)",
+                        "name": "page_index",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "rating",
+                        "description": "Sort by (rating, score, record_time)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Order (desc or asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PlayRecordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/seasons": {
+            "get": {
+                "description": "Retrieve all seasons with their member (new) songs, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "List seasons",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SeasonInfo"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a season (Admin only). The active season decides which songs are new (B15): once any\nseason exists, each song's b15 flag mirrors membership in the active season and switches\nautomatically when a season starts or ends. Use from_current to seed the first season with the\nsongs currently marked b15.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "Create a season",
+                "parameters": [
+                    {
+                        "description": "Season",
+                        "name": "season",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.CreateSeasonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.SeasonInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/seasons/{season_id}": {
+            "get": {
+                "description": "Retrieve a season with its member (new) songs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "Get a season",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Season ID, name or \\",
+                        "name": "season_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SeasonInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename or reschedule a season (Admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "Update a season",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Season ID",
+                        "name": "season_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Season",
+                        "name": "season",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UpdateSeasonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SeasonInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a season (Admin only). Deleting the last season keeps the current b15 flags and makes\nthem directly editable again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "Delete a season",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Season ID",
+                        "name": "season_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/seasons/{season_id}/songs": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the member (new) songs of a season (Admin only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "season"
+                ],
+                "summary": "Set the songs of a season",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Season ID",
+                        "name": "season_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member song IDs",
+                        "name": "songs",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.SetSeasonSongsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SeasonInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "description": "Retrieve a list of all charts with their details",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get all charts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ChartInfo"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update song details and its charts (Admin only). Every changed field is recorded in the song's history.\nWith preview=true nothing is committed; instead a report of how the update would change\naffected users' B50 (including rating recalculation for level changes) is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Update an existing song",
+                "parameters": [
+                    {
+                        "description": "Song update info",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UpdateSongRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Only report the rating impact, do not commit",
+                        "name": "preview",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "preview=true",
+                        "schema": {
+                            "$ref": "#/definitions/model.RatingImpactReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/changes": {
+            "get": {
+                "description": "Retrieve the charts created, updated (including fitting_level changes and restores) and retired\nsince a cursor, in the same row format as GET /songs. Pass the returned cursor as since on the\nnext call; without since every live chart is returned as created. Rows may repeat across\nconsecutive responses and should be applied by chart ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get catalog changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous call",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.SongChanges"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/search": {
+            "get": {
+                "description": "Fuzzy-search songs by title, per-chart override title, artist, album and community alias.\nMatching ignores case, width and kana script; romaji matches kana titles and\npinyin (full or initials) matches Chinese titles. Results are sorted by score.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Search songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results (max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SongSearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}": {
+            "get": {
+                "description": "Retrieve detailed information about a single song by ID\nWith src=wiki the song's metadata as currently published on the community wiki is laid over\nthe stored song (fields the wiki leaves empty keep their stored values).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get single song info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song ID or wiki_id",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "prp",
+                        "description": "Source (prp or wiki)",
+                        "name": "src",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "502": {
+                        "description": "src=wiki and the wiki could not be reached",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retire (soft-delete) a song (Admin only). Its charts are hidden from /songs and excluded\nfrom B50, but players' records on them remain visible in record history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Retire a song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}/aliases": {
+            "get": {
+                "description": "Retrieve the community aliases of a song",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get song aliases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SongAlias"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a community alias to a song (Admin only). Aliases are unique across all songs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Add a song alias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Alias info",
+                        "name": "alias",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.CreateSongAliasRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.SongAlias"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}/aliases/{alias_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a community alias from a song (Admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Delete a song alias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alias ID",
+                        "name": "alias_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve every recorded change to a song and its charts, newest first (Admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Get song change history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ChartHistory"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore a previously retired song (Admin only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song"
+                ],
+                "summary": "Restore a retired song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Retired song ID or wiki_id",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{song_id}/wiki-sync": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compare a song with its community wiki page and file a pending proposal for every differing\nartist, BPM, illustrator, song cover or chart cover (Admin only). The song itself is not changed;\nproposals are applied only once accepted. Proposals identical to a pending one are not filed again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wiki"
+                ],
+                "summary": "Sync a song from the wiki",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Song address (ID, wiki_id or alias)",
+                        "name": "song_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Newly filed proposals",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SongMetadataProposal"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Wiki source not configured",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "description": "Authenticate user and return access and refresh JWT tokens",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Token"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the profile of the currently authenticated user, with their skill as estimated by the last fitting run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get current user info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the profile of the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update current user info",
+                "parameters": [
+                    {
+                        "description": "User update info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/me/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the current user's password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Password change info",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -694,8 +2062,139 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.UploadToken"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/refresh": {
+            "post": {
+                "description": "Exchange a valid refresh token for a new access/refresh token pair",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Token"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "Create a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.UserPublic"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reset-password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reset a user's password by admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Reset user password (Admin only)",
+                "parameters": [
+                    {
+                        "description": "Password reset info",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -703,39 +2202,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/refresh": {
-            "post": {
-                "description": "Exchange a valid refresh token for a new access/refresh token pair",
-                "consumes": [
-                    "application/json"
+        "/wiki/proposals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "List metadata proposals filed by wiki syncs, newest first (Admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "wiki"
                 ],
-                "summary": "Refresh access token",
+                "summary": "List song metadata proposals",
                 "parameters": [
                     {
-                        "description": "Refresh token",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.RefreshTokenRequest"
-                        }
+                        "type": "string",
+                        "description": "Filter by status (pending, accepted or rejected)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by song ID",
+                        "name": "song_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Token"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SongMetadataProposal"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -743,35 +2250,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/register": {
+        "/wiki/proposals/{proposal_id}/accept": {
             "post": {
-                "description": "Create a new user account",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Apply a pending proposal to the song (Admin only). The change is recorded in the song's history.\nFails with 409 if the proposal was already reviewed or the field was edited after the sync.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "wiki"
                 ],
-                "summary": "Register a new user",
+                "summary": "Accept a song metadata proposal",
                 "parameters": [
                     {
-                        "description": "User registration info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.CreateUserRequest"
-                        }
+                        "type": "integer",
+                        "description": "Proposal ID",
+                        "name": "proposal_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.UserPublic"
+                            "$ref": "#/definitions/model.SongMetadataProposal"
                         }
                     },
                     "400": {
@@ -779,44 +2286,51 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
                     }
                 }
             }
         },
-        "/user/reset-password": {
+        "/wiki/proposals/{proposal_id}/reject": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Reset a user's password by admin",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Mark a pending proposal as rejected without changing the song (Admin only)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "wiki"
                 ],
-                "summary": "Reset user password (Admin only)",
+                "summary": "Reject a song metadata proposal",
                 "parameters": [
                     {
-                        "description": "Password reset info",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.ResetPasswordRequest"
-                        }
+                        "type": "integer",
+                        "description": "Proposal ID",
+                        "name": "proposal_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Response"
+                            "$ref": "#/definitions/model.SongMetadataProposal"
                         }
                     },
                     "400": {
@@ -825,8 +2339,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.Response"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/model.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/model.Response"
                         }
@@ -853,6 +2373,139 @@ const docTemplate = `{
                 }
             }
         },
+        "model.Catalog": {
+            "type": "object",
+            "required": [
+                "songs"
+            ],
+            "properties": {
+                "songs": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/model.CatalogSong"
+                    }
+                }
+            }
+        },
+        "model.CatalogChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action is one of create, update, level_change or retire.",
+                    "type": "string",
+                    "example": "level_change"
+                },
+                "difficulty": {
+                    "description": "Difficulty is set when the change applies to a single chart; it is\nempty when a whole song is created or retired, or for song metadata.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Difficulty"
+                        }
+                    ],
+                    "example": "massive"
+                },
+                "field": {
+                    "description": "Field, OldValue and NewValue describe updates and level changes,\nusing the same renderings as ChartHistory.",
+                    "type": "string",
+                    "example": "level"
+                },
+                "new_value": {
+                    "type": "string",
+                    "example": "14.7"
+                },
+                "old_value": {
+                    "type": "string",
+                    "example": "14.5"
+                },
+                "song_id": {
+                    "description": "SongID is 0 for songs that are being created.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "wiki_id": {
+                    "type": "string",
+                    "example": "w123"
+                }
+            }
+        },
+        "model.CatalogPlan": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied is true when the plan was committed rather than only computed.",
+                    "type": "boolean"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CatalogChange"
+                    }
+                }
+            }
+        },
+        "model.CatalogSong": {
+            "type": "object",
+            "required": [
+                "artist",
+                "charts",
+                "title",
+                "wiki_id"
+            ],
+            "properties": {
+                "album": {
+                    "type": "string",
+                    "example": "First Album"
+                },
+                "artist": {
+                    "type": "string",
+                    "example": "Artist Name"
+                },
+                "b15": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "bpm": {
+                    "type": "string",
+                    "example": "180"
+                },
+                "charts": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/model.ChartInput"
+                    }
+                },
+                "cover": {
+                    "type": "string",
+                    "example": "Cover_d3d3d3.jpg"
+                },
+                "genre": {
+                    "type": "string",
+                    "example": "Pop"
+                },
+                "illustrator": {
+                    "type": "string",
+                    "example": "Artist"
+                },
+                "length": {
+                    "type": "string",
+                    "example": "2:30"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Song Title"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                },
+                "wiki_id": {
+                    "type": "string",
+                    "example": "w123"
+                }
+            }
+        },
         "model.Chart": {
             "type": "object",
             "properties": {
@@ -871,6 +2524,15 @@ const docTemplate = `{
                     "type": "number",
                     "x-nullable": "true"
                 },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true"
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -883,30 +2545,79 @@ const docTemplate = `{
                 "notes": {
                     "type": "integer"
                 },
-                "override_artist": {
+                "override_artist": {
+                    "type": "string",
+                    "example": "Alt Artist"
+                },
+                "override_cover": {
+                    "type": "string",
+                    "example": "Cover_alt.jpg"
+                },
+                "override_title": {
+                    "type": "string",
+                    "example": "Alt Title"
+                },
+                "override_version": {
+                    "type": "string",
+                    "example": "2.0.0"
+                },
+                "song": {
+                    "$ref": "#/definitions/model.Song"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ChartHistory": {
+            "type": "object",
+            "properties": {
+                "changed_by": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "chart_id": {
+                    "description": "ChartID is nil for song-level fields (title, artist, b15, ...), which apply to every chart of the song.",
+                    "type": "integer",
+                    "x-nullable": "true",
+                    "example": 10
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "description": "Difficulty of the chart the entry refers to; empty for song-level fields.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Difficulty"
+                        }
+                    ],
+                    "example": "massive"
+                },
+                "field": {
                     "type": "string",
-                    "example": "Alt Artist"
+                    "example": "level"
                 },
-                "override_cover": {
-                    "type": "string",
-                    "example": "Cover_alt.jpg"
+                "id": {
+                    "type": "integer"
                 },
-                "override_title": {
+                "new_value": {
                     "type": "string",
-                    "example": "Alt Title"
+                    "x-nullable": "true",
+                    "example": "14.7"
                 },
-                "override_version": {
+                "old_value": {
+                    "description": "OldValue / NewValue are string renderings of the field; nil means \"unset\"\n(e.g. OldValue is nil when a chart is created).",
                     "type": "string",
-                    "example": "2.0.0"
-                },
-                "song": {
-                    "$ref": "#/definitions/model.Song"
+                    "x-nullable": "true",
+                    "example": "14.5"
                 },
                 "song_id": {
-                    "type": "integer"
-                },
-                "updated_at": {
-                    "type": "string"
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
@@ -951,6 +2662,17 @@ const docTemplate = `{
                     "x-nullable": "true",
                     "example": 13.4
                 },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 13.25
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 13.55
+                },
                 "genre": {
                     "type": "string",
                     "example": "Pop"
@@ -1013,12 +2735,25 @@ const docTemplate = `{
                     "type": "number",
                     "x-nullable": "true"
                 },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true"
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "level": {
                     "type": "number"
                 },
+                "retired": {
+                    "description": "Retired is true when the chart or its song has been retired by an admin.\nRetired charts no longer count towards B50 but remain in record history.",
+                    "type": "boolean"
+                },
                 "song_id": {
                     "type": "integer"
                 },
@@ -1086,6 +2821,309 @@ const docTemplate = `{
                 }
             }
         },
+        "model.ChartPatch": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "number",
+                    "example": 14.5
+                },
+                "level_design": {
+                    "type": "string",
+                    "example": "Designer"
+                },
+                "notes": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1000
+                },
+                "override_artist": {
+                    "type": "string",
+                    "example": "Alt Artist"
+                },
+                "override_cover": {
+                    "type": "string",
+                    "example": "Cover_alt.jpg"
+                },
+                "override_title": {
+                    "type": "string",
+                    "example": "Alt Title"
+                },
+                "override_version": {
+                    "type": "string",
+                    "example": "2.0.0"
+                }
+            }
+        },
+        "model.ChartRank": {
+            "type": "object",
+            "properties": {
+                "percentile": {
+                    "description": "Percentile is the share of players, in percent, whose best score is at\nor below this one.",
+                    "type": "number",
+                    "example": 90.8
+                },
+                "rank": {
+                    "description": "Rank is 1 for the highest score; tied players share a rank.",
+                    "type": "integer",
+                    "example": 12
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 1005000
+                }
+            }
+        },
+        "model.ChartStatistic": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "effective_sample_size": {
+                    "description": "EffectiveSampleSize (N_eff) is computed via Kish's formula:\n  N_eff = (Σ w_i)² / Σ w_i²\nwhere w_i is the final composite weight of each sample. It reflects how\nmany \"ideal\" samples the weighted aggregation is equivalent to.",
+                    "type": "number"
+                },
+                "fitting_level": {
+                    "description": "FittingLevel mirrors the value persisted to charts.fitting_level. Nil\nwhen the sample was insufficient to publish a level.",
+                    "type": "number"
+                },
+                "fitting_level_lower": {
+                    "description": "FittingLevelLower and FittingLevelUpper bound the bootstrap confidence\ninterval of FittingLevel (fitting.confidence_level). Nil when\nFittingLevel is, or when the interval is disabled.",
+                    "type": "number"
+                },
+                "fitting_level_upper": {
+                    "type": "number"
+                },
+                "last_computed_at": {
+                    "description": "LastComputedAt is the wall-clock time of the most recent computation.",
+                    "type": "string"
+                },
+                "mad": {
+                    "description": "MAD is the median absolute deviation of inferred levels, used as the\nrobust dispersion estimator inside the Tukey biweight step.",
+                    "type": "number"
+                },
+                "official_level": {
+                    "description": "OfficialLevel is a snapshot of charts.level at the time of computation.",
+                    "type": "number"
+                },
+                "sample_count": {
+                    "description": "SampleCount is the raw number of best_play_records considered for this chart\n(before robust trimming / weighting).",
+                    "type": "integer"
+                },
+                "std_dev": {
+                    "description": "StdDev is the weighted standard deviation of inferred levels (post trim).",
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weighted_mean": {
+                    "description": "WeightedMean is the weighted arithmetic mean of per-sample inferred\nlevels, before shrinkage.",
+                    "type": "number"
+                },
+                "weighted_median": {
+                    "description": "WeightedMedian is the weighted median of per-sample inferred levels;\nalso serves as the initial anchor for robust (Tukey biweight) trimming.",
+                    "type": "number"
+                }
+            }
+        },
+        "model.ChartStats": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "distribution": {
+                    "description": "Distribution is nil when the chart has too few players to publish it.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ScoreDistribution"
+                        }
+                    ],
+                    "x-nullable": "true"
+                },
+                "fitting": {
+                    "description": "Fitting is the output of the last fitting run; nil before the chart was\nfirst fitted.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChartStatistic"
+                        }
+                    ],
+                    "x-nullable": "true"
+                },
+                "fitting_level": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.4
+                },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.25
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.55
+                },
+                "level": {
+                    "type": "number",
+                    "example": 15.2
+                },
+                "my_rank": {
+                    "description": "MyRank is the caller's place on the chart (authenticated requests with a\nbest record only, and only when Distribution is published).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ChartRank"
+                        }
+                    ]
+                }
+            }
+        },
+        "model.ChartStatsSummary": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "difficulty": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Difficulty"
+                        }
+                    ],
+                    "example": "massive"
+                },
+                "effective_sample_size": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 41.3
+                },
+                "fitting_level": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.4
+                },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.25
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.55
+                },
+                "level": {
+                    "type": "number",
+                    "example": 15.2
+                },
+                "pass_rate": {
+                    "description": "PassRate is nil when the chart has too few players to publish it.",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 0.667
+                },
+                "player_count": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "sample_count": {
+                    "description": "SampleCount, EffectiveSampleSize and StdDev come from the last fitting\nrun; nil before the chart was first fitted.",
+                    "type": "integer",
+                    "x-nullable": "true",
+                    "example": 96
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "std_dev": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 0.21
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Felys"
+                },
+                "wiki_id": {
+                    "type": "string",
+                    "example": "felys"
+                }
+            }
+        },
+        "model.ChartVoteSummary": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer"
+                },
+                "community_level": {
+                    "description": "CommunityLevel is Level + MeanDelta; nil without votes.",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.45
+                },
+                "fitting_level": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.4
+                },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.25
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.55
+                },
+                "level": {
+                    "type": "number",
+                    "example": 15.2
+                },
+                "mean_delta": {
+                    "description": "MeanDelta is the average vote; nil without votes.",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 0.25
+                },
+                "my_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "my_vote": {
+                    "description": "MyVote and MyTags are the caller's own vote and tags (authenticated requests only).",
+                    "type": "number",
+                    "example": 0.3
+                },
+                "tags": {
+                    "description": "Tags are sorted by count, most used first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TagCount"
+                    }
+                },
+                "vote_count": {
+                    "description": "VoteCount is the number of level votes.",
+                    "type": "integer",
+                    "example": 20
+                }
+            }
+        },
         "model.ChartWithScore": {
             "type": "object",
             "properties": {
@@ -1124,6 +3162,27 @@ const docTemplate = `{
                 "DifficultyReboot"
             ]
         },
+        "model.EstimatedSkill": {
+            "type": "object",
+            "properties": {
+                "computed_at": {
+                    "type": "string"
+                },
+                "record_count": {
+                    "type": "integer",
+                    "example": 312
+                },
+                "skill": {
+                    "description": "Skill is the mean rating of the player's top-K best records, in the\nfloat form (e.g. 162.35).",
+                    "type": "number",
+                    "example": 162.35
+                },
+                "top_k": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
         "model.PlayRecord": {
             "type": "object",
             "required": [
@@ -1189,6 +3248,11 @@ const docTemplate = `{
                 "chart": {
                     "$ref": "#/definitions/model.ChartInfoSimple"
                 },
+                "expected_score": {
+                    "description": "ExpectedScore is the score the player's estimated skill predicts on\nthe chart (b50 scope only, when the skill is known).",
+                    "type": "integer",
+                    "example": 1004200
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1206,6 +3270,14 @@ const docTemplate = `{
         "model.PlayRecordResponse": {
             "type": "object",
             "properties": {
+                "estimated_skill": {
+                    "description": "EstimatedSkill is the player's skill as of the last fitting run (b50\nscope only; absent before the player was first fitted).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.EstimatedSkill"
+                        }
+                    ]
+                },
                 "nickname": {
                     "type": "string"
                 },
@@ -1223,6 +3295,57 @@ const docTemplate = `{
                 }
             }
         },
+        "model.RatingImpactReport": {
+            "type": "object",
+            "properties": {
+                "affected_users": {
+                    "description": "AffectedUsers is the number of users holding a best record on any chart of the song.",
+                    "type": "integer",
+                    "example": 120
+                },
+                "b50_changed_users": {
+                    "description": "B50ChangedUsers counts users whose set of B50 charts would change.",
+                    "type": "integer",
+                    "example": 12
+                },
+                "changes": {
+                    "description": "Changes lists the history entries the update would record.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChartHistory"
+                    }
+                },
+                "max_gain": {
+                    "type": "integer",
+                    "example": 640
+                },
+                "max_loss": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "rating_changed_users": {
+                    "description": "RatingChangedUsers counts users whose B50 sum would change.",
+                    "type": "integer",
+                    "example": 80
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total_delta": {
+                    "description": "TotalDelta, MaxGain and MaxLoss are in B50-sum units (see UserRatingImpact);\nMaxLoss is the most negative delta, so it is never positive.",
+                    "type": "integer",
+                    "example": 25600
+                },
+                "users": {
+                    "description": "Users lists every user whose B50 sum or composition would change, largest |delta| first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UserRatingImpact"
+                    }
+                }
+            }
+        },
         "model.Response": {
             "type": "object",
             "properties": {
@@ -1234,7 +3357,289 @@ const docTemplate = `{
                 }
             }
         },
-        "model.Song": {
+        "model.ScoreBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "min_score": {
+                    "type": "integer",
+                    "example": 1000000
+                }
+            }
+        },
+        "model.ScoreDistribution": {
+            "type": "object",
+            "properties": {
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScoreBucket"
+                    }
+                },
+                "pass_count": {
+                    "description": "PassCount is the number of best scores at or above the pass score.",
+                    "type": "integer",
+                    "example": 80
+                },
+                "pass_rate": {
+                    "type": "number",
+                    "example": 0.667
+                },
+                "percentiles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScorePercentile"
+                    }
+                },
+                "player_count": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "model.ScorePercentile": {
+            "type": "object",
+            "properties": {
+                "percentile": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 1003500
+                }
+            }
+        },
+        "model.SeasonInfo": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active is true for the season that currently decides B15.",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "description": "EndsAt is nil for an open-ended season.",
+                    "type": "string",
+                    "x-nullable": "true"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "2025 Summer"
+                },
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "starts_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Song": {
+            "type": "object",
+            "required": [
+                "artist",
+                "title",
+                "wiki_id"
+            ],
+            "properties": {
+                "album": {
+                    "type": "string",
+                    "example": "First Album"
+                },
+                "artist": {
+                    "type": "string",
+                    "example": "Artist Name"
+                },
+                "b15": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "bpm": {
+                    "type": "string",
+                    "example": "180"
+                },
+                "charts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Chart"
+                    }
+                },
+                "cover": {
+                    "type": "string",
+                    "example": "Cover_d3d3d3.jpg"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "genre": {
+                    "type": "string",
+                    "example": "Pop"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "illustrator": {
+                    "type": "string",
+                    "example": "Artist"
+                },
+                "length": {
+                    "type": "string",
+                    "example": "2:30"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Song Title"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                },
+                "wiki_id": {
+                    "type": "string",
+                    "example": "w123"
+                }
+            }
+        },
+        "model.SongAlias": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "nickname"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SongChanges": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Created holds the rows (as in GET /songs) of charts added since the cursor",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChartInfo"
+                    }
+                },
+                "cursor": {
+                    "description": "Cursor is passed as since on the next request",
+                    "type": "string",
+                    "example": "1767225600000000"
+                },
+                "retired_chart_ids": {
+                    "description": "RetiredChartIDs lists charts retired since the cursor, including the\ncharts of retired songs",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "retired_song_ids": {
+                    "description": "RetiredSongIDs lists songs retired since the cursor",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "updated": {
+                    "description": "Updated holds the current rows of charts whose chart or song changed,\nincluding fitting_level updates and restored songs and charts",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChartInfo"
+                    }
+                }
+            }
+        },
+        "model.SongMetadataProposal": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "description": "ChartID and Difficulty are set for chart-level fields (override_cover).",
+                    "type": "integer",
+                    "x-nullable": "true",
+                    "example": 10
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current_value": {
+                    "description": "CurrentValue is the value at the time of the sync (nil = unset override).\nAccepting fails if the song has been edited since.",
+                    "type": "string",
+                    "x-nullable": "true",
+                    "example": "Old Artist"
+                },
+                "difficulty": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Difficulty"
+                        }
+                    ],
+                    "example": "massive"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "artist"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "proposed_value": {
+                    "type": "string",
+                    "example": "New Artist"
+                },
+                "reviewed_at": {
+                    "type": "string",
+                    "x-nullable": "true"
+                },
+                "reviewed_by": {
+                    "description": "ReviewedBy / ReviewedAt are set once the proposal is accepted or rejected.",
+                    "type": "string",
+                    "x-nullable": "true",
+                    "example": "admin"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "source": {
+                    "type": "string",
+                    "example": "wiki"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "model.SongSearchResult": {
             "type": "object",
             "required": [
                 "artist",
@@ -1258,26 +3663,14 @@ const docTemplate = `{
                     "type": "string",
                     "example": "180"
                 },
-                "charts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/model.Chart"
-                    }
-                },
                 "cover": {
                     "type": "string",
                     "example": "Cover_d3d3d3.jpg"
                 },
-                "created_at": {
-                    "type": "string"
-                },
                 "genre": {
                     "type": "string",
                     "example": "Pop"
                 },
-                "id": {
-                    "type": "integer"
-                },
                 "illustrator": {
                     "type": "string",
                     "example": "Artist"
@@ -1286,13 +3679,29 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2:30"
                 },
+                "matched_field": {
+                    "description": "MatchedField is the field that produced the best match:\ntitle, override_title, artist, album or alias.",
+                    "type": "string",
+                    "example": "alias"
+                },
+                "matched_text": {
+                    "description": "MatchedText is the original (un-normalised) text that matched.",
+                    "type": "string",
+                    "example": "nickname"
+                },
+                "score": {
+                    "description": "Score is the match quality in (0, 1]; results are sorted by it descending.",
+                    "type": "number",
+                    "example": 0.95
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "title": {
                     "type": "string",
                     "example": "Song Title"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
                 "version": {
                     "type": "string",
                     "example": "1.0.0"
@@ -1303,6 +3712,121 @@ const docTemplate = `{
                 }
             }
         },
+        "model.TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "stamina"
+                }
+            }
+        },
+        "model.TierListEntry": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer"
+                },
+                "community_level": {
+                    "description": "CommunityLevel is Level + MeanDelta; nil without votes.",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.45
+                },
+                "cover": {
+                    "type": "string",
+                    "example": "Cover_d3d3d3.jpg"
+                },
+                "difficulty": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Difficulty"
+                        }
+                    ],
+                    "example": "massive"
+                },
+                "fitting_level": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.4
+                },
+                "fitting_level_lower": {
+                    "description": "Confidence interval of FittingLevel (see ChartStatistic).",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.25
+                },
+                "fitting_level_upper": {
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 15.55
+                },
+                "level": {
+                    "type": "number",
+                    "example": 15.2
+                },
+                "mean_delta": {
+                    "description": "MeanDelta is the average vote; nil without votes.",
+                    "type": "number",
+                    "x-nullable": "true",
+                    "example": 0.25
+                },
+                "my_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "my_vote": {
+                    "description": "MyVote and MyTags are the caller's own vote and tags (authenticated requests only).",
+                    "type": "number",
+                    "example": 0.3
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "tags": {
+                    "description": "Tags are sorted by count, most used first.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TagCount"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Felys"
+                },
+                "vote_count": {
+                    "description": "VoteCount is the number of level votes.",
+                    "type": "integer",
+                    "example": 20
+                },
+                "wiki_id": {
+                    "type": "string",
+                    "example": "felys"
+                }
+            }
+        },
+        "model.TierListGroup": {
+            "type": "object",
+            "properties": {
+                "charts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TierListEntry"
+                    }
+                },
+                "level": {
+                    "type": "number",
+                    "example": 15.2
+                }
+            }
+        },
         "model.Token": {
             "type": "object",
             "properties": {
@@ -1388,6 +3912,76 @@ const docTemplate = `{
                 }
             }
         },
+        "model.UserProfile": {
+            "type": "object",
+            "required": [
+                "email",
+                "username"
+            ],
+            "properties": {
+                "account": {
+                    "type": "string",
+                    "example": "act_001"
+                },
+                "account_number": {
+                    "type": "integer",
+                    "example": 1001
+                },
+                "anonymous_probe": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "estimated_skill": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.EstimatedSkill"
+                        }
+                    ],
+                    "x-nullable": "true"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_admin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "小明"
+                },
+                "qq_account": {
+                    "type": "string",
+                    "example": "12345678"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "upload_token": {
+                    "type": "string",
+                    "example": "token_xyz"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "user123"
+                },
+                "uuid": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
         "model.UserPublic": {
             "type": "object",
             "properties": {
@@ -1429,6 +4023,30 @@ const docTemplate = `{
                 }
             }
         },
+        "model.UserRatingImpact": {
+            "type": "object",
+            "properties": {
+                "b50_changed": {
+                    "type": "boolean"
+                },
+                "delta": {
+                    "type": "integer",
+                    "example": 320
+                },
+                "new_b50_sum": {
+                    "type": "integer",
+                    "example": 815320
+                },
+                "old_b50_sum": {
+                    "type": "integer",
+                    "example": 815000
+                },
+                "username": {
+                    "type": "string",
+                    "example": "player1"
+                }
+            }
+        },
         "request.BatchCreatePlayRecordRequest": {
             "type": "object",
             "required": [
@@ -1468,6 +4086,85 @@ const docTemplate = `{
                 }
             }
         },
+        "request.ChartTagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "stamina",
+                        "tech"
+                    ]
+                }
+            }
+        },
+        "request.ChartVoteRequest": {
+            "type": "object",
+            "required": [
+                "delta"
+            ],
+            "properties": {
+                "delta": {
+                    "description": "Delta is how much harder (positive) or easier (negative) the chart feels\nthan its official level, within ±model.MaxVoteDelta.",
+                    "type": "number",
+                    "maximum": 1.5,
+                    "minimum": -1.5,
+                    "example": 0.3
+                }
+            }
+        },
+        "request.CreateSeasonRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "starts_at"
+            ],
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "from_current": {
+                    "description": "FromCurrent adds every song currently marked b15 to the season, which is\nhow the first season is seeded from the legacy per-song flags.",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "2025 Summer"
+                },
+                "song_ids": {
+                    "description": "SongIDs are the songs that are new in this season.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.CreateSongAliasRequest": {
+            "type": "object",
+            "required": [
+                "alias"
+            ],
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "nickname"
+                }
+            }
+        },
         "request.CreateSongRequest": {
             "type": "object",
             "required": [
@@ -1586,6 +4283,40 @@ const docTemplate = `{
                 }
             }
         },
+        "request.SetSeasonSongsRequest": {
+            "type": "object",
+            "required": [
+                "song_ids"
+            ],
+            "properties": {
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "request.UpdateSeasonRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "starts_at"
+            ],
+            "properties": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "2025 Summer"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
         "request.UpdateSongRequest": {
             "type": "object",
             "required": [
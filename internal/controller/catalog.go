@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/logging"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogContentTypes maps catalog formats to their response content type.
+var catalogContentTypes = map[string]string{
+	model.CatalogFormatYAML: "application/yaml; charset=utf-8",
+	model.CatalogFormatJSON: "application/json; charset=utf-8",
+}
+
+// ExportCatalog godoc
+// @Summary Export the song catalog
+// @Description Export every live song and chart as a catalog file keyed by wiki_id (Admin only)
+// @Tags catalog
+// @Produce json
+// @Produce application/yaml
+// @Security BearerAuth
+// @Param format query string false "Catalog format" Enums(yaml, json) default(json)
+// @Success 200 {object} model.Catalog
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /catalog [get]
+func (ctrl *SongController) ExportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", model.CatalogFormatJSON)
+	contentType, ok := catalogContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "format must be yaml or json"})
+		return
+	}
+
+	catalog, err := ctrl.songService.ExportCatalog(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	data, err := service.EncodeCatalog(catalog, format)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="catalog.`+format+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportCatalog godoc
+// @Summary Import the song catalog
+// @Description Diff a catalog file against the database and return the plan (create, update, level_change, retire).
+// @Description With apply=true the plan is committed in a single transaction, reusing the song update
+// @Description validation, history and rating recalculation. With prune=true live songs missing from
+// @Description the catalog are retired. (Admin only)
+// @Tags catalog
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "Catalog format" Enums(yaml, json) default(json)
+// @Param apply query bool false "Commit the plan instead of only computing it" default(false)
+// @Param prune query bool false "Retire songs missing from the catalog" default(false)
+// @Param catalog body model.Catalog true "Catalog file"
+// @Success 200 {object} model.CatalogPlan
+// @Failure 400 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /catalog [post]
+func (ctrl *SongController) ImportCatalog(c *gin.Context) {
+	format := c.DefaultQuery("format", model.CatalogFormatJSON)
+	var opts service.CatalogImportOptions
+	var err error
+	if opts.Apply, err = strconv.ParseBool(c.DefaultQuery("apply", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "apply must be a boolean"})
+		return
+	}
+	if opts.Prune, err = strconv.ParseBool(c.DefaultQuery("prune", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "prune must be a boolean"})
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+	catalog, err := service.DecodeCatalog(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(),
+		slog.Bool("apply", opts.Apply), slog.Bool("prune", opts.Prune))
+	plan, err := ctrl.songService.ImportCatalog(ctx, catalog, opts, c.GetString("username"))
+	if err != nil {
+		if errors.Is(err, service.ErrConflict) {
+			c.JSON(http.StatusConflict, model.Response{Error: err.Error()})
+		} else {
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
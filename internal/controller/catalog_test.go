@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSongController_Catalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "catalog_test", Title: "Catalog Song", Artist: "Artist"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14, Notes: 600}},
+	}
+	env.db.Create(&song)
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+	r.GET("/catalog", env.songCtrl.ExportCatalog)
+	r.POST("/catalog", setUser, env.songCtrl.ImportCatalog)
+
+	t.Run("Export YAML", func(t *testing.T) {
+		w := performRequest(r, "GET", "/catalog?format=yaml", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/yaml")
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "catalog.yaml")
+		assert.Contains(t, w.Body.String(), "wiki_id: catalog_test")
+	})
+
+	t.Run("Export JSON by default", func(t *testing.T) {
+		w := performRequest(r, "GET", "/catalog", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var catalog model.Catalog
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &catalog))
+		assert.Len(t, catalog.Songs, 1)
+	})
+
+	t.Run("Export unknown format", func(t *testing.T) {
+		w := performRequest(r, "GET", "/catalog?format=xml", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	body := `
+songs:
+  - wiki_id: catalog_test
+    title: Catalog Song
+    artist: Artist
+    charts:
+      - {difficulty: massive, level: 14.2, notes: 600}
+  - wiki_id: catalog_new
+    title: New Song
+    artist: Artist
+    charts:
+      - {difficulty: massive, level: 13, notes: 500}
+`
+	yamlHeader := map[string]string{"Content-Type": "application/yaml"}
+
+	t.Run("Import plan then apply", func(t *testing.T) {
+		w := performRequest(r, "POST", "/catalog?format=yaml", strings.NewReader(body), yamlHeader)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var plan model.CatalogPlan
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+		assert.False(t, plan.Applied)
+		assert.Len(t, plan.Changes, 2)
+		assert.Equal(t, model.CatalogActionLevelChange, plan.Changes[0].Action)
+		assert.Equal(t, model.CatalogActionCreate, plan.Changes[1].Action)
+
+		w = performRequest(r, "POST", "/catalog?format=yaml&apply=true", strings.NewReader(body), yamlHeader)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+		assert.True(t, plan.Applied)
+
+		var chart model.Chart
+		env.db.Where("song_id = ?", song.ID).First(&chart)
+		assert.Equal(t, 14.2, chart.Level)
+
+		var history []model.ChartHistory
+		env.db.Where("song_id = ?", song.ID).Find(&history)
+		assert.Len(t, history, 1)
+		assert.Equal(t, "admin", history[0].ChangedBy)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		w := performRequest(r, "POST", "/catalog?format=yaml&apply=maybe", strings.NewReader(body), yamlHeader)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = performRequest(r, "POST", "/catalog", strings.NewReader(body), yamlHeader)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "YAML body parsed as JSON")
+
+		w = performRequest(r, "POST", "/catalog", bytes.NewBufferString(`{"songs":[]}`), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
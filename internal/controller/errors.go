@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus returns the HTTP status of a request that failed with err:
+// 504 when a database query ran past its timeout, 503 when the request was
+// cancelled (the client went away or the server is shutting down), and
+// status otherwise.
+func errorStatus(err error, status int) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return status
+	}
+}
+
+// writeResolveError answers a failed song_addr, chart_addr or season lookup: 404 when
+// nothing matches the address, the errorStatus of any other failure.
+func writeResolveError(c *gin.Context, err error) {
+	status := http.StatusNotFound
+	if !errors.Is(err, service.ErrNotFound) {
+		status = errorStatus(err, http.StatusInternalServerError)
+	}
+	c.JSON(status, model.Response{Error: err.Error()})
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStatus(t *testing.T) {
+	assert.Equal(t, http.StatusGatewayTimeout, errorStatus(fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusInternalServerError))
+	assert.Equal(t, http.StatusServiceUnavailable, errorStatus(context.Canceled, http.StatusBadRequest))
+	assert.Equal(t, http.StatusBadRequest, errorStatus(errors.New("invalid"), http.StatusBadRequest))
+}
+
+// Database queries past their timeout are answered with 504.
+func TestQueryTimeoutResponses(t *testing.T) {
+	env := setupEnv(t)
+	r := gin.Default()
+	r.GET("/songs/:song_id", env.songCtrl.GetSingleSongInfo)
+	r.GET("/songs/:song_id/history", env.songCtrl.GetSongHistory)
+	r.GET("/charts/:chart_addr/stats", env.statsCtrl.GetChartStats)
+	r.GET("/charts/:chart_addr/votes", env.voteCtrl.GetChartVotes)
+	env.db.Create(&model.Song{
+		SongBase: model.SongBase{Title: "Slow", Artist: "A", WikiID: "slow"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 10}},
+	})
+
+	config.DatabaseReadTimeoutDuration = time.Nanosecond
+	t.Cleanup(config.InitDefaults)
+
+	w := performRequest(r, "GET", "/songs/1", nil, nil)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	// Resolving the song or chart address is itself a query: running out of
+	// time there is not reported as "not found".
+	for _, path := range []string{"/songs/slow/history", "/charts/slow:massive/stats", "/charts/1/votes"} {
+		w = performRequest(r, "GET", path, nil, nil)
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code, path)
+	}
+
+	config.DatabaseReadTimeoutDuration = 0
+	w = performRequest(r, "GET", "/charts/nosuchsong:massive/stats", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = performRequest(r, "GET", "/charts/nocolon/stats", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "malformed addresses match nothing")
+}
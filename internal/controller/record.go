@@ -20,13 +20,15 @@ type RecordController struct {
 	recordService *service.RecordService
 	userService   *service.UserService
 	songService   *service.SongService
+	statsService  *service.StatsService
 }
 
-func NewRecordController(recordService *service.RecordService, userService *service.UserService, songService *service.SongService) *RecordController {
+func NewRecordController(recordService *service.RecordService, userService *service.UserService, songService *service.SongService, statsService *service.StatsService) *RecordController {
 	return &RecordController{
 		recordService: recordService,
 		userService:   userService,
 		songService:   songService,
+		statsService:  statsService,
 	}
 }
 
@@ -117,6 +119,7 @@ func parseRecordFilter(c *gin.Context) (model.RecordFilter, error) {
 // @Param max_level query number false "Maximum chart level (inclusive)"
 // @Param difficulty query []string false "Filter by difficulty (detected, invaded, massive, reboot)" collectionFormat(multi)
 // @Param b15 query boolean false "Filter by season: true = new (B15), false = old (B35)"
+// @Param season query string false "Season (ID, name or \"active\") deciding which songs are new for the b50 split and the b15 filter (default: the active season)"
 // @Success 200 {object} model.PlayRecordResponse "b50/best/all scope"
 // @Success 200 {object} model.AllChartsResponse "all-charts scope"
 // @Failure 400 {object} model.Response
@@ -136,6 +139,14 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
 		return
 	}
+	if seasonAddr := c.Query("season"); seasonAddr != "" {
+		season, err := ctrl.songService.ResolveSeason(c.Request.Context(), seasonAddr)
+		if err != nil {
+			writeResolveError(c, err)
+			return
+		}
+		filter.SeasonID = &season.ID
+	}
 
 	// Validate underflow
 	if underflow < 0 {
@@ -154,7 +165,7 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 	var currentUser *model.User
 	currentUsername, exists := c.Get("username")
 	if exists {
-		currentUser, _ = ctrl.userService.GetUser(currentUsername.(string))
+		currentUser, _ = ctrl.userService.GetUser(ctx, currentUsername.(string))
 	}
 
 	// Check authority
@@ -162,15 +173,15 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 		if errors.Is(err, service.ErrNotFound) {
 			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
 		} else {
-			c.JSON(http.StatusForbidden, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusForbidden), model.Response{Error: err.Error()})
 		}
 		return
 	}
 
 	// Fetch target user for nickname
-	targetUser, err := ctrl.userService.GetUser(username)
+	targetUser, err := ctrl.userService.GetUser(ctx, username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 	if targetUser == nil {
@@ -182,29 +193,37 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 	case "b50":
 		records, err := ctrl.recordService.GetBest50Records(ctx, username, underflow, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
+		// The estimated skill is an extra: the b50 is served without it when
+		// it cannot be read.
+		skill, _ := ctrl.statsService.GetEstimatedSkill(ctx, username)
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
 		for _, r := range records {
-			recordInfos = append(recordInfos, model.ToPlayRecordInfo(r))
+			info := model.ToPlayRecordInfo(r)
+			if skill != nil {
+				info.ExpectedScore = skill.ExpectedScore(info.Chart)
+			}
+			recordInfos = append(recordInfos, info)
 		}
 		c.JSON(http.StatusOK, model.PlayRecordResponse{
-			Username: username,
-			Nickname: targetUser.Nickname,
-			Total:    len(recordInfos),
-			Records:  recordInfos,
+			Username:       username,
+			Nickname:       targetUser.Nickname,
+			Total:          len(recordInfos),
+			Records:        recordInfos,
+			EstimatedSkill: skill,
 		})
 
 	case "best":
 		records, err := ctrl.recordService.GetBestRecords(ctx, username, p.pageSize, p.pageIndex-1, p.sortBy, p.order, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		total, err := ctrl.recordService.CountBestRecords(ctx, username, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
@@ -221,12 +240,12 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 	case "all":
 		records, err := ctrl.recordService.GetAllRecords(ctx, username, p.pageSize, p.pageIndex-1, p.sortBy, p.order, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		total, err := ctrl.recordService.CountAllRecords(ctx, username, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
@@ -243,7 +262,7 @@ func (ctrl *RecordController) GetPlayRecords(c *gin.Context) {
 	case "all-charts":
 		charts, err := ctrl.recordService.GetAllChartsWithBestScores(ctx, username, filter)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, model.AllChartsResponse{
@@ -285,8 +304,12 @@ func (ctrl *RecordController) UploadRecords(c *gin.Context) {
 	if currentUser == username {
 		authorized = true
 	} else {
-		user, err := ctrl.userService.GetUser(username)
-		if err == nil && user != nil && req.UploadToken != "" &&
+		user, err := ctrl.userService.GetUser(c.Request.Context(), username)
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+			return
+		}
+		if user != nil && req.UploadToken != "" &&
 			subtle.ConstantTimeCompare([]byte(req.UploadToken), []byte(user.UploadToken)) == 1 {
 			authorized = true
 		}
@@ -303,7 +326,7 @@ func (ctrl *RecordController) UploadRecords(c *gin.Context) {
 	)
 	records, err := ctrl.recordService.CreateRecords(ctx, username, req.PlayRecords, req.IsReplace)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -315,10 +338,10 @@ func (ctrl *RecordController) checkProbeAuthority(c *gin.Context, username strin
 	var currentUser *model.User
 	currentUsername, exists := c.Get("username")
 	if exists {
-		currentUser, _ = ctrl.userService.GetUser(currentUsername.(string))
+		currentUser, _ = ctrl.userService.GetUser(c.Request.Context(), currentUsername.(string))
 	}
 	if err := ctrl.userService.CheckProbeAuthority(c.Request.Context(), username, currentUser); err != nil {
-		c.JSON(http.StatusForbidden, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusForbidden), model.Response{Error: err.Error()})
 		return false
 	}
 	return true
@@ -354,7 +377,7 @@ func (ctrl *RecordController) GetSongRecords(c *gin.Context) {
 
 	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		writeResolveError(c, err)
 		return
 	}
 
@@ -363,9 +386,9 @@ func (ctrl *RecordController) GetSongRecords(c *gin.Context) {
 	}
 
 	// Fetch target user for nickname
-	targetUser, err := ctrl.userService.GetUser(username)
+	targetUser, err := ctrl.userService.GetUser(ctx, username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 	if targetUser == nil {
@@ -377,7 +400,7 @@ func (ctrl *RecordController) GetSongRecords(c *gin.Context) {
 	case "best":
 		records, err := ctrl.recordService.GetBestRecordsBySong(ctx, username, songID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
@@ -395,12 +418,12 @@ func (ctrl *RecordController) GetSongRecords(c *gin.Context) {
 		p := parsePaginationParams(c)
 		records, err := ctrl.recordService.GetAllRecordsBySong(ctx, username, songID, p.pageSize, p.pageIndex-1, p.sortBy, p.order)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		total, err := ctrl.recordService.CountAllRecordsBySong(ctx, username, songID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
@@ -449,7 +472,7 @@ func (ctrl *RecordController) GetChartRecords(c *gin.Context) {
 
 	chartID, err := ctrl.songService.ResolveChartID(ctx, chartAddr)
 	if err != nil {
-		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		writeResolveError(c, err)
 		return
 	}
 
@@ -458,9 +481,9 @@ func (ctrl *RecordController) GetChartRecords(c *gin.Context) {
 	}
 
 	// Fetch target user for nickname
-	targetUser, err := ctrl.userService.GetUser(username)
+	targetUser, err := ctrl.userService.GetUser(ctx, username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 	if targetUser == nil {
@@ -472,7 +495,7 @@ func (ctrl *RecordController) GetChartRecords(c *gin.Context) {
 	case "best":
 		record, err := ctrl.recordService.GetBestRecordByChart(ctx, username, chartID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, 1)
@@ -490,12 +513,12 @@ func (ctrl *RecordController) GetChartRecords(c *gin.Context) {
 		p := parsePaginationParams(c)
 		records, err := ctrl.recordService.GetAllRecordsByChart(ctx, username, chartID, p.pageSize, p.pageIndex-1, p.sortBy, p.order)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		total, err := ctrl.recordService.CountAllRecordsByChart(ctx, username, chartID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 			return
 		}
 		recordInfos := make([]model.PlayRecordInfo, 0, len(records))
@@ -48,13 +48,22 @@ func TestRecordController(t *testing.T) {
 	})
 
 	t.Run("GetPlayRecords Success", func(t *testing.T) {
+		// A skill of 100.00 is the rating of 1,000,000 on a level-10 chart
+		env.db.Create(&model.FittingPlayerSkill{Username: "testuser", AvgRating: 100, NumRecords: 1, TopK: 1})
+
 		w := performRequest(r, "GET", "/records/testuser?scope=b50", nil, nil)
 		assert.Equal(t, http.StatusOK, w.Code)
-		var resp map[string]interface{}
+		var resp model.PlayRecordResponse
 		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
-		assert.Equal(t, "testuser", resp["username"])
-		assert.Equal(t, "Test Nickname", resp["nickname"])
+		assert.Equal(t, "testuser", resp.Username)
+		assert.Equal(t, "Test Nickname", resp.Nickname)
+		if assert.NotNil(t, resp.EstimatedSkill) {
+			assert.Equal(t, 100.0, resp.EstimatedSkill.Skill)
+		}
+		if assert.Len(t, resp.Records, 1) {
+			assert.Equal(t, intPtr(1000000), resp.Records[0].ExpectedScore)
+		}
 	})
 }
 
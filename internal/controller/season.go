@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/logging"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"paradigm-reboot-prober-go/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeSeasonError maps season errors to HTTP responses.
+func writeSeasonError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+	case errors.Is(err, service.ErrConflict):
+		c.JSON(http.StatusConflict, model.Response{Error: err.Error()})
+	default:
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+	}
+}
+
+// parseSeasonID reads the numeric season_id path parameter, writing a 400 on failure.
+func parseSeasonID(c *gin.Context) (int, bool) {
+	seasonID, err := strconv.Atoi(c.Param("season_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid season_id"})
+		return 0, false
+	}
+	return seasonID, true
+}
+
+// GetSeasons godoc
+// @Summary List seasons
+// @Description Retrieve all seasons with their member (new) songs, oldest first
+// @Tags season
+// @Produce json
+// @Success 200 {array} model.SeasonInfo
+// @Failure 500 {object} model.Response
+// @Router /seasons [get]
+func (ctrl *SongController) GetSeasons(c *gin.Context) {
+	seasons, err := ctrl.songService.GetSeasons(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, seasons)
+}
+
+// GetSeason godoc
+// @Summary Get a season
+// @Description Retrieve a season with its member (new) songs
+// @Tags season
+// @Produce json
+// @Param season_id path string true "Season ID, name or \"active\""
+// @Success 200 {object} model.SeasonInfo
+// @Failure 404 {object} model.Response
+// @Router /seasons/{season_id} [get]
+func (ctrl *SongController) GetSeason(c *gin.Context) {
+	seasonAddr := c.Param("season_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("season_addr", seasonAddr))
+
+	season, err := ctrl.songService.GetSeason(ctx, seasonAddr)
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// CreateSeason godoc
+// @Summary Create a season
+// @Description Create a season (Admin only). The active season decides which songs are new (B15): once any
+// @Description season exists, each song's b15 flag mirrors membership in the active season and switches
+// @Description automatically when a season starts or ends. Use from_current to seed the first season with the
+// @Description songs currently marked b15.
+// @Tags season
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param season body request.CreateSeasonRequest true "Season"
+// @Success 201 {object} model.SeasonInfo
+// @Failure 400 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /seasons [post]
+func (ctrl *SongController) CreateSeason(c *gin.Context) {
+	var req request.CreateSeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("season_name", req.Name))
+	season, err := ctrl.songService.CreateSeason(ctx, &req)
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, season)
+}
+
+// UpdateSeason godoc
+// @Summary Update a season
+// @Description Rename or reschedule a season (Admin only)
+// @Tags season
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param season_id path int true "Season ID"
+// @Param season body request.UpdateSeasonRequest true "Season"
+// @Success 200 {object} model.SeasonInfo
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /seasons/{season_id} [put]
+func (ctrl *SongController) UpdateSeason(c *gin.Context) {
+	seasonID, ok := parseSeasonID(c)
+	if !ok {
+		return
+	}
+	var req request.UpdateSeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("season_id", seasonID))
+	season, err := ctrl.songService.UpdateSeason(ctx, seasonID, &req)
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// SetSeasonSongs godoc
+// @Summary Set the songs of a season
+// @Description Replace the member (new) songs of a season (Admin only)
+// @Tags season
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param season_id path int true "Season ID"
+// @Param songs body request.SetSeasonSongsRequest true "Member song IDs"
+// @Success 200 {object} model.SeasonInfo
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /seasons/{season_id}/songs [put]
+func (ctrl *SongController) SetSeasonSongs(c *gin.Context) {
+	seasonID, ok := parseSeasonID(c)
+	if !ok {
+		return
+	}
+	var req request.SetSeasonSongsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("season_id", seasonID))
+	season, err := ctrl.songService.SetSeasonSongs(ctx, seasonID, req.SongIDs)
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
+
+// DeleteSeason godoc
+// @Summary Delete a season
+// @Description Delete a season (Admin only). Deleting the last season keeps the current b15 flags and makes
+// @Description them directly editable again.
+// @Tags season
+// @Produce json
+// @Security BearerAuth
+// @Param season_id path int true "Season ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /seasons/{season_id} [delete]
+func (ctrl *SongController) DeleteSeason(c *gin.Context) {
+	seasonID, ok := parseSeasonID(c)
+	if !ok {
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("season_id", seasonID))
+	if err := ctrl.songService.DeleteSeason(ctx, seasonID); err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "season deleted"})
+}
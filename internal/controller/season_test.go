@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongController_Seasons(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	r := gin.Default()
+	r.GET("/seasons", env.songCtrl.GetSeasons)
+	r.GET("/seasons/:season_id", env.songCtrl.GetSeason)
+	r.POST("/seasons", env.songCtrl.CreateSeason)
+	r.PUT("/seasons/:season_id", env.songCtrl.UpdateSeason)
+	r.PUT("/seasons/:season_id/songs", env.songCtrl.SetSeasonSongs)
+	r.DELETE("/seasons/:season_id", env.songCtrl.DeleteSeason)
+	r.GET("/records/:username", env.recordCtrl.GetPlayRecords)
+
+	env.db.Create(&model.User{UserBase: model.UserBase{Username: "season_player", AnonymousProbe: true}})
+	var songs []model.Song
+	for _, title := range []string{"Old", "New"} {
+		song := model.Song{
+			SongBase: model.SongBase{WikiID: title, Title: title},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14}},
+		}
+		env.db.Create(&song)
+		songs = append(songs, song)
+		_, err := env.recordService.CreateRecords(context.Background(), "season_player", []model.PlayRecordBase{
+			{ChartID: song.Charts[0].ID, Score: intPtr(1000000)},
+		}, false)
+		require.NoError(t, err)
+	}
+	jsonHeader := map[string]string{"Content-Type": "application/json"}
+	postJSON := func(method, path string, body any) (*model.SeasonInfo, int, string) {
+		raw, _ := json.Marshal(body)
+		w := performRequest(r, method, path, bytes.NewBuffer(raw), jsonHeader)
+		var info model.SeasonInfo
+		_ = json.Unmarshal(w.Body.Bytes(), &info)
+		return &info, w.Code, w.Body.String()
+	}
+	b15Songs := func(path string) []int {
+		w := performRequest(r, "GET", path, nil, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp model.PlayRecordResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		ids := []int{}
+		for _, rec := range resp.Records {
+			if rec.Chart.B15 {
+				ids = append(ids, rec.Chart.SongID)
+			}
+		}
+		return ids
+	}
+	now := time.Now().UTC()
+
+	past, code, body := postJSON("POST", "/seasons", map[string]any{
+		"name": "Past", "starts_at": now.Add(-48 * time.Hour), "ends_at": now.Add(-24 * time.Hour),
+		"song_ids": []int{songs[0].ID},
+	})
+	require.Equal(t, http.StatusCreated, code, body)
+	assert.False(t, past.Active)
+	current, code, body := postJSON("POST", "/seasons", map[string]any{
+		"name": "Current", "starts_at": now.Add(-24 * time.Hour), "song_ids": []int{songs[1].ID},
+	})
+	require.Equal(t, http.StatusCreated, code, body)
+	assert.True(t, current.Active)
+
+	t.Run("Create errors", func(t *testing.T) {
+		_, code, _ := postJSON("POST", "/seasons", map[string]any{"name": "Current", "starts_at": now})
+		assert.Equal(t, http.StatusConflict, code)
+		_, code, _ = postJSON("POST", "/seasons", map[string]any{"name": "No start"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		_, code, _ = postJSON("POST", "/seasons", map[string]any{
+			"name": "Unknown song", "starts_at": now, "song_ids": []int{99999},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		w := performRequest(r, "GET", "/seasons", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var seasons []model.SeasonInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seasons))
+		require.Len(t, seasons, 2)
+		assert.Equal(t, "Past", seasons[0].Name)
+
+		for _, addr := range []string{"active", "Current", fmt.Sprint(current.ID)} {
+			w = performRequest(r, "GET", "/seasons/"+addr, nil, nil)
+			assert.Equal(t, http.StatusOK, w.Code, addr)
+			assert.Contains(t, w.Body.String(), `"name":"Current"`)
+		}
+		w = performRequest(r, "GET", "/seasons/missing", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("B50 split by season", func(t *testing.T) {
+		assert.Equal(t, []int{songs[1].ID}, b15Songs("/records/season_player?scope=b50"))
+		assert.Equal(t, []int{songs[0].ID}, b15Songs("/records/season_player?scope=b50&season=Past"))
+		assert.Equal(t, []int{songs[0].ID}, b15Songs(fmt.Sprintf("/records/season_player?scope=best&b15=true&season=%d", past.ID)))
+
+		w := performRequest(r, "GET", "/records/season_player?scope=b50&season=missing", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Update, set songs and delete", func(t *testing.T) {
+		info, code, body := postJSON("PUT", fmt.Sprintf("/seasons/%d/songs", current.ID), map[string]any{
+			"song_ids": []int{songs[0].ID, songs[1].ID},
+		})
+		require.Equal(t, http.StatusOK, code, body)
+		assert.Equal(t, []int{songs[0].ID, songs[1].ID}, info.SongIDs)
+		assert.ElementsMatch(t, []int{songs[0].ID, songs[1].ID}, b15Songs("/records/season_player?scope=b50"))
+
+		_, code, _ = postJSON("PUT", fmt.Sprintf("/seasons/%d", current.ID), map[string]any{
+			"name": "Current", "starts_at": now, "ends_at": now.Add(-time.Hour),
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+		_, code, _ = postJSON("PUT", "/seasons/99999", map[string]any{"name": "X", "starts_at": now})
+		assert.Equal(t, http.StatusNotFound, code)
+		_, code, _ = postJSON("PUT", "/seasons/abc", map[string]any{"name": "X", "starts_at": now})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		// Ending the current season leaves no season active, so nothing is new
+		info, code, body = postJSON("PUT", fmt.Sprintf("/seasons/%d", current.ID), map[string]any{
+			"name": "Current", "starts_at": now.Add(-24 * time.Hour), "ends_at": now.Add(-time.Minute),
+		})
+		require.Equal(t, http.StatusOK, code, body)
+		assert.False(t, info.Active)
+		assert.Empty(t, b15Songs("/records/season_player?scope=b50"))
+
+		w := performRequest(r, "DELETE", fmt.Sprintf("/seasons/%d", past.ID), nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = performRequest(r, "DELETE", fmt.Sprintf("/seasons/%d", past.ID), nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
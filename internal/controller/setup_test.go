@@ -26,6 +26,15 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&model.Chart{},
 		&model.PlayRecord{},
 		&model.BestPlayRecord{},
+		&model.SongAlias{},
+		&model.ChartHistory{},
+		&model.SongMetadataProposal{},
+		&model.Season{},
+		&model.SeasonSong{},
+		&model.ChartVote{},
+		&model.ChartTag{},
+		&model.ChartStatistic{},
+		&model.FittingPlayerSkill{},
 	)
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
@@ -53,9 +62,12 @@ type testEnv struct {
 	userService   *service.UserService
 	songService   *service.SongService
 	recordService *service.RecordService
+	voteService   *service.VoteService
 	userCtrl      *UserController
 	songCtrl      *SongController
 	recordCtrl    *RecordController
+	voteCtrl      *VoteController
+	statsCtrl     *StatsController
 }
 
 func setupEnv(t *testing.T) *testEnv {
@@ -69,16 +81,21 @@ func setupEnv(t *testing.T) *testEnv {
 	recordRepo := repository.NewRecordRepository(db)
 
 	userService := service.NewUserService(userRepo)
-	songService := service.NewSongService(songRepo)
+	songService := service.NewSongService(songRepo, recordRepo, nil)
 	recordService := service.NewRecordService(recordRepo, songRepo)
+	voteService := service.NewVoteService(repository.NewVoteRepository(db), songRepo)
+	statsService := service.NewStatsService(repository.NewStatsRepository(db), songRepo, recordRepo)
 
 	return &testEnv{
 		db:            db,
 		userService:   userService,
 		songService:   songService,
 		recordService: recordService,
-		userCtrl:      NewUserController(userService),
+		voteService:   voteService,
+		userCtrl:      NewUserController(userService, statsService),
 		songCtrl:      NewSongController(songService),
-		recordCtrl:    NewRecordController(recordService, userService, songService),
+		recordCtrl:    NewRecordController(recordService, userService, songService, statsService),
+		voteCtrl:      NewVoteController(voteService, songService),
+		statsCtrl:     NewStatsController(statsService, songService),
 	}
 }
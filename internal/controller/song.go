@@ -33,7 +33,7 @@ func NewSongController(songService *service.SongService) *SongController {
 func (ctrl *SongController) GetAllCharts(c *gin.Context) {
 	charts, etag, err := ctrl.songService.GetAllChartsWithETag(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -47,16 +47,49 @@ func (ctrl *SongController) GetAllCharts(c *gin.Context) {
 	c.JSON(http.StatusOK, charts)
 }
 
+// GetSongChanges godoc
+// @Summary Get catalog changes
+// @Description Retrieve the charts created, updated (including fitting_level changes and restores) and retired
+// @Description since a cursor, in the same row format as GET /songs. Pass the returned cursor as since on the
+// @Description next call; without since every live chart is returned as created. Rows may repeat across
+// @Description consecutive responses and should be applied by chart ID.
+// @Tags song
+// @Produce json
+// @Param since query string false "Cursor returned by the previous call"
+// @Success 200 {object} model.SongChanges
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/changes [get]
+func (ctrl *SongController) GetSongChanges(c *gin.Context) {
+	since := c.Query("since")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("since", since))
+
+	changes, err := ctrl.songService.GetSongChanges(ctx, since)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+			return
+		}
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.JSON(http.StatusOK, changes)
+}
+
 // GetSingleSongInfo godoc
 // @Summary Get single song info
 // @Description Retrieve detailed information about a single song by ID
 // @Tags song
 // @Produce json
-// @Param song_id path string true "Song ID"
+// @Description With src=wiki the song's metadata as currently published on the community wiki is laid over
+// @Description the stored song (fields the wiki leaves empty keep their stored values).
+// @Param song_id path string true "Song ID or wiki_id"
 // @Param src query string false "Source (prp or wiki)" default(prp)
 // @Success 200 {object} model.Song
 // @Failure 400 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Failure 502 {object} model.Response "src=wiki and the wiki could not be reached"
 // @Router /songs/{song_id} [get]
 func (ctrl *SongController) GetSingleSongInfo(c *gin.Context) {
 	songIDStr := c.Param("song_id")
@@ -71,13 +104,23 @@ func (ctrl *SongController) GetSingleSongInfo(c *gin.Context) {
 			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, song)
-		return
+		if src == "prp" {
+			c.JSON(http.StatusOK, song)
+			return
+		}
+		songID = song.ID
 	}
 
 	song, err := ctrl.songService.GetSingleSong(ctx, songID, src)
 	if err != nil {
-		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		case errors.Is(err, service.ErrUpstream):
+			c.JSON(http.StatusBadGateway, model.Response{Error: err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, song)
@@ -102,7 +145,7 @@ func (ctrl *SongController) CreateSong(c *gin.Context) {
 	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_title", req.Title))
 	charts, err := ctrl.songService.CreateSong(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, charts)
@@ -110,14 +153,19 @@ func (ctrl *SongController) CreateSong(c *gin.Context) {
 
 // UpdateSong godoc
 // @Summary Update an existing song
-// @Description Update song details and its charts (Admin only)
+// @Description Update song details and its charts (Admin only). Every changed field is recorded in the song's history.
+// @Description With preview=true nothing is committed; instead a report of how the update would change
+// @Description affected users' B50 (including rating recalculation for level changes) is returned.
 // @Tags song
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param song body request.UpdateSongRequest true "Song update info"
+// @Param preview query boolean false "Only report the rating impact, do not commit" default(false)
 // @Success 200 {array} model.ChartInfo
+// @Success 200 {object} model.RatingImpactReport "preview=true"
 // @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
 // @Router /songs [put]
 func (ctrl *SongController) UpdateSong(c *gin.Context) {
 	var req request.UpdateSongRequest
@@ -125,19 +173,385 @@ func (ctrl *SongController) UpdateSong(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
 		return
 	}
+	preview, err := strconv.ParseBool(c.DefaultQuery("preview", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid preview parameter, expected true or false"})
+		return
+	}
 
 	ctx := logging.AppendCtx(c.Request.Context(),
 		slog.Int("song_id", req.ID),
 		slog.String("song_title", req.Title),
 	)
-	charts, err := ctrl.songService.UpdateSong(ctx, &req)
+	username := c.GetString("username")
+
+	var result any
+	if preview {
+		result, err = ctrl.songService.PreviewSongUpdate(ctx, &req, username)
+	} else {
+		result, err = ctrl.songService.UpdateSong(ctx, &req, username)
+	}
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
 			c.JSON(http.StatusNotFound, model.Response{Error: "song not found"})
 		} else {
-			c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
 		}
 		return
 	}
-	c.JSON(http.StatusOK, charts)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSongHistory godoc
+// @Summary Get song change history
+// @Description Retrieve every recorded change to a song and its charts, newest first (Admin only)
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Success 200 {array} model.ChartHistory
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/{song_id}/history [get]
+func (ctrl *SongController) GetSongHistory(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	history, err := ctrl.songService.GetSongHistory(ctx, songID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// SearchSongs godoc
+// @Summary Search songs
+// @Description Fuzzy-search songs by title, per-chart override title, artist, album and community alias.
+// @Description Matching ignores case, width and kana script; romaji matches kana titles and
+// @Description pinyin (full or initials) matches Chinese titles. Results are sorted by score.
+// @Tags song
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Maximum number of results (max 50)" default(20)
+// @Success 200 {array} model.SongSearchResult
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/search [get]
+func (ctrl *SongController) SearchSongs(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "missing q parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid limit parameter"})
+		return
+	}
+	limit = min(limit, maxSearchLimit)
+
+	results, err := ctrl.songService.SearchSongs(c.Request.Context(), query, limit)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetSongAliases godoc
+// @Summary Get song aliases
+// @Description Retrieve the community aliases of a song
+// @Tags song
+// @Produce json
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Success 200 {array} model.SongAlias
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/{song_id}/aliases [get]
+func (ctrl *SongController) GetSongAliases(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	aliases, err := ctrl.songService.GetSongAliases(ctx, songID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, aliases)
+}
+
+// CreateSongAlias godoc
+// @Summary Add a song alias
+// @Description Add a community alias to a song (Admin only). Aliases are unique across all songs.
+// @Tags song
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Param alias body request.CreateSongAliasRequest true "Alias info"
+// @Success 201 {object} model.SongAlias
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /songs/{song_id}/aliases [post]
+func (ctrl *SongController) CreateSongAlias(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	var req request.CreateSongAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	alias, err := ctrl.songService.CreateSongAlias(ctx, songID, req.Alias)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		case errors.Is(err, service.ErrConflict):
+			c.JSON(http.StatusConflict, model.Response{Error: err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, alias)
+}
+
+// DeleteSongAlias godoc
+// @Summary Delete a song alias
+// @Description Remove a community alias from a song (Admin only)
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Param alias_id path int true "Alias ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /songs/{song_id}/aliases/{alias_id} [delete]
+func (ctrl *SongController) DeleteSongAlias(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	aliasID, err := strconv.Atoi(c.Param("alias_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid alias_id"})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(),
+		slog.String("song_addr", songAddr),
+		slog.Int("alias_id", aliasID),
+	)
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	if err := ctrl.songService.DeleteSongAlias(ctx, songID, aliasID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		} else {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "alias deleted"})
+}
+
+// RetireSong godoc
+// @Summary Retire a song
+// @Description Retire (soft-delete) a song (Admin only). Its charts are hidden from /songs and excluded
+// @Description from B50, but players' records on them remain visible in record history.
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/{song_id} [delete]
+func (ctrl *SongController) RetireSong(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	if err := ctrl.songService.RetireSong(ctx, songID, c.GetString("username")); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		} else {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "song retired"})
+}
+
+// RestoreSong godoc
+// @Summary Restore a retired song
+// @Description Restore a previously retired song (Admin only)
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Retired song ID or wiki_id"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /songs/{song_id}/restore [post]
+func (ctrl *SongController) RestoreSong(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+
+	if _, err := ctrl.songService.RestoreSong(ctx, songAddr, c.GetString("username")); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		} else {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "song restored"})
+}
+
+// RetireChart godoc
+// @Summary Retire a chart
+// @Description Retire (soft-delete) a single chart (Admin only). The chart is hidden from /songs and excluded
+// @Description from B50, but players' records on it remain visible in record history.
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /charts/{chart_addr} [delete]
+func (ctrl *SongController) RetireChart(c *gin.Context) {
+	chartAddr := c.Param("chart_addr")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("chart_addr", chartAddr))
+
+	chartID, err := ctrl.songService.ResolveChartID(ctx, chartAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	if err := ctrl.songService.RetireChart(ctx, chartID, c.GetString("username")); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		} else {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "chart retired"})
+}
+
+// PatchChart godoc
+// @Summary Update a single chart
+// @Description Change only the supplied fields of a chart (Admin only); an empty override field clears the
+// @Description override. Send the chart's updated_at (or the ETag of a previous PATCH) as If-Match to refuse
+// @Description the write with 412 if someone else changed the chart in the meantime. Every change is recorded
+// @Description in the song's history and ratings are recalculated only when the level changes.
+// @Tags song
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Param If-Match header string false "updated_at of the chart as last read"
+// @Param chart body model.ChartPatch true "Fields to change"
+// @Success 200 {object} model.ChartInfo
+// @Header 200 {string} ETag "Version to send as If-Match on the next update"
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 412 {object} model.Response
+// @Router /charts/{chart_addr} [patch]
+func (ctrl *SongController) PatchChart(c *gin.Context) {
+	chartAddr := c.Param("chart_addr")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("chart_addr", chartAddr))
+
+	chartID, err := ctrl.songService.ResolveChartID(ctx, chartAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+	var patch model.ChartPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	chart, etag, err := ctrl.songService.PatchChart(ctx, chartID, patch, c.GetHeader("If-Match"), c.GetString("username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		case errors.Is(err, service.ErrPreconditionFailed):
+			c.JSON(http.StatusPreconditionFailed, model.Response{Error: err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.Header("ETag", etag)
+	c.JSON(http.StatusOK, chart)
+}
+
+// RestoreChart godoc
+// @Summary Restore a retired chart
+// @Description Restore a previously retired chart (Admin only). Fails if the song already has a live chart
+// @Description of the same difficulty. With wiki_id:difficulty the most recently retired chart is restored.
+// @Tags song
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Retired chart ID or wiki_id:difficulty"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /charts/{chart_addr}/restore [post]
+func (ctrl *SongController) RestoreChart(c *gin.Context) {
+	chartAddr := c.Param("chart_addr")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("chart_addr", chartAddr))
+
+	if _, err := ctrl.songService.RestoreChart(ctx, chartAddr, c.GetString("username")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotFound):
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+		case errors.Is(err, service.ErrConflict):
+			c.JSON(http.StatusConflict, model.Response{Error: err.Error()})
+		default:
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "chart restored"})
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"paradigm-reboot-prober-go/internal/model"
 	"paradigm-reboot-prober-go/internal/model/request"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -154,3 +156,283 @@ func TestSongController_UpdateSong(t *testing.T) {
 	assert.Equal(t, "Updated Song", charts[0].Title)
 	assert.Equal(t, 14.0, charts[0].Level)
 }
+
+func TestSongController_SearchAndAliases(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	r := gin.Default()
+	r.GET("/songs/search", env.songCtrl.SearchSongs)
+	r.GET("/songs/:song_id", env.songCtrl.GetSingleSongInfo)
+	r.GET("/songs/:song_id/aliases", env.songCtrl.GetSongAliases)
+	r.POST("/songs/:song_id/aliases", env.songCtrl.CreateSongAlias)
+	r.DELETE("/songs/:song_id/aliases/:alias_id", env.songCtrl.DeleteSongAlias)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "search_song", Title: "Searchable Song", Artist: "Artist"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 10}},
+	}
+	env.db.Create(&song)
+
+	jsonHeader := map[string]string{"Content-Type": "application/json"}
+
+	t.Run("Search", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/search?q=searchable", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var results []model.SongSearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		assert.Len(t, results, 1)
+		assert.Equal(t, song.ID, results[0].SongID)
+	})
+
+	t.Run("Search missing query", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/search", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Search invalid limit", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/search?q=a&limit=0", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	var alias model.SongAlias
+	t.Run("Create alias", func(t *testing.T) {
+		body, _ := json.Marshal(request.CreateSongAliasRequest{Alias: "ss"})
+		w := performRequest(r, "POST", "/songs/search_song/aliases", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &alias))
+		assert.Equal(t, song.ID, alias.SongID)
+	})
+
+	t.Run("Create duplicate alias", func(t *testing.T) {
+		body, _ := json.Marshal(request.CreateSongAliasRequest{Alias: "SS"})
+		w := performRequest(r, "POST", "/songs/1/aliases", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Create alias for unknown song", func(t *testing.T) {
+		body, _ := json.Marshal(request.CreateSongAliasRequest{Alias: "ghost"})
+		w := performRequest(r, "POST", "/songs/999/aliases", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("List aliases by alias address", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/ss/aliases", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var aliases []model.SongAlias
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &aliases))
+		assert.Len(t, aliases, 1)
+	})
+
+	t.Run("Delete alias", func(t *testing.T) {
+		path := "/songs/search_song/aliases/" + strconv.Itoa(alias.ID)
+		w := performRequest(r, "DELETE", path, nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequest(r, "DELETE", path, nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSongController_UpdateSongPreviewAndHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "preview_test", Title: "Preview Song", Artist: "Artist"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 10, Notes: 500}},
+	}
+	env.db.Create(&song)
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+	r.PUT("/songs", setUser, env.songCtrl.UpdateSong)
+	r.GET("/songs/:song_id/history", env.songCtrl.GetSongHistory)
+
+	reqBody := request.UpdateSongRequest{
+		ID:       song.ID,
+		SongBase: model.SongBase{WikiID: "preview_test", Title: "Preview Song", Artist: "Artist"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 11, Notes: 500}},
+	}
+	body, _ := json.Marshal(reqBody)
+	jsonHeader := map[string]string{"Content-Type": "application/json"}
+
+	t.Run("Preview returns report", func(t *testing.T) {
+		w := performRequest(r, "PUT", "/songs?preview=true", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report model.RatingImpactReport
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, song.ID, report.SongID)
+		assert.Len(t, report.Changes, 1)
+		assert.Equal(t, "admin", report.Changes[0].ChangedBy)
+	})
+
+	t.Run("Invalid preview parameter", func(t *testing.T) {
+		w := performRequest(r, "PUT", "/songs?preview=maybe", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Update then history", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/preview_test/history", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+
+		w = performRequest(r, "PUT", "/songs", bytes.NewBuffer(body), jsonHeader)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "GET", "/songs/preview_test/history", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var history []model.ChartHistory
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		assert.Len(t, history, 1)
+		assert.Equal(t, "level", history[0].Field)
+	})
+
+	t.Run("History of unknown song", func(t *testing.T) {
+		w := performRequest(r, "GET", "/songs/unknown/history", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSongController_RetireAndRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "retire_test", Title: "Retire Song"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyInvaded, Level: 12, Notes: 400},
+			{Difficulty: model.DifficultyMassive, Level: 14, Notes: 600},
+		},
+	}
+	env.db.Create(&song)
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+	r.GET("/songs/:song_id", env.songCtrl.GetSingleSongInfo)
+	r.DELETE("/songs/:song_id", setUser, env.songCtrl.RetireSong)
+	r.POST("/songs/:song_id/restore", setUser, env.songCtrl.RestoreSong)
+	r.DELETE("/charts/:chart_addr", setUser, env.songCtrl.RetireChart)
+	r.POST("/charts/:chart_addr/restore", setUser, env.songCtrl.RestoreChart)
+
+	t.Run("Retire and restore chart", func(t *testing.T) {
+		w := performRequest(r, "DELETE", "/charts/retire_test:massive", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "GET", "/songs/retire_test", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var info model.Song
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.Len(t, info.Charts, 1)
+
+		w = performRequest(r, "DELETE", "/charts/retire_test:massive", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "POST", "/charts/retire_test:massive/restore", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "POST", "/charts/retire_test:massive/restore", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "POST", "/charts/retire_test/restore", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Retire and restore song", func(t *testing.T) {
+		w := performRequest(r, "DELETE", "/songs/retire_test", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = performRequest(r, "GET", "/songs/retire_test", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "DELETE", "/songs/retire_test", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = performRequest(r, "POST", "/songs/"+strconv.Itoa(song.ID)+"/restore", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = performRequest(r, "GET", "/songs/retire_test", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequest(r, "POST", "/songs/retire_test/restore", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSongController_GetSongChanges(t *testing.T) {
+	env := setupEnv(t)
+	r := gin.Default()
+	r.GET("/songs/changes", env.songCtrl.GetSongChanges)
+	r.GET("/songs/:song_id", env.songCtrl.GetSingleSongInfo)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "changes_song", Title: "Changes"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14}},
+	}
+	env.db.Create(&song)
+
+	w := performRequest(r, "GET", "/songs/changes", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var changes model.SongChanges
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &changes))
+	assert.Len(t, changes.Created, 1)
+	assert.Equal(t, "Changes", changes.Created[0].Title)
+	assert.NotEmpty(t, changes.Cursor)
+
+	w = performRequest(r, "GET", "/songs/changes?since="+changes.Cursor, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"retired_song_ids":[]`)
+
+	w = performRequest(r, "GET", "/songs/changes?since=abc", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSongController_PatchChart(t *testing.T) {
+	env := setupEnv(t)
+	r := gin.Default()
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+	r.GET("/songs/:song_id", env.songCtrl.GetSingleSongInfo)
+	r.PATCH("/charts/:chart_addr", setUser, env.songCtrl.PatchChart)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "patch_song", Title: "Patch Song"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14, Notes: 900}},
+	}
+	env.db.Create(&song)
+	patch := func(addr, body string, headers map[string]string) *httptest.ResponseRecorder {
+		h := map[string]string{"Content-Type": "application/json"}
+		for k, v := range headers {
+			h[k] = v
+		}
+		return performRequest(r, "PATCH", "/charts/"+addr, bytes.NewBufferString(body), h)
+	}
+
+	w := patch("patch_song:massive", `{"level": 14.3}`, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var info model.ChartInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, 14.3, info.Level)
+	assert.Equal(t, 900, info.Notes)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	t.Run("If-Match with the ETag or updated_at", func(t *testing.T) {
+		w := patch(strconv.Itoa(song.Charts[0].ID), `{"notes": 901}`, map[string]string{"If-Match": etag})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = patch(strconv.Itoa(song.Charts[0].ID), `{"notes": 902}`, map[string]string{"If-Match": etag})
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		// The updated_at of a fresh read works as well
+		w = performRequest(r, "GET", "/songs/patch_song", nil, nil)
+		var current model.Song
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &current))
+		updatedAt, _ := current.Charts[0].UpdatedAt.MarshalJSON()
+		w = patch("patch_song:massive", `{"notes": 902}`, map[string]string{"If-Match": string(updatedAt)})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, patch("patch_song:massive", `{"level": -1}`, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, patch("patch_song:massive", `{}`, nil).Code)
+		assert.Equal(t, http.StatusNotFound, patch("patch_song:reboot", `{"notes": 1}`, nil).Code)
+		assert.Equal(t, http.StatusNotFound, patch("99999", `{"notes": 1}`, nil).Code)
+	})
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/logging"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsController struct {
+	statsService *service.StatsService
+	songService  *service.SongService
+}
+
+func NewStatsController(statsService *service.StatsService, songService *service.SongService) *StatsController {
+	return &StatsController{
+		statsService: statsService,
+		songService:  songService,
+	}
+}
+
+// GetChartStats godoc
+// @Summary Get statistics of a chart
+// @Description Retrieve the fitting statistics of a chart and the distribution of players' best scores on it
+// @Description (pass rate, percentiles, histogram). The distribution is omitted for charts with too few players.
+// @Description For authenticated callers with a best record on the chart, the response also contains their rank.
+// @Description Statistics are cached and may be a few minutes old.
+// @Tags stats
+// @Produce json
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Success 200 {object} model.ChartStats
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /charts/{chart_addr}/stats [get]
+func (ctrl *StatsController) GetChartStats(c *gin.Context) {
+	chartID, err := ctrl.songService.ResolveChartID(c.Request.Context(), c.Param("chart_addr"))
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("chart_id", chartID))
+
+	stats, err := ctrl.statsService.GetChartStats(ctx, chartID, c.GetString("username"))
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+			return
+		}
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetChartStatsList godoc
+// @Summary Get statistics of all charts
+// @Description Retrieve an overview of every live chart: its fitting sample size and spread, its number of
+// @Description players and its pass rate (omitted for charts with too few players), hardest level first.
+// @Description Statistics are cached and may be a few minutes old.
+// @Tags stats
+// @Produce json
+// @Success 200 {array} model.ChartStatsSummary
+// @Failure 500 {object} model.Response
+// @Router /charts/stats [get]
+func (ctrl *StatsController) GetChartStatsList(c *gin.Context) {
+	rows, err := ctrl.statsService.GetChartStatsList(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
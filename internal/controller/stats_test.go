@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+	config.GlobalConfig.Stats.MinPlayers = 2
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) {
+		if username := c.GetHeader("X-Test-User"); username != "" {
+			c.Set("username", username)
+		}
+	}
+	r.GET("/charts/stats", env.statsCtrl.GetChartStatsList)
+	r.GET("/charts/:chart_addr/stats", setUser, env.statsCtrl.GetChartStats)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "stats_ctrl", Title: "Stats Ctrl"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0}},
+	}
+	env.db.Create(&song)
+	chartID := song.Charts[0].ID
+	for username, score := range map[string]int{"stats_p1": 995000, "stats_p2": 1004000} {
+		_, err := env.recordService.CreateRecords(context.Background(), username, []model.PlayRecordBase{
+			{ChartID: chartID, Score: intPtr(score)},
+		}, false)
+		require.NoError(t, err)
+	}
+
+	t.Run("GET chart stats", func(t *testing.T) {
+		w := performRequest(r, "GET", "/charts/stats_ctrl:massive/stats", nil, map[string]string{"X-Test-User": "stats_p1"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats model.ChartStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Nil(t, stats.Fitting)
+		require.NotNil(t, stats.Distribution)
+		assert.Equal(t, 2, stats.Distribution.PlayerCount)
+		assert.InDelta(t, 0.5, stats.Distribution.PassRate, 1e-9)
+		require.NotNil(t, stats.MyRank)
+		assert.Equal(t, 2, stats.MyRank.Rank)
+		assert.NotContains(t, w.Body.String(), "stats_p2", "no usernames are exposed")
+
+		w = performRequest(r, "GET", fmt.Sprintf("/charts/%d/stats", chartID), nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "my_rank")
+
+		w = performRequest(r, "GET", "/charts/99999/stats", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GET stats list", func(t *testing.T) {
+		w := performRequest(r, "GET", "/charts/stats", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var rows []model.ChartStatsSummary
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		require.Len(t, rows, 1)
+		assert.Equal(t, chartID, rows[0].ChartID)
+		assert.Equal(t, 2, rows[0].PlayerCount)
+		require.NotNil(t, rows[0].PassRate)
+		assert.InDelta(t, 0.5, *rows[0].PassRate, 1e-9)
+	})
+}
@@ -14,11 +14,12 @@ import (
 )
 
 type UserController struct {
-	userService *service.UserService
+	userService  *service.UserService
+	statsService *service.StatsService
 }
 
-func NewUserController(userService *service.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService *service.UserService, statsService *service.StatsService) *UserController {
+	return &UserController{userService: userService, statsService: statsService}
 }
 
 // Register godoc
@@ -43,7 +44,7 @@ func (ctrl *UserController) Register(c *gin.Context) {
 	ctx := logging.AppendCtx(c.Request.Context(), slog.String("register_user", req.Username))
 	user, err := ctrl.userService.CreateUser(ctx, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -74,7 +75,7 @@ func (ctrl *UserController) Login(c *gin.Context) {
 	ctx := logging.AppendCtx(c.Request.Context(), slog.String("login_user", username))
 	accessToken, refreshToken, err := ctrl.userService.Login(ctx, username, password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusUnauthorized), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -105,7 +106,7 @@ func (ctrl *UserController) RefreshToken(c *gin.Context) {
 	ctx := c.Request.Context()
 	accessToken, refreshToken, err := ctrl.userService.RefreshToken(ctx, req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusUnauthorized), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -118,18 +119,18 @@ func (ctrl *UserController) RefreshToken(c *gin.Context) {
 
 // GetMe godoc
 // @Summary Get current user info
-// @Description Get the profile of the currently authenticated user
+// @Description Get the profile of the currently authenticated user, with their skill as estimated by the last fitting run
 // @Tags user
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} model.User
+// @Success 200 {object} model.UserProfile
 // @Failure 401 {object} model.Response
 // @Router /user/me [get]
 func (ctrl *UserController) GetMe(c *gin.Context) {
 	username := c.GetString("username")
-	user, err := ctrl.userService.GetUser(username)
+	user, err := ctrl.userService.GetUser(c.Request.Context(), username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 	if user == nil {
@@ -137,7 +138,10 @@ func (ctrl *UserController) GetMe(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	// The estimated skill is an extra: the profile is served without it when
+	// it cannot be read.
+	skill, _ := ctrl.statsService.GetEstimatedSkill(c.Request.Context(), username)
+	c.JSON(http.StatusOK, model.UserProfile{User: *user, EstimatedSkill: skill})
 }
 
 // RefreshUploadToken godoc
@@ -153,7 +157,7 @@ func (ctrl *UserController) RefreshUploadToken(c *gin.Context) {
 	username := c.GetString("username")
 	token, err := ctrl.userService.RefreshUploadToken(c.Request.Context(), username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		return
 	}
 
@@ -184,7 +188,7 @@ func (ctrl *UserController) UpdateMe(c *gin.Context) {
 		if errors.Is(err, service.ErrNotFound) {
 			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
 		} else {
-			c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
 		}
 		return
 	}
@@ -215,7 +219,7 @@ func (ctrl *UserController) ChangePassword(c *gin.Context) {
 		if errors.Is(err, service.ErrUnauthorized) {
 			c.JSON(http.StatusUnauthorized, model.Response{Error: err.Error()})
 		} else {
-			c.JSON(http.StatusInternalServerError, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusInternalServerError), model.Response{Error: err.Error()})
 		}
 		return
 	}
@@ -244,7 +248,7 @@ func (ctrl *UserController) ResetPassword(c *gin.Context) {
 		if errors.Is(err, service.ErrNotFound) {
 			c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
 		} else {
-			c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+			c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
 		}
 		return
 	}
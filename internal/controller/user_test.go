@@ -59,12 +59,19 @@ func TestUserController(t *testing.T) {
 			env.userCtrl.GetMe(c)
 		})
 
+		env.db.Create(&model.FittingPlayerSkill{Username: "testuser", AvgRating: 162.35, NumRecords: 80, TopK: 50})
+
 		w := performRequest(r, "GET", "/me", nil, nil)
 		assert.Equal(t, http.StatusOK, w.Code)
-		var user model.User
-		err := json.Unmarshal(w.Body.Bytes(), &user)
+		var profile model.UserProfile
+		err := json.Unmarshal(w.Body.Bytes(), &profile)
 		assert.NoError(t, err)
-		assert.Equal(t, "testuser", user.Username)
+		assert.Equal(t, "testuser", profile.Username)
+		if assert.NotNil(t, profile.EstimatedSkill) {
+			assert.Equal(t, 162.35, profile.EstimatedSkill.Skill)
+			assert.Equal(t, 50, profile.EstimatedSkill.TopK)
+			assert.Equal(t, 80, profile.EstimatedSkill.RecordCount)
+		}
 	})
 
 	t.Run("RefreshUploadToken", func(t *testing.T) {
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/logging"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"paradigm-reboot-prober-go/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VoteController struct {
+	voteService *service.VoteService
+	songService *service.SongService
+}
+
+func NewVoteController(voteService *service.VoteService, songService *service.SongService) *VoteController {
+	return &VoteController{
+		voteService: voteService,
+		songService: songService,
+	}
+}
+
+// writeVoteError maps vote errors to HTTP responses.
+func writeVoteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, model.Response{Error: err.Error()})
+	default:
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+	}
+}
+
+// resolveChart resolves the chart_addr path parameter, writing the error response on failure.
+func (ctrl *VoteController) resolveChart(c *gin.Context) (int, bool) {
+	chartID, err := ctrl.songService.ResolveChartID(c.Request.Context(), c.Param("chart_addr"))
+	if err != nil {
+		writeResolveError(c, err)
+		return 0, false
+	}
+	return chartID, true
+}
+
+// GetChartVotes godoc
+// @Summary Get community votes of a chart
+// @Description Retrieve the community level votes and tags of a chart. For authenticated callers the response
+// @Description also contains their own vote and tags.
+// @Tags vote
+// @Produce json
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Success 200 {object} model.ChartVoteSummary
+// @Failure 404 {object} model.Response
+// @Router /charts/{chart_addr}/votes [get]
+func (ctrl *VoteController) GetChartVotes(c *gin.Context) {
+	chartID, ok := ctrl.resolveChart(c)
+	if !ok {
+		return
+	}
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("chart_id", chartID))
+
+	summary, err := ctrl.voteService.GetChartVotes(ctx, chartID, c.GetString("username"))
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// VoteChart godoc
+// @Summary Vote on a chart's level
+// @Description Record how much harder (positive) or easier (negative) a chart feels than its official level,
+// @Description within ±1.5. Each player has one vote per chart, which this replaces, and only players with a
+// @Description best record on the chart may vote.
+// @Tags vote
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Param vote body request.ChartVoteRequest true "Vote"
+// @Success 200 {object} model.ChartVoteSummary
+// @Failure 400 {object} model.Response
+// @Failure 403 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /charts/{chart_addr}/vote [put]
+func (ctrl *VoteController) VoteChart(c *gin.Context) {
+	chartID, ok := ctrl.resolveChart(c)
+	if !ok {
+		return
+	}
+	var req request.ChartVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	username := c.GetString("username")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("chart_id", chartID))
+	if err := ctrl.voteService.Vote(ctx, username, chartID, *req.Delta); err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	summary, err := ctrl.voteService.GetChartVotes(ctx, chartID, username)
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// DeleteChartVote godoc
+// @Summary Withdraw a level vote
+// @Description Remove the caller's level vote on a chart
+// @Tags vote
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /charts/{chart_addr}/vote [delete]
+func (ctrl *VoteController) DeleteChartVote(c *gin.Context) {
+	chartID, ok := ctrl.resolveChart(c)
+	if !ok {
+		return
+	}
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("chart_id", chartID))
+
+	if err := ctrl.voteService.DeleteVote(ctx, c.GetString("username"), chartID); err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.Response{Message: "vote deleted"})
+}
+
+// SetChartTags godoc
+// @Summary Tag a chart
+// @Description Replace the caller's tags on a chart (at most 5, e.g. "stamina", "tech", "sight-read-trap"); an
+// @Description empty list removes them. Tags are lowercased and their words joined with hyphens. Only players
+// @Description with a best record on the chart may tag it.
+// @Tags vote
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param chart_addr path string true "Chart address (ID or wiki_id:difficulty)"
+// @Param tags body request.ChartTagsRequest true "Tags"
+// @Success 200 {object} model.ChartVoteSummary
+// @Failure 400 {object} model.Response
+// @Failure 403 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /charts/{chart_addr}/tags [put]
+func (ctrl *VoteController) SetChartTags(c *gin.Context) {
+	chartID, ok := ctrl.resolveChart(c)
+	if !ok {
+		return
+	}
+	var req request.ChartTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: err.Error()})
+		return
+	}
+
+	username := c.GetString("username")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("chart_id", chartID))
+	if _, err := ctrl.voteService.SetTags(ctx, username, chartID, req.Tags); err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	summary, err := ctrl.voteService.GetChartVotes(ctx, chartID, username)
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetTierList godoc
+// @Summary Get the community difficulty tier list
+// @Description Charts with community level votes, grouped by official level (hardest first). Within a level,
+// @Description charts are ordered by community level (official level + mean vote), hardest-feeling first.
+// @Tags vote
+// @Produce json
+// @Param min_level query number false "Minimum official level (inclusive)"
+// @Param max_level query number false "Maximum official level (inclusive)"
+// @Param min_votes query int false "Minimum number of votes" default(1)
+// @Param tag query string false "Only charts carrying this tag"
+// @Success 200 {array} model.TierListGroup
+// @Failure 400 {object} model.Response
+// @Router /charts/tiers [get]
+func (ctrl *VoteController) GetTierList(c *gin.Context) {
+	var filter service.TierListFilter
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"min_level", &filter.MinLevel}, {"max_level", &filter.MaxLevel}} {
+		if s := c.Query(p.name); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, model.Response{Error: "invalid " + p.name + " parameter"})
+				return
+			}
+			*p.dst = &v
+		}
+	}
+	if s := c.Query("min_votes"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			c.JSON(http.StatusBadRequest, model.Response{Error: "invalid min_votes parameter"})
+			return
+		}
+		filter.MinVotes = v
+	}
+	filter.Tag = c.Query("tag")
+
+	tiers, err := ctrl.voteService.GetTierList(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tiers)
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoteController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) {
+		if username := c.GetHeader("X-Test-User"); username != "" {
+			c.Set("username", username)
+		}
+	}
+	r.GET("/charts/tiers", env.voteCtrl.GetTierList)
+	r.GET("/charts/:chart_addr/votes", setUser, env.voteCtrl.GetChartVotes)
+	r.PUT("/charts/:chart_addr/vote", setUser, env.voteCtrl.VoteChart)
+	r.DELETE("/charts/:chart_addr/vote", setUser, env.voteCtrl.DeleteChartVote)
+	r.PUT("/charts/:chart_addr/tags", setUser, env.voteCtrl.SetChartTags)
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "vote_ctrl", Title: "Vote Ctrl"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0}},
+	}
+	env.db.Create(&song)
+	chartID := song.Charts[0].ID
+	_, err := env.recordService.CreateRecords(context.Background(), "voter", []model.PlayRecordBase{
+		{ChartID: chartID, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+
+	send := func(method, path, username string, body any) int {
+		raw, _ := json.Marshal(body)
+		w := performRequest(r, method, path, bytes.NewBuffer(raw), map[string]string{
+			"Content-Type": "application/json",
+			"X-Test-User":  username,
+		})
+		return w.Code
+	}
+
+	t.Run("PUT vote", func(t *testing.T) {
+		path := fmt.Sprintf("/charts/%d/vote", chartID)
+		assert.Equal(t, http.StatusBadRequest, send("PUT", path, "voter", map[string]any{}))
+		assert.Equal(t, http.StatusBadRequest, send("PUT", path, "voter", map[string]any{"delta": 2}))
+		assert.Equal(t, http.StatusForbidden, send("PUT", path, "stranger", map[string]any{"delta": 0.5}))
+		assert.Equal(t, http.StatusNotFound, send("PUT", "/charts/99999/vote", "voter", map[string]any{"delta": 0.5}))
+
+		// Zero is a valid vote ("feels like its level")
+		assert.Equal(t, http.StatusOK, send("PUT", path, "voter", map[string]any{"delta": 0}))
+
+		w := performRequest(r, "PUT", "/charts/vote_ctrl:massive/vote", bytes.NewBufferString(`{"delta":0.4}`),
+			map[string]string{"Content-Type": "application/json", "X-Test-User": "voter"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var summary model.ChartVoteSummary
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, 1, summary.VoteCount)
+		require.NotNil(t, summary.MyVote)
+		assert.InDelta(t, 0.4, *summary.MyVote, 1e-9)
+		require.NotNil(t, summary.CommunityLevel)
+		assert.InDelta(t, 15.4, *summary.CommunityLevel, 1e-9)
+	})
+
+	t.Run("PUT tags", func(t *testing.T) {
+		path := fmt.Sprintf("/charts/%d/tags", chartID)
+		assert.Equal(t, http.StatusBadRequest, send("PUT", path, "voter",
+			map[string]any{"tags": []string{"a", "b", "c", "d", "e", "f"}}))
+		assert.Equal(t, http.StatusForbidden, send("PUT", path, "stranger",
+			map[string]any{"tags": []string{"tech"}}))
+		assert.Equal(t, http.StatusOK, send("PUT", path, "voter",
+			map[string]any{"tags": []string{"Sight Read Trap"}}))
+	})
+
+	t.Run("GET votes", func(t *testing.T) {
+		w := performRequest(r, "GET", fmt.Sprintf("/charts/%d/votes", chartID), nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "my_vote")
+		var summary model.ChartVoteSummary
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, []model.TagCount{{Tag: "sight-read-trap", Count: 1}}, summary.Tags)
+
+		w = performRequest(r, "GET", fmt.Sprintf("/charts/%d/votes", chartID), nil, map[string]string{"X-Test-User": "voter"})
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, []string{"sight-read-trap"}, summary.MyTags)
+	})
+
+	t.Run("GET tiers", func(t *testing.T) {
+		w := performRequest(r, "GET", "/charts/tiers?min_level=15&tag=sight-read-trap", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var tiers []model.TierListGroup
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tiers))
+		require.Len(t, tiers, 1)
+		require.Len(t, tiers[0].Charts, 1)
+		assert.Equal(t, "vote_ctrl", tiers[0].Charts[0].WikiID)
+
+		w = performRequest(r, "GET", "/charts/tiers?min_votes=2", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[]", w.Body.String())
+
+		w = performRequest(r, "GET", "/charts/tiers?min_level=abc", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("DELETE vote", func(t *testing.T) {
+		path := fmt.Sprintf("/charts/%d/vote", chartID)
+		assert.Equal(t, http.StatusOK, send("DELETE", path, "voter", nil))
+		assert.Equal(t, http.StatusNotFound, send("DELETE", path, "voter", nil))
+	})
+}
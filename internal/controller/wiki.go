@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"paradigm-reboot-prober-go/internal/logging"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeProposalError maps metadata proposal errors to HTTP responses.
+func writeProposalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, model.Response{Error: err.Error()})
+	case errors.Is(err, service.ErrConflict):
+		c.JSON(http.StatusConflict, model.Response{Error: err.Error()})
+	case errors.Is(err, service.ErrUpstream):
+		c.JSON(http.StatusBadGateway, model.Response{Error: err.Error()})
+	default:
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+	}
+}
+
+// SyncSongFromWiki godoc
+// @Summary Sync a song from the wiki
+// @Description Compare a song with its community wiki page and file a pending proposal for every differing
+// @Description artist, BPM, illustrator, song cover or chart cover (Admin only). The song itself is not changed;
+// @Description proposals are applied only once accepted. Proposals identical to a pending one are not filed again.
+// @Tags wiki
+// @Produce json
+// @Security BearerAuth
+// @Param song_id path string true "Song address (ID, wiki_id or alias)"
+// @Success 200 {array} model.SongMetadataProposal "Newly filed proposals"
+// @Failure 400 {object} model.Response "Wiki source not configured"
+// @Failure 404 {object} model.Response
+// @Failure 502 {object} model.Response
+// @Router /songs/{song_id}/wiki-sync [post]
+func (ctrl *SongController) SyncSongFromWiki(c *gin.Context) {
+	songAddr := c.Param("song_id")
+	ctx := logging.AppendCtx(c.Request.Context(), slog.String("song_addr", songAddr))
+
+	songID, err := ctrl.songService.ResolveSongID(ctx, songAddr)
+	if err != nil {
+		writeResolveError(c, err)
+		return
+	}
+
+	proposals, err := ctrl.songService.SyncSongFromWiki(ctx, songID)
+	if err != nil {
+		writeProposalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, proposals)
+}
+
+// GetMetadataProposals godoc
+// @Summary List song metadata proposals
+// @Description List metadata proposals filed by wiki syncs, newest first (Admin only)
+// @Tags wiki
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status (pending, accepted or rejected)"
+// @Param song_id query int false "Filter by song ID"
+// @Success 200 {array} model.SongMetadataProposal
+// @Failure 400 {object} model.Response
+// @Router /wiki/proposals [get]
+func (ctrl *SongController) GetMetadataProposals(c *gin.Context) {
+	songID := 0
+	if s := c.Query("song_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, model.Response{Error: "invalid song_id"})
+			return
+		}
+		songID = id
+	}
+
+	proposals, err := ctrl.songService.GetMetadataProposals(c.Request.Context(), c.Query("status"), songID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), model.Response{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, proposals)
+}
+
+// AcceptMetadataProposal godoc
+// @Summary Accept a song metadata proposal
+// @Description Apply a pending proposal to the song (Admin only). The change is recorded in the song's history.
+// @Description Fails with 409 if the proposal was already reviewed or the field was edited after the sync.
+// @Tags wiki
+// @Produce json
+// @Security BearerAuth
+// @Param proposal_id path int true "Proposal ID"
+// @Success 200 {object} model.SongMetadataProposal
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /wiki/proposals/{proposal_id}/accept [post]
+func (ctrl *SongController) AcceptMetadataProposal(c *gin.Context) {
+	proposalID, err := strconv.Atoi(c.Param("proposal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid proposal_id"})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("proposal_id", proposalID))
+	proposal, err := ctrl.songService.AcceptMetadataProposal(ctx, proposalID, c.GetString("username"))
+	if err != nil {
+		writeProposalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, proposal)
+}
+
+// RejectMetadataProposal godoc
+// @Summary Reject a song metadata proposal
+// @Description Mark a pending proposal as rejected without changing the song (Admin only)
+// @Tags wiki
+// @Produce json
+// @Security BearerAuth
+// @Param proposal_id path int true "Proposal ID"
+// @Success 200 {object} model.SongMetadataProposal
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Router /wiki/proposals/{proposal_id}/reject [post]
+func (ctrl *SongController) RejectMetadataProposal(c *gin.Context) {
+	proposalID, err := strconv.Atoi(c.Param("proposal_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.Response{Error: "invalid proposal_id"})
+		return
+	}
+
+	ctx := logging.AppendCtx(c.Request.Context(), slog.Int("proposal_id", proposalID))
+	proposal, err := ctrl.songService.RejectMetadataProposal(ctx, proposalID, c.GetString("username"))
+	if err != nil {
+		writeProposalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, proposal)
+}
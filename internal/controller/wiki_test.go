@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/internal/service"
+	"paradigm-reboot-prober-go/internal/wiki"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongController_Wiki(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	env := setupEnv(t)
+
+	fixture := filepath.Join(t.TempDir(), "wiki.yaml")
+	require.NoError(t, os.WriteFile(fixture, []byte(`
+songs:
+  - wiki_id: wiki_song
+    title: Wiki Song
+    artist: Wiki Artist
+    bpm: "200"
+`), 0o644))
+	provider, err := wiki.NewFileProvider(fixture)
+	require.NoError(t, err)
+	songCtrl := NewSongController(service.NewSongService(
+		repository.NewSongRepository(env.db), repository.NewRecordRepository(env.db), provider))
+
+	song := model.Song{
+		SongBase: model.SongBase{WikiID: "wiki_song", Title: "Wiki Song", Artist: "Local Artist", BPM: "200"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14, Notes: 600}},
+	}
+	env.db.Create(&song)
+	local := model.Song{
+		SongBase: model.SongBase{WikiID: "local_song", Title: "Local", Artist: "A"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 12, Notes: 500}},
+	}
+	env.db.Create(&local)
+
+	r := gin.Default()
+	setUser := func(c *gin.Context) { c.Set("username", "admin") }
+	r.GET("/songs/:song_id", songCtrl.GetSingleSongInfo)
+	r.POST("/songs/:song_id/wiki-sync", songCtrl.SyncSongFromWiki)
+	r.GET("/wiki/proposals", songCtrl.GetMetadataProposals)
+	r.POST("/wiki/proposals/:proposal_id/accept", setUser, songCtrl.AcceptMetadataProposal)
+	r.POST("/wiki/proposals/:proposal_id/reject", setUser, songCtrl.RejectMetadataProposal)
+
+	t.Run("Get with src=wiki", func(t *testing.T) {
+		for _, addr := range []string{fmt.Sprint(song.ID), "wiki_song"} {
+			w := performRequest(r, "GET", "/songs/"+addr+"?src=wiki", nil, nil)
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var got model.Song
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, "Wiki Artist", got.Artist)
+		}
+
+		w := performRequest(r, "GET", "/songs/wiki_song", nil, nil)
+		assert.Contains(t, w.Body.String(), "Local Artist")
+
+		w = performRequest(r, "GET", "/songs/local_song?src=wiki", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "GET", "/songs/wiki_song?src=other", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Sync, list and review", func(t *testing.T) {
+		w := performRequest(r, "POST", "/songs/wiki_song/wiki-sync", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var proposals []model.SongMetadataProposal
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &proposals))
+		require.Len(t, proposals, 1)
+		assert.Equal(t, model.ProposalFieldArtist, proposals[0].Field)
+
+		w = performRequest(r, "GET", fmt.Sprintf("/wiki/proposals?status=pending&song_id=%d", song.ID), nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &proposals))
+		assert.Len(t, proposals, 1)
+
+		path := fmt.Sprintf("/wiki/proposals/%d", proposals[0].ID)
+		w = performRequest(r, "POST", path+"/accept", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stored model.Song
+		env.db.First(&stored, song.ID)
+		assert.Equal(t, "Wiki Artist", stored.Artist)
+
+		w = performRequest(r, "POST", path+"/reject", nil, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		w := performRequest(r, "POST", "/songs/local_song/wiki-sync", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "POST", "/songs/missing/wiki-sync", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = performRequest(r, "GET", "/wiki/proposals?status=bogus", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = performRequest(r, "GET", "/wiki/proposals?song_id=x", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = performRequest(r, "POST", "/wiki/proposals/x/accept", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = performRequest(r, "POST", "/wiki/proposals/9999/accept", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
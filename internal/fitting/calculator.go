@@ -2,6 +2,7 @@ package fitting
 
 import (
 	"math"
+	"math/rand/v2"
 	"sort"
 )
 
@@ -57,14 +58,26 @@ type Params struct {
 	ScoreGoodWeight     float64 // score-quality weight at ScoreGoodAt; must be in (0, 1)
 	TukeyK              float64 // tuning constant for the Tukey biweight robustness step
 	MinPlayerRecords    int     // drop samples from players with fewer than this many best_play_records (0 = no filter)
+	BootstrapReplicates int     // bootstrap resamples for the FittingLevel confidence interval; <=0 disables the interval
+	BootstrapSeed       uint64  // seed of the bootstrap resampling
+	ConfidenceLevel     float64 // coverage of the bootstrap interval; must be in (0, 1)
+	Estimator           string  // estimator the runner publishes (EstimatorRobust or EstimatorJoint); empty means robust
+	JointMaxIterations  int     // cap on the alternating passes of the joint estimator; <1 means 10
+	JointTolerance      float64 // the joint estimator stops once no level moves more than this in a pass; <=0 means 0.001
 }
 
 // Result is the output of ComputeFitting. FittingLevel is nil when the chart
 // did not accumulate enough effective samples. The remaining fields are
 // populated in every case (with zero values when the sample set was empty),
 // so callers can persist them into chart_statistics for post-hoc analysis.
+//
+// FittingLevelLower / FittingLevelUpper bound the bootstrap confidence
+// interval of FittingLevel; both are nil when FittingLevel is, or when the
+// interval is disabled (Params.BootstrapReplicates <= 0).
 type Result struct {
 	FittingLevel        *float64
+	FittingLevelLower   *float64
+	FittingLevelUpper   *float64
 	SampleCount         int
 	EffectiveSampleSize float64
 	WeightedMean        float64
@@ -91,6 +104,9 @@ type Result struct {
 //     (N_eff = (Σw)² / Σw²) of the surviving samples.
 //  5. Bayesian shrinkage: pull the weighted mean toward the official level
 //     with prior strength κ, then cap the deviation at MaxDeviation.
+//  6. Confidence interval: when a level is published, steps 2–5 are re-run
+//     on bootstrap resamples of the pre-weighted samples (see
+//     bootstrapInterval).
 //
 // When fewer than MinEffectiveSamples surviving samples remain, FittingLevel
 // is left nil — we prefer abstention over publishing a shaky number.
@@ -102,72 +118,17 @@ func ComputeFitting(officialLevel float64, samples []Sample, params Params) Resu
 	prew := make([]float64, 0, len(samples))
 	var raw int
 	for _, s := range samples {
-		if s.Score < params.MinScore {
-			continue
-		}
-		level, ok := InverseLevel(s.Score, s.PlayerSkill)
-		if !ok {
+		sw := preWeight(officialLevel, s, params)
+		switch sw.Status {
+		case SampleBelowMinScore, SampleNotInvertible:
 			continue
 		}
 		raw++
-		// proximity weight: Gaussian on (skill − 10·Level) in rating units.
-		//
-		// Crucially, the σ is **asymmetric**. A player whose skill greatly
-		// exceeds 10·Level (i.e. a high-rank player on a low-level chart) is
-		// almost certain to hit an AP-tier score, at which point InverseLevel
-		// degenerates into simply echoing the player's skill rather than
-		// measuring the chart. We therefore shrink σ on that side
-		// (σ_high = σ · HighSkillSigmaRatio).
-		//
-		// Just rescaling σ is not enough on its own — Kish's N_eff is
-		// scale-invariant, so 5 identically-weighted samples still count as
-		// N_eff=5 even when each carries 1% weight. We therefore also
-		// **hard-discard** any sample beyond a 2.5 · σ radius, so raw /
-		// inferred / N_eff all drop together. Combined with asymmetric σ,
-		// over-skilled samples (diff > 2.5 · σ_high) are dropped entirely,
-		// which is what causes chronically mis-played lv14 charts to correctly
-		// abstain rather than publish a skill-echoed fit.
-		const proximityCutoffSigmas = 2.5
-		diff := s.PlayerSkill - 10.0*officialLevel
-		sigma := params.ProximitySigma
-		if diff > 0 && params.HighSkillSigmaRatio > 0 {
-			sigma = sigma * params.HighSkillSigmaRatio
-		}
-		if math.Abs(diff) > proximityCutoffSigmas*sigma {
-			continue
-		}
-		proximity := math.Exp(-(diff * diff) / (2.0 * sigma * sigma))
-		// volume weight: linear ramp to 1.0 at VolumeFullAt records.
-		volume := 1.0
-		if params.VolumeFullAt > 0 && s.PlayerRecords < params.VolumeFullAt {
-			volume = float64(s.PlayerRecords) / float64(params.VolumeFullAt)
-		}
-		// score-quality weight: the actual score a player achieved conveys how
-		// much of the chart they "really" have under control. Business domain
-		// knowledge from Paradigm: Reboot:
-		//   - score < 1,000,000: the player has not really "passed" the chart
-		//     (the rating curve's inversion is also numerically unstable below
-		//     1M) — give zero weight.
-		//   - score ≥ 1,007,500: the player "会打" (has a handle on) the chart;
-		//     samples here carry substantial weight.
-		//   - score ≥ 1,009,000: the commonly pursued "高分" tier; saturate the
-		//     weight to 1.0 — these are the most reliable samples.
-		scoreQ := scoreQualityWeight(s.Score, params)
-		// sample-age weight: exponential half-life decay on play-time. Players'
-		// behaviour drifts over time — charts get played by newer, better-tuned
-		// cohorts; older records are less representative of "how players of this
-		// skill band play this chart TODAY". When SampleHalflifeDays is set,
-		// each sample gets an extra factor exp(-ln2 · AgeDays / halflife), so a
-		// record that is one halflife old contributes half as much. Disabled
-		// (factor = 1) when SampleHalflifeDays ≤ 0 or AgeDays ≤ 0 (fresh sample /
-		// missing timestamp fallback).
-		ageW := sampleAgeWeight(s.AgeDays, params.SampleHalflifeDays)
-		w := proximity * volume * scoreQ * ageW
-		if w <= 0 || math.IsNaN(w) {
+		if sw.Status != SampleKept {
 			continue
 		}
-		inferred = append(inferred, level)
-		prew = append(prew, w)
+		inferred = append(inferred, sw.InferredLevel)
+		prew = append(prew, sw.PreWeight)
 	}
 	res.SampleCount = raw
 
@@ -175,6 +136,99 @@ func ComputeFitting(officialLevel float64, samples []Sample, params Params) Resu
 		return res
 	}
 
+	est := estimate(officialLevel, inferred, prew, params)
+	est.SampleCount = raw
+	if est.FittingLevel != nil {
+		// ----- 6. Bootstrap confidence interval -----
+		est.FittingLevelLower, est.FittingLevelUpper = bootstrapInterval(officialLevel, inferred, prew, *est.FittingLevel, params)
+	}
+	return est
+}
+
+// preWeight inverts one sample and computes its pre-weight components
+// (step 1 of ComputeFitting). Status is SampleKept when the sample enters
+// the robust trimming, or tells why it does not; the components are filled
+// in whenever the score could be inverted.
+func preWeight(officialLevel float64, s Sample, params Params) SampleWeights {
+	sw := SampleWeights{Sample: s}
+	if s.Score < params.MinScore {
+		sw.Status = SampleBelowMinScore
+		return sw
+	}
+	level, ok := InverseLevel(s.Score, s.PlayerSkill)
+	if !ok {
+		sw.Status = SampleNotInvertible
+		return sw
+	}
+	sw.InferredLevel = level
+	// proximity weight: Gaussian on (skill − 10·Level) in rating units.
+	//
+	// Crucially, the σ is **asymmetric**. A player whose skill greatly
+	// exceeds 10·Level (i.e. a high-rank player on a low-level chart) is
+	// almost certain to hit an AP-tier score, at which point InverseLevel
+	// degenerates into simply echoing the player's skill rather than
+	// measuring the chart. We therefore shrink σ on that side
+	// (σ_high = σ · HighSkillSigmaRatio).
+	//
+	// Just rescaling σ is not enough on its own — Kish's N_eff is
+	// scale-invariant, so 5 identically-weighted samples still count as
+	// N_eff=5 even when each carries 1% weight. We therefore also
+	// **hard-discard** any sample beyond a 2.5 · σ radius, so raw /
+	// inferred / N_eff all drop together. Combined with asymmetric σ,
+	// over-skilled samples (diff > 2.5 · σ_high) are dropped entirely,
+	// which is what causes chronically mis-played lv14 charts to correctly
+	// abstain rather than publish a skill-echoed fit.
+	const proximityCutoffSigmas = 2.5
+	diff := s.PlayerSkill - 10.0*officialLevel
+	sigma := params.ProximitySigma
+	if diff > 0 && params.HighSkillSigmaRatio > 0 {
+		sigma = sigma * params.HighSkillSigmaRatio
+	}
+	outOfRange := math.Abs(diff) > proximityCutoffSigmas*sigma
+	proximity := math.Exp(-(diff * diff) / (2.0 * sigma * sigma))
+	// volume weight: linear ramp to 1.0 at VolumeFullAt records.
+	volume := 1.0
+	if params.VolumeFullAt > 0 && s.PlayerRecords < params.VolumeFullAt {
+		volume = float64(s.PlayerRecords) / float64(params.VolumeFullAt)
+	}
+	// score-quality weight: the actual score a player achieved conveys how
+	// much of the chart they "really" have under control. Business domain
+	// knowledge from Paradigm: Reboot:
+	//   - score < 1,000,000: the player has not really "passed" the chart
+	//     (the rating curve's inversion is also numerically unstable below
+	//     1M) — give zero weight.
+	//   - score ≥ 1,007,500: the player "会打" (has a handle on) the chart;
+	//     samples here carry substantial weight.
+	//   - score ≥ 1,009,000: the commonly pursued "高分" tier; saturate the
+	//     weight to 1.0 — these are the most reliable samples.
+	scoreQ := scoreQualityWeight(s.Score, params)
+	// sample-age weight: exponential half-life decay on play-time. Players'
+	// behaviour drifts over time — charts get played by newer, better-tuned
+	// cohorts; older records are less representative of "how players of this
+	// skill band play this chart TODAY". When SampleHalflifeDays is set,
+	// each sample gets an extra factor exp(-ln2 · AgeDays / halflife), so a
+	// record that is one halflife old contributes half as much. Disabled
+	// (factor = 1) when SampleHalflifeDays ≤ 0 or AgeDays ≤ 0 (fresh sample /
+	// missing timestamp fallback).
+	ageW := sampleAgeWeight(s.AgeDays, params.SampleHalflifeDays)
+	sw.Proximity, sw.Volume, sw.ScoreQuality, sw.Age = proximity, volume, scoreQ, ageW
+	sw.PreWeight = proximity * volume * scoreQ * ageW
+	switch {
+	case outOfRange:
+		sw.Status = SampleOutOfRange
+	case sw.PreWeight <= 0 || math.IsNaN(sw.PreWeight):
+		sw.Status = SampleZeroWeight
+	default:
+		sw.Status = SampleKept
+	}
+	return sw
+}
+
+// estimate runs steps 2–5 of ComputeFitting on the pre-weighted inferred
+// levels (at least one). SampleCount is left to the caller.
+func estimate(officialLevel float64, inferred, prew []float64, params Params) Result {
+	res := Result{}
+
 	// ----- 2. Weighted median + MAD -----
 	median := weightedMedian(inferred, prew)
 	res.WeightedMedian = median
@@ -187,13 +241,7 @@ func ComputeFitting(officialLevel float64, samples []Sample, params Params) Resu
 
 	// ----- 3. Tukey biweight robust weights -----
 	final := make([]float64, len(inferred))
-	// Denominator for the scaled residual. Use a safe floor when MAD is near
-	// zero (samples unusually concentrated), scaled from the official level:
-	// 1% of (|Level|+1) is a conservative minimum dispersion.
-	scale := params.TukeyK * mad
-	if scale <= 1e-9 {
-		scale = params.TukeyK * 0.01 * (math.Abs(officialLevel) + 1.0)
-	}
+	scale := tukeyScale(officialLevel, mad, params)
 	for i := range inferred {
 		u := (inferred[i] - median) / scale
 		if math.Abs(u) >= 1.0 {
@@ -278,6 +326,84 @@ func ComputeFitting(officialLevel float64, samples []Sample, params Params) Resu
 	return res
 }
 
+// tukeyScale is the denominator of the scaled residuals in the Tukey
+// biweight step. It falls back to a safe floor when MAD is near zero
+// (samples unusually concentrated), scaled from the official level: 1% of
+// (|Level|+1) is a conservative minimum dispersion.
+func tukeyScale(officialLevel, mad float64, params Params) float64 {
+	scale := params.TukeyK * mad
+	if scale <= 1e-9 {
+		scale = params.TukeyK * 0.01 * (math.Abs(officialLevel) + 1.0)
+	}
+	return scale
+}
+
+// bootstrapInterval returns the percentile bootstrap interval of a published
+// fitting level. The pre-weighted samples are resampled with replacement
+// Params.BootstrapReplicates times; every resample goes through steps 2–5
+// again, and the interval spans the central Params.ConfidenceLevel of the
+// levels the resamples publish (resamples that abstain are left out).
+//
+// The samples are put in a canonical order before resampling, so the
+// interval depends only on the data and Params.BootstrapSeed — not on the
+// order the database returned the rows in. Shrinkage and the deviation cap
+// make the estimator non-smooth, so the percentile interval is widened when
+// needed to contain the point estimate itself.
+func bootstrapInterval(officialLevel float64, inferred, prew []float64, level float64, params Params) (*float64, *float64) {
+	if params.BootstrapReplicates <= 0 || params.ConfidenceLevel <= 0 || params.ConfidenceLevel >= 1 {
+		return nil, nil
+	}
+	n := len(inferred)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if inferred[order[a]] != inferred[order[b]] {
+			return inferred[order[a]] < inferred[order[b]]
+		}
+		return prew[order[a]] < prew[order[b]]
+	})
+	values := make([]float64, n)
+	weights := make([]float64, n)
+	for i, j := range order {
+		values[i], weights[i] = inferred[j], prew[j]
+	}
+
+	rng := rand.New(rand.NewPCG(params.BootstrapSeed, uint64(n)))
+	resValues := make([]float64, n)
+	resWeights := make([]float64, n)
+	levels := make([]float64, 0, params.BootstrapReplicates)
+	for range params.BootstrapReplicates {
+		for i := range resValues {
+			j := rng.IntN(n)
+			resValues[i], resWeights[i] = values[j], weights[j]
+		}
+		if r := estimate(officialLevel, resValues, resWeights, params); r.FittingLevel != nil {
+			levels = append(levels, *r.FittingLevel)
+		}
+	}
+	if len(levels) == 0 {
+		return nil, nil
+	}
+	sort.Float64s(levels)
+	tail := (1 - params.ConfidenceLevel) / 2
+	lower := math.Min(quantile(levels, tail), level)
+	upper := math.Max(quantile(levels, 1-tail), level)
+	return &lower, &upper
+}
+
+// quantile returns the p-quantile of sorted (non-empty) values, linearly
+// interpolated between the closest ranks.
+func quantile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
 // effectiveMaxDeviation returns the hard cap on |FittingLevel − Level| to
 // apply at the given official level, honouring the optional level-dependent
 // ramp driven by Params.MaxDeviationLow / MaxDeviationLowAt /
@@ -1108,3 +1108,77 @@ func TestComputeFitting_SampleAgeDecay_DisabledIsNoOp(t *testing.T) {
 	assert.InDelta(t, *res1.FittingLevel, *res2.FittingLevel, 1e-9,
 		"SampleHalflifeDays=0 must make AgeDays irrelevant")
 }
+
+// noisySamples draws n players on a chart of the given true level, with the
+// same score noise as TestComputeFitting_DrawsTowardTrueLevel.
+func noisySamples(n int, trueLevel float64, seed int64) []Sample {
+	rng := rand.New(rand.NewSource(seed))
+	samples := make([]Sample, 0, n)
+	for i := 0; i < n; i++ {
+		skill := 10*trueLevel + rng.Float64()*10.0
+		noise := (rng.Float64() - 0.5) * 3000
+		samples = append(samples, Sample{
+			Username:      fmt.Sprintf("p%03d", i),
+			Score:         simulateScore(trueLevel, skill) + int(noise),
+			PlayerSkill:   skill,
+			PlayerRecords: 50,
+		})
+	}
+	return samples
+}
+
+// The bootstrap interval brackets the published level, is reproducible for a
+// seed whatever the sample order, and narrows as the evidence grows.
+func TestComputeFitting_BootstrapInterval(t *testing.T) {
+	params := defaultParams()
+	params.BootstrapReplicates = 200
+	params.BootstrapSeed = 7
+	params.ConfidenceLevel = 0.9
+
+	samples := noisySamples(40, 15.5, 1)
+	res := ComputeFitting(15.5, samples, params)
+	if !assert.NotNil(t, res.FittingLevel) || !assert.NotNil(t, res.FittingLevelLower) || !assert.NotNil(t, res.FittingLevelUpper) {
+		return
+	}
+	assert.LessOrEqual(t, *res.FittingLevelLower, *res.FittingLevel)
+	assert.GreaterOrEqual(t, *res.FittingLevelUpper, *res.FittingLevel)
+	width := *res.FittingLevelUpper - *res.FittingLevelLower
+	assert.Greater(t, width, 0.0)
+	assert.Less(t, width, 0.5)
+
+	// The interval never moves the point estimate
+	off := params
+	off.BootstrapReplicates = 0
+	plain := ComputeFitting(15.5, samples, off)
+	assert.Equal(t, *plain.FittingLevel, *res.FittingLevel)
+	assert.Nil(t, plain.FittingLevelLower)
+	assert.Nil(t, plain.FittingLevelUpper)
+
+	reversed := make([]Sample, len(samples))
+	for i, s := range samples {
+		reversed[len(samples)-1-i] = s
+	}
+	again := ComputeFitting(15.5, reversed, params)
+	assert.Equal(t, *res.FittingLevelLower, *again.FittingLevelLower, "same seed, same interval")
+	assert.Equal(t, *res.FittingLevelUpper, *again.FittingLevelUpper)
+
+	more := ComputeFitting(15.5, noisySamples(400, 15.5, 1), params)
+	if assert.NotNil(t, more.FittingLevelLower) {
+		assert.Less(t, *more.FittingLevelUpper-*more.FittingLevelLower, width)
+	}
+
+	// No interval without a level
+	abstain := ComputeFitting(15.5, samples[:2], params)
+	assert.Nil(t, abstain.FittingLevel)
+	assert.Nil(t, abstain.FittingLevelLower)
+	assert.Nil(t, abstain.FittingLevelUpper)
+}
+
+func TestQuantile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	assert.Equal(t, 1.0, quantile(values, 0))
+	assert.Equal(t, 3.0, quantile(values, 0.5))
+	assert.Equal(t, 5.0, quantile(values, 1))
+	assert.InDelta(t, 1.4, quantile(values, 0.1), 1e-9)
+	assert.Equal(t, 7.0, quantile([]float64{7}, 0.95))
+}
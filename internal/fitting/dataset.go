@@ -0,0 +1,230 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"paradigm-reboot-prober-go/pkg/rating"
+
+	"gorm.io/gorm"
+)
+
+// datasetRecord is one best record, compacted for repeated fitting.
+type datasetRecord struct {
+	chart   int // index into Dataset.charts
+	player  int // index into Dataset.players
+	score   int
+	rating  int // stored rating ×100, from the official level
+	ageDays float64
+}
+
+// Dataset is an in-memory copy of every best record. Estimators that pool
+// evidence across charts fit it as a whole, and Evaluate reuses it for every
+// fold and every Params of a grid search.
+type Dataset struct {
+	charts     []chartRow
+	players    []string
+	records    []datasetRecord // grouped by chart, in chart order
+	starts     []int           // records of chart i are records[starts[i]:starts[i+1]]
+	byPlayer   [][]int         // record indices per player, highest rating first
+	watermarks []time.Time     // per chart: newest updated_at behind its records, zero without any
+}
+
+// Records returns the number of loaded best records.
+func (d *Dataset) Records() int { return len(d.records) }
+
+// LoadDataset reads every best record of every chart, with the stored
+// rating and the score. Only cfg.ChartBatchSize is used; nothing is written.
+func LoadDataset(ctx context.Context, db *gorm.DB, cfg RunnerConfig) (*Dataset, error) {
+	return NewRunner(db, Params{}, cfg).loadDataset(ctx)
+}
+
+// loadDataset implements LoadDataset, in chart batches of ChartBatchSize.
+func (r *Runner) loadDataset(ctx context.Context) (*Dataset, error) {
+	charts, err := r.fetchChartsSorted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch charts: %w", err)
+	}
+	type row struct {
+		ChartID    int
+		Username   string
+		Score      int
+		Rating     int
+		RecordTime time.Time
+		UpdatedAt  time.Time
+	}
+	d := &Dataset{charts: charts, starts: make([]int, len(charts)+1), watermarks: make([]time.Time, len(charts))}
+	playerIndex := make(map[string]int)
+	now := r.now()
+	for start := 0; start < len(charts); start += r.cfg.ChartBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+r.cfg.ChartBatchSize, len(charts))
+		index := make(map[int]int, end-start)
+		ids := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			index[charts[i].ID] = i
+			ids = append(ids, charts[i].ID)
+		}
+		var rows []row
+		if err := r.db.WithContext(ctx).
+			Table("best_play_records").
+			Select("best_play_records.chart_id AS chart_id, best_play_records.username AS username, play_records.score AS score, play_records.rating AS rating, play_records.record_time AS record_time, play_records.updated_at AS updated_at").
+			Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
+			Where("best_play_records.chart_id IN ?", ids).
+			Where("best_play_records.deleted_at IS NULL").
+			Where("play_records.deleted_at IS NULL").
+			Order("best_play_records.chart_id ASC, best_play_records.username ASC").
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("fetch best records: %w", err)
+		}
+		for _, row := range rows {
+			p, ok := playerIndex[row.Username]
+			if !ok {
+				p = len(d.players)
+				playerIndex[row.Username] = p
+				d.players = append(d.players, row.Username)
+				d.byPlayer = append(d.byPlayer, nil)
+			}
+			c := index[row.ChartID]
+			if row.UpdatedAt.After(d.watermarks[c]) {
+				d.watermarks[c] = row.UpdatedAt
+			}
+			ageDays := 0.0
+			if !row.RecordTime.IsZero() {
+				ageDays = math.Max(0, now.Sub(row.RecordTime).Hours()/24.0)
+			}
+			d.byPlayer[p] = append(d.byPlayer[p], len(d.records))
+			d.records = append(d.records, datasetRecord{
+				chart: c, player: p, score: row.Score, rating: row.Rating, ageDays: ageDays,
+			})
+		}
+	}
+	// Chart batches come in id order, so records are already grouped by chart.
+	for i, c := 0, 0; c < len(charts); c++ {
+		d.starts[c] = i
+		for i < len(d.records) && d.records[i].chart == c {
+			i++
+		}
+	}
+	d.starts[len(charts)] = len(d.records)
+	for _, idx := range d.byPlayer {
+		sort.SliceStable(idx, func(i, j int) bool { return d.records[idx[i]].rating > d.records[idx[j]].rating })
+	}
+	return d, nil
+}
+
+// subset returns the Dataset of the records keep accepts, sharing the charts
+// and players of d.
+func (d *Dataset) subset(keep func(i int) bool) *Dataset {
+	out := &Dataset{
+		charts:     d.charts,
+		players:    d.players,
+		starts:     make([]int, len(d.charts)+1),
+		byPlayer:   make([][]int, len(d.players)),
+		watermarks: d.watermarks,
+	}
+	remap := make([]int, len(d.records))
+	for c := range d.charts {
+		out.starts[c] = len(out.records)
+		for i := d.starts[c]; i < d.starts[c+1]; i++ {
+			remap[i] = -1
+			if keep(i) {
+				remap[i] = len(out.records)
+				out.records = append(out.records, d.records[i])
+			}
+		}
+	}
+	out.starts[len(d.charts)] = len(out.records)
+	for p, idx := range d.byPlayer {
+		for _, i := range idx {
+			if remap[i] >= 0 {
+				out.byPlayer[p] = append(out.byPlayer[p], remap[i])
+			}
+		}
+	}
+	return out
+}
+
+// storedSkills computes every player's skill from the stored ratings, the
+// way collectPlayerSkills does.
+func (d *Dataset) storedSkills(topK int) []PlayerSkill {
+	skills := make([]PlayerSkill, len(d.players))
+	for p, idx := range d.byPlayer {
+		sum := 0
+		k := min(topK, len(idx))
+		for _, i := range idx[:k] {
+			sum += d.records[i].rating
+		}
+		skills[p].NumRecords = len(idx)
+		if k > 0 {
+			skills[p].AvgRating = float64(sum) / float64(k) / 100.0
+		}
+	}
+	return skills
+}
+
+// skillsAt computes every player's skill as if the charts had the given
+// levels, rating each best record with SingleRating(levels[chart], score).
+func (d *Dataset) skillsAt(levels []float64, topK int) []PlayerSkill {
+	skills := make([]PlayerSkill, len(d.players))
+	var ratings []int
+	for p, idx := range d.byPlayer {
+		ratings = ratings[:0]
+		for _, i := range idx {
+			rec := d.records[i]
+			ratings = append(ratings, rating.SingleRating(levels[rec.chart], rec.score))
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(ratings)))
+		sum := 0
+		k := min(topK, len(ratings))
+		for _, v := range ratings[:k] {
+			sum += v
+		}
+		skills[p].NumRecords = len(idx)
+		if k > 0 {
+			skills[p].AvgRating = float64(sum) / float64(k) / 100.0
+		}
+	}
+	return skills
+}
+
+// fitCharts runs ComputeFitting on every chart with the given player skills;
+// the results are indexed like d.charts.
+func (d *Dataset) fitCharts(ctx context.Context, skills []PlayerSkill, params Params) ([]Result, error) {
+	out := make([]Result, len(d.charts))
+	var samples []Sample
+	for c, chart := range d.charts {
+		if c%256 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		samples = d.chartSamples(c, skills, params, samples[:0])
+		out[c] = ComputeFitting(chart.Level, samples, params)
+	}
+	return out, nil
+}
+
+// chartSamples appends the samples of chart c, with the given player
+// skills, to buf.
+func (d *Dataset) chartSamples(c int, skills []PlayerSkill, params Params, buf []Sample) []Sample {
+	for _, rec := range d.records[d.starts[c]:d.starts[c+1]] {
+		skill := skills[rec.player]
+		if skill.NumRecords == 0 || (params.MinPlayerRecords > 0 && skill.NumRecords < params.MinPlayerRecords) {
+			continue
+		}
+		buf = append(buf, Sample{
+			Username:      d.players[rec.player],
+			Score:         rec.score,
+			PlayerSkill:   skill.AvgRating,
+			PlayerRecords: skill.NumRecords,
+			AgeDays:       rec.ageDays,
+		})
+	}
+	return buf
+}
@@ -0,0 +1,180 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+)
+
+// Estimator names, the values of config.fitting.estimator.
+const (
+	EstimatorRobust = "robust"
+	EstimatorJoint  = "joint"
+)
+
+// Estimator is a strategy turning the best records of a Dataset into a
+// Result per chart, keyed by chart ID. Params.Estimator selects the one a
+// Runner publishes.
+type Estimator interface {
+	Name() string
+	Fit(ctx context.Context, d *Dataset, params Params) (map[int]Result, error)
+	// PerChart reports whether a chart's Result only depends on its own best
+	// records and the skills of their players. The Runner streams such
+	// estimators chart batch by chart batch and runs them incrementally;
+	// the others are fitted on the whole Dataset at every run.
+	PerChart() bool
+}
+
+var estimators = map[string]Estimator{
+	EstimatorRobust: robustEstimator{},
+	EstimatorJoint:  jointEstimator{},
+}
+
+// EstimatorByName returns the estimator of a config.fitting.estimator value;
+// the empty name is the robust one.
+func EstimatorByName(name string) (Estimator, error) {
+	if name == "" {
+		name = EstimatorRobust
+	}
+	est, ok := estimators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fitting estimator %q", name)
+	}
+	return est, nil
+}
+
+// Estimators returns every estimator, sorted by name.
+func Estimators() []Estimator {
+	out := make([]Estimator, 0, len(estimators))
+	for _, est := range estimators {
+		out = append(out, est)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// skillTopK returns params.SkillTopK, defaulting to 50.
+func skillTopK(params Params) int {
+	if params.SkillTopK < 1 {
+		return 50 // defensive fallback; config validation should keep us out of here
+	}
+	return params.SkillTopK
+}
+
+// resultsByID keys results indexed like d.charts by chart ID.
+func (d *Dataset) resultsByID(results []Result) map[int]Result {
+	out := make(map[int]Result, len(results))
+	for c, res := range results {
+		out[d.charts[c].ID] = res
+	}
+	return out
+}
+
+// robustEstimator is ComputeFitting applied to every chart on its own, with
+// the player skills taken from the stored ratings (official levels).
+type robustEstimator struct{}
+
+func (robustEstimator) Name() string   { return EstimatorRobust }
+func (robustEstimator) PerChart() bool { return true }
+
+func (robustEstimator) Fit(ctx context.Context, d *Dataset, params Params) (map[int]Result, error) {
+	results, err := d.fitCharts(ctx, d.storedSkills(skillTopK(params)), params)
+	if err != nil {
+		return nil, err
+	}
+	return d.resultsByID(results), nil
+}
+
+// jointEstimator estimates player skills and chart levels together. The
+// robust estimator measures skills with the official levels, so a misrated
+// chart biases the skill of everyone who played it, and through them every
+// other chart they played. The joint estimator alternates the two halves of
+// the model until the levels settle:
+//
+//  1. chart step: ComputeFitting on every chart with the current skills,
+//     which keeps its robust weighting and its shrinkage toward the official
+//     level (the regularisation of the joint model);
+//  2. player step: every skill is recomputed as the top-K average of
+//     SingleRating(level, score), with the levels of step 1 (the official
+//     level where fitting abstains).
+//
+// The first chart step is exactly the robust estimator. Shifting every level
+// and skill together leaves the model unchanged, so the mean deviation from
+// the official levels is pinned to that of the first pass: the iterations
+// only move charts relative to each other. The published Results come from
+// a last chart step on the converged skills, with the bootstrap interval.
+type jointEstimator struct{}
+
+func (jointEstimator) Name() string   { return EstimatorJoint }
+func (jointEstimator) PerChart() bool { return false }
+
+func (jointEstimator) Fit(ctx context.Context, d *Dataset, params Params) (map[int]Result, error) {
+	topK := skillTopK(params)
+	maxIter := params.JointMaxIterations
+	if maxIter < 1 {
+		maxIter = 10
+	}
+	tol := params.JointTolerance
+	if tol <= 0 {
+		tol = 0.001
+	}
+	// The confidence interval never moves the level; only the last pass needs it.
+	fit := params
+	fit.BootstrapReplicates = 0
+
+	skills := d.storedSkills(topK)
+	levels := make([]float64, len(d.charts))
+	for c, chart := range d.charts {
+		levels[c] = chart.Level
+	}
+	anchor := math.NaN()
+	iter, delta := 0, math.Inf(1)
+	for ; iter < maxIter && delta > tol; iter++ {
+		results, err := d.fitCharts(ctx, skills, fit)
+		if err != nil {
+			return nil, err
+		}
+		shift := meanDeviation(d.charts, results)
+		if math.IsNaN(anchor) {
+			anchor = shift
+		}
+		shift -= anchor
+		delta = 0
+		for c, res := range results {
+			next := d.charts[c].Level
+			if res.FittingLevel != nil {
+				next = *res.FittingLevel - shift
+			}
+			delta = math.Max(delta, math.Abs(next-levels[c]))
+			levels[c] = next
+		}
+		skills = d.skillsAt(levels, topK)
+	}
+	slog.InfoContext(ctx, "joint fitting finished",
+		"iterations", iter, "max_level_delta", delta, "converged", delta <= tol)
+
+	results, err := d.fitCharts(ctx, skills, params)
+	if err != nil {
+		return nil, err
+	}
+	return d.resultsByID(results), nil
+}
+
+// meanDeviation returns the N_eff-weighted mean of FittingLevel − Level over
+// the charts with a published level, 0 without any.
+func meanDeviation(charts []chartRow, results []Result) float64 {
+	var sum, weight float64
+	for c, res := range results {
+		if res.FittingLevel == nil {
+			continue
+		}
+		sum += res.EffectiveSampleSize * (*res.FittingLevel - charts[c].Level)
+		weight += res.EffectiveSampleSize
+	}
+	if weight == 0 {
+		return 0
+	}
+	return sum / weight
+}
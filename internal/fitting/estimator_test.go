@@ -0,0 +1,124 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedMisratedCharts seeds five charts played by two groups of 15 players:
+// both play the first three, the first group also plays a chart officially
+// 16.0 that plays like a 15.2, the second a correctly rated 15.8. It returns
+// the charts with their true levels.
+func seedMisratedCharts(t *testing.T, db *gorm.DB) (charts []model.Chart, trueLevels map[int]float64) {
+	trueLevels = make(map[int]float64)
+	for i, lv := range [][2]float64{{15.0, 15.0}, {15.3, 15.3}, {15.5, 15.5}, {15.8, 15.8}, {16.0, 15.2}} {
+		song := model.Song{SongBase: model.SongBase{
+			WikiID: fmt.Sprintf("joint_song_%d", i), Title: "Joint", Artist: "A", Genre: "G", Cover: "c",
+			Illustrator: "I", Version: "V", Album: "Al", BPM: "100", Length: "1:00",
+		}}
+		require.NoError(t, db.Create(&song).Error)
+		c := model.Chart{SongID: song.ID, Difficulty: model.DifficultyMassive, Level: lv[0], Notes: 1000}
+		require.NoError(t, db.Create(&c).Error)
+		charts = append(charts, c)
+		trueLevels[c.ID] = lv[1]
+	}
+	for i := 0; i < 30; i++ {
+		u := fmt.Sprintf("jt%02d", i)
+		seedUser(t, db, u)
+		skill := 151.0 + float64(i/2)*0.6
+		played := []model.Chart{charts[0], charts[1], charts[2], charts[3+i%2]}
+		for _, c := range played {
+			seedBestRecord(t, db, u, c.ID, simulateScore(trueLevels[c.ID], skill), c.Level)
+		}
+	}
+	return charts, trueLevels
+}
+
+// A misrated chart inflates the skill of the players who played it, so the
+// robust estimator overrates the other charts they play relative to those
+// played by everyone else. Shifting every level together is unobservable, so
+// the estimators are compared on the levels relative to their mean: the
+// joint estimator, which re-measures skills with the fitted levels, gets them
+// far closer to the truth.
+func TestEstimators_MisratedChart(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	charts, trueLevels := seedMisratedCharts(t, db)
+
+	data, err := LoadDataset(ctx, db, RunnerConfig{ChartBatchSize: 2})
+	require.NoError(t, err)
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+	}
+
+	totalErr := make(map[string]float64)
+	for _, est := range Estimators() {
+		results, err := est.Fit(ctx, data, params)
+		require.NoError(t, err)
+		require.Len(t, results, len(charts))
+		offset := 0.0
+		for _, c := range charts {
+			if !assert.NotNil(t, results[c.ID].FittingLevel, "%s chart %d", est.Name(), c.ID) {
+				return
+			}
+			offset += (*results[c.ID].FittingLevel - trueLevels[c.ID]) / float64(len(charts))
+		}
+		for _, c := range charts {
+			totalErr[est.Name()] += math.Abs(*results[c.ID].FittingLevel - trueLevels[c.ID] - offset)
+		}
+	}
+	assert.Less(t, totalErr[EstimatorJoint], totalErr[EstimatorRobust]/2)
+
+	// The robust estimator is the runner's per-chart pipeline.
+	report, err := NewRunner(db, params, RunnerConfig{}).RunFull(ctx)
+	require.NoError(t, err)
+	robust, err := estimators[EstimatorRobust].Fit(ctx, data, params)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.ChartsPublished)
+	for _, c := range charts {
+		var got model.Chart
+		require.NoError(t, db.First(&got, c.ID).Error)
+		assert.InDelta(t, *robust[c.ID].FittingLevel, *got.FittingLevel, 1e-9)
+	}
+
+	// With the joint estimator the runner publishes its levels, in full sweeps.
+	params.Estimator = EstimatorJoint
+	joint, err := estimators[EstimatorJoint].Fit(ctx, data, params)
+	require.NoError(t, err)
+	require.NoError(t, db.Where("1 = 1").Delete(&model.FittingPlayerSkill{}).Error)
+	report, err = NewRunner(db, params, RunnerConfig{}).Run(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.Incremental)
+	var skills int64
+	db.Model(&model.FittingPlayerSkill{}).Count(&skills)
+	assert.EqualValues(t, 30, skills, "player skills saved by pooled runs too")
+	assert.Equal(t, 5, report.ChartsPublished)
+	assert.NotNil(t, report.Watermark)
+	for _, c := range charts {
+		var got model.Chart
+		require.NoError(t, db.First(&got, c.ID).Error)
+		assert.InDelta(t, *joint[c.ID].FittingLevel, *got.FittingLevel, 1e-9)
+	}
+
+	_, err = EstimatorByName("elo")
+	assert.Error(t, err)
+	est, err := EstimatorByName("")
+	require.NoError(t, err)
+	assert.Equal(t, EstimatorRobust, est.Name())
+}
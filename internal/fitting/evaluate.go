@@ -0,0 +1,206 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"paradigm-reboot-prober-go/pkg/rating"
+)
+
+// EvalConfig controls how Evaluate splits and scores best_play_records.
+type EvalConfig struct {
+	// Folds is the number of cross-validation folds: every record is held
+	// out exactly once, and fitted on the other Folds−1 folds. Ignored when
+	// Holdout is set.
+	Folds int
+	// Holdout, when in (0, 1), makes a single split holding out this
+	// fraction of the records instead of cross-validating.
+	Holdout float64
+	// Seed selects the split; records are assigned by a hash of the seed,
+	// the player and the chart, so the split is stable across params.
+	Seed uint64
+	// MinScore and MinPlayerRecords pick the held-out records that are
+	// scored: score ≥ MinScore, by a player with at least MinPlayerRecords
+	// (≥ 1) training records. They are fixed across a grid search so every
+	// candidate is scored on the same records.
+	MinScore         int
+	MinPlayerRecords int
+}
+
+// EvalMetrics are the errors of a level predictor over held-out records.
+// The error of a record is SingleRating(level, score) − the player's skill
+// proxy (top-K average rating, from their training records), in rating units.
+type EvalMetrics struct {
+	Bias float64 `json:"bias"` // mean error
+	MAE  float64 `json:"mae"`
+	RMSE float64 `json:"rmse"`
+}
+
+// EvalBand groups the held-out records of charts whose official level lies
+// in [Band, Band+1). Band is −1 for the overall figures.
+type EvalBand struct {
+	Band    int `json:"band"`
+	Records int `json:"records"`
+	// Coverage is the share of the records whose chart had a fitted level
+	// in their fold; the others are predicted with the official level, as
+	// the probe server shows it when fitting abstains.
+	Coverage float64     `json:"coverage"`
+	Fitted   EvalMetrics `json:"fitted"`
+	Official EvalMetrics `json:"official"`
+}
+
+// EvalResult is the outcome of evaluating one Params.
+type EvalResult struct {
+	Params  Params     `json:"params"`
+	Folds   int        `json:"folds"`
+	Overall EvalBand   `json:"overall"`
+	Bands   []EvalBand `json:"bands"`
+}
+
+// folds assigns every record to a fold, and returns the number of folds to
+// evaluate. With a holdout fraction, held-out records are in fold 0 and the
+// others in fold −1, which is never held out.
+func (d *Dataset) folds(cfg EvalConfig) ([]int, int) {
+	out := make([]int, len(d.records))
+	var seed [8]byte
+	for i := range seed {
+		seed[i] = byte(cfg.Seed >> (8 * i))
+	}
+	for i, rec := range d.records {
+		id := d.charts[rec.chart].ID
+		h := fnv.New64a()
+		h.Write(seed[:])
+		h.Write([]byte(d.players[rec.player]))
+		h.Write([]byte{0, byte(id), byte(id >> 8), byte(id >> 16), byte(id >> 24)})
+		sum := h.Sum64()
+		if cfg.Holdout > 0 {
+			out[i] = -1
+			if float64(sum>>11)/(1<<53) < cfg.Holdout {
+				out[i] = 0
+			}
+			continue
+		}
+		out[i] = int(sum % uint64(cfg.Folds))
+	}
+	if cfg.Holdout > 0 {
+		return out, 1
+	}
+	return out, cfg.Folds
+}
+
+// metricAcc accumulates the errors behind an EvalMetrics.
+type metricAcc struct {
+	n               int
+	sum, sumAbs, sq float64
+}
+
+func (a *metricAcc) add(e float64) {
+	a.n++
+	a.sum += e
+	a.sumAbs += math.Abs(e)
+	a.sq += e * e
+}
+
+func (a metricAcc) metrics() EvalMetrics {
+	if a.n == 0 {
+		return EvalMetrics{}
+	}
+	n := float64(a.n)
+	return EvalMetrics{Bias: a.sum / n, MAE: a.sumAbs / n, RMSE: math.Sqrt(a.sq / n)}
+}
+
+// bandAcc accumulates one EvalBand.
+type bandAcc struct {
+	covered          int
+	fitted, official metricAcc
+}
+
+func (b bandAcc) band(band int) EvalBand {
+	out := EvalBand{Band: band, Records: b.fitted.n, Fitted: b.fitted.metrics(), Official: b.official.metrics()}
+	if b.fitted.n > 0 {
+		out.Coverage = float64(b.covered) / float64(b.fitted.n)
+	}
+	return out
+}
+
+// Evaluate cross-validates params: for every fold, player skills and chart
+// levels are fitted from the other folds only, with the estimator named by
+// params.Estimator, and each held-out record is scored by how well
+// SingleRating(fitted level, score) predicts the player's skill proxy. The
+// official level is scored alongside as a baseline.
+func (d *Dataset) Evaluate(ctx context.Context, params Params, cfg EvalConfig) (EvalResult, error) {
+	if cfg.Holdout <= 0 && cfg.Folds < 2 {
+		return EvalResult{}, fmt.Errorf("need at least 2 folds or a holdout fraction, got folds=%d holdout=%g", cfg.Folds, cfg.Holdout)
+	}
+	if cfg.Holdout >= 1 {
+		return EvalResult{}, fmt.Errorf("holdout fraction must be below 1, got %g", cfg.Holdout)
+	}
+	est, err := EstimatorByName(params.Estimator)
+	if err != nil {
+		return EvalResult{}, err
+	}
+	minRecords := max(cfg.MinPlayerRecords, 1)
+	// The confidence interval never moves the level; skip the bootstrap.
+	fit := params
+	fit.BootstrapReplicates = 0
+
+	fold, nFolds := d.folds(cfg)
+	var overall bandAcc
+	bands := make(map[int]*bandAcc)
+	for f := 0; f < nFolds; f++ {
+		if err := ctx.Err(); err != nil {
+			return EvalResult{}, err
+		}
+		// 1. Fit the training records. The scoring target is the skill proxy
+		//    of the training records, whatever skills the estimator used.
+		train := d.subset(func(i int) bool { return fold[i] != f })
+		skills := train.storedSkills(skillTopK(params))
+		results, err := est.Fit(ctx, train, fit)
+		if err != nil {
+			return EvalResult{}, err
+		}
+
+		// 2. Score the held-out records of every chart.
+		for c, chart := range d.charts {
+			level := chart.Level
+			res := results[chart.ID]
+			if res.FittingLevel != nil {
+				level = *res.FittingLevel
+			}
+			band := int(math.Floor(chart.Level))
+			b := bands[band]
+			if b == nil {
+				b = &bandAcc{}
+				bands[band] = b
+			}
+			for i := d.starts[c]; i < d.starts[c+1]; i++ {
+				rec := d.records[i]
+				skill := skills[rec.player]
+				if fold[i] != f || rec.score < cfg.MinScore || skill.NumRecords < minRecords {
+					continue
+				}
+				fitted := float64(rating.SingleRating(level, rec.score))/100.0 - skill.AvgRating
+				official := float64(rating.SingleRating(chart.Level, rec.score))/100.0 - skill.AvgRating
+				for _, acc := range []*bandAcc{&overall, b} {
+					acc.fitted.add(fitted)
+					acc.official.add(official)
+					if res.FittingLevel != nil {
+						acc.covered++
+					}
+				}
+			}
+		}
+	}
+
+	out := EvalResult{Params: params, Folds: nFolds, Overall: overall.band(-1)}
+	for band, acc := range bands {
+		if acc.fitted.n > 0 {
+			out.Bands = append(out.Bands, acc.band(band))
+		}
+	}
+	sort.Slice(out.Bands, func(i, j int) bool { return out.Bands[i].Band < out.Bands[j].Band })
+	return out, nil
+}
@@ -0,0 +1,104 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDataset_Evaluate seeds four correctly rated charts and one whose
+// official level is 0.8 too high: held out, the fitted level of the latter
+// should predict player skill far better than its official level.
+func TestDataset_Evaluate(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	type seeded struct {
+		chart     model.Chart
+		trueLevel float64
+	}
+	var charts []seeded
+	for i, lv := range [][2]float64{{15.0, 15.0}, {15.3, 15.3}, {15.5, 15.5}, {15.8, 15.8}, {16.0, 15.2}} {
+		song := model.Song{SongBase: model.SongBase{
+			WikiID: fmt.Sprintf("eval_song_%d", i), Title: "Evaluate", Artist: "A", Genre: "G", Cover: "c",
+			Illustrator: "I", Version: "V", Album: "Al", BPM: "100", Length: "1:00",
+		}}
+		if err := db.Create(&song).Error; err != nil {
+			t.Fatalf("create song: %v", err)
+		}
+		c := model.Chart{SongID: song.ID, Difficulty: model.DifficultyMassive, Level: lv[0], Notes: 1000}
+		if err := db.Create(&c).Error; err != nil {
+			t.Fatalf("create chart: %v", err)
+		}
+		charts = append(charts, seeded{chart: c, trueLevel: lv[1]})
+	}
+	for i := 0; i < 20; i++ {
+		u := fmt.Sprintf("ev%02d", i)
+		seedUser(t, db, u)
+		skill := 152.0 + float64(i)*0.4
+		for _, c := range charts {
+			seedBestRecord(t, db, u, c.chart.ID, simulateScore(c.trueLevel, skill), c.chart.Level)
+		}
+	}
+
+	data, err := LoadDataset(ctx, db, RunnerConfig{ChartBatchSize: 2})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	assert.Equal(t, 100, data.Records())
+
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+	}
+	cfg := EvalConfig{Folds: 5, Seed: 7, MinScore: 500000, MinPlayerRecords: 1}
+	res, err := data.Evaluate(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	assert.Equal(t, 5, res.Folds)
+	assert.Equal(t, 100, res.Overall.Records, "every record is held out exactly once")
+	if !assert.Len(t, res.Bands, 2) {
+		return
+	}
+	assert.Equal(t, 15, res.Bands[0].Band)
+	assert.Equal(t, 80, res.Bands[0].Records)
+	mis := res.Bands[1]
+	assert.Equal(t, 16, mis.Band)
+	assert.Equal(t, 20, mis.Records)
+	assert.Equal(t, 1.0, mis.Coverage)
+	assert.Less(t, mis.Fitted.MAE, mis.Official.MAE/2)
+	assert.Greater(t, mis.Official.Bias, 5.0, "the official level overrates the chart")
+	assert.Less(t, res.Overall.Fitted.MAE, res.Overall.Official.MAE)
+
+	// The split only depends on the seed
+	again, err := data.Evaluate(ctx, params, cfg)
+	if err != nil {
+		t.Fatalf("evaluate again: %v", err)
+	}
+	assert.Equal(t, res, again)
+
+	holdout := cfg
+	holdout.Holdout = 0.3
+	single, err := data.Evaluate(ctx, params, holdout)
+	if err != nil {
+		t.Fatalf("evaluate holdout: %v", err)
+	}
+	assert.Equal(t, 1, single.Folds)
+	assert.Greater(t, single.Overall.Records, 10)
+	assert.Less(t, single.Overall.Records, 50)
+
+	_, err = data.Evaluate(ctx, params, EvalConfig{Folds: 1})
+	assert.Error(t, err)
+}
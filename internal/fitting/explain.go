@@ -0,0 +1,120 @@
+package fitting
+
+import "math"
+
+// Statuses of a SampleWeights: kept, or the step of ComputeFitting that
+// dropped the sample.
+const (
+	SampleKept          = "kept"             // contributes to the weighted mean
+	SampleTrimmed       = "trimmed"          // residual beyond the Tukey cutoff
+	SampleBelowMinScore = "below_min_score"  // score < Params.MinScore
+	SampleNotInvertible = "not_invertible"   // InverseLevel found no level
+	SampleOutOfRange    = "out_of_proximity" // skill beyond the 2.5σ proximity cutoff
+	SampleZeroWeight    = "zero_weight"      // a pre-weight component is zero
+	SampleFewRecords    = "few_records"      // player below Params.MinPlayerRecords (Dataset.Explain only)
+)
+
+// SampleWeights is how ComputeFitting treated one sample: its inferred
+// level, every pre-weight component, the Tukey factor and the final weight.
+// The components are zero for samples dropped before inversion.
+type SampleWeights struct {
+	Sample
+	Status        string
+	InferredLevel float64
+	Proximity     float64
+	Volume        float64
+	ScoreQuality  float64
+	Age           float64
+	PreWeight     float64 // Proximity × Volume × ScoreQuality × Age
+	// Residual is u = (InferredLevel − weighted median) / Tukey scale, for
+	// kept and trimmed samples: |u| ≥ 1 is trimmed.
+	Residual float64
+	Tukey    float64 // biweight factor (1 − u²)², 0 when trimmed
+	Weight   float64 // PreWeight × Tukey, the sample's weight in the weighted mean
+}
+
+// ExplainSamples runs ComputeFitting and reports, in the order of samples,
+// how each sample entered it. It is meant for diagnostics (`fitting analyze
+// -format`) and for the outlier report of full sweeps (see PlayerOutlier).
+func ExplainSamples(officialLevel float64, samples []Sample, params Params) ([]SampleWeights, Result) {
+	return explainWeights(officialLevel, samples, params), ComputeFitting(officialLevel, samples, params)
+}
+
+// explainWeights is ExplainSamples without the Result.
+func explainWeights(officialLevel float64, samples []Sample, params Params) []SampleWeights {
+	out := make([]SampleWeights, len(samples))
+	var kept []int
+	var inferred, prew []float64
+	for i, s := range samples {
+		out[i] = preWeight(officialLevel, s, params)
+		if out[i].Status == SampleKept {
+			kept = append(kept, i)
+			inferred = append(inferred, out[i].InferredLevel)
+			prew = append(prew, out[i].PreWeight)
+		}
+	}
+	if len(kept) > 0 {
+		// Steps 2–3 of estimate, per sample.
+		median := weightedMedian(inferred, prew)
+		absDev := make([]float64, len(inferred))
+		for j, v := range inferred {
+			absDev[j] = math.Abs(v - median)
+		}
+		scale := tukeyScale(officialLevel, weightedMedian(absDev, prew), params)
+		for j, i := range kept {
+			u := (inferred[j] - median) / scale
+			out[i].Residual = u
+			if math.Abs(u) >= 1.0 {
+				out[i].Status = SampleTrimmed
+				continue
+			}
+			biw := 1.0 - u*u
+			out[i].Tukey = biw * biw
+			out[i].Weight = prew[j] * biw * biw
+		}
+	}
+	return out
+}
+
+// ChartExplanation is ExplainSamples applied to one chart of a Dataset.
+type ChartExplanation struct {
+	ChartID      int
+	Level        float64
+	FittingLevel *float64 // published level, from before the dataset was loaded
+	Result       Result
+	Samples      []SampleWeights
+}
+
+// Explain runs ExplainSamples on the charts keep accepts, given their ID
+// and official level. Player skills come from the stored ratings, as for
+// the robust estimator, so the samples are exactly those a run feeds to
+// ComputeFitting; the samples of players below Params.MinPlayerRecords,
+// which a run leaves out, are listed too with SampleFewRecords.
+func (d *Dataset) Explain(params Params, keep func(chartID int, level float64) bool) []ChartExplanation {
+	skills := d.storedSkills(skillTopK(params))
+	var out []ChartExplanation
+	for c, chart := range d.charts {
+		if !keep(chart.ID, chart.Level) {
+			continue
+		}
+		all := d.chartSamples(c, skills, Params{}, nil)
+		samples := d.chartSamples(c, skills, params, nil)
+		explained, res := ExplainSamples(chart.Level, samples, params)
+		weights := make([]SampleWeights, 0, len(all))
+		for _, s := range all {
+			if len(explained) > 0 && explained[0].Sample == s {
+				weights, explained = append(weights, explained[0]), explained[1:]
+			} else {
+				weights = append(weights, SampleWeights{Sample: s, Status: SampleFewRecords})
+			}
+		}
+		out = append(out, ChartExplanation{
+			ChartID:      chart.ID,
+			Level:        chart.Level,
+			FittingLevel: chart.FittingLevel,
+			Result:       res,
+			Samples:      weights,
+		})
+	}
+	return out
+}
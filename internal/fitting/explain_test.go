@@ -0,0 +1,98 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExplainSamples must account for every sample the way ComputeFitting does:
+// the kept samples' weights reproduce its weighted mean and N_eff.
+func TestExplainSamples(t *testing.T) {
+	params := defaultParams()
+	params.SampleHalflifeDays = 30
+	official := 16.0
+	var samples []Sample
+	for i := 0; i < 12; i++ {
+		skill := 158.0 + float64(i)*0.5
+		samples = append(samples, Sample{
+			Username:      fmt.Sprintf("p%d", i),
+			Score:         simulateScore(16.0, skill),
+			PlayerSkill:   skill,
+			PlayerRecords: 20 + i*5,
+			AgeDays:       float64(i * 10),
+		})
+	}
+	samples = append(samples,
+		Sample{Username: "low", Score: 400000, PlayerSkill: 160, PlayerRecords: 50},
+		Sample{Username: "far", Score: simulateScore(10.0, 105), PlayerSkill: 105, PlayerRecords: 50},
+		Sample{Username: "outlier", Score: simulateScore(13.0, 160), PlayerSkill: 160, PlayerRecords: 50},
+	)
+
+	weights, res := ExplainSamples(official, samples, params)
+	require.Len(t, weights, len(samples))
+	assert.Equal(t, ComputeFitting(official, samples, params), res)
+
+	status := make(map[string]string, len(weights))
+	var sumW, sumWX, sumW2 float64
+	raw := 0
+	for i, w := range weights {
+		assert.Equal(t, samples[i], w.Sample, "same order as the input")
+		status[w.Username] = w.Status
+		if w.Status != SampleBelowMinScore && w.Status != SampleNotInvertible {
+			raw++
+			assert.InDelta(t, w.Proximity*w.Volume*w.ScoreQuality*w.Age, w.PreWeight, 1e-12)
+		}
+		if w.Status == SampleKept {
+			sumW += w.Weight
+			sumWX += w.Weight * w.InferredLevel
+			sumW2 += w.Weight * w.Weight
+		} else {
+			assert.Zero(t, w.Weight, w.Username)
+		}
+	}
+	assert.Equal(t, SampleBelowMinScore, status["low"])
+	assert.Equal(t, SampleOutOfRange, status["far"])
+	assert.Equal(t, SampleTrimmed, status["outlier"])
+	assert.Equal(t, SampleKept, status["p0"])
+	assert.Less(t, weights[11].Age, weights[0].Age, "older samples decay")
+
+	assert.Equal(t, res.SampleCount, raw)
+	assert.InDelta(t, res.WeightedMean, sumWX/sumW, 1e-9)
+	assert.InDelta(t, res.EffectiveSampleSize, sumW*sumW/sumW2, 1e-9)
+}
+
+func TestDataset_Explain(t *testing.T) {
+	db := setupTestDB(t)
+	charts, _ := seedMisratedCharts(t, db)
+	d, err := LoadDataset(context.Background(), db, RunnerConfig{ChartBatchSize: 2})
+	require.NoError(t, err)
+	params := Params{MinEffectiveSamples: 2, SkillTopK: 50, ProximitySigma: 15, VolumeFullAt: 3, PriorStrength: 1, MaxDeviation: 1.5, MinScore: 500000, TukeyK: 4.685}
+
+	robust, err := EstimatorByName(EstimatorRobust)
+	require.NoError(t, err)
+	want, err := robust.Fit(context.Background(), d, params)
+	require.NoError(t, err)
+
+	got := d.Explain(params, func(_ int, level float64) bool { return level >= 15.5 })
+	require.Len(t, got, 3)
+	for _, c := range got {
+		assert.GreaterOrEqual(t, c.Level, 15.5)
+		assert.Equal(t, want[c.ChartID], c.Result, "chart %d", c.ChartID)
+		assert.Len(t, c.Samples, c.Result.SampleCount)
+	}
+	assert.Equal(t, charts[2].ID, got[0].ChartID)
+
+	// Players below MinPlayerRecords (4 records each here) are listed but left out.
+	params.MinPlayerRecords = 5
+	got = d.Explain(params, func(id int, _ float64) bool { return id == charts[0].ID })
+	require.Len(t, got, 1)
+	assert.Zero(t, got[0].Result.SampleCount)
+	require.Len(t, got[0].Samples, 30)
+	for _, s := range got[0].Samples {
+		assert.Equal(t, SampleFewRecords, s.Status)
+	}
+}
@@ -0,0 +1,188 @@
+package fitting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrRunNotFound is returned by Rollback for an unknown run ID.
+var ErrRunNotFound = errors.New("fitting run not found")
+
+// RecentRuns returns the most recent fitting runs, newest first.
+func RecentRuns(ctx context.Context, db *gorm.DB, limit int) ([]model.FittingRun, error) {
+	var runs []model.FittingRun
+	err := db.WithContext(ctx).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// ChartRunEntry is a chart's snapshot in one run, with the run it belongs to.
+type ChartRunEntry struct {
+	model.FittingRunChart
+	Kind      string
+	Status    string
+	StartedAt time.Time
+}
+
+// ChartRunHistory returns the snapshots of a chart across the most recent
+// runs, newest first.
+func ChartRunHistory(ctx context.Context, db *gorm.DB, chartID, limit int) ([]ChartRunEntry, error) {
+	var entries []ChartRunEntry
+	err := db.WithContext(ctx).
+		Table("fitting_run_charts").
+		Select("fitting_run_charts.*, fitting_runs.kind, fitting_runs.status, fitting_runs.started_at").
+		Joins("JOIN fitting_runs ON fitting_runs.id = fitting_run_charts.run_id").
+		Where("fitting_run_charts.chart_id = ?", chartID).
+		Order("fitting_run_charts.run_id DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
+// RollbackReport summarises a rollback.
+type RollbackReport struct {
+	RunID     int // fitting_runs.id of the rollback itself; 0 for a dry run
+	Restored  int // charts whose published level was changed back
+	Unchanged int // charts already at the run's level
+	Skipped   int // charts retired since, or whose official level changed since
+}
+
+// Rollback restores the published levels (charts.fitting_level and
+// chart_statistics) of every chart to what the completed run runID wrote.
+// Charts whose official level changed since are skipped, since the old
+// fitting level was derived from a different prior. The rollback is recorded
+// as a run of its own, so it shows in the history and can itself be undone by
+// rolling back to an earlier run. With dryRun nothing is written.
+//
+// The next regular run recomputes every level, so fix whatever made the run
+// bad (usually its params) before it comes around.
+func Rollback(ctx context.Context, db *gorm.DB, runID int, dryRun bool) (report RollbackReport, err error) {
+	var target model.FittingRun
+	if err := db.WithContext(ctx).First(&target, runID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return report, fmt.Errorf("%w: %d", ErrRunNotFound, runID)
+		}
+		return report, err
+	}
+	if target.Kind == model.FittingRunKindShadow {
+		return report, fmt.Errorf("run %d is a shadow run; its levels were never published", runID)
+	}
+	if target.Status != model.FittingRunStatusCompleted {
+		return report, fmt.Errorf("run %d is %s; only completed runs can be restored", runID, target.Status)
+	}
+
+	var snapshots []model.FittingRunChart
+	if err := db.WithContext(ctx).Where("run_id = ?", runID).Order("chart_id").Find(&snapshots).Error; err != nil {
+		return report, err
+	}
+	var charts []chartRow
+	if err := db.WithContext(ctx).Model(&model.Chart{}).Select("id, level, fitting_level").Scan(&charts).Error; err != nil {
+		return report, err
+	}
+	current := make(map[int]chartRow, len(charts))
+	for _, c := range charts {
+		current[c.ID] = c
+	}
+
+	started := time.Now()
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rollback := model.FittingRun{
+			Kind:       model.FittingRunKindRollback,
+			Status:     model.FittingRunStatusCompleted,
+			StartedAt:  started,
+			Params:     target.Params,
+			ConfigHash: target.ConfigHash,
+			RollbackOf: &runID,
+		}
+		if !dryRun {
+			if err := tx.Create(&rollback).Error; err != nil {
+				return err
+			}
+			report.RunID = rollback.ID
+		}
+
+		published := 0
+		for _, snap := range snapshots {
+			c, ok := current[snap.ChartID]
+			if !ok || c.Level != snap.OfficialLevel {
+				report.Skipped++
+				continue
+			}
+			if sameLevel(c.FittingLevel, snap.FittingLevel) {
+				report.Unchanged++
+			} else {
+				report.Restored++
+			}
+			if snap.FittingLevel != nil {
+				published++
+			}
+			if dryRun {
+				continue
+			}
+
+			// The statistics were computed when the restored run started.
+			stat := model.ChartStatistic{
+				ChartID:             snap.ChartID,
+				OfficialLevel:       snap.OfficialLevel,
+				FittingLevel:        snap.FittingLevel,
+				FittingLevelLower:   snap.FittingLevelLower,
+				FittingLevelUpper:   snap.FittingLevelUpper,
+				SampleCount:         snap.SampleCount,
+				EffectiveSampleSize: snap.EffectiveSampleSize,
+				WeightedMean:        snap.WeightedMean,
+				WeightedMedian:      snap.WeightedMedian,
+				StdDev:              snap.StdDev,
+				MAD:                 snap.MAD,
+				LastComputedAt:      target.StartedAt,
+			}
+			if err := writeFittingLevel(tx, stat); err != nil {
+				return err
+			}
+			if err := upsertStatistic(tx, stat); err != nil {
+				return err
+			}
+			snapshot := snapshotOf(rollback.ID, stat, c.FittingLevel)
+			if err := tx.Create(&snapshot).Error; err != nil {
+				return fmt.Errorf("insert fitting_run_charts %d: %w", c.ID, err)
+			}
+		}
+
+		if dryRun {
+			return nil
+		}
+		// Counters mean the same as for regular runs, over the charts restored.
+		completed := time.Now()
+		processed := report.Restored + report.Unchanged
+		return tx.Model(&rollback).Updates(map[string]interface{}{
+			"completed_at":     completed,
+			"duration_ms":      completed.Sub(started).Milliseconds(),
+			"charts_total":     len(snapshots),
+			"charts_processed": processed,
+			"charts_published": published,
+			"charts_abstained": processed - published,
+		}).Error
+	})
+	if err != nil {
+		return RollbackReport{}, err
+	}
+	if !dryRun {
+		slog.InfoContext(ctx, "fitting levels rolled back",
+			"run_id", report.RunID, "rollback_of", runID,
+			"restored", report.Restored, "unchanged", report.Unchanged, "skipped", report.Skipped)
+	}
+	return report, nil
+}
+
+// sameLevel reports whether two published levels are equal, NULL included.
+func sameLevel(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,384 @@
+package fitting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// watermarkOverlap widens the evidence scan of incremental runs below the
+// previous watermark, so rows committed slightly out of updated_at order (or
+// stamped by servers with a little clock skew) are not missed. Charts whose
+// own watermark already covers them are not recomputed twice.
+const watermarkOverlap = time.Minute
+
+// sweepPlan is what one run computes.
+type sweepPlan struct {
+	incremental    bool
+	skills         map[string]PlayerSkill // every player, for the sample weights
+	charts         []chartRow             // charts to recompute
+	total          int                    // non-retired charts
+	playersChanged int                    // players whose skill differs from the stored snapshot
+	watermark      *time.Time             // recorded on the run for the next incremental one
+}
+
+// A chart's fitting depends on its best records and on the skills of the
+// players who hold them, and a player's skill depends on the ratings of all
+// their best records. Both change only when a play record behind a best
+// record is created (new best) or updated (rating recalculated after an
+// official level change), which moves that play record's updated_at. An
+// incremental run therefore scans the best records whose play record is newer
+// than the previous run's watermark, recomputes the skills of their players
+// and recomputes:
+//
+//   - charts with such a best record newer than the chart's own watermark,
+//   - charts holding a best record of a player whose skill changed,
+//   - charts never computed, or whose official level changed since.
+//
+// Everything else keeps its published level. A full sweep is forced when
+// there is no clean previous run to build on or the last one is older than
+// RunnerConfig.FullSweepInterval; it also refreshes levels that drift without
+// new evidence, such as with sample-age decay.
+func (r *Runner) plan(ctx context.Context, runID int, forceFull bool) (sweepPlan, error) {
+	var plan sweepPlan
+	base, reason, err := r.incrementalBase(ctx, runID, forceFull)
+	if err != nil {
+		return plan, fmt.Errorf("find incremental base: %w", err)
+	}
+
+	stored, err := r.loadSkillSnapshot(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("load player skills: %w", err)
+	}
+	charts, err := r.fetchChartsSorted(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("fetch charts: %w", err)
+	}
+	plan.total = len(charts)
+
+	if base == nil {
+		slog.InfoContext(ctx, "fitting run is a full sweep", "reason", reason)
+		// Take the watermark first: evidence arriving during the run is newer.
+		if plan.watermark, err = r.latestEvidence(ctx); err != nil {
+			return plan, fmt.Errorf("fetch watermark: %w", err)
+		}
+		if plan.skills, err = r.collectPlayerSkills(ctx); err != nil {
+			return plan, fmt.Errorf("collect player skills: %w", err)
+		}
+		changed := changedSkills(stored, plan.skills)
+		plan.playersChanged = len(changed)
+		if err := r.saveSkillSnapshot(ctx, plan.skills, nil); err != nil {
+			return plan, fmt.Errorf("save player skills: %w", err)
+		}
+		plan.charts = charts
+		return plan, nil
+	}
+
+	// 1. New evidence since the previous run.
+	plan.incremental = true
+	evidence, watermark, err := r.evidenceSince(ctx, base.Watermark.Add(-watermarkOverlap))
+	if err != nil {
+		return plan, fmt.Errorf("fetch new evidence: %w", err)
+	}
+	if watermark.Before(*base.Watermark) {
+		watermark = *base.Watermark
+	}
+	plan.watermark = &watermark
+
+	// 2. Recompute the skills of the players behind it.
+	players := make([]string, 0, len(evidence.players))
+	for u := range evidence.players {
+		players = append(players, u)
+	}
+	slices.Sort(players)
+	recomputed, err := r.collectSkillsOf(ctx, players)
+	if err != nil {
+		return plan, fmt.Errorf("collect player skills: %w", err)
+	}
+	changed := changedSkills(stored, recomputed)
+	plan.playersChanged = len(changed)
+	plan.skills = stored
+	for u, skill := range recomputed {
+		plan.skills[u] = skill
+	}
+	// Save every recomputed skill, changed or not, so that its computed_at
+	// stays current.
+	if err := r.saveSkillSnapshot(ctx, recomputed, players); err != nil {
+		return plan, fmt.Errorf("save player skills: %w", err)
+	}
+
+	// 3. Pick the charts to recompute.
+	affected, err := r.chartsPlayedBy(ctx, changed)
+	if err != nil {
+		return plan, fmt.Errorf("fetch charts of changed players: %w", err)
+	}
+	computed, err := r.loadChartWatermarks(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("load chart watermarks: %w", err)
+	}
+	for _, c := range charts {
+		prev, ok := computed[c.ID]
+		newest, hasEvidence := evidence.charts[c.ID]
+		switch {
+		case !ok, prev.OfficialLevel != c.Level, affected[c.ID],
+			hasEvidence && (prev.SamplesWatermark == nil || newest.After(*prev.SamplesWatermark)):
+			plan.charts = append(plan.charts, c)
+		}
+	}
+	slog.InfoContext(ctx, "fitting run is incremental",
+		"base_run_id", base.ID,
+		"players_recomputed", len(recomputed),
+		"players_changed", plan.playersChanged,
+		"charts_recomputed", len(plan.charts),
+	)
+	return plan, nil
+}
+
+// incrementalBase returns the run an incremental run can build on, or nil
+// and the reason a full sweep is needed instead. Shadow runs never count.
+func (r *Runner) incrementalBase(ctx context.Context, runID int, forceFull bool) (*model.FittingRun, string, error) {
+	if forceFull {
+		return nil, "forced", nil
+	}
+	if r.cfg.FullSweepInterval <= 0 {
+		return nil, "incremental runs disabled", nil
+	}
+	_, hash, err := encodeParams(r.params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var prev model.FittingRun
+	err = r.db.WithContext(ctx).
+		Where("id < ? AND kind <> ?", runID, model.FittingRunKindShadow).
+		Order("id DESC").
+		First(&prev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "no previous run", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case prev.Kind != model.FittingRunKindRun:
+		return nil, "previous run was a " + prev.Kind, nil
+	case prev.Status != model.FittingRunStatusCompleted:
+		return nil, "previous run did not complete", nil
+	case prev.ErrorsEncountered > 0:
+		return nil, "previous run had errors", nil
+	case prev.Watermark == nil:
+		return nil, "previous run recorded no watermark", nil
+	case prev.ConfigHash != hash:
+		return nil, "params changed", nil
+	}
+
+	var lastFull model.FittingRun
+	err = r.db.WithContext(ctx).
+		Where("kind = ? AND status = ? AND incremental = ?", model.FittingRunKindRun, model.FittingRunStatusCompleted, false).
+		Order("id DESC").
+		First(&lastFull).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "no full sweep on record", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	if time.Since(lastFull.StartedAt) >= r.cfg.FullSweepInterval {
+		return nil, "full sweep due", nil
+	}
+	return &prev, "", nil
+}
+
+// evidence is the new evidence found by an incremental run: the players
+// behind it, and the newest updated_at per chart.
+type evidence struct {
+	players map[string]struct{}
+	charts  map[int]time.Time
+}
+
+// evidenceSince returns the best records whose play record was updated after
+// since, and the newest updated_at among them (zero when there is none).
+func (r *Runner) evidenceSince(ctx context.Context, since time.Time) (evidence, time.Time, error) {
+	type row struct {
+		Username  string
+		ChartID   int
+		UpdatedAt time.Time
+	}
+	var rows []row
+	if err := r.db.WithContext(ctx).
+		Table("best_play_records").
+		Select("best_play_records.username AS username, best_play_records.chart_id AS chart_id, play_records.updated_at AS updated_at").
+		Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
+		Where("play_records.updated_at > ?", since).
+		Where("best_play_records.deleted_at IS NULL").
+		Where("play_records.deleted_at IS NULL").
+		Scan(&rows).Error; err != nil {
+		return evidence{}, time.Time{}, err
+	}
+	ev := evidence{players: make(map[string]struct{}), charts: make(map[int]time.Time)}
+	var newest time.Time
+	for _, row := range rows {
+		ev.players[row.Username] = struct{}{}
+		if row.UpdatedAt.After(ev.charts[row.ChartID]) {
+			ev.charts[row.ChartID] = row.UpdatedAt
+		}
+		if row.UpdatedAt.After(newest) {
+			newest = row.UpdatedAt
+		}
+	}
+	return ev, newest, nil
+}
+
+// latestEvidence returns the newest updated_at among the play records behind
+// best records, or nil when there are none.
+func (r *Runner) latestEvidence(ctx context.Context) (*time.Time, error) {
+	var rows []struct{ UpdatedAt time.Time }
+	if err := r.db.WithContext(ctx).
+		Table("best_play_records").
+		Select("play_records.updated_at AS updated_at").
+		Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
+		Where("best_play_records.deleted_at IS NULL").
+		Where("play_records.deleted_at IS NULL").
+		Order("play_records.updated_at DESC").
+		Limit(1).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0].UpdatedAt, nil
+}
+
+// chartsPlayedBy returns the set of charts holding a best record of any of
+// the given players.
+func (r *Runner) chartsPlayedBy(ctx context.Context, usernames []string) (map[int]bool, error) {
+	out := make(map[int]bool)
+	batch := r.cfg.PlayerBatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	for page := range slices.Chunk(usernames, batch) {
+		var ids []int
+		if err := r.db.WithContext(ctx).
+			Model(&model.BestPlayRecord{}).
+			Where("username IN ?", page).
+			Distinct().
+			Pluck("chart_id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			out[id] = true
+		}
+	}
+	return out, nil
+}
+
+// chartWatermark is what an incremental run needs to know about the last
+// computation of a chart.
+type chartWatermark struct {
+	ChartID          int
+	OfficialLevel    float64
+	SamplesWatermark *time.Time
+}
+
+// loadChartWatermarks returns the chart_statistics watermark of every
+// computed chart.
+func (r *Runner) loadChartWatermarks(ctx context.Context) (map[int]chartWatermark, error) {
+	var rows []chartWatermark
+	if err := r.db.WithContext(ctx).
+		Model(&model.ChartStatistic{}).
+		Select("chart_id, official_level, samples_watermark").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]chartWatermark, len(rows))
+	for _, row := range rows {
+		out[row.ChartID] = row
+	}
+	return out, nil
+}
+
+// loadSkillSnapshot reads fitting_player_skills.
+func (r *Runner) loadSkillSnapshot(ctx context.Context) (map[string]PlayerSkill, error) {
+	var rows []model.FittingPlayerSkill
+	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]PlayerSkill, len(rows))
+	for _, row := range rows {
+		out[row.Username] = PlayerSkill{AvgRating: row.AvgRating, NumRecords: row.NumRecords}
+	}
+	return out, nil
+}
+
+// saveSkillSnapshot writes skills to fitting_player_skills. With a nil
+// usernames the table is replaced by skills; otherwise only the rows of the
+// listed players are rewritten, and those missing from skills dropped.
+func (r *Runner) saveSkillSnapshot(ctx context.Context, skills map[string]PlayerSkill, usernames []string) error {
+	if usernames != nil && len(usernames) == 0 {
+		return nil
+	}
+	now := time.Now()
+	topK := skillTopK(r.params)
+	rows := make([]model.FittingPlayerSkill, 0, len(skills))
+	add := func(u string, skill PlayerSkill) {
+		rows = append(rows, model.FittingPlayerSkill{
+			Username:   u,
+			AvgRating:  skill.AvgRating,
+			NumRecords: skill.NumRecords,
+			TopK:       min(topK, skill.NumRecords),
+			UpdatedAt:  now,
+		})
+	}
+	if usernames == nil {
+		for u, skill := range skills {
+			add(u, skill)
+		}
+	} else {
+		for _, u := range usernames {
+			if skill, ok := skills[u]; ok {
+				add(u, skill)
+			}
+		}
+	}
+	batch := r.cfg.PlayerBatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if usernames == nil {
+			if err := tx.Where("1 = 1").Delete(&model.FittingPlayerSkill{}).Error; err != nil {
+				return err
+			}
+		} else {
+			for page := range slices.Chunk(usernames, batch) {
+				if err := tx.Where("username IN ?", page).Delete(&model.FittingPlayerSkill{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, batch).Error
+	})
+}
+
+// changedSkills returns the players of recomputed whose skill differs from
+// the stored snapshot, sorted.
+func changedSkills(stored, recomputed map[string]PlayerSkill) []string {
+	changed := make([]string, 0)
+	for u, skill := range recomputed {
+		if prev, ok := stored[u]; !ok || prev != skill {
+			changed = append(changed, u)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,214 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/pkg/rating"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestRunner_IncrementalMatchesFull checks that incremental runs recompute
+// only the charts with new evidence, and leave every chart exactly where a
+// full sweep over the same data puts it.
+func TestRunner_IncrementalMatchesFull(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	song := model.Song{SongBase: model.SongBase{
+		WikiID: "incr_song", Title: "Incremental", Artist: "A", Genre: "G", Cover: "c",
+		Illustrator: "I", Version: "V", Album: "Al", BPM: "100", Length: "1:00",
+	}}
+	if err := db.Create(&song).Error; err != nil {
+		t.Fatalf("create song: %v", err)
+	}
+	newChart := func(d model.Difficulty, level float64) model.Chart {
+		c := model.Chart{SongID: song.ID, Difficulty: d, Level: level, Notes: 1000}
+		if err := db.Create(&c).Error; err != nil {
+			t.Fatalf("create chart: %v", err)
+		}
+		return c
+	}
+	a := newChart(model.DifficultyDetected, 15.0)
+	b := newChart(model.DifficultyInvaded, 15.5)
+	c := newChart(model.DifficultyMassive, 16.0)
+
+	// Group 1 plays A and B, group 2 plays B and C.
+	for i := 0; i < 8; i++ {
+		skill := 152.0 + float64(i)*0.75
+		u1, u2 := fmt.Sprintf("g1_%02d", i), fmt.Sprintf("g2_%02d", i)
+		seedUser(t, db, u1)
+		seedUser(t, db, u2)
+		seedBestRecord(t, db, u1, a.ID, simulateScore(14.6, skill), a.Level)
+		seedBestRecord(t, db, u1, b.ID, simulateScore(15.2, skill), b.Level)
+		seedBestRecord(t, db, u2, b.ID, simulateScore(15.3, skill+2), b.Level)
+		seedBestRecord(t, db, u2, c.ID, simulateScore(15.6, skill+2), c.Level)
+	}
+
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+	}
+	runner := NewRunner(db, params, RunnerConfig{ChartBatchSize: 2, PlayerBatchSize: 3, FullSweepInterval: time.Hour})
+
+	run := func(full bool) RunReport {
+		t.Helper()
+		var report RunReport
+		var err error
+		if full {
+			report, err = runner.RunFull(ctx)
+		} else {
+			report, err = runner.Run(ctx)
+		}
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return report
+	}
+	// state is everything a run publishes.
+	type chartState struct {
+		Level *float64
+		Stat  model.ChartStatistic
+	}
+	state := func() map[int]chartState {
+		t.Helper()
+		out := make(map[int]chartState)
+		for _, id := range []int{a.ID, b.ID, c.ID} {
+			var ch model.Chart
+			var st model.ChartStatistic
+			if err := db.First(&ch, id).Error; err != nil {
+				t.Fatalf("reload chart: %v", err)
+			}
+			if err := db.Where("chart_id = ?", id).First(&st).Error; err != nil {
+				t.Fatalf("reload stat: %v", err)
+			}
+			st.BaseModel, st.LastComputedAt, st.SamplesWatermark = model.BaseModel{}, time.Time{}, nil
+			out[id] = chartState{Level: ch.FittingLevel, Stat: st}
+		}
+		return out
+	}
+	// assertMatchesFull runs a full sweep and compares it with the current state.
+	assertMatchesFull := func(label string) {
+		t.Helper()
+		incremental := state()
+		report := run(true)
+		assert.False(t, report.Incremental, label)
+		full := state()
+		for id, want := range full {
+			got := incremental[id]
+			if assert.NotNil(t, got.Level, "%s: chart %d", label, id) && assert.NotNil(t, want.Level) {
+				assert.InDelta(t, *want.Level, *got.Level, 1e-9, "%s: chart %d", label, id)
+			}
+			assert.Equal(t, want.Stat, got.Stat, "%s: chart %d", label, id)
+		}
+	}
+
+	first := run(false)
+	assert.False(t, first.Incremental, "no previous run to build on")
+	assert.Equal(t, 3, first.ChartsProcessed)
+	assert.Equal(t, 16, first.PlayersChanged)
+	if assert.NotNil(t, first.Watermark) {
+		assert.False(t, first.Watermark.IsZero())
+	}
+
+	// Nothing new: nothing recomputed
+	idle := run(false)
+	assert.True(t, idle.Incremental)
+	assert.Equal(t, 0, idle.ChartsProcessed)
+	assert.Equal(t, 3, idle.ChartsSkipped)
+	assert.Equal(t, 0, idle.PlayersChanged)
+	assert.Equal(t, 16, idle.PlayersConsidered)
+
+	// A new best of a group-1 player changes A and, through their skill, B
+	var before, after model.FittingPlayerSkill
+	db.First(&before, "username = ?", "g1_03")
+	improveBest(t, db, "g1_03", a.ID, simulateScore(14.6, 158), a.Level)
+	report := run(false)
+	assert.True(t, report.Incremental)
+	assert.Equal(t, 1, report.PlayersChanged)
+	db.First(&after, "username = ?", "g1_03")
+	assert.Greater(t, after.AvgRating, before.AvgRating)
+	assert.True(t, after.UpdatedAt.After(before.UpdatedAt))
+	assert.Equal(t, 2, after.NumRecords)
+	assert.Equal(t, 2, after.TopK)
+	assert.Equal(t, 2, report.ChartsProcessed)
+	assert.Equal(t, 1, report.ChartsSkipped)
+	var snapshots []int
+	db.Model(&model.FittingRunChart{}).Where("run_id = ?", report.RunID).Order("chart_id").Pluck("chart_id", &snapshots)
+	assert.Equal(t, []int{a.ID, b.ID}, snapshots)
+	assertMatchesFull("new best")
+
+	// A new player on C only changes C
+	seedUser(t, db, "newcomer")
+	seedBestRecord(t, db, "newcomer", c.ID, simulateScore(15.6, 157), c.Level)
+	report = run(false)
+	assert.True(t, report.Incremental)
+	assert.Equal(t, 1, report.ChartsProcessed)
+	assertMatchesFull("new player")
+
+	// An official level change recalculates ratings, which moves the skills
+	// of every player of C and so B as well
+	if err := db.Model(&model.Chart{}).Where("id = ?", c.ID).Update("level", 15.8).Error; err != nil {
+		t.Fatalf("update level: %v", err)
+	}
+	var records []model.PlayRecord
+	db.Where("chart_id = ?", c.ID).Find(&records)
+	for _, pr := range records {
+		db.Model(&pr).Update("rating", rating.SingleRating(15.8, *pr.Score))
+	}
+	report = run(false)
+	assert.True(t, report.Incremental)
+	assert.Equal(t, 9, report.PlayersChanged)
+	assert.Equal(t, 2, report.ChartsProcessed)
+	assertMatchesFull("level change")
+
+	// New params force a full sweep
+	strong := params
+	strong.PriorStrength = 5
+	report, err := NewRunner(db, strong, RunnerConfig{ChartBatchSize: 2, PlayerBatchSize: 3, FullSweepInterval: time.Hour}).Run(ctx)
+	if err != nil {
+		t.Fatalf("run with new params: %v", err)
+	}
+	assert.False(t, report.Incremental)
+	assert.Equal(t, 3, report.ChartsProcessed)
+
+	// So does a rollback, and a due full sweep
+	if _, err := Rollback(ctx, db, first.RunID, false); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assert.False(t, run(false).Incremental, "after a rollback")
+	assert.True(t, run(false).Incremental)
+	db.Model(&model.FittingRun{}).Where("incremental = ?", false).Update("started_at", time.Now().Add(-2*time.Hour))
+	assert.False(t, run(false).Incremental, "full sweep due")
+}
+
+// improveBest records a new best score of a player on a chart, the way the
+// probe server does: a new play record the best record is pointed at.
+func improveBest(t *testing.T, db *gorm.DB, username string, chartID int, score int, level float64) {
+	t.Helper()
+	s := score
+	pr := model.PlayRecord{
+		PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &s},
+		Username:       username,
+		Rating:         rating.SingleRating(level, score),
+	}
+	if err := db.Create(&pr).Error; err != nil {
+		t.Fatalf("create play record: %v", err)
+	}
+	if err := db.Exec("UPDATE best_play_records SET play_record_id = ? WHERE username = ? AND chart_id = ?",
+		pr.ID, username, chartID).Error; err != nil {
+		t.Fatalf("update best record: %v", err)
+	}
+}
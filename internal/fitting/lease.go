@@ -0,0 +1,323 @@
+package fitting
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultLeaseName is the lease every `fitting run` instance competes for.
+const DefaultLeaseName = "fitting"
+
+var (
+	// ErrLeaseHeld is returned by Lease.Acquire when another instance holds
+	// the lease and LeaseConfig.Wait is off.
+	ErrLeaseHeld = errors.New("the fitting lease is held by another instance")
+	// ErrLeaseLost is the cause of a lease context cancelled because the
+	// lease could not be renewed.
+	ErrLeaseLost = errors.New("the fitting lease was lost")
+)
+
+// LeaseConfig tunes a Lease.
+type LeaseConfig struct {
+	Name   string        // lease name; default DefaultLeaseName
+	Holder string        // identifies this instance; default "<hostname>-<pid>"
+	TTL    time.Duration // the lease row expires this long after its last renewal; renewed and retried every TTL/3
+	Wait   bool          // Acquire waits for the lease instead of returning ErrLeaseHeld
+}
+
+// Lease is a database-backed lease that keeps a single fitting instance
+// publishing levels at a time, so two deployments (a rolling update, an
+// accidental scale-up) do not race on charts.fitting_level and
+// chart_statistics.
+//
+// On PostgreSQL it is a session-level advisory lock, held on a dedicated
+// connection: it is released by the server as soon as that session ends,
+// and renewing it means checking the connection is still alive. Other
+// databases (SQLite) use a row of fitting_leases whose expiry the holder
+// pushes forward; another instance may take it over once it has expired.
+type Lease struct {
+	cfg     LeaseConfig
+	backend leaseBackend
+
+	mu     sync.Mutex
+	held   bool
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// leaseBackend is the database side of a Lease.
+type leaseBackend interface {
+	tryAcquire(ctx context.Context) (bool, error)
+	// renew returns an error wrapping ErrLeaseLost when the lease is gone
+	// for sure; other errors are retried until the lease would expire.
+	renew(ctx context.Context) error
+	release(ctx context.Context) error
+}
+
+// NewLease constructs a lease on db; call Acquire to take it.
+func NewLease(db *gorm.DB, cfg LeaseConfig) *Lease {
+	if cfg.Name == "" {
+		cfg.Name = DefaultLeaseName
+	}
+	if cfg.Holder == "" {
+		host, _ := os.Hostname()
+		cfg.Holder = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+	var backend leaseBackend
+	if db.Dialector.Name() == "postgres" {
+		backend = &advisoryLease{db: db, key: leaseKey(cfg.Name)}
+	} else {
+		backend = &rowLease{db: db, name: cfg.Name, holder: cfg.Holder, ttl: cfg.TTL}
+	}
+	return &Lease{cfg: cfg, backend: backend}
+}
+
+// Holder identifies this instance in the lease.
+func (l *Lease) Holder() string { return l.cfg.Holder }
+
+// Held reports whether this instance holds the lease.
+func (l *Lease) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.held
+}
+
+// Acquire takes the lease, waiting for it with LeaseConfig.Wait (and
+// returning ErrLeaseHeld otherwise). The lease is then renewed in the
+// background until ctx is cancelled or Release is called, and released
+// either way. The returned context is cancelled with ErrLeaseLost as its
+// cause when a renewal fails, so that a run under it stops before another
+// instance takes over.
+func (l *Lease) Acquire(ctx context.Context) (context.Context, error) {
+	retry := l.cfg.TTL / 3
+	waiting := false
+	for {
+		attempted := time.Now()
+		ok, err := l.backend.tryAcquire(ctx)
+		if ctx.Err() != nil {
+			if ok {
+				// Taken just as ctx ended: nothing will renew or release it
+				// (on PostgreSQL its pinned connection would keep the lock).
+				l.release(ctx)
+			}
+			return nil, ctx.Err()
+		}
+		switch {
+		case err != nil && !l.cfg.Wait:
+			return nil, fmt.Errorf("acquire fitting lease: %w", err)
+		case err != nil:
+			slog.WarnContext(ctx, "failed to acquire fitting lease; retrying", "err", err)
+		case ok:
+			slog.InfoContext(ctx, "fitting lease acquired", "lease", l.cfg.Name, "holder", l.cfg.Holder)
+			return l.hold(ctx, attempted.Add(l.cfg.TTL)), nil
+		case !l.cfg.Wait:
+			return nil, ErrLeaseHeld
+		case !waiting:
+			slog.InfoContext(ctx, "fitting lease held by another instance; waiting", "lease", l.cfg.Name)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Release stops renewing the lease and releases it. It is a no-op when the
+// lease is not held.
+func (l *Lease) Release() {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel(context.Canceled)
+	<-done
+}
+
+// hold starts renewing an acquired lease that expires no earlier than expires.
+func (l *Lease) hold(ctx context.Context, expires time.Time) context.Context {
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	l.mu.Lock()
+	l.held, l.cancel, l.done = true, cancel, done
+	l.mu.Unlock()
+	go l.keep(leaseCtx, cancel, done, expires)
+	return leaseCtx
+}
+
+// keep renews the lease every TTL/3 until ctx is done or a renewal fails
+// for good, then releases it. expires is the earliest the lease can expire
+// on the database: a renewal (or acquisition) started at t secures it until
+// at least t+TTL. A failed renewal is retried only while the next attempt
+// still lands before that, so the run stops before another instance can
+// take the lease over.
+func (l *Lease) keep(ctx context.Context, cancel context.CancelCauseFunc, done chan struct{}, expires time.Time) {
+	defer close(done)
+	interval := l.cfg.TTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.release(ctx)
+			return
+		case <-ticker.C:
+			started := time.Now()
+			renewCtx, cancelRenew := context.WithDeadline(ctx, expires)
+			err := l.backend.renew(renewCtx)
+			cancelRenew()
+			if err == nil {
+				expires = started.Add(l.cfg.TTL)
+				continue
+			}
+			if ctx.Err() != nil {
+				continue // cancelled meanwhile; released on the next iteration
+			}
+			if !errors.Is(err, ErrLeaseLost) && time.Now().Add(interval).Before(expires) {
+				slog.WarnContext(ctx, "failed to renew fitting lease; retrying", "err", err)
+				continue
+			}
+			slog.ErrorContext(ctx, "fitting lease lost; stopping", "lease", l.cfg.Name, "err", err)
+			cancel(ErrLeaseLost)
+			l.release(ctx)
+			return
+		}
+	}
+}
+
+// release releases the lease on the database, with a context of its own
+// since ctx is usually cancelled by now.
+func (l *Lease) release(ctx context.Context) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := l.backend.release(releaseCtx); err != nil {
+		slog.WarnContext(ctx, "failed to release fitting lease", "err", err)
+	} else {
+		slog.InfoContext(ctx, "fitting lease released", "lease", l.cfg.Name)
+	}
+	l.mu.Lock()
+	l.held, l.cancel, l.done = false, nil, nil
+	l.mu.Unlock()
+}
+
+// leaseKey maps a lease name to a PostgreSQL advisory lock key.
+func leaseKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("fitting-lease:" + name))
+	return int64(h.Sum64())
+}
+
+// advisoryLease is a PostgreSQL session-level advisory lock.
+type advisoryLease struct {
+	db   *gorm.DB
+	key  int64
+	conn *sql.Conn // the session holding the lock
+}
+
+func (a *advisoryLease) tryAcquire(ctx context.Context) (bool, error) {
+	sqlDB, err := a.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", a.key).Scan(&ok); err != nil || !ok {
+		_ = conn.Close()
+		return false, err
+	}
+	a.conn = conn
+	return true, nil
+}
+
+func (a *advisoryLease) renew(ctx context.Context) error {
+	// The lock lives as long as the session: a dead connection has lost it.
+	if _, err := a.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+		return fmt.Errorf("%w: %w", ErrLeaseLost, err)
+	}
+	return nil
+}
+
+func (a *advisoryLease) release(ctx context.Context) error {
+	if a.conn == nil {
+		return nil
+	}
+	// Unlock explicitly: closing only returns the connection to the pool,
+	// where the session, and the lock with it, lives on.
+	_, err := a.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", a.key)
+	if closeErr := a.conn.Close(); err == nil {
+		err = closeErr
+	}
+	a.conn = nil
+	return err
+}
+
+// rowLease is a fitting_leases row with an expiry.
+type rowLease struct {
+	db     *gorm.DB
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+func (r *rowLease) tryAcquire(ctx context.Context) (bool, error) {
+	acquired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		lease := model.FittingLease{Name: r.name, Holder: r.holder, AcquiredAt: now, ExpiresAt: now.Add(r.ttl)}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			acquired = true
+			return nil
+		}
+		// Take the row over when it has expired (or is already ours).
+		res = tx.Model(&model.FittingLease{}).
+			Where("name = ? AND (holder = ? OR expires_at < ?)", r.name, r.holder, now).
+			Updates(map[string]any{"holder": r.holder, "acquired_at": now, "expires_at": now.Add(r.ttl)})
+		acquired = res.RowsAffected == 1
+		return res.Error
+	})
+	return acquired, err
+}
+
+func (r *rowLease) renew(ctx context.Context) error {
+	res := r.db.WithContext(ctx).Model(&model.FittingLease{}).
+		Where("name = ? AND holder = ?", r.name, r.holder).
+		Update("expires_at", time.Now().UTC().Add(r.ttl))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: taken over by another instance", ErrLeaseLost)
+	}
+	return nil
+}
+
+func (r *rowLease) release(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("name = ? AND holder = ?", r.name, r.holder).
+		Delete(&model.FittingLease{}).Error
+}
@@ -0,0 +1,172 @@
+package fitting
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease_SingleHolder(t *testing.T) {
+	db := setupTestDB(t)
+	ttl := 300 * time.Millisecond
+	a := NewLease(db, LeaseConfig{Holder: "a", TTL: ttl})
+	b := NewLease(db, LeaseConfig{Holder: "b", TTL: ttl})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	aCtx, err := a.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, a.Held())
+
+	// Renewals keep the lease well past its TTL.
+	time.Sleep(2 * ttl)
+	_, err = b.Acquire(ctx)
+	assert.ErrorIs(t, err, ErrLeaseHeld)
+	assert.False(t, b.Held())
+	assert.NoError(t, aCtx.Err())
+
+	// A waiting instance takes over once the holder is cancelled.
+	b.cfg.Wait = true
+	acquired := make(chan error, 1)
+	go func() {
+		_, err := b.Acquire(ctx)
+		acquired <- err
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("b acquired the lease while a held it")
+	case <-time.After(ttl):
+	}
+	cancel()
+	require.Eventually(t, func() bool { return !a.Held() }, 5*time.Second, 10*time.Millisecond)
+	var n int64
+	db.Model(&model.FittingLease{}).Where("holder = ?", "a").Count(&n)
+	assert.Zero(t, n, "released on cancellation")
+	assert.ErrorIs(t, <-acquired, context.Canceled, "b waited on the same, now cancelled, context")
+
+	bCtx, err := b.Acquire(context.Background())
+	require.NoError(t, err)
+	defer b.Release()
+	assert.NoError(t, bCtx.Err())
+	var lease model.FittingLease
+	require.NoError(t, db.First(&lease, "name = ?", DefaultLeaseName).Error)
+	assert.Equal(t, "b", lease.Holder)
+}
+
+func TestLease_Lost(t *testing.T) {
+	db := setupTestDB(t)
+	ttl := 300 * time.Millisecond
+	a := NewLease(db, LeaseConfig{Holder: "a", TTL: ttl})
+	aCtx, err := a.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// Another instance took the row over (say a paused a for longer than the TTL).
+	require.NoError(t, db.Model(&model.FittingLease{}).Where("name = ?", DefaultLeaseName).
+		Update("holder", "b").Error)
+	select {
+	case <-aCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("lease context not cancelled after the lease was lost")
+	}
+	assert.ErrorIs(t, context.Cause(aCtx), ErrLeaseLost)
+	require.Eventually(t, func() bool { return !a.Held() }, 5*time.Second, 10*time.Millisecond)
+
+	// Losing the lease must not release the new holder's row.
+	var lease model.FittingLease
+	require.NoError(t, db.First(&lease, "name = ?", DefaultLeaseName).Error)
+	assert.Equal(t, "b", lease.Holder)
+
+	// An expired lease is taken over.
+	require.NoError(t, db.Model(&model.FittingLease{}).Where("name = ?", DefaultLeaseName).
+		Update("expires_at", time.Now().UTC().Add(-time.Second)).Error)
+	_, err = a.Acquire(context.Background())
+	require.NoError(t, err)
+	a.Release()
+	assert.False(t, a.Held())
+}
+
+// fakeLease is a leaseBackend whose renewals fail a given number of times.
+type fakeLease struct {
+	acquire       func() // called on every tryAcquire
+	renewFailures atomic.Int32
+	renewals      atomic.Int32
+	released      atomic.Bool
+}
+
+func (f *fakeLease) tryAcquire(context.Context) (bool, error) {
+	if f.acquire != nil {
+		f.acquire()
+	}
+	return true, nil
+}
+
+func (f *fakeLease) renew(context.Context) error {
+	f.renewals.Add(1)
+	if f.renewFailures.Add(-1) >= 0 {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (f *fakeLease) release(context.Context) error {
+	f.released.Store(true)
+	return nil
+}
+
+func TestLease_RenewFailures(t *testing.T) {
+	ttl := 600 * time.Millisecond
+
+	t.Run("A failed renewal is retried", func(t *testing.T) {
+		backend := &fakeLease{}
+		backend.renewFailures.Store(1)
+		l := &Lease{cfg: LeaseConfig{Name: DefaultLeaseName, Holder: "a", TTL: ttl}, backend: backend}
+		leaseCtx, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		defer l.Release()
+
+		require.Eventually(t, func() bool { return backend.renewals.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, leaseCtx.Err())
+		assert.True(t, l.Held())
+	})
+
+	t.Run("The lease is given up before it can expire", func(t *testing.T) {
+		backend := &fakeLease{}
+		backend.renewFailures.Store(1 << 20)
+		l := &Lease{cfg: LeaseConfig{Name: DefaultLeaseName, Holder: "a", TTL: ttl}, backend: backend}
+		acquired := time.Now()
+		leaseCtx, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+
+		select {
+		case <-leaseCtx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("lease context not cancelled while renewals kept failing")
+		}
+		// The row written on acquisition expires TTL later; the holder must
+		// stop before a standby could take it.
+		assert.Less(t, time.Since(acquired), ttl)
+		assert.ErrorIs(t, context.Cause(leaseCtx), ErrLeaseLost)
+		require.Eventually(t, func() bool { return !l.Held() }, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, backend.released.Load())
+	})
+}
+
+func TestLease_AcquireCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The lease is taken just as the context is cancelled.
+	backend := &fakeLease{acquire: cancel}
+	l := &Lease{cfg: LeaseConfig{Name: DefaultLeaseName, Holder: "a", TTL: time.Minute}, backend: backend}
+
+	_, err := l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, l.Held())
+	assert.True(t, backend.released.Load(), "a lease acquired after cancellation is released")
+}
@@ -0,0 +1,139 @@
+package fitting
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sort"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PlayerOutlier is how far a player's samples sit from the fitted model
+// across charts. The Tukey step down-weights a single far sample on its
+// own; a player whose samples are far on most of their charts, above or
+// below what their skill predicts, is worth a look by an admin (cheating,
+// shared accounts). The report ranks them by MedianAbsResidual, so that a
+// few odd charts do not put a player on it.
+type PlayerOutlier struct {
+	Username          string
+	Charts            int     // charts with a residual (kept or trimmed samples)
+	Trimmed           int     // charts where the sample was trimmed, |u| ≥ 1
+	MedianAbsResidual float64 // median |u| over those charts
+	MeanResidual      float64 // mean u; < 0 scores above the skill, > 0 below
+}
+
+// outlierTally collects the residuals of every player across the charts of a
+// run.
+type outlierTally map[string][]float64
+
+// add records the residuals of a chart's samples.
+func (t outlierTally) add(weights []SampleWeights) {
+	for _, w := range weights {
+		if w.Status == SampleKept || w.Status == SampleTrimmed {
+			t[w.Username] = append(t[w.Username], w.Residual)
+		}
+	}
+}
+
+// report ranks the players with residuals on at least minCharts charts,
+// most consistent outlier first, and keeps the first size of them.
+func (t outlierTally) report(minCharts, size int) []PlayerOutlier {
+	var out []PlayerOutlier
+	for u, residuals := range t {
+		if len(residuals) < max(minCharts, 1) {
+			continue
+		}
+		o := PlayerOutlier{Username: u, Charts: len(residuals)}
+		abs := make([]float64, len(residuals))
+		sum := 0.0
+		for i, r := range residuals {
+			abs[i] = math.Abs(r)
+			sum += r
+			if abs[i] >= 1 {
+				o.Trimmed++
+			}
+		}
+		sort.Float64s(abs)
+		if n := len(abs); n%2 == 1 {
+			o.MedianAbsResidual = abs[n/2]
+		} else {
+			o.MedianAbsResidual = (abs[n/2-1] + abs[n/2]) / 2
+		}
+		o.MeanResidual = sum / float64(len(residuals))
+		out = append(out, o)
+	}
+	slices.SortFunc(out, func(a, b PlayerOutlier) int {
+		if c := cmp.Compare(b.MedianAbsResidual, a.MedianAbsResidual); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Trimmed, a.Trimmed); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Username, b.Username)
+	})
+	return out[:min(size, len(out))]
+}
+
+// saveOutliers ranks the tally and writes the report of run runID.
+func (r *Runner) saveOutliers(ctx context.Context, runID int, tally outlierTally) (int, error) {
+	report := tally.report(r.cfg.OutlierMinCharts, r.cfg.OutlierReportSize)
+	if len(report) == 0 {
+		return 0, nil
+	}
+	rows := make([]model.FittingRunOutlier, len(report))
+	for i, o := range report {
+		rows[i] = model.FittingRunOutlier{
+			RunID:             runID,
+			Rank:              i + 1,
+			Username:          o.Username,
+			Charts:            o.Charts,
+			Trimmed:           o.Trimmed,
+			MedianAbsResidual: o.MedianAbsResidual,
+			MeanResidual:      o.MeanResidual,
+		}
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(rows, 500).Error; err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// RunOutliers returns the outlier report of run runID, best ranked first;
+// with runID 0, that of the latest run with one. run is nil when there is
+// no such report.
+func RunOutliers(ctx context.Context, db *gorm.DB, runID, limit int) (*model.FittingRun, []model.FittingRunOutlier, error) {
+	if runID == 0 {
+		var ids []int
+		if err := db.WithContext(ctx).Model(&model.FittingRunOutlier{}).
+			Order("run_id DESC").Limit(1).Pluck("run_id", &ids).Error; err != nil {
+			return nil, nil, err
+		}
+		if len(ids) == 0 {
+			return nil, nil, nil
+		}
+		runID = ids[0]
+	}
+	var run model.FittingRun
+	if err := db.WithContext(ctx).First(&run, runID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRunNotFound
+		}
+		return nil, nil, err
+	}
+	var rows []model.FittingRunOutlier
+	err := db.WithContext(ctx).Where("run_id = ?", runID).Order("rank").Limit(limit).Find(&rows).Error
+	return &run, rows, err
+}
+
+// PlayerOutlierHistory returns a player's entries in the outlier reports
+// of the retained runs, newest first.
+func PlayerOutlierHistory(ctx context.Context, db *gorm.DB, username string, limit int) ([]model.FittingRunOutlier, error) {
+	var rows []model.FittingRunOutlier
+	err := db.WithContext(ctx).Where("username = ?", username).Order("run_id DESC").Limit(limit).Find(&rows).Error
+	return rows, err
+}
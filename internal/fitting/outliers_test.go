@@ -0,0 +1,110 @@
+package fitting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlierTally_Report(t *testing.T) {
+	tally := make(outlierTally)
+	add := func(username string, residuals ...float64) {
+		for _, u := range residuals {
+			status := SampleKept
+			if u <= -1 || u >= 1 {
+				status = SampleTrimmed
+			}
+			tally.add([]SampleWeights{{Sample: Sample{Username: username}, Status: status, Residual: u}})
+		}
+	}
+	add("steady", 0.1, -0.2, 0.15, 0.3)
+	add("shared", 1.2, -0.9, 1.5, -1.1)
+	add("cheater", -0.6, -0.7, -1.3, -0.5)
+	add("one_bad_chart", 0.1, 0.1, 0.0, 3.0)
+	add("few", 2.0, 2.0)
+	tally.add([]SampleWeights{{Sample: Sample{Username: "dropped"}, Status: SampleOutOfRange}})
+
+	report := tally.report(3, 10)
+	require.Len(t, report, 4)
+	assert.Equal(t, []string{"shared", "cheater", "steady", "one_bad_chart"},
+		[]string{report[0].Username, report[1].Username, report[2].Username, report[3].Username})
+	assert.Equal(t, PlayerOutlier{Username: "shared", Charts: 4, Trimmed: 3, MedianAbsResidual: 1.15, MeanResidual: 0.175}, roundOutlier(report[0]))
+	assert.Less(t, report[1].MeanResidual, 0.0, "scores above the skill")
+
+	assert.Len(t, tally.report(3, 2), 2)
+	assert.Len(t, tally.report(2, 10), 5)
+}
+
+func roundOutlier(o PlayerOutlier) PlayerOutlier {
+	round := func(v float64) float64 { return float64(int(v*1000+0.5)) / 1000 }
+	o.MedianAbsResidual, o.MeanResidual = round(o.MedianAbsResidual), round(o.MeanResidual)
+	return o
+}
+
+// A shared account, strong on some charts and weak on others, sits far from
+// the model on most of its charts and tops the report of full sweeps.
+func TestRunner_OutlierReport(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	charts, trueLevels := seedMisratedCharts(t, db)
+	seedUser(t, db, "shared")
+	for i, c := range charts {
+		skill := 162.0
+		if i%2 == 1 {
+			skill = 146
+		}
+		seedBestRecord(t, db, "shared", c.ID, simulateScore(trueLevels[c.ID], skill), c.Level)
+	}
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+	}
+	cfg := RunnerConfig{FullSweepInterval: time.Hour, OutlierReportSize: 5, OutlierMinCharts: 4, KeepRuns: 2}
+
+	for _, estimator := range []string{EstimatorJoint, EstimatorRobust} {
+		params.Estimator = estimator
+		report, err := NewRunner(db, params, cfg).RunFull(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.OutliersReported, estimator)
+
+		run, rows, err := RunOutliers(ctx, db, 0, 10)
+		require.NoError(t, err)
+		require.NotNil(t, run)
+		assert.Equal(t, report.RunID, run.ID, estimator)
+		assert.Equal(t, 5, run.OutliersReported)
+		require.Len(t, rows, 5)
+		assert.Equal(t, "shared", rows[0].Username, estimator)
+		assert.Equal(t, 1, rows[0].Rank)
+		assert.Equal(t, 5, rows[0].Charts)
+		for i := 1; i < len(rows); i++ {
+			assert.GreaterOrEqual(t, rows[i-1].MedianAbsResidual, rows[i].MedianAbsResidual)
+		}
+	}
+
+	// Incremental runs see only some charts and write no report.
+	report, err := NewRunner(db, params, cfg).Run(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Incremental)
+	assert.Zero(t, report.OutliersReported)
+	_, rows, err := RunOutliers(ctx, db, report.RunID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	// Reports are pruned with their runs.
+	history, err := PlayerOutlierHistory(ctx, db, "shared", 10)
+	require.NoError(t, err)
+	assert.Len(t, history, 1, "only the last full run is among the 2 kept")
+
+	_, _, err = RunOutliers(ctx, db, 9999, 10)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+}
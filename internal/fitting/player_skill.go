@@ -3,6 +3,7 @@ package fitting
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 
@@ -55,68 +56,10 @@ func (r *Runner) collectPlayerSkills(ctx context.Context) (map[string]PlayerSkil
 			break
 		}
 
-		// 2. Fetch (username, rating) for this page of users, sorted so we can
-		//    group them in a single linear pass.
-		type ratingRow struct {
-			Username string
-			Rating   int
-		}
-		var rows []ratingRow
-		if err := r.db.WithContext(ctx).
-			Table("play_records").
-			Select("play_records.username AS username, play_records.rating AS rating").
-			Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
-			Where("play_records.username IN ?", usernames).
-			Where("play_records.deleted_at IS NULL").
-			Where("best_play_records.deleted_at IS NULL").
-			Order("play_records.username ASC, play_records.rating DESC").
-			Scan(&rows).Error; err != nil {
-			return nil, fmt.Errorf("fetch ratings batch: %w", err)
-		}
-
-		// 3. Linear group-by-user and accumulate skill.
-		topK := r.params.SkillTopK
-		if topK < 1 {
-			topK = 50 // defensive fallback; config validation should keep us out of here
-		}
-		curUser := ""
-		topRatings := make([]int, 0, topK)
-		totalCount := 0
-		flush := func() {
-			if curUser == "" {
-				return
-			}
-			k := topRatings
-			if len(k) > topK {
-				k = k[:topK]
-			}
-			sum := 0
-			for _, v := range k {
-				sum += v
-			}
-			avg := 0.0
-			if len(k) > 0 {
-				avg = float64(sum) / float64(len(k)) / 100.0
-			}
-			skills[curUser] = PlayerSkill{
-				AvgRating:  avg,
-				NumRecords: totalCount,
-			}
-		}
-		for _, row := range rows {
-			if row.Username != curUser {
-				flush()
-				curUser = row.Username
-				topRatings = topRatings[:0]
-				totalCount = 0
-			}
-			totalCount++
-			// topRatings keeps only the top-K (rows are already sorted DESC by rating).
-			if len(topRatings) < topK {
-				topRatings = append(topRatings, row.Rating)
-			}
+		// 2. Compute the skills of this page of users.
+		if err := r.fillPlayerSkills(ctx, usernames, skills); err != nil {
+			return nil, err
 		}
-		flush()
 
 		lastUsername = usernames[len(usernames)-1]
 
@@ -132,14 +75,100 @@ func (r *Runner) collectPlayerSkills(ctx context.Context) (map[string]PlayerSkil
 	return skills, nil
 }
 
-// fetchChartsSorted returns [id, level] for every non-deleted chart, sorted by
+// fillPlayerSkills computes the skills of the given users into skills.
+func (r *Runner) fillPlayerSkills(ctx context.Context, usernames []string, skills map[string]PlayerSkill) error {
+	// 1. Fetch (username, rating) for these users, sorted so we can group
+	//    them in a single linear pass.
+	type ratingRow struct {
+		Username string
+		Rating   int
+	}
+	var rows []ratingRow
+	if err := r.db.WithContext(ctx).
+		Table("play_records").
+		Select("play_records.username AS username, play_records.rating AS rating").
+		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
+		Where("play_records.username IN ?", usernames).
+		Where("play_records.deleted_at IS NULL").
+		Where("best_play_records.deleted_at IS NULL").
+		Order("play_records.username ASC, play_records.rating DESC").
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("fetch ratings batch: %w", err)
+	}
+
+	// 2. Linear group-by-user and accumulate skill.
+	topK := r.params.SkillTopK
+	if topK < 1 {
+		topK = 50 // defensive fallback; config validation should keep us out of here
+	}
+	curUser := ""
+	topRatings := make([]int, 0, topK)
+	totalCount := 0
+	flush := func() {
+		if curUser == "" {
+			return
+		}
+		k := topRatings
+		if len(k) > topK {
+			k = k[:topK]
+		}
+		sum := 0
+		for _, v := range k {
+			sum += v
+		}
+		avg := 0.0
+		if len(k) > 0 {
+			avg = float64(sum) / float64(len(k)) / 100.0
+		}
+		skills[curUser] = PlayerSkill{
+			AvgRating:  avg,
+			NumRecords: totalCount,
+		}
+	}
+	for _, row := range rows {
+		if row.Username != curUser {
+			flush()
+			curUser = row.Username
+			topRatings = topRatings[:0]
+			totalCount = 0
+		}
+		totalCount++
+		// topRatings keeps only the top-K (rows are already sorted DESC by rating).
+		if len(topRatings) < topK {
+			topRatings = append(topRatings, row.Rating)
+		}
+	}
+	flush()
+	return nil
+}
+
+// collectSkillsOf computes the skills of the given users only, in pages of
+// PlayerBatchSize. Users without best records are absent from the result.
+func (r *Runner) collectSkillsOf(ctx context.Context, usernames []string) (map[string]PlayerSkill, error) {
+	skills := make(map[string]PlayerSkill, len(usernames))
+	batch := r.cfg.PlayerBatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	for page := range slices.Chunk(usernames, batch) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := r.fillPlayerSkills(ctx, page, skills); err != nil {
+			return nil, err
+		}
+	}
+	return skills, nil
+}
+
+// fetchChartsSorted returns [id, level, fitting_level] for every non-deleted chart, sorted by
 // id. We order by id so pagination by chart id gives stable, deterministic
 // batches even if the chart table grows between runs.
 func (r *Runner) fetchChartsSorted(ctx context.Context) ([]chartRow, error) {
 	var rows []chartRow
 	if err := r.db.WithContext(ctx).
 		Model(&model.Chart{}).
-		Select("id, level").
+		Select("id, level, fitting_level").
 		Order("id ASC").
 		Scan(&rows).Error; err != nil {
 		return nil, err
@@ -151,41 +180,51 @@ func (r *Runner) fetchChartsSorted(ctx context.Context) ([]chartRow, error) {
 }
 
 type chartRow struct {
-	ID    int
-	Level float64
+	ID           int
+	Level        float64
+	FittingLevel *float64 // published before this run
 }
 
 // fetchBestSamples pulls (username, score, record_time) for every best record on the
 // given charts, joined in memory with the player-skill map. RecordTime is
 // translated into Sample.AgeDays (days since now) so the calculator can
 // apply the optional sample-age decay weight.
+//
+// It also returns the watermark of each chart that has best records: the
+// newest updated_at among the play records behind them, whether or not they
+// passed the sample filters.
 func (r *Runner) fetchBestSamples(
 	ctx context.Context,
 	chartIDs []int,
 	skills map[string]PlayerSkill,
-) (map[int][]Sample, error) {
+) (map[int][]Sample, map[int]time.Time, error) {
 	type row struct {
 		ChartID    int
 		Username   string
 		Score      int
 		RecordTime time.Time
+		UpdatedAt  time.Time
 	}
 	var rows []row
 	if err := r.db.WithContext(ctx).
 		Table("best_play_records").
-		Select("best_play_records.chart_id AS chart_id, best_play_records.username AS username, play_records.score AS score, play_records.record_time AS record_time").
+		Select("best_play_records.chart_id AS chart_id, best_play_records.username AS username, play_records.score AS score, play_records.record_time AS record_time, play_records.updated_at AS updated_at").
 		Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
 		Where("best_play_records.chart_id IN ?", chartIDs).
 		Where("best_play_records.deleted_at IS NULL").
 		Where("play_records.deleted_at IS NULL").
 		Scan(&rows).Error; err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	now := r.now()
 	minRecords := r.params.MinPlayerRecords
 	out := make(map[int][]Sample, len(chartIDs))
+	watermarks := make(map[int]time.Time, len(chartIDs))
 	for _, row := range rows {
+		if row.UpdatedAt.After(watermarks[row.ChartID]) {
+			watermarks[row.ChartID] = row.UpdatedAt
+		}
 		skill, ok := skills[row.Username]
 		if !ok {
 			continue // player has no skill snapshot (e.g. zero records — impossible here)
@@ -209,5 +248,5 @@ func (r *Runner) fetchBestSamples(
 			AgeDays:       ageDays,
 		})
 	}
-	return out, nil
+	return out, watermarks, nil
 }
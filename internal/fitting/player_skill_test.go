@@ -261,7 +261,7 @@ func TestFetchBestSamples_FilterByMinRecords(t *testing.T) {
 		"veteran": {AvgRating: 165.0, NumRecords: 50}, // passes
 		"rookie":  {AvgRating: 165.0, NumRecords: 2},  // filtered out
 	}
-	got, err := r.fetchBestSamples(context.Background(), []int{chartID}, skills)
+	got, _, err := r.fetchBestSamples(context.Background(), []int{chartID}, skills)
 	assert.NoError(t, err)
 	// Only the veteran's sample should survive.
 	if assert.Len(t, got[chartID], 1) {
@@ -281,7 +281,7 @@ func TestFetchBestSamples_SkillMissing(t *testing.T) {
 
 	r := newTestRunner(db, RunnerConfig{ChartBatchSize: 10})
 	// Intentionally empty skills map → every row's Username miss lookup.
-	got, err := r.fetchBestSamples(context.Background(), []int{chartID}, map[string]PlayerSkill{})
+	got, _, err := r.fetchBestSamples(context.Background(), []int{chartID}, map[string]PlayerSkill{})
 	assert.NoError(t, err)
 	assert.Empty(t, got[chartID], "samples whose player lacks a skill snapshot get dropped")
 }
@@ -316,7 +316,7 @@ func TestFetchBestSamples_AgeDaysPopulated(t *testing.T) {
 		"year":  {AvgRating: 165.0, NumRecords: 50},
 		"zero":  {AvgRating: 165.0, NumRecords: 50},
 	}
-	got, err := r.fetchBestSamples(context.Background(), charts, skills)
+	got, _, err := r.fetchBestSamples(context.Background(), charts, skills)
 	assert.NoError(t, err)
 
 	byUser := map[string]float64{}
@@ -2,11 +2,17 @@ package fitting
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"paradigm-reboot-prober-go/internal/metrics"
 	"paradigm-reboot-prober-go/internal/model"
 
 	"gorm.io/gorm"
@@ -18,16 +24,35 @@ type RunnerConfig struct {
 	ChartBatchSize  int
 	PlayerBatchSize int
 	BatchPause      time.Duration
+	// KeepRuns is how many of the most recent fitting_runs, with their chart
+	// snapshots, are kept; older ones are pruned after each run. 0 keeps all.
+	KeepRuns int
+	// FullSweepInterval is how often a run recomputes every chart; the runs
+	// in between are incremental (see plan). 0 makes every run a full sweep.
+	FullSweepInterval time.Duration
+	// OutlierReportSize is how many players the outlier report of a full
+	// sweep keeps (see PlayerOutlier); 0 disables the report.
+	// OutlierMinCharts is how many charts with a residual a player needs to
+	// be ranked.
+	OutlierReportSize int
+	OutlierMinCharts  int
 }
 
 // Runner orchestrates a single offline fitting pass across the entire charts
 // table. Exposed as a reusable type so that the cmd/fitting binary can use
 // it from both a ticker loop and a one-shot execution (`--once`).
 type Runner struct {
-	db      *gorm.DB
-	params  Params
-	cfg     RunnerConfig
-	nowFunc func() time.Time // injectable "now" for testing sample-age decay; defaults to time.Now
+	db       *gorm.DB
+	params   Params
+	cfg      RunnerConfig
+	nowFunc  func() time.Time // injectable "now" for testing sample-age decay; defaults to time.Now
+	progress runProgress
+}
+
+// runProgress counts the charts of the pass in progress, for Progress.
+type runProgress struct {
+	processed atomic.Int64
+	total     atomic.Int64
 }
 
 // NewRunner constructs a runner. `db` is expected to already have the shared
@@ -51,17 +76,30 @@ func (r *Runner) now() time.Time {
 	return time.Now()
 }
 
+// Progress returns how many charts the pass in progress (or the last one)
+// has computed, out of how many it will compute. total is 0 until the pass
+// knows which charts it recomputes.
+func (r *Runner) Progress() (processed, total int) {
+	return int(r.progress.processed.Load()), int(r.progress.total.Load())
+}
+
 // RunReport summarizes one execution, useful for logging and tests.
 type RunReport struct {
+	RunID             int // fitting_runs.id of this run
 	Started           time.Time
 	Completed         time.Time
 	Duration          time.Duration
+	Incremental       bool       // only charts with new evidence were recomputed
+	Watermark         *time.Time // newest evidence the run has seen
 	PlayersConsidered int
+	PlayersChanged    int // players whose skill changed since the previous run
 	ChartsTotal       int
 	ChartsProcessed   int
 	ChartsPublished   int // FittingLevel persisted to charts.fitting_level
 	ChartsAbstained   int // insufficient samples → nil fitting
 	ChartsEmpty       int // no samples at all
+	ChartsSkipped     int // left alone by an incremental run
+	OutliersReported  int // players in the outlier report (full sweeps only)
 	ErrorsEncountered int
 }
 
@@ -70,12 +108,30 @@ type RunReport struct {
 // cancellation aborts promptly; partial progress stays persisted (updates
 // are committed per-chart, not per-batch).
 //
+// Unless a full sweep is due, the pass is incremental: only the players and
+// charts with new evidence since the previous run are recomputed (see plan).
+// Estimators that are not PerChart always sweep every chart.
+func (r *Runner) Run(ctx context.Context) (RunReport, error) {
+	return r.run(ctx, false)
+}
+
+// RunFull is Run with a full sweep forced.
+func (r *Runner) RunFull(ctx context.Context) (RunReport, error) {
+	return r.run(ctx, true)
+}
+
+// run implements Run and RunFull.
+//
 // Named returns so the deferred finalizer can inspect err and emit a
 // per-outcome log line (errors → ERROR, otherwise INFO), plus unconditionally
 // stamp report.Completed / report.Duration regardless of exit path.
-func (r *Runner) Run(ctx context.Context) (report RunReport, err error) {
+func (r *Runner) run(ctx context.Context, forceFull bool) (report RunReport, err error) {
 	report.Started = time.Now()
+	r.progress.processed.Store(0)
+	r.progress.total.Store(0)
+	metrics.SetFittingRunInProgress(true)
 	slog.InfoContext(ctx, "fitting run starting",
+		"estimator", r.params.Estimator,
 		"chart_batch_size", r.cfg.ChartBatchSize,
 		"player_batch_size", r.cfg.PlayerBatchSize,
 		"batch_pause_ms", r.cfg.BatchPause.Milliseconds(),
@@ -85,40 +141,169 @@ func (r *Runner) Run(ctx context.Context) (report RunReport, err error) {
 		report.Duration = report.Completed.Sub(report.Started)
 		attrs := []any{
 			"duration_ms", report.Duration.Milliseconds(),
+			"incremental", report.Incremental,
 			"players_considered", report.PlayersConsidered,
+			"players_changed", report.PlayersChanged,
 			"charts_total", report.ChartsTotal,
 			"charts_processed", report.ChartsProcessed,
 			"charts_published", report.ChartsPublished,
 			"charts_abstained", report.ChartsAbstained,
 			"charts_empty", report.ChartsEmpty,
+			"charts_skipped", report.ChartsSkipped,
+			"outliers_reported", report.OutliersReported,
 			"errors", report.ErrorsEncountered,
 		}
+		outcome := metrics.FittingRunSucceeded
 		if err != nil {
+			outcome = metrics.FittingRunFailed
+			if errors.Is(err, context.Canceled) {
+				outcome = metrics.FittingRunCancelled
+			}
 			slog.ErrorContext(ctx, "fitting run failed", append(attrs, "err", err)...)
 		} else {
 			slog.InfoContext(ctx, "fitting run completed", attrs...)
 		}
+		metrics.SetFittingRunInProgress(false)
+		metrics.ObserveFittingRun(metrics.FittingRun{
+			Outcome:     outcome,
+			Incremental: report.Incremental,
+			Duration:    report.Duration,
+			Completed:   report.Completed,
+			Published:   report.ChartsPublished,
+			Abstained:   report.ChartsAbstained,
+			Empty:       report.ChartsEmpty,
+			Skipped:     report.ChartsSkipped,
+		})
+		if report.RunID != 0 {
+			// Record the outcome even when ctx was cancelled.
+			if ferr := r.finishRun(context.WithoutCancel(ctx), report, err); ferr != nil {
+				slog.ErrorContext(ctx, "record fitting run outcome failed", "run_id", report.RunID, "err", ferr)
+			}
+		}
 	}()
 
-	// 1. Player-skill snapshot (single pass over best_play_records).
-	skills, err := r.collectPlayerSkills(ctx)
+	// 0. Record the run so its chart snapshots can refer to it.
+	if report.RunID, err = r.startRun(ctx, model.FittingRunKindRun, report.Started); err != nil {
+		return report, fmt.Errorf("record fitting run: %w", err)
+	}
+
+	// 1–3. Compute the charts and persist them.
+	est, err := EstimatorByName(r.params.Estimator)
+	if err != nil {
+		return report, err
+	}
+	persist := func(ctx context.Context, c chartRow, res Result, watermark *time.Time) error {
+		return r.persist(ctx, report.RunID, c, res, watermark)
+	}
+	if !est.PerChart() {
+		// Every chart depends on every record: always a full sweep.
+		if report.Watermark, err = r.latestEvidence(ctx); err != nil {
+			return report, fmt.Errorf("fetch watermark: %w", err)
+		}
+		// The estimator keeps its skills to itself; refresh the snapshot the
+		// server shows, and incremental runs start from, all the same.
+		skills, err := r.collectPlayerSkills(ctx)
+		if err != nil {
+			return report, fmt.Errorf("collect player skills: %w", err)
+		}
+		if err := r.saveSkillSnapshot(ctx, skills, nil); err != nil {
+			return report, fmt.Errorf("save player skills: %w", err)
+		}
+		tally := r.newOutlierTally()
+		if err := r.computePooled(ctx, &report, est, tally, persist); err != nil {
+			return report, err
+		}
+		r.reportOutliers(ctx, &report, tally)
+	} else if err := r.runPerChart(ctx, &report, forceFull, persist); err != nil {
+		return report, err
+	}
+
+	// 4. Keep the run history bounded.
+	if err := r.pruneRuns(ctx, report.RunID); err != nil {
+		slog.ErrorContext(ctx, "prune fitting runs failed", "err", err)
+		report.ErrorsEncountered++
+	}
+
+	return report, nil
+}
+
+// runPerChart is the body of a run with a PerChart estimator: player skills
+// are refreshed and the charts with new evidence recomputed (see plan).
+func (r *Runner) runPerChart(
+	ctx context.Context,
+	report *RunReport,
+	forceFull bool,
+	persist func(context.Context, chartRow, Result, *time.Time) error,
+) error {
+	// 1. Player skills and the charts to recompute.
+	plan, err := r.plan(ctx, report.RunID, forceFull)
 	if err != nil {
-		return report, fmt.Errorf("collect player skills: %w", err)
+		return err
+	}
+	report.Incremental = plan.incremental
+	report.PlayersConsidered = len(plan.skills)
+	report.PlayersChanged = plan.playersChanged
+	report.ChartsTotal = plan.total
+	report.ChartsSkipped = plan.total - len(plan.charts)
+	r.progress.total.Store(int64(len(plan.charts)))
+	slog.InfoContext(ctx, "player skills collected", "players", len(plan.skills))
+
+	// 2–3. Compute the charts and persist them. Only full sweeps see every
+	// chart of a player, so only they report outliers.
+	var tally outlierTally
+	if !plan.incremental {
+		tally = r.newOutlierTally()
+	}
+	if err := r.computeCharts(ctx, report, plan.skills, plan.charts, tally, persist); err != nil {
+		return err
+	}
+	report.Watermark = plan.watermark
+	r.reportOutliers(ctx, report, tally)
+	return nil
+}
+
+// newOutlierTally returns an empty tally, or nil when the outlier report
+// is disabled.
+func (r *Runner) newOutlierTally() outlierTally {
+	if r.cfg.OutlierReportSize <= 0 {
+		return nil
 	}
-	report.PlayersConsidered = len(skills)
-	slog.InfoContext(ctx, "player skills collected", "players", len(skills))
+	return make(outlierTally)
+}
 
-	// 2. Pull the full chart list up front (bounded — charts is a small table).
-	charts, err := r.fetchChartsSorted(ctx)
+// reportOutliers saves the outlier report of the run from tally, when set.
+// A failure is logged and counted but does not fail the run.
+func (r *Runner) reportOutliers(ctx context.Context, report *RunReport, tally outlierTally) {
+	if tally == nil {
+		return
+	}
+	n, err := r.saveOutliers(ctx, report.RunID, tally)
 	if err != nil {
-		return report, fmt.Errorf("fetch charts: %w", err)
+		slog.ErrorContext(ctx, "save outlier report failed", "err", err)
+		report.ErrorsEncountered++
+		return
 	}
-	report.ChartsTotal = len(charts)
+	report.OutliersReported = n
+}
 
+// computeCharts computes the fitting of the given charts in batches with
+// the given player skills and hands each result to handle, with the chart's
+// watermark (nil when it has no best records). Counters are accumulated into
+// report, and the samples' residuals into tally unless it is nil; an error
+// from handle is logged and counted but does not stop the pass. It returns
+// early only on context cancellation.
+func (r *Runner) computeCharts(
+	ctx context.Context,
+	report *RunReport,
+	skills map[string]PlayerSkill,
+	charts []chartRow,
+	tally outlierTally,
+	handle func(context.Context, chartRow, Result, *time.Time) error,
+) error {
 	// 3. Batch-process charts.
 	for start := 0; start < len(charts); start += r.cfg.ChartBatchSize {
 		if err := ctx.Err(); err != nil {
-			return report, err
+			return err
 		}
 		end := start + r.cfg.ChartBatchSize
 		if end > len(charts) {
@@ -127,13 +312,11 @@ func (r *Runner) Run(ctx context.Context) (report RunReport, err error) {
 		batch := charts[start:end]
 
 		chartIDs := make([]int, len(batch))
-		levelByID := make(map[int]float64, len(batch))
 		for i, c := range batch {
 			chartIDs[i] = c.ID
-			levelByID[c.ID] = c.Level
 		}
 
-		samplesByChart, err := r.fetchBestSamples(ctx, chartIDs, skills)
+		samplesByChart, watermarks, err := r.fetchBestSamples(ctx, chartIDs, skills)
 		if err != nil {
 			slog.ErrorContext(ctx, "fetch best samples batch failed",
 				"batch_start", start, "err", err)
@@ -143,20 +326,21 @@ func (r *Runner) Run(ctx context.Context) (report RunReport, err error) {
 
 		for _, c := range batch {
 			if err := ctx.Err(); err != nil {
-				return report, err
+				return err
 			}
 			samples := samplesByChart[c.ID]
 			res := ComputeFitting(c.Level, samples, r.params)
-			report.ChartsProcessed++
-			if len(samples) == 0 {
-				report.ChartsEmpty++
-			} else if res.FittingLevel == nil {
-				report.ChartsAbstained++
-			} else {
-				report.ChartsPublished++
+			if tally != nil {
+				tally.add(explainWeights(c.Level, samples, r.params))
 			}
+			report.count(len(samples) == 0, res)
+			r.progress.processed.Add(1)
 
-			if err := r.persist(ctx, c.ID, c.Level, res); err != nil {
+			var watermark *time.Time
+			if w, ok := watermarks[c.ID]; ok {
+				watermark = &w
+			}
+			if err := handle(ctx, c, res, watermark); err != nil {
 				slog.ErrorContext(ctx, "persist fitting result failed",
 					"chart_id", c.ID, "err", err)
 				report.ErrorsEncountered++
@@ -166,70 +350,292 @@ func (r *Runner) Run(ctx context.Context) (report RunReport, err error) {
 		if r.cfg.BatchPause > 0 && end < len(charts) {
 			select {
 			case <-ctx.Done():
-				return report, ctx.Err()
+				return ctx.Err()
 			case <-time.After(r.cfg.BatchPause):
 			}
 		}
 	}
+	return nil
+}
 
-	return report, nil
+// computePooled fits every chart at once with an estimator that is not
+// PerChart, over a Dataset of every best record, and hands each result to
+// handle like computeCharts does. The residuals for tally, unless nil, are
+// those of the robust per-chart model, whose skills come from the stored
+// ratings.
+func (r *Runner) computePooled(
+	ctx context.Context,
+	report *RunReport,
+	est Estimator,
+	tally outlierTally,
+	handle func(context.Context, chartRow, Result, *time.Time) error,
+) error {
+	d, err := r.loadDataset(ctx)
+	if err != nil {
+		return fmt.Errorf("load best records: %w", err)
+	}
+	report.PlayersConsidered = len(d.players)
+	report.ChartsTotal = len(d.charts)
+	r.progress.total.Store(int64(len(d.charts)))
+	slog.InfoContext(ctx, "best records loaded", "players", len(d.players), "records", len(d.records))
+
+	results, err := est.Fit(ctx, d, r.params)
+	if err != nil {
+		return fmt.Errorf("%s estimator: %w", est.Name(), err)
+	}
+	var skills []PlayerSkill
+	var samples []Sample
+	if tally != nil {
+		skills = d.storedSkills(skillTopK(r.params))
+	}
+	for i, c := range d.charts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if tally != nil {
+			samples = d.chartSamples(i, skills, r.params, samples[:0])
+			tally.add(explainWeights(c.Level, samples, r.params))
+		}
+		res := results[c.ID]
+		report.count(d.starts[i] == d.starts[i+1], res)
+		r.progress.processed.Add(1)
+
+		var watermark *time.Time
+		if w := d.watermarks[i]; !w.IsZero() {
+			watermark = &w
+		}
+		if err := handle(ctx, c, res, watermark); err != nil {
+			slog.ErrorContext(ctx, "persist fitting result failed",
+				"chart_id", c.ID, "err", err)
+			report.ErrorsEncountered++
+		}
+	}
+	return nil
+}
+
+// count adds a computed chart to the counters; empty is true when the chart
+// has no best records at all.
+func (report *RunReport) count(empty bool, res Result) {
+	report.ChartsProcessed++
+	if empty {
+		report.ChartsEmpty++
+	} else if res.FittingLevel == nil {
+		report.ChartsAbstained++
+	} else {
+		report.ChartsPublished++
+	}
 }
 
-// persist writes charts.fitting_level and upserts chart_statistics. It runs
-// inside a short per-chart transaction so a long run does not hold large
-// locks; the main probe server keeps serving live queries.
-func (r *Runner) persist(ctx context.Context, chartID int, officialLevel float64, res Result) error {
+// startRun inserts the fitting_runs row of a new run and returns its ID.
+func (r *Runner) startRun(ctx context.Context, kind string, started time.Time) (int, error) {
+	params, hash, err := encodeParams(r.params)
+	if err != nil {
+		return 0, err
+	}
+	run := model.FittingRun{
+		Kind:       kind,
+		Status:     model.FittingRunStatusRunning,
+		StartedAt:  started,
+		Params:     params,
+		ConfigHash: hash,
+	}
+	if err := r.db.WithContext(ctx).Create(&run).Error; err != nil {
+		return 0, err
+	}
+	return run.ID, nil
+}
+
+// finishRun stores the outcome and counters of a run.
+func (r *Runner) finishRun(ctx context.Context, report RunReport, runErr error) error {
+	updates := map[string]interface{}{
+		"status":             model.FittingRunStatusCompleted,
+		"completed_at":       report.Completed,
+		"duration_ms":        report.Duration.Milliseconds(),
+		"players_considered": report.PlayersConsidered,
+		"charts_total":       report.ChartsTotal,
+		"charts_processed":   report.ChartsProcessed,
+		"charts_published":   report.ChartsPublished,
+		"charts_abstained":   report.ChartsAbstained,
+		"charts_empty":       report.ChartsEmpty,
+		"charts_skipped":     report.ChartsSkipped,
+		"players_changed":    report.PlayersChanged,
+		"outliers_reported":  report.OutliersReported,
+		"incremental":        report.Incremental,
+		"watermark":          report.Watermark,
+		"errors_encountered": report.ErrorsEncountered,
+	}
+	if runErr != nil {
+		updates["status"] = model.FittingRunStatusFailed
+		updates["error"] = runErr.Error()
+	}
+	return r.db.WithContext(ctx).Model(&model.FittingRun{}).Where("id = ?", report.RunID).Updates(updates).Error
+}
+
+// pruneRuns deletes the runs older than the KeepRuns most recent ones, with
+// their chart snapshots and outlier reports. latestRunID is the run that just finished.
+func (r *Runner) pruneRuns(ctx context.Context, latestRunID int) error {
+	if r.cfg.KeepRuns <= 0 {
+		return nil
+	}
+	var cutoff []int
+	if err := r.db.WithContext(ctx).Model(&model.FittingRun{}).
+		Where("id <= ?", latestRunID).
+		Order("id DESC").
+		Offset(r.cfg.KeepRuns-1).
+		Limit(1).
+		Pluck("id", &cutoff).Error; err != nil {
+		return err
+	}
+	if len(cutoff) == 0 {
+		return nil
+	}
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. Update charts.fitting_level. A nil FittingLevel persists NULL,
-		//    explicitly signalling "abstained" to downstream consumers.
-		if err := tx.Model(&model.Chart{}).
-			Where("id = ?", chartID).
-			Update("fitting_level", res.FittingLevel).Error; err != nil {
-			return fmt.Errorf("update chart %d: %w", chartID, err)
-		}
-
-		// 2. Upsert chart_statistics. We use two-step read-modify-write so the
-		//    logic is identical across SQLite and PostgreSQL (no Clauses/
-		//    OnConflict syntax divergence). Contention is irrelevant here —
-		//    only the fitting binary writes this table.
-		now := time.Now()
-		stat := model.ChartStatistic{
-			ChartID:             chartID,
-			OfficialLevel:       officialLevel,
-			FittingLevel:        res.FittingLevel,
-			SampleCount:         res.SampleCount,
-			EffectiveSampleSize: res.EffectiveSampleSize,
-			WeightedMean:        res.WeightedMean,
-			WeightedMedian:      res.WeightedMedian,
-			StdDev:              res.StdDev,
-			MAD:                 res.MAD,
-			LastComputedAt:      now,
-		}
-		var existing model.ChartStatistic
-		err := tx.Where("chart_id = ?", chartID).First(&existing).Error
-		switch {
-		case err == nil:
-			stat.CreatedAt = existing.CreatedAt // preserve initial observation time
-			if err := tx.Model(&existing).Updates(map[string]interface{}{
-				"official_level":        stat.OfficialLevel,
-				"fitting_level":         stat.FittingLevel,
-				"sample_count":          stat.SampleCount,
-				"effective_sample_size": stat.EffectiveSampleSize,
-				"weighted_mean":         stat.WeightedMean,
-				"weighted_median":       stat.WeightedMedian,
-				"std_dev":               stat.StdDev,
-				"mad":                   stat.MAD,
-				"last_computed_at":      stat.LastComputedAt,
-			}).Error; err != nil {
-				return fmt.Errorf("update chart_statistics %d: %w", chartID, err)
-			}
-		case errors.Is(err, gorm.ErrRecordNotFound):
-			if err := tx.Create(&stat).Error; err != nil {
-				return fmt.Errorf("insert chart_statistics %d: %w", chartID, err)
-			}
-		default:
-			return fmt.Errorf("read chart_statistics %d: %w", chartID, err)
+		if err := tx.Where("run_id < ?", cutoff[0]).Delete(&model.FittingRunChart{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("run_id < ?", cutoff[0]).Delete(&model.FittingRunOutlier{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id < ?", cutoff[0]).Delete(&model.FittingRun{}).Error
+	})
+}
+
+// persist writes charts.fitting_level, upserts chart_statistics and snapshots
+// the chart for the run. It runs inside a short per-chart transaction so a
+// long run does not hold large locks; the main probe server keeps serving
+// live queries.
+func (r *Runner) persist(ctx context.Context, runID int, c chartRow, res Result, watermark *time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stat := statisticOf(c, res, time.Now())
+		stat.SamplesWatermark = watermark
+		if err := writeFittingLevel(tx, stat); err != nil {
+			return err
+		}
+		if err := upsertStatistic(tx, stat); err != nil {
+			return err
+		}
+		snapshot := snapshotOf(runID, stat, c.FittingLevel)
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return fmt.Errorf("insert fitting_run_charts %d: %w", c.ID, err)
 		}
 		return nil
 	})
 }
+
+// writeFittingLevel copies the level of stat and its confidence interval to
+// charts.fitting_level / fitting_level_lower / fitting_level_upper. A nil
+// level persists NULL, explicitly signalling "abstained" to downstream
+// consumers. Rows whose values are unchanged are skipped so that updated_at
+// (which drives the catalog change feed and ETag) only moves on real changes.
+func writeFittingLevel(tx *gorm.DB, stat model.ChartStatistic) error {
+	columns := []struct {
+		name  string
+		value *float64
+	}{
+		{"fitting_level", stat.FittingLevel},
+		{"fitting_level_lower", stat.FittingLevelLower},
+		{"fitting_level_upper", stat.FittingLevelUpper},
+	}
+	var changed []string
+	var args []interface{}
+	values := make(map[string]interface{}, len(columns))
+	for _, col := range columns {
+		values[col.name] = col.value
+		if col.value == nil {
+			changed = append(changed, col.name+" IS NOT NULL")
+		} else {
+			changed = append(changed, "("+col.name+" IS NULL OR "+col.name+" <> ?)")
+			args = append(args, *col.value)
+		}
+	}
+	if err := tx.Model(&model.Chart{}).
+		Where("id = ?", stat.ChartID).
+		Where(strings.Join(changed, " OR "), args...).
+		Updates(values).Error; err != nil {
+		return fmt.Errorf("update chart %d: %w", stat.ChartID, err)
+	}
+	return nil
+}
+
+// upsertStatistic writes the chart_statistics row of a chart. We use two-step
+// read-modify-write so the logic is identical across SQLite and PostgreSQL
+// (no Clauses/OnConflict syntax divergence). Contention is irrelevant here —
+// only the fitting binary writes this table.
+func upsertStatistic(tx *gorm.DB, stat model.ChartStatistic) error {
+	var existing model.ChartStatistic
+	err := tx.Where("chart_id = ?", stat.ChartID).First(&existing).Error
+	switch {
+	case err == nil:
+		// CreatedAt is left alone to preserve the initial observation time
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"official_level":        stat.OfficialLevel,
+			"fitting_level":         stat.FittingLevel,
+			"fitting_level_lower":   stat.FittingLevelLower,
+			"fitting_level_upper":   stat.FittingLevelUpper,
+			"sample_count":          stat.SampleCount,
+			"effective_sample_size": stat.EffectiveSampleSize,
+			"weighted_mean":         stat.WeightedMean,
+			"weighted_median":       stat.WeightedMedian,
+			"std_dev":               stat.StdDev,
+			"mad":                   stat.MAD,
+			"last_computed_at":      stat.LastComputedAt,
+			"samples_watermark":     stat.SamplesWatermark,
+		}).Error; err != nil {
+			return fmt.Errorf("update chart_statistics %d: %w", stat.ChartID, err)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Create(&stat).Error; err != nil {
+			return fmt.Errorf("insert chart_statistics %d: %w", stat.ChartID, err)
+		}
+	default:
+		return fmt.Errorf("read chart_statistics %d: %w", stat.ChartID, err)
+	}
+	return nil
+}
+
+// statisticOf builds the chart_statistics row of a chart from its result.
+func statisticOf(c chartRow, res Result, computedAt time.Time) model.ChartStatistic {
+	return model.ChartStatistic{
+		ChartID:             c.ID,
+		OfficialLevel:       c.Level,
+		FittingLevel:        res.FittingLevel,
+		FittingLevelLower:   res.FittingLevelLower,
+		FittingLevelUpper:   res.FittingLevelUpper,
+		SampleCount:         res.SampleCount,
+		EffectiveSampleSize: res.EffectiveSampleSize,
+		WeightedMean:        res.WeightedMean,
+		WeightedMedian:      res.WeightedMedian,
+		StdDev:              res.StdDev,
+		MAD:                 res.MAD,
+		LastComputedAt:      computedAt,
+	}
+}
+
+// snapshotOf builds the fitting_run_charts row recording stat for a run.
+func snapshotOf(runID int, stat model.ChartStatistic, previous *float64) model.FittingRunChart {
+	return model.FittingRunChart{
+		RunID:               runID,
+		ChartID:             stat.ChartID,
+		OfficialLevel:       stat.OfficialLevel,
+		PreviousLevel:       previous,
+		FittingLevel:        stat.FittingLevel,
+		FittingLevelLower:   stat.FittingLevelLower,
+		FittingLevelUpper:   stat.FittingLevelUpper,
+		SampleCount:         stat.SampleCount,
+		EffectiveSampleSize: stat.EffectiveSampleSize,
+		WeightedMean:        stat.WeightedMean,
+		WeightedMedian:      stat.WeightedMedian,
+		StdDev:              stat.StdDev,
+		MAD:                 stat.MAD,
+	}
+}
+
+// encodeParams returns the JSON encoding of params and its SHA-256 in hex.
+func encodeParams(params Params) (string, string, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", "", fmt.Errorf("encode params: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return string(raw), hex.EncodeToString(sum[:]), nil
+}
@@ -35,6 +35,11 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&model.PlayRecord{},
 		&model.BestPlayRecord{},
 		&model.ChartStatistic{},
+		&model.FittingRun{},
+		&model.FittingRunChart{},
+		&model.FittingRunOutlier{},
+		&model.FittingPlayerSkill{},
+		&model.FittingLease{},
 	); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
@@ -170,6 +175,18 @@ func TestRunner_EndToEnd(t *testing.T) {
 	assert.NotNil(t, stat.FittingLevel)
 	assert.Greater(t, stat.SampleCount, 0)
 	assert.Greater(t, stat.EffectiveSampleSize, 0.0)
+
+	// A re-run over the same data leaves the chart row (and its updated_at,
+	// which drives the catalog change feed) untouched.
+	if _, err := runner.Run(ctx); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	var rerun model.Chart
+	if err := db.First(&rerun, chart.ID).Error; err != nil {
+		t.Fatalf("reload chart: %v", err)
+	}
+	assert.Equal(t, *updated.FittingLevel, *rerun.FittingLevel)
+	assert.True(t, updated.UpdatedAt.Equal(rerun.UpdatedAt), "unchanged fitting_level must not bump updated_at")
 }
 
 // TestRunner_InsufficientSamples ensures that a chart with no best records
@@ -312,6 +329,193 @@ func TestRunner_PersistUpdatesExistingStat(t *testing.T) {
 		"LastComputedAt should be >= first run's")
 }
 
+// TestRunner_HistoryAndRollback covers the run history: every run is recorded
+// with a per-chart snapshot, Rollback restores an earlier run's levels, and
+// old runs are pruned beyond KeepRuns.
+func TestRunner_HistoryAndRollback(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	song := model.Song{SongBase: model.SongBase{
+		WikiID: "history_song", Title: "History", Artist: "A", Genre: "G", Cover: "c",
+		Illustrator: "I", Version: "V", Album: "Al", BPM: "100", Length: "1:00",
+	}}
+	if err := db.Create(&song).Error; err != nil {
+		t.Fatalf("create song: %v", err)
+	}
+	chart := model.Chart{SongID: song.ID, Difficulty: model.DifficultyMassive, Level: 16.5, Notes: 1000}
+	if err := db.Create(&chart).Error; err != nil {
+		t.Fatalf("create chart: %v", err)
+	}
+	filler := model.Chart{SongID: song.ID, Difficulty: model.DifficultyInvaded, Level: 14.5, Notes: 800}
+	if err := db.Create(&filler).Error; err != nil {
+		t.Fatalf("create filler: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		u := fmt.Sprintf("h%02d", i)
+		seedUser(t, db, u)
+		skill := 155.0 + float64(i)*0.5
+		seedBestRecord(t, db, u, chart.ID, simulateScore(15.5, skill), chart.Level)
+		seedBestRecord(t, db, u, filler.ID, simulateScore(filler.Level, skill), filler.Level)
+	}
+
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+		BootstrapReplicates: 50,
+		BootstrapSeed:       1,
+		ConfidenceLevel:     0.9,
+	}
+	fittingLevel := func() *float64 {
+		var c model.Chart
+		if err := db.First(&c, chart.ID).Error; err != nil {
+			t.Fatalf("reload chart: %v", err)
+		}
+		return c.FittingLevel
+	}
+
+	// Run 1 with a weak prior, run 2 with a much stronger one (a "bad" run
+	// that pulls the level back toward the official one).
+	good, err := NewRunner(db, params, RunnerConfig{ChartBatchSize: 10, PlayerBatchSize: 50}).Run(ctx)
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	goodLevel := fittingLevel()
+	if !assert.NotNil(t, goodLevel) {
+		return
+	}
+	// The confidence interval is published next to the level
+	var goodChart model.Chart
+	if err := db.First(&goodChart, chart.ID).Error; err != nil {
+		t.Fatalf("reload chart: %v", err)
+	}
+	if !assert.NotNil(t, goodChart.FittingLevelLower) || !assert.NotNil(t, goodChart.FittingLevelUpper) {
+		return
+	}
+	assert.LessOrEqual(t, *goodChart.FittingLevelLower, *goodLevel)
+	assert.GreaterOrEqual(t, *goodChart.FittingLevelUpper, *goodLevel)
+	strong := params
+	strong.PriorStrength = 50
+	bad, err := NewRunner(db, strong, RunnerConfig{ChartBatchSize: 10, PlayerBatchSize: 50}).Run(ctx)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	badLevel := fittingLevel()
+	if !assert.NotNil(t, badLevel) {
+		return
+	}
+	assert.Greater(t, *badLevel, *goodLevel)
+
+	// Both runs are recorded with their params
+	runs, err := RecentRuns(ctx, db, 10)
+	if err != nil {
+		t.Fatalf("recent runs: %v", err)
+	}
+	if !assert.Len(t, runs, 2) {
+		return
+	}
+	assert.Equal(t, bad.RunID, runs[0].ID)
+	assert.Equal(t, model.FittingRunStatusCompleted, runs[0].Status)
+	assert.Equal(t, 2, runs[0].ChartsProcessed)
+	assert.NotNil(t, runs[0].CompletedAt)
+	assert.NotEqual(t, runs[0].ConfigHash, runs[1].ConfigHash)
+	assert.Contains(t, runs[0].Params, `"PriorStrength":50`)
+
+	// The chart's history shows the drift between them
+	history, err := ChartRunHistory(ctx, db, chart.ID, 10)
+	if err != nil {
+		t.Fatalf("chart history: %v", err)
+	}
+	if !assert.Len(t, history, 2) {
+		return
+	}
+	assert.Equal(t, bad.RunID, history[0].RunID)
+	assert.Equal(t, model.FittingRunKindRun, history[0].Kind)
+	assert.InDelta(t, *goodLevel, *history[0].PreviousLevel, 1e-9)
+	assert.InDelta(t, *badLevel, *history[0].FittingLevel, 1e-9)
+	assert.Nil(t, history[1].PreviousLevel)
+
+	// A dry run writes nothing
+	report, err := Rollback(ctx, db, good.RunID, true)
+	if err != nil {
+		t.Fatalf("dry-run rollback: %v", err)
+	}
+	assert.Equal(t, 0, report.RunID)
+	assert.Equal(t, 2, report.Restored+report.Unchanged)
+	assert.GreaterOrEqual(t, report.Restored, 1)
+	assert.InDelta(t, *badLevel, *fittingLevel(), 1e-9)
+
+	report, err = Rollback(ctx, db, good.RunID, false)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assert.Equal(t, 2, report.Restored+report.Unchanged)
+	assert.InDelta(t, *goodLevel, *fittingLevel(), 1e-9)
+	var stat model.ChartStatistic
+	if err := db.Where("chart_id = ?", chart.ID).First(&stat).Error; err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	assert.InDelta(t, *goodLevel, *stat.FittingLevel, 1e-9)
+	var restored model.Chart
+	if err := db.First(&restored, chart.ID).Error; err != nil {
+		t.Fatalf("reload chart: %v", err)
+	}
+	for _, bounds := range [][2]*float64{
+		{restored.FittingLevelLower, restored.FittingLevelUpper},
+		{stat.FittingLevelLower, stat.FittingLevelUpper},
+	} {
+		if assert.NotNil(t, bounds[0]) && assert.NotNil(t, bounds[1]) {
+			assert.InDelta(t, *goodChart.FittingLevelLower, *bounds[0], 1e-9)
+			assert.InDelta(t, *goodChart.FittingLevelUpper, *bounds[1], 1e-9)
+		}
+	}
+
+	var rollback model.FittingRun
+	if err := db.First(&rollback, report.RunID).Error; err != nil {
+		t.Fatalf("rollback run: %v", err)
+	}
+	assert.Equal(t, model.FittingRunKindRollback, rollback.Kind)
+	if assert.NotNil(t, rollback.RollbackOf) {
+		assert.Equal(t, good.RunID, *rollback.RollbackOf)
+	}
+	history, _ = ChartRunHistory(ctx, db, chart.ID, 1)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, model.FittingRunKindRollback, history[0].Kind)
+	}
+
+	// Charts whose official level changed since are left alone
+	if err := db.Model(&model.Chart{}).Where("id = ?", chart.ID).Update("level", 16.4).Error; err != nil {
+		t.Fatalf("update level: %v", err)
+	}
+	report, err = Rollback(ctx, db, bad.RunID, false)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	assert.Equal(t, 1, report.Skipped)
+	assert.InDelta(t, *goodLevel, *fittingLevel(), 1e-9)
+
+	_, err = Rollback(ctx, db, 9999, false)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+
+	// Pruning keeps the most recent KeepRuns runs and their snapshots
+	last, err := NewRunner(db, params, RunnerConfig{ChartBatchSize: 10, PlayerBatchSize: 50, KeepRuns: 2}).Run(ctx)
+	if err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	var runCount, oldSnapshots int64
+	db.Model(&model.FittingRun{}).Count(&runCount)
+	db.Model(&model.FittingRunChart{}).Where("run_id < ?", last.RunID-1).Count(&oldSnapshots)
+	assert.Equal(t, int64(2), runCount)
+	assert.Equal(t, int64(0), oldSnapshots)
+}
+
 // seedBestRecord inserts one PlayRecord + one BestPlayRecord pointing at it,
 // with a rating precomputed via SingleRating so skill computation works.
 func seedBestRecord(t *testing.T, db *gorm.DB, username string, chartID int, score int, level float64) {
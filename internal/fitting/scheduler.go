@@ -0,0 +1,161 @@
+package fitting
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Triggers of a ScheduledRun.
+const (
+	TriggerTick   = "tick"   // the ticker, or the first run at start-up
+	TriggerManual = "manual" // Scheduler.Trigger
+)
+
+// ErrRunInProgress is returned by Scheduler.Trigger while a run is in
+// progress or already queued.
+var ErrRunInProgress = errors.New("a fitting run is already in progress")
+
+// ScheduledRun is one run started by a Scheduler.
+type ScheduledRun struct {
+	Trigger   string
+	Full      bool // a full sweep was requested
+	Started   time.Time
+	Report    RunReport // set once the run has finished
+	Err       error     // why the run failed, if it did
+	Cancelled bool      // stopped by Scheduler.Cancel
+}
+
+// SchedulerStatus is a snapshot of a Scheduler.
+type SchedulerStatus struct {
+	Current         *ScheduledRun // nil when idle
+	ChartsProcessed int           // of the current run
+	ChartsTotal     int           // of the current run; 0 until it knows
+	Last            *ScheduledRun // last finished run, nil before the first one
+	NextTick        time.Time     // zero before the ticker starts
+	Interval        time.Duration
+}
+
+// Scheduler runs a Runner once at start-up and then every interval, the way
+// `fitting run` does in continuous mode, and lets an operator trigger an
+// extra run or cancel the one in progress. Runs never overlap.
+type Scheduler struct {
+	runner   *Runner
+	interval time.Duration
+	trigger  chan bool // full sweep requested
+
+	mu       sync.Mutex
+	current  *ScheduledRun
+	cancel   context.CancelFunc
+	manual   bool // cancel was requested through Cancel
+	last     *ScheduledRun
+	nextTick time.Time
+}
+
+// NewScheduler constructs a scheduler; call Run to start it.
+func NewScheduler(runner *Runner, interval time.Duration) *Scheduler {
+	return &Scheduler{runner: runner, interval: interval, trigger: make(chan bool, 1)}
+}
+
+// Run executes the schedule until ctx is cancelled. A failed run is logged
+// and the schedule goes on: a transient DB error should not kill the
+// microservice.
+func (s *Scheduler) Run(ctx context.Context) {
+	// Immediate first run so there is no long initial idle delay after boot.
+	s.execute(ctx, TriggerTick, false)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	s.setNextTick(time.Now().Add(s.interval))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.setNextTick(time.Now().Add(s.interval))
+			s.execute(ctx, TriggerTick, false)
+		case full := <-s.trigger:
+			s.execute(ctx, TriggerManual, full)
+		}
+	}
+}
+
+// Trigger queues a run to start as soon as the scheduler is idle, a full
+// sweep when full is set. It returns ErrRunInProgress when a run is in
+// progress or already queued.
+func (s *Scheduler) Trigger(full bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil {
+		return ErrRunInProgress
+	}
+	select {
+	case s.trigger <- full:
+		return nil
+	default:
+		return ErrRunInProgress
+	}
+}
+
+// Cancel cancels the run in progress and reports whether there was one.
+// What the run has already persisted stays persisted.
+func (s *Scheduler) Cancel() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel == nil {
+		return false
+	}
+	s.manual = true
+	s.cancel()
+	return true
+}
+
+// Status returns a snapshot of the scheduler.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := SchedulerStatus{Last: s.last, NextTick: s.nextTick, Interval: s.interval}
+	if s.current != nil {
+		current := *s.current
+		st.Current = &current
+		st.ChartsProcessed, st.ChartsTotal = s.runner.Progress()
+	}
+	return st
+}
+
+func (s *Scheduler) setNextTick(t time.Time) {
+	s.mu.Lock()
+	s.nextTick = t
+	s.mu.Unlock()
+}
+
+// execute runs the runner once and records the outcome.
+func (s *Scheduler) execute(ctx context.Context, trigger string, full bool) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	run := &ScheduledRun{Trigger: trigger, Full: full, Started: time.Now()}
+	s.mu.Lock()
+	s.current, s.cancel, s.manual = run, cancel, false
+	s.mu.Unlock()
+
+	pass := s.runner.Run
+	if full {
+		pass = s.runner.RunFull
+	}
+	report, err := pass(runCtx)
+	if err != nil {
+		slog.ErrorContext(ctx, "fitting run failed",
+			"trigger", trigger,
+			"err", err,
+			"duration_ms", report.Duration.Milliseconds(),
+		)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.Report, run.Err = report, err
+	run.Cancelled = s.manual && errors.Is(err, context.Canceled)
+	s.last, s.current, s.cancel = run, nil, nil
+}
@@ -0,0 +1,66 @@
+package fitting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_TriggerAndCancel(t *testing.T) {
+	db := setupTestDB(t)
+	seedMisratedCharts(t, db)
+	params := Params{MinEffectiveSamples: 2, SkillTopK: 50, ProximitySigma: 15, VolumeFullAt: 3, PriorStrength: 1, MaxDeviation: 1.5, MinScore: 500000, TukeyK: 4.685}
+	// One chart per batch with a pause in between, so a run lasts long enough to be observed.
+	runner := NewRunner(db, params, RunnerConfig{ChartBatchSize: 1, BatchPause: 100 * time.Millisecond})
+	s := NewScheduler(runner, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The first run starts right away.
+	require.Eventually(t, func() bool { return s.Status().Last != nil }, 5*time.Second, 10*time.Millisecond)
+	st := s.Status()
+	assert.Equal(t, TriggerTick, st.Last.Trigger)
+	assert.NoError(t, st.Last.Err)
+	assert.Equal(t, 5, st.Last.Report.ChartsPublished)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), st.NextTick, time.Minute)
+	assert.False(t, s.Cancel(), "nothing to cancel while idle")
+
+	// A manual full sweep runs next, reporting its progress.
+	require.NoError(t, s.Trigger(true))
+	require.Eventually(t, func() bool {
+		st := s.Status()
+		return st.Current != nil && st.ChartsTotal == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, s.Trigger(false), ErrRunInProgress)
+	require.Eventually(t, func() bool {
+		st := s.Status()
+		return st.Current == nil && st.Last.Trigger == TriggerManual
+	}, 5*time.Second, 10*time.Millisecond)
+	st = s.Status()
+	assert.True(t, st.Last.Full)
+	assert.False(t, st.Last.Report.Incremental)
+	assert.NoError(t, st.Last.Err)
+
+	// Cancelling stops the run in progress without stopping the schedule.
+	require.NoError(t, s.Trigger(true))
+	require.Eventually(t, func() bool { return s.Status().Current != nil }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, s.Cancel())
+	require.Eventually(t, func() bool { return s.Status().Current == nil }, 5*time.Second, 10*time.Millisecond)
+	st = s.Status()
+	assert.True(t, st.Last.Cancelled)
+	assert.ErrorIs(t, st.Last.Err, context.Canceled)
+	assert.Less(t, st.Last.Report.ChartsProcessed, 5)
+	assert.NoError(t, s.Trigger(false), "the scheduler is still running")
+}
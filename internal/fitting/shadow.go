@@ -0,0 +1,217 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"paradigm-reboot-prober-go/internal/model"
+)
+
+// How a shadow run's level for a chart compares with the published one.
+const (
+	ShadowUnchanged        = "unchanged"         // same level, or abstained in both
+	ShadowMoved            = "moved"             // published in both, at different levels
+	ShadowAbstentionGained = "abstention_gained" // published now, abstained in the shadow run
+	ShadowAbstentionLost   = "abstention_lost"   // abstained now, published in the shadow run
+)
+
+// shadowDeltaEdges are the bucket boundaries of ShadowSummary.Histogram. The
+// first and last buckets are open-ended.
+var shadowDeltaEdges = []float64{-0.5, -0.2, -0.1, -0.05, -0.01, 0.01, 0.05, 0.1, 0.2, 0.5}
+
+// ShadowChart compares the level currently published for a chart
+// (charts.fitting_level) with the one computed by a shadow run.
+type ShadowChart struct {
+	ChartID             int      `json:"chart_id"`
+	OfficialLevel       float64  `json:"official_level"`
+	OldLevel            *float64 `json:"old_level"`
+	NewLevel            *float64 `json:"new_level"`
+	Delta               *float64 `json:"delta"` // NewLevel − OldLevel; nil unless both are published
+	SampleCount         int      `json:"sample_count"`
+	EffectiveSampleSize float64  `json:"effective_sample_size"`
+	Change              string   `json:"change"`
+}
+
+// DeltaBucket counts the charts whose delta lies in [Min, Max). A nil bound
+// is unbounded.
+type DeltaBucket struct {
+	Min   *float64 `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int      `json:"count"`
+}
+
+// ShadowSummary aggregates the per-chart comparisons of a shadow run.
+type ShadowSummary struct {
+	Charts            int `json:"charts"`
+	Unchanged         int `json:"unchanged"`
+	Moved             int `json:"moved"`
+	AbstentionsGained int `json:"abstentions_gained"`
+	AbstentionsLost   int `json:"abstentions_lost"`
+	// Delta statistics over the charts published in both.
+	MeanDelta    float64       `json:"mean_delta"`
+	MeanAbsDelta float64       `json:"mean_abs_delta"`
+	MaxAbsDelta  float64       `json:"max_abs_delta"`
+	Histogram    []DeltaBucket `json:"histogram"`
+}
+
+// ShadowReport is the outcome of a shadow run.
+type ShadowReport struct {
+	RunReport
+	Charts  []ShadowChart
+	Summary ShadowSummary
+}
+
+// Shadow computes every chart like a full sweep of Run, but publishes
+// nothing: the results are compared with the levels currently in
+// charts.fitting_level, so a Runner built with candidate Params shows what
+// they would change.
+//
+// With record set, the shadow run is also stored in fitting_runs (kind
+// "shadow") with its chart snapshots, where PreviousLevel is the published
+// level. The probe server never reads those tables, and Rollback refuses
+// shadow runs. charts and chart_statistics are never written.
+func (r *Runner) Shadow(ctx context.Context, record bool) (report ShadowReport, err error) {
+	report.Started = time.Now()
+	r.progress.processed.Store(0)
+	r.progress.total.Store(0)
+	slog.InfoContext(ctx, "fitting shadow run starting", "record", record)
+	defer func() {
+		report.Completed = time.Now()
+		report.Duration = report.Completed.Sub(report.Started)
+		if err != nil {
+			slog.ErrorContext(ctx, "fitting shadow run failed", "err", err)
+		} else {
+			slog.InfoContext(ctx, "fitting shadow run completed",
+				"duration_ms", report.Duration.Milliseconds(),
+				"charts", report.Summary.Charts,
+				"moved", report.Summary.Moved,
+				"abstentions_gained", report.Summary.AbstentionsGained,
+				"abstentions_lost", report.Summary.AbstentionsLost,
+			)
+		}
+		if report.RunID != 0 {
+			if ferr := r.finishRun(context.WithoutCancel(ctx), report.RunReport, err); ferr != nil {
+				slog.ErrorContext(ctx, "record fitting run outcome failed", "run_id", report.RunID, "err", ferr)
+			}
+		}
+	}()
+
+	if record {
+		if report.RunID, err = r.startRun(ctx, model.FittingRunKindShadow, report.Started); err != nil {
+			return report, fmt.Errorf("record fitting run: %w", err)
+		}
+	}
+
+	est, err := EstimatorByName(r.params.Estimator)
+	if err != nil {
+		return report, err
+	}
+	compare := func(ctx context.Context, c chartRow, res Result, _ *time.Time) error {
+		report.Charts = append(report.Charts, compareShadow(c, res))
+		if report.RunID == 0 {
+			return nil
+		}
+		snapshot := snapshotOf(report.RunID, statisticOf(c, res, time.Now()), c.FittingLevel)
+		if err := r.db.WithContext(ctx).Create(&snapshot).Error; err != nil {
+			return fmt.Errorf("insert fitting_run_charts %d: %w", c.ID, err)
+		}
+		return nil
+	}
+	if !est.PerChart() {
+		if err := r.computePooled(ctx, &report.RunReport, est, nil, compare); err != nil {
+			return report, err
+		}
+	} else {
+		skills, err := r.collectPlayerSkills(ctx)
+		if err != nil {
+			return report, fmt.Errorf("collect player skills: %w", err)
+		}
+		report.PlayersConsidered = len(skills)
+		charts, err := r.fetchChartsSorted(ctx)
+		if err != nil {
+			return report, fmt.Errorf("fetch charts: %w", err)
+		}
+		report.ChartsTotal = len(charts)
+		r.progress.total.Store(int64(len(charts)))
+		if err := r.computeCharts(ctx, &report.RunReport, skills, charts, nil, compare); err != nil {
+			return report, err
+		}
+	}
+	report.Summary = SummarizeShadow(report.Charts)
+	return report, nil
+}
+
+// compareShadow compares a chart's published level with a shadow result.
+func compareShadow(c chartRow, res Result) ShadowChart {
+	sc := ShadowChart{
+		ChartID:             c.ID,
+		OfficialLevel:       c.Level,
+		OldLevel:            c.FittingLevel,
+		NewLevel:            res.FittingLevel,
+		SampleCount:         res.SampleCount,
+		EffectiveSampleSize: res.EffectiveSampleSize,
+	}
+	switch {
+	case sameLevel(c.FittingLevel, res.FittingLevel):
+		sc.Change = ShadowUnchanged
+	case res.FittingLevel == nil:
+		sc.Change = ShadowAbstentionGained
+	case c.FittingLevel == nil:
+		sc.Change = ShadowAbstentionLost
+	default:
+		sc.Change = ShadowMoved
+	}
+	if c.FittingLevel != nil && res.FittingLevel != nil {
+		d := *res.FittingLevel - *c.FittingLevel
+		sc.Delta = &d
+	}
+	return sc
+}
+
+// SummarizeShadow aggregates per-chart comparisons into a ShadowSummary.
+func SummarizeShadow(charts []ShadowChart) ShadowSummary {
+	sum := ShadowSummary{Charts: len(charts), Histogram: make([]DeltaBucket, len(shadowDeltaEdges)+1)}
+	for i := range sum.Histogram {
+		if i > 0 {
+			sum.Histogram[i].Min = &shadowDeltaEdges[i-1]
+		}
+		if i < len(shadowDeltaEdges) {
+			sum.Histogram[i].Max = &shadowDeltaEdges[i]
+		}
+	}
+
+	deltas := 0
+	for _, c := range charts {
+		switch c.Change {
+		case ShadowUnchanged:
+			sum.Unchanged++
+		case ShadowMoved:
+			sum.Moved++
+		case ShadowAbstentionGained:
+			sum.AbstentionsGained++
+		case ShadowAbstentionLost:
+			sum.AbstentionsLost++
+		}
+		if c.Delta == nil {
+			continue
+		}
+		d := *c.Delta
+		deltas++
+		sum.MeanDelta += d
+		sum.MeanAbsDelta += math.Abs(d)
+		sum.MaxAbsDelta = math.Max(sum.MaxAbsDelta, math.Abs(d))
+		bucket := 0
+		for bucket < len(shadowDeltaEdges) && d >= shadowDeltaEdges[bucket] {
+			bucket++
+		}
+		sum.Histogram[bucket].Count++
+	}
+	if deltas > 0 {
+		sum.MeanDelta /= float64(deltas)
+		sum.MeanAbsDelta /= float64(deltas)
+	}
+	return sum
+}
@@ -0,0 +1,159 @@
+package fitting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"paradigm-reboot-prober-go/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeShadow(t *testing.T) {
+	lv := func(v float64) *float64 { return &v }
+	charts := []ShadowChart{
+		compareShadow(chartRow{ID: 1, Level: 15, FittingLevel: lv(15.2)}, Result{FittingLevel: lv(15.2)}),
+		compareShadow(chartRow{ID: 2, Level: 15, FittingLevel: lv(15.2)}, Result{FittingLevel: lv(15.5)}),
+		compareShadow(chartRow{ID: 3, Level: 15, FittingLevel: lv(15.2)}, Result{FittingLevel: lv(14.1)}),
+		compareShadow(chartRow{ID: 4, Level: 15, FittingLevel: lv(15.2)}, Result{}),
+		compareShadow(chartRow{ID: 5, Level: 15}, Result{FittingLevel: lv(15.1)}),
+		compareShadow(chartRow{ID: 6, Level: 15}, Result{}),
+	}
+	assert.Equal(t, []string{
+		ShadowUnchanged, ShadowMoved, ShadowMoved, ShadowAbstentionGained, ShadowAbstentionLost, ShadowUnchanged,
+	}, []string{charts[0].Change, charts[1].Change, charts[2].Change, charts[3].Change, charts[4].Change, charts[5].Change})
+	assert.Nil(t, charts[3].Delta)
+	assert.Nil(t, charts[4].Delta)
+
+	sum := SummarizeShadow(charts)
+	assert.Equal(t, 6, sum.Charts)
+	assert.Equal(t, 2, sum.Unchanged)
+	assert.Equal(t, 2, sum.Moved)
+	assert.Equal(t, 1, sum.AbstentionsGained)
+	assert.Equal(t, 1, sum.AbstentionsLost)
+	// Deltas 0, +0.3 and −1.1
+	assert.InDelta(t, -0.8/3, sum.MeanDelta, 1e-9)
+	assert.InDelta(t, 1.4/3, sum.MeanAbsDelta, 1e-9)
+	assert.InDelta(t, 1.1, sum.MaxAbsDelta, 1e-9)
+
+	counts := make([]int, len(sum.Histogram))
+	for i, b := range sum.Histogram {
+		counts[i] = b.Count
+	}
+	assert.Equal(t, []int{1, 0, 0, 0, 0, 1, 0, 0, 0, 1, 0}, counts)
+	assert.Nil(t, sum.Histogram[0].Min)
+	assert.Nil(t, sum.Histogram[len(sum.Histogram)-1].Max)
+}
+
+func TestRunner_Shadow(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	song := model.Song{SongBase: model.SongBase{
+		WikiID: "shadow_song", Title: "Shadow", Artist: "A", Genre: "G", Cover: "c",
+		Illustrator: "I", Version: "V", Album: "Al", BPM: "100", Length: "1:00",
+	}}
+	if err := db.Create(&song).Error; err != nil {
+		t.Fatalf("create song: %v", err)
+	}
+	chart := model.Chart{SongID: song.ID, Difficulty: model.DifficultyMassive, Level: 16.5, Notes: 1000}
+	if err := db.Create(&chart).Error; err != nil {
+		t.Fatalf("create chart: %v", err)
+	}
+	filler := model.Chart{SongID: song.ID, Difficulty: model.DifficultyInvaded, Level: 14.5, Notes: 800}
+	if err := db.Create(&filler).Error; err != nil {
+		t.Fatalf("create filler: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		u := fmt.Sprintf("sh%02d", i)
+		seedUser(t, db, u)
+		skill := 155.0 + float64(i)*0.5
+		seedBestRecord(t, db, u, chart.ID, simulateScore(15.5, skill), chart.Level)
+		seedBestRecord(t, db, u, filler.ID, simulateScore(filler.Level, skill), filler.Level)
+	}
+
+	params := Params{
+		MinEffectiveSamples: 2.0,
+		SkillTopK:           50,
+		ProximitySigma:      15.0,
+		VolumeFullAt:        3,
+		PriorStrength:       1.0,
+		MaxDeviation:        1.5,
+		MinScore:            500000,
+		TukeyK:              4.685,
+		MinPlayerRecords:    1,
+	}
+	cfg := RunnerConfig{ChartBatchSize: 10, PlayerBatchSize: 50}
+	live, err := NewRunner(db, params, cfg).Run(ctx)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var before model.Chart
+	db.First(&before, chart.ID)
+	if !assert.NotNil(t, before.FittingLevel) {
+		return
+	}
+	var statBefore model.ChartStatistic
+	db.Where("chart_id = ?", chart.ID).First(&statBefore)
+
+	strong := params
+	strong.PriorStrength = 50
+	report, err := NewRunner(db, strong, cfg).Shadow(ctx, false)
+	if err != nil {
+		t.Fatalf("shadow: %v", err)
+	}
+	assert.Equal(t, 0, report.RunID)
+	assert.Equal(t, 2, report.ChartsProcessed)
+	if !assert.Len(t, report.Charts, 2) {
+		return
+	}
+	var sc ShadowChart
+	for _, c := range report.Charts {
+		if c.ChartID == chart.ID {
+			sc = c
+		}
+	}
+	assert.Equal(t, ShadowMoved, sc.Change)
+	assert.InDelta(t, *before.FittingLevel, *sc.OldLevel, 1e-9)
+	if assert.NotNil(t, sc.Delta) {
+		assert.Greater(t, *sc.Delta, 0.0, "a stronger prior pulls toward the official level")
+	}
+	assert.Equal(t, 2, report.Summary.Charts)
+
+	// Nothing was published or recorded
+	var after model.Chart
+	db.First(&after, chart.ID)
+	assert.InDelta(t, *before.FittingLevel, *after.FittingLevel, 1e-9)
+	var statAfter model.ChartStatistic
+	db.Where("chart_id = ?", chart.ID).First(&statAfter)
+	assert.Equal(t, statBefore.LastComputedAt.Unix(), statAfter.LastComputedAt.Unix())
+	var runs int64
+	db.Model(&model.FittingRun{}).Count(&runs)
+	assert.Equal(t, int64(1), runs)
+
+	// With record the shadow run lands in the history, but cannot be restored
+	recorded, err := NewRunner(db, strong, cfg).Shadow(ctx, true)
+	if err != nil {
+		t.Fatalf("recorded shadow: %v", err)
+	}
+	if !assert.NotZero(t, recorded.RunID) {
+		return
+	}
+	history, err := ChartRunHistory(ctx, db, chart.ID, 10)
+	if err != nil {
+		t.Fatalf("chart history: %v", err)
+	}
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, model.FittingRunKindShadow, history[0].Kind)
+		assert.Equal(t, model.FittingRunStatusCompleted, history[0].Status)
+		assert.InDelta(t, *before.FittingLevel, *history[0].PreviousLevel, 1e-9)
+		assert.InDelta(t, *sc.NewLevel, *history[0].FittingLevel, 1e-9)
+		assert.Equal(t, live.RunID, history[1].RunID)
+	}
+	db.First(&after, chart.ID)
+	assert.InDelta(t, *before.FittingLevel, *after.FittingLevel, 1e-9)
+
+	_, err = Rollback(ctx, db, recorded.RunID, true)
+	assert.ErrorContains(t, err, "shadow")
+}
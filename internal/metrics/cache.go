@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Results of a cached repository read, the `result` label of
+// cache_requests_total.
+const (
+	CacheHit       = "hit"       // served from a fresh entry
+	CacheStale     = "stale"     // served from an expired entry while it is refreshed
+	CacheMiss      = "miss"      // loaded from the database
+	CacheCoalesced = "coalesced" // waited for the load of a concurrent miss
+)
+
+var cacheRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Total number of cached repository reads, partitioned by cache namespace (song, user, record, stats) and result (hit, stale, miss, coalesced).",
+	},
+	[]string{"namespace", "result"},
+)
+
+// ObserveCacheRequest counts one cached read of namespace.
+func ObserveCacheRequest(namespace, result string) {
+	cacheRequestsTotal.WithLabelValues(namespace, result).Inc()
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveCacheRequest(t *testing.T) {
+	hits := cacheRequestsTotal.WithLabelValues("song", CacheHit)
+	misses := cacheRequestsTotal.WithLabelValues("song", CacheMiss)
+	beforeHits, beforeMisses := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	ObserveCacheRequest("song", CacheHit)
+	ObserveCacheRequest("song", CacheHit)
+	ObserveCacheRequest("song", CacheMiss)
+
+	assert.Equal(t, beforeHits+2, testutil.ToFloat64(hits))
+	assert.Equal(t, beforeMisses+1, testutil.ToFloat64(misses))
+}
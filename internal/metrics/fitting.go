@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Outcomes of a fitting run, the `outcome` label of the fitting run metrics.
+const (
+	FittingRunSucceeded = "success"
+	FittingRunFailed    = "error"
+	FittingRunCancelled = "cancelled"
+)
+
+var (
+	fittingRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fitting_runs_total",
+			Help: "Total number of fitting runs, partitioned by outcome (success, error, cancelled).",
+		},
+		[]string{"outcome"},
+	)
+
+	fittingRunDurationSeconds = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "fitting_run_duration_seconds",
+			Help:    "Duration of fitting runs in seconds, partitioned by mode (full, incremental) and outcome.",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+		},
+		[]string{"mode", "outcome"},
+	)
+
+	fittingLastRunCharts = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fitting_last_run_charts",
+			Help: "Charts of the last finished fitting run, partitioned by result (published, abstained, empty, skipped).",
+		},
+		[]string{"result"},
+	)
+
+	fittingLastSuccessTimestampSeconds = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fitting_last_success_timestamp_seconds",
+			Help: "Unix time at which the last successful fitting run completed.",
+		},
+	)
+
+	fittingRunInProgress = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fitting_run_in_progress",
+			Help: "1 while a fitting run is in progress, 0 otherwise.",
+		},
+	)
+)
+
+// FittingRun is what ObserveFittingRun records of a finished fitting run.
+type FittingRun struct {
+	Outcome     string // FittingRunSucceeded, FittingRunFailed or FittingRunCancelled
+	Incremental bool
+	Duration    time.Duration
+	Completed   time.Time
+	Published   int
+	Abstained   int
+	Empty       int
+	Skipped     int
+}
+
+// ObserveFittingRun records a finished fitting run.
+func ObserveFittingRun(run FittingRun) {
+	mode := "full"
+	if run.Incremental {
+		mode = "incremental"
+	}
+	fittingRunsTotal.WithLabelValues(run.Outcome).Inc()
+	fittingRunDurationSeconds.WithLabelValues(mode, run.Outcome).Observe(run.Duration.Seconds())
+	fittingLastRunCharts.WithLabelValues("published").Set(float64(run.Published))
+	fittingLastRunCharts.WithLabelValues("abstained").Set(float64(run.Abstained))
+	fittingLastRunCharts.WithLabelValues("empty").Set(float64(run.Empty))
+	fittingLastRunCharts.WithLabelValues("skipped").Set(float64(run.Skipped))
+	if run.Outcome == FittingRunSucceeded {
+		fittingLastSuccessTimestampSeconds.Set(float64(run.Completed.Unix()))
+	}
+}
+
+// SetFittingRunInProgress flags whether a fitting run is in progress.
+func SetFittingRunInProgress(inProgress bool) {
+	v := 0.0
+	if inProgress {
+		v = 1
+	}
+	fittingRunInProgress.Set(v)
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveFittingRun(t *testing.T) {
+	before := testutil.ToFloat64(fittingRunsTotal.WithLabelValues(FittingRunSucceeded))
+	completed := time.Unix(1_700_000_000, 0)
+	ObserveFittingRun(FittingRun{
+		Outcome:     FittingRunSucceeded,
+		Incremental: true,
+		Duration:    90 * time.Second,
+		Completed:   completed,
+		Published:   420,
+		Abstained:   30,
+		Empty:       5,
+		Skipped:     1000,
+	})
+
+	assert.Equal(t, before+1, testutil.ToFloat64(fittingRunsTotal.WithLabelValues(FittingRunSucceeded)))
+	assert.Equal(t, 420.0, testutil.ToFloat64(fittingLastRunCharts.WithLabelValues("published")))
+	assert.Equal(t, 30.0, testutil.ToFloat64(fittingLastRunCharts.WithLabelValues("abstained")))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(fittingLastRunCharts.WithLabelValues("skipped")))
+	assert.Equal(t, float64(completed.Unix()), testutil.ToFloat64(fittingLastSuccessTimestampSeconds))
+	assert.Equal(t, 1, testutil.CollectAndCount(fittingRunDurationSeconds, "fitting_run_duration_seconds"))
+
+	// A failed run keeps the last success timestamp.
+	ObserveFittingRun(FittingRun{Outcome: FittingRunFailed, Completed: completed.Add(time.Hour)})
+	assert.Equal(t, float64(completed.Unix()), testutil.ToFloat64(fittingLastSuccessTimestampSeconds))
+
+	SetFittingRunInProgress(true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(fittingRunInProgress))
+	SetFittingRunInProgress(false)
+	assert.Equal(t, 0.0, testutil.ToFloat64(fittingRunInProgress))
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"paradigm-reboot-prober-go/internal/logging"
@@ -53,8 +55,12 @@ func AuthMiddleware(userService *service.UserService) gin.HandlerFunc {
 		}
 
 		// Verify the user still exists and is active
-		user, err := userService.GetUser(username)
-		if err != nil || user == nil {
+		user, err := userService.GetUser(c.Request.Context(), username)
+		if err != nil {
+			lookupFailed(c, err)
+			return
+		}
+		if user == nil {
 			c.JSON(http.StatusUnauthorized, model.Response{Error: "user not found"})
 			c.Abort()
 			return
@@ -101,7 +107,7 @@ func OptionalAuthMiddleware(userService *service.UserService) gin.HandlerFunc {
 
 		username, err := auth.ExtractUsername(tokenString)
 		if err == nil {
-			user, err := userService.GetUser(username)
+			user, err := userService.GetUser(c.Request.Context(), username)
 			if err == nil && user != nil && user.IsActive {
 				ctx := logging.AppendCtx(c.Request.Context(), slog.String("username", username))
 				c.Request = c.Request.WithContext(ctx)
@@ -122,8 +128,12 @@ func AdminMiddleware(userService *service.UserService) gin.HandlerFunc {
 			return
 		}
 
-		user, err := userService.GetUser(usernameStr)
-		if err != nil || user == nil {
+		user, err := userService.GetUser(c.Request.Context(), usernameStr)
+		if err != nil {
+			lookupFailed(c, err)
+			return
+		}
+		if user == nil {
 			c.JSON(http.StatusUnauthorized, model.Response{Error: "User not found"})
 			c.Abort()
 			return
@@ -139,3 +149,15 @@ func AdminMiddleware(userService *service.UserService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// lookupFailed aborts a request whose user could not be looked up: with 504
+// when the query ran past its timeout, 503 otherwise (database unreachable,
+// request cancelled).
+func lookupFailed(c *gin.Context, err error) {
+	status := http.StatusServiceUnavailable
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	c.JSON(status, model.Response{Error: err.Error()})
+	c.Abort()
+}
@@ -347,3 +347,23 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddleware_LookupTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userService := setupAuthTest(t)
+	config.DatabaseReadTimeoutDuration = time.Nanosecond
+	t.Cleanup(config.InitDefaults)
+
+	r := gin.New()
+	r.Use(AuthMiddleware(userService))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+
+	token, _ := auth.GenerateAccessJWT("testuser", nil)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// A user lookup that times out is not reported as a bad token
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
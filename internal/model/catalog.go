@@ -0,0 +1,52 @@
+package model
+
+// Supported encodings of a catalog file.
+const (
+	CatalogFormatYAML = "yaml"
+	CatalogFormatJSON = "json"
+)
+
+// Actions that a catalog import plan can contain.
+const (
+	CatalogActionCreate      = "create"
+	CatalogActionUpdate      = "update"
+	CatalogActionLevelChange = "level_change"
+	CatalogActionRetire      = "retire"
+)
+
+// Catalog is the full song/chart catalog as exchanged by the catalog
+// import/export tool. Songs are matched against the database by wiki_id.
+type Catalog struct {
+	Songs []CatalogSong `json:"songs" yaml:"songs" binding:"required,min=1,dive"`
+}
+
+// CatalogSong is a single song of a catalog, with the same fields as a
+// create/update song request.
+type CatalogSong struct {
+	SongBase `yaml:",inline"`
+	Charts   []ChartInput `json:"charts" yaml:"charts" binding:"required,min=1,dive"`
+}
+
+// CatalogChange is one line of a catalog import plan.
+type CatalogChange struct {
+	// Action is one of create, update, level_change or retire.
+	Action string `json:"action" example:"level_change"`
+	WikiID string `json:"wiki_id" example:"w123"`
+	// SongID is 0 for songs that are being created.
+	SongID int `json:"song_id" example:"1"`
+	// Difficulty is set when the change applies to a single chart; it is
+	// empty when a whole song is created or retired, or for song metadata.
+	Difficulty Difficulty `json:"difficulty,omitempty" example:"massive"`
+	// Field, OldValue and NewValue describe updates and level changes,
+	// using the same renderings as ChartHistory.
+	Field    string  `json:"field,omitempty" example:"level"`
+	OldValue *string `json:"old_value,omitempty" example:"14.5"`
+	NewValue *string `json:"new_value,omitempty" example:"14.7"`
+}
+
+// CatalogPlan is the result of diffing a catalog against the database.
+type CatalogPlan struct {
+	Changes []CatalogChange `json:"changes"`
+	// Applied is true when the plan was committed rather than only computed.
+	Applied bool `json:"applied"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// Fields tracked in ChartHistory. Song-level fields use the SongBase json names.
+// ChartHistoryFieldChart records a chart being created/restored (NewValue set) or
+// removed/retired (OldValue set); ChartHistoryFieldSong does the same for a whole song.
+const (
+	ChartHistoryFieldLevel = "level"
+	ChartHistoryFieldChart = "chart"
+	ChartHistoryFieldSong  = "song"
+)
+
+// ChartHistory is an append-only audit log of catalog edits: every change to a
+// chart's level or metadata (and to the metadata of the song it belongs to)
+// is recorded with who made it and the old/new values.
+type ChartHistory struct {
+	ID     int `gorm:"primaryKey" json:"id"`
+	SongID int `gorm:"not null;index" json:"song_id" example:"1"`
+	// ChartID is nil for song-level fields (title, artist, b15, ...), which apply to every chart of the song.
+	ChartID *int `gorm:"index" json:"chart_id" example:"10" extensions:"x-nullable=true"`
+	// Difficulty of the chart the entry refers to; empty for song-level fields.
+	Difficulty Difficulty `gorm:"type:varchar(20)" json:"difficulty" example:"massive"`
+	Field      string     `gorm:"type:varchar(32);not null" json:"field" example:"level"`
+	// OldValue / NewValue are string renderings of the field; nil means "unset"
+	// (e.g. OldValue is nil when a chart is created).
+	OldValue  *string   `json:"old_value" example:"14.5" extensions:"x-nullable=true"`
+	NewValue  *string   `json:"new_value" example:"14.7" extensions:"x-nullable=true"`
+	ChangedBy string    `gorm:"not null" json:"changed_by" example:"admin"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ChartHistory) TableName() string {
+	return "chart_histories"
+}
+
+// UserRatingImpact describes how a single user's B50 would change.
+// Sums are in the same integer unit as PlayRecord.Rating (rating × 100);
+// the displayed player rating is the sum divided by the B50 size.
+type UserRatingImpact struct {
+	Username   string `json:"username" example:"player1"`
+	OldB50Sum  int    `json:"old_b50_sum" example:"815000"`
+	NewB50Sum  int    `json:"new_b50_sum" example:"815320"`
+	Delta      int    `json:"delta" example:"320"`
+	B50Changed bool   `json:"b50_changed"`
+}
+
+// RatingImpactReport summarises the effect of a catalog update on players' B50
+// without committing it.
+type RatingImpactReport struct {
+	SongID int `json:"song_id" example:"1"`
+	// Changes lists the history entries the update would record.
+	Changes []ChartHistory `json:"changes"`
+	// AffectedUsers is the number of users holding a best record on any chart of the song.
+	AffectedUsers int `json:"affected_users" example:"120"`
+	// B50ChangedUsers counts users whose set of B50 charts would change.
+	B50ChangedUsers int `json:"b50_changed_users" example:"12"`
+	// RatingChangedUsers counts users whose B50 sum would change.
+	RatingChangedUsers int `json:"rating_changed_users" example:"80"`
+	// TotalDelta, MaxGain and MaxLoss are in B50-sum units (see UserRatingImpact);
+	// MaxLoss is the most negative delta, so it is never positive.
+	TotalDelta int `json:"total_delta" example:"25600"`
+	MaxGain    int `json:"max_gain" example:"640"`
+	MaxLoss    int `json:"max_loss" example:"0"`
+	// Users lists every user whose B50 sum or composition would change, largest |delta| first.
+	Users []UserRatingImpact `json:"users"`
+}
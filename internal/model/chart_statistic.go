@@ -22,6 +22,12 @@ type ChartStatistic struct {
 	// when the sample was insufficient to publish a level.
 	FittingLevel *float64 `gorm:"column:fitting_level" json:"fitting_level"`
 
+	// FittingLevelLower and FittingLevelUpper bound the bootstrap confidence
+	// interval of FittingLevel (fitting.confidence_level). Nil when
+	// FittingLevel is, or when the interval is disabled.
+	FittingLevelLower *float64 `gorm:"column:fitting_level_lower" json:"fitting_level_lower"`
+	FittingLevelUpper *float64 `gorm:"column:fitting_level_upper" json:"fitting_level_upper"`
+
 	// SampleCount is the raw number of best_play_records considered for this chart
 	// (before robust trimming / weighting).
 	SampleCount int `gorm:"not null;column:sample_count" json:"sample_count"`
@@ -49,6 +55,12 @@ type ChartStatistic struct {
 
 	// LastComputedAt is the wall-clock time of the most recent computation.
 	LastComputedAt time.Time `gorm:"not null;column:last_computed_at" json:"last_computed_at"`
+
+	// SamplesWatermark is the newest updated_at among the play records behind
+	// the chart's best records at the time of computation. Incremental runs
+	// skip the chart until newer evidence arrives. Nil for rows written
+	// before watermarks were tracked.
+	SamplesWatermark *time.Time `gorm:"column:samples_watermark" json:"-"`
 }
 
 // TableName specifies the table name for GORM.
@@ -0,0 +1,83 @@
+package model
+
+// ScorePercentile is the best score at the given percentile of a chart's
+// players, e.g. Percentile 90 is the score that 90% of players do not exceed.
+type ScorePercentile struct {
+	Percentile int `json:"percentile" example:"50"`
+	Score      int `json:"score" example:"1003500"`
+}
+
+// ScoreBucket counts the best scores from MinScore up to the next bucket's
+// MinScore.
+type ScoreBucket struct {
+	MinScore int `json:"min_score" example:"1000000"`
+	Count    int `json:"count" example:"42"`
+}
+
+// ScoreDistribution describes the best scores of all players on a chart.
+// It only holds aggregates; no individual record can be read from it.
+type ScoreDistribution struct {
+	PlayerCount int `json:"player_count" example:"120"`
+	// PassCount is the number of best scores at or above the pass score.
+	PassCount   int               `json:"pass_count" example:"80"`
+	PassRate    float64           `json:"pass_rate" example:"0.667"`
+	Percentiles []ScorePercentile `json:"percentiles"`
+	Histogram   []ScoreBucket     `json:"histogram"`
+}
+
+// ChartRank places one player's best score among all players of a chart.
+type ChartRank struct {
+	Score int `json:"score" example:"1005000"`
+	// Rank is 1 for the highest score; tied players share a rank.
+	Rank int `json:"rank" example:"12"`
+	// Percentile is the share of players, in percent, whose best score is at
+	// or below this one.
+	Percentile float64 `json:"percentile" example:"90.8"`
+}
+
+// ChartStats is the public statistics view of a single chart.
+type ChartStats struct {
+	ChartID      int      `json:"chart_id" example:"1"`
+	Level        float64  `json:"level" example:"15.2"`
+	FittingLevel *float64 `json:"fitting_level" example:"15.4" extensions:"x-nullable=true"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower" example:"15.25" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper" example:"15.55" extensions:"x-nullable=true"`
+	// Fitting is the output of the last fitting run; nil before the chart was
+	// first fitted.
+	Fitting *ChartStatistic `json:"fitting" extensions:"x-nullable=true"`
+	// Distribution is nil when the chart has too few players to publish it.
+	Distribution *ScoreDistribution `json:"distribution" extensions:"x-nullable=true"`
+	// MyRank is the caller's place on the chart (authenticated requests with a
+	// best record only, and only when Distribution is published).
+	MyRank *ChartRank `json:"my_rank,omitempty"`
+}
+
+// ChartScoreCount is the number of players and passes on a chart.
+type ChartScoreCount struct {
+	ChartID     int
+	PlayerCount int
+	PassCount   int
+}
+
+// ChartStatsSummary is one row of the chart statistics overview.
+type ChartStatsSummary struct {
+	ChartID      int        `json:"chart_id" example:"1"`
+	SongID       int        `json:"song_id" example:"1"`
+	WikiID       string     `json:"wiki_id" example:"felys"`
+	Title        string     `json:"title" example:"Felys"`
+	Difficulty   Difficulty `json:"difficulty" example:"massive"`
+	Level        float64    `json:"level" example:"15.2"`
+	FittingLevel *float64   `json:"fitting_level" example:"15.4" extensions:"x-nullable=true"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower" example:"15.25" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper" example:"15.55" extensions:"x-nullable=true"`
+	// SampleCount, EffectiveSampleSize and StdDev come from the last fitting
+	// run; nil before the chart was first fitted.
+	SampleCount         *int     `json:"sample_count" example:"96" extensions:"x-nullable=true"`
+	EffectiveSampleSize *float64 `json:"effective_sample_size" example:"41.3" extensions:"x-nullable=true"`
+	StdDev              *float64 `json:"std_dev" example:"0.21" extensions:"x-nullable=true"`
+	PlayerCount         int      `json:"player_count" example:"120"`
+	// PassRate is nil when the chart has too few players to publish it.
+	PassRate *float64 `json:"pass_rate" example:"0.667" extensions:"x-nullable=true"`
+}
@@ -0,0 +1,92 @@
+package model
+
+import "time"
+
+// MaxVoteDelta bounds a community level vote: players rate how much harder
+// (positive) or easier (negative) a chart feels than its official level.
+// It matches the default fitting.max_deviation so both signals share a scale.
+const MaxVoteDelta = 1.5
+
+// MaxChartTagsPerUser caps how many tags one player may put on a chart.
+const MaxChartTagsPerUser = 5
+
+// ChartVote is one player's opinion of how hard a chart feels relative to its
+// official level. Each player has at most one vote per chart, and only players
+// with a best record on the chart may vote.
+type ChartVote struct {
+	ID        int       `gorm:"primaryKey" json:"-"`
+	ChartID   int       `gorm:"not null;uniqueIndex:idx_chart_vote_user" json:"chart_id"`
+	Username  string    `gorm:"not null;uniqueIndex:idx_chart_vote_user;index" json:"username"`
+	Delta     float64   `gorm:"not null" json:"delta" example:"0.3"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ChartVote) TableName() string {
+	return "chart_votes"
+}
+
+// ChartTag is a descriptive tag (e.g. "stamina", "tech", "sight-read-trap")
+// that a player put on a chart. Tags are normalised to lowercase words joined
+// by hyphens.
+type ChartTag struct {
+	ID        int       `gorm:"primaryKey" json:"-"`
+	ChartID   int       `gorm:"not null;uniqueIndex:idx_chart_tag_user" json:"chart_id"`
+	Username  string    `gorm:"not null;uniqueIndex:idx_chart_tag_user;index" json:"username"`
+	Tag       string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_chart_tag_user;index" json:"tag" example:"stamina"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (ChartTag) TableName() string {
+	return "chart_tags"
+}
+
+// TagCount is how many players put a tag on a chart.
+type TagCount struct {
+	Tag   string `json:"tag" example:"stamina"`
+	Count int    `json:"count" example:"12"`
+}
+
+// ChartVoteStats aggregates the community votes and tags of a chart.
+type ChartVoteStats struct {
+	ChartID int `json:"chart_id"`
+	// VoteCount is the number of level votes.
+	VoteCount int `json:"vote_count" example:"20"`
+	// MeanDelta is the average vote; nil without votes.
+	MeanDelta *float64 `json:"mean_delta" example:"0.25" extensions:"x-nullable=true"`
+	// Tags are sorted by count, most used first.
+	Tags []TagCount `json:"tags"`
+}
+
+// ChartVoteSummary is the community view of a single chart.
+type ChartVoteSummary struct {
+	ChartVoteStats
+	Level        float64  `json:"level" example:"15.2"`
+	FittingLevel *float64 `json:"fitting_level" example:"15.4" extensions:"x-nullable=true"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower" example:"15.25" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper" example:"15.55" extensions:"x-nullable=true"`
+	// CommunityLevel is Level + MeanDelta; nil without votes.
+	CommunityLevel *float64 `json:"community_level" example:"15.45" extensions:"x-nullable=true"`
+	// MyVote and MyTags are the caller's own vote and tags (authenticated requests only).
+	MyVote *float64 `json:"my_vote,omitempty" example:"0.3"`
+	MyTags []string `json:"my_tags,omitempty"`
+}
+
+// TierListEntry is a chart placed in a difficulty tier list.
+type TierListEntry struct {
+	ChartVoteSummary
+	SongID     int        `json:"song_id" example:"1"`
+	WikiID     string     `json:"wiki_id" example:"felys"`
+	Title      string     `json:"title" example:"Felys"`
+	Difficulty Difficulty `json:"difficulty" example:"massive"`
+	Cover      string     `json:"cover" example:"Cover_d3d3d3.jpg"`
+}
+
+// TierListGroup holds the charts of one official level, hardest-feeling first.
+type TierListGroup struct {
+	Level  float64         `json:"level" example:"15.2"`
+	Charts []TierListEntry `json:"charts"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// FittingLease is the lease row that keeps a single fitting instance
+// (cmd/fitting) publishing at a time on databases without advisory locks
+// (SQLite). The holder renews ExpiresAt while it runs; another instance may
+// take the lease over once it has expired. On PostgreSQL a session-level
+// advisory lock is used instead and this table stays empty.
+type FittingLease struct {
+	Name       string    `gorm:"primaryKey;type:varchar(64)" json:"name"`
+	Holder     string    `gorm:"type:varchar(255);not null" json:"holder"`
+	AcquiredAt time.Time `gorm:"not null" json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"paradigm-reboot-prober-go/pkg/rating"
+)
+
+// Kinds of fitting runs.
+const (
+	FittingRunKindRun      = "run"      // a regular calculation pass
+	FittingRunKindRollback = "rollback" // levels restored from an earlier run
+	FittingRunKindShadow   = "shadow"   // levels computed with trial params, never published
+)
+
+// Statuses of fitting runs.
+const (
+	FittingRunStatusRunning   = "running"
+	FittingRunStatusCompleted = "completed"
+	FittingRunStatusFailed    = "failed"
+)
+
+// FittingRun records one execution of the fitting calculator (cmd/fitting):
+// when it ran, with which parameters, and its RunReport counters. Each run
+// also snapshots every chart it processed into fitting_run_charts, so that
+// the drift of a chart's level can be followed and a bad run rolled back.
+type FittingRun struct {
+	ID     int    `gorm:"primaryKey" json:"id"`
+	Kind   string `gorm:"type:varchar(16);not null" json:"kind"`
+	Status string `gorm:"type:varchar(16);not null" json:"status"`
+
+	StartedAt   time.Time  `gorm:"not null;index" json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	DurationMs  int64      `gorm:"not null;default:0" json:"duration_ms"`
+
+	// Params is the JSON-encoded fitting.Params of the run and ConfigHash its
+	// SHA-256, so runs with identical parameters are easy to group.
+	Params     string `gorm:"type:text;not null" json:"params"`
+	ConfigHash string `gorm:"type:varchar(64);not null;index" json:"config_hash"`
+
+	// RollbackOf is the run whose levels a rollback restored.
+	RollbackOf *int `json:"rollback_of,omitempty"`
+
+	// Incremental runs only recompute the charts with new evidence since the
+	// previous run. Watermark is the newest updated_at among the play records
+	// behind best_play_records that the run has seen; the next incremental
+	// run looks for evidence after it.
+	Incremental bool       `gorm:"not null;default:false" json:"incremental"`
+	Watermark   *time.Time `json:"watermark,omitempty"`
+
+	PlayersConsidered int `gorm:"not null;default:0" json:"players_considered"`
+	ChartsTotal       int `gorm:"not null;default:0" json:"charts_total"`
+	ChartsProcessed   int `gorm:"not null;default:0" json:"charts_processed"`
+	ChartsPublished   int `gorm:"not null;default:0" json:"charts_published"`
+	ChartsAbstained   int `gorm:"not null;default:0" json:"charts_abstained"`
+	ChartsEmpty       int `gorm:"not null;default:0" json:"charts_empty"`
+	ChartsSkipped     int `gorm:"not null;default:0" json:"charts_skipped"`
+	PlayersChanged    int `gorm:"not null;default:0" json:"players_changed"`
+	OutliersReported  int `gorm:"not null;default:0" json:"outliers_reported"`
+	ErrorsEncountered int `gorm:"not null;default:0" json:"errors_encountered"`
+
+	// Error is the error that aborted a failed run.
+	Error string `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+}
+
+// TableName specifies the table name for GORM.
+func (FittingRun) TableName() string { return "fitting_runs" }
+
+// FittingRunChart is the state of one chart after a fitting run: the values
+// the run wrote to charts.fitting_level and chart_statistics, and the level
+// that was published before it.
+type FittingRunChart struct {
+	ID      int `gorm:"primaryKey" json:"-"`
+	RunID   int `gorm:"not null;uniqueIndex:idx_fitting_run_chart" json:"run_id"`
+	ChartID int `gorm:"not null;uniqueIndex:idx_fitting_run_chart;index" json:"chart_id"`
+
+	OfficialLevel float64 `gorm:"not null" json:"official_level"`
+	// PreviousLevel is charts.fitting_level before the run.
+	PreviousLevel *float64 `json:"previous_level"`
+	FittingLevel  *float64 `json:"fitting_level"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper"`
+
+	SampleCount         int     `gorm:"not null" json:"sample_count"`
+	EffectiveSampleSize float64 `gorm:"not null" json:"effective_sample_size"`
+	WeightedMean        float64 `gorm:"not null" json:"weighted_mean"`
+	WeightedMedian      float64 `gorm:"not null" json:"weighted_median"`
+	StdDev              float64 `gorm:"not null" json:"std_dev"`
+	MAD                 float64 `gorm:"not null;column:mad" json:"mad"`
+}
+
+// TableName specifies the table name for GORM.
+func (FittingRunChart) TableName() string { return "fitting_run_charts" }
+
+// FittingRunOutlier is one player of the outlier report of a full fitting
+// run: players whose best records keep landing far from the fitted model
+// across many charts, such as possible cheaters or shared accounts. Rank 1
+// is the most consistent outlier. Residuals are the standardised residuals
+// u of the Tukey step, |u| ≥ 1 being trimmed; see fitting.PlayerOutlier.
+type FittingRunOutlier struct {
+	ID       int    `gorm:"primaryKey" json:"-"`
+	RunID    int    `gorm:"not null;uniqueIndex:idx_fitting_run_outlier" json:"run_id"`
+	Rank     int    `gorm:"not null;uniqueIndex:idx_fitting_run_outlier" json:"rank"`
+	Username string `gorm:"type:varchar(255);not null;index" json:"username"`
+
+	Charts  int `gorm:"not null" json:"charts"`  // charts where the player's sample got a residual
+	Trimmed int `gorm:"not null" json:"trimmed"` // of which trimmed
+	// MedianAbsResidual is the median |u| over those charts, the ranking key.
+	MedianAbsResidual float64 `gorm:"not null" json:"median_abs_residual"`
+	// MeanResidual is the mean u: negative when the player scores above what
+	// their skill predicts, positive when below.
+	MeanResidual float64 `gorm:"not null" json:"mean_residual"`
+}
+
+// TableName specifies the table name for GORM.
+func (FittingRunOutlier) TableName() string { return "fitting_run_outliers" }
+
+// FittingPlayerSkill is the skill snapshot of a player as of the latest
+// fitting run: the mean of their top-K best ratings (fitting.PlayerSkill).
+// Incremental runs recompute only the players with new records and read
+// everyone else's skill from here. Like chart_statistics, the table belongs
+// to the fitting service; the server only reads it, to show the player's
+// estimated skill (EstimatedSkill).
+type FittingPlayerSkill struct {
+	Username   string  `gorm:"primaryKey" json:"username"`
+	AvgRating  float64 `gorm:"not null" json:"avg_rating"`
+	NumRecords int     `gorm:"not null" json:"num_records"`
+	// TopK is the number of best ratings AvgRating averages: the smaller of
+	// NumRecords and the skill_top_k of the run.
+	TopK int `gorm:"not null;default:0" json:"top_k"`
+	// UpdatedAt is when the skill was last computed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (FittingPlayerSkill) TableName() string { return "fitting_player_skills" }
+
+// EstimatedSkill is the public view of a FittingPlayerSkill.
+type EstimatedSkill struct {
+	// Skill is the mean rating of the player's top-K best records, in the
+	// float form (e.g. 162.35).
+	Skill       float64   `json:"skill" example:"162.35"`
+	TopK        int       `json:"top_k" example:"50"`
+	RecordCount int       `json:"record_count" example:"312"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
+
+// ToEstimatedSkill converts a FittingPlayerSkill to its public view.
+func (s *FittingPlayerSkill) ToEstimatedSkill() EstimatedSkill {
+	return EstimatedSkill{
+		Skill:       s.AvgRating,
+		TopK:        s.TopK,
+		RecordCount: s.NumRecords,
+		ComputedAt:  s.UpdatedAt,
+	}
+}
+
+// ExpectedScore is the score a player of this skill is expected to reach on
+// the chart: the lowest score whose rating equals the skill, at the fitting
+// level when the chart has one and the official level otherwise. Nil when
+// no score reaches the skill.
+func (s EstimatedSkill) ExpectedScore(chart ChartInfoSimple) *int {
+	level := chart.Level
+	if chart.FittingLevel != nil {
+		level = *chart.FittingLevel
+	}
+	score, ok := rating.InverseScore(level, int(math.Round(s.Skill*100)))
+	if !ok {
+		return nil
+	}
+	return &score
+}
@@ -48,6 +48,9 @@ type PlayRecordInfo struct {
 	Score      int             `json:"score"`
 	Rating     int             `json:"rating"`
 	Chart      ChartInfoSimple `json:"chart"`
+	// ExpectedScore is the score the player's estimated skill predicts on
+	// the chart (b50 scope only, when the skill is known).
+	ExpectedScore *int `json:"expected_score,omitempty" example:"1004200"`
 }
 
 // ToPlayRecordInfo converts a PlayRecord (with preloaded Chart.Song) to PlayRecordInfo
@@ -60,10 +63,13 @@ func ToPlayRecordInfo(record *PlayRecord) PlayRecordInfo {
 	}
 	if record.Chart != nil {
 		info.Chart = ChartInfoSimple{
-			ID:           record.Chart.ID,
-			Difficulty:   record.Chart.Difficulty,
-			Level:        record.Chart.Level,
-			FittingLevel: record.Chart.FittingLevel,
+			ID:                record.Chart.ID,
+			Difficulty:        record.Chart.Difficulty,
+			Level:             record.Chart.Level,
+			FittingLevel:      record.Chart.FittingLevel,
+			FittingLevelLower: record.Chart.FittingLevelLower,
+			FittingLevelUpper: record.Chart.FittingLevelUpper,
+			Retired:           record.Chart.DeletedAt.Valid,
 		}
 		if record.Chart.Song != nil {
 			effective := record.Chart.Song.WithOverride(record.Chart.SongBaseOverride)
@@ -73,6 +79,7 @@ func ToPlayRecordInfo(record *PlayRecord) PlayRecordInfo {
 			info.Chart.B15 = effective.B15
 			info.Chart.SongID = record.Chart.Song.ID
 			info.Chart.Cover = effective.Cover
+			info.Chart.Retired = info.Chart.Retired || record.Chart.Song.DeletedAt.Valid
 		}
 	}
 	return info
@@ -91,6 +98,9 @@ type PlayRecordResponse struct {
 	Nickname string           `json:"nickname"`
 	Total    int              `json:"total"`
 	Records  []PlayRecordInfo `json:"records"`
+	// EstimatedSkill is the player's skill as of the last fitting run (b50
+	// scope only; absent before the player was first fitted).
+	EstimatedSkill *EstimatedSkill `json:"estimated_skill,omitempty"`
 }
 
 // RecordFilter holds optional filter parameters for record queries
@@ -99,9 +109,12 @@ type RecordFilter struct {
 	MaxLevel     *float64
 	Difficulties []Difficulty
 	B15          *bool
+	// SeasonID, when set, decides which songs are new (B15) by membership in
+	// that season instead of the active one.
+	SeasonID *int
 }
 
 // IsEmpty returns true if the filter has no active conditions
 func (f RecordFilter) IsEmpty() bool {
-	return f.MinLevel == nil && f.MaxLevel == nil && len(f.Difficulties) == 0 && f.B15 == nil
+	return f.MinLevel == nil && f.MaxLevel == nil && len(f.Difficulties) == 0 && f.B15 == nil && f.SeasonID == nil
 }
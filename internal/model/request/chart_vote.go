@@ -0,0 +1,13 @@
+package request
+
+// ChartVoteRequest represents a player's level vote on a chart
+type ChartVoteRequest struct {
+	// Delta is how much harder (positive) or easier (negative) the chart feels
+	// than its official level, within ±model.MaxVoteDelta.
+	Delta *float64 `json:"delta" binding:"required,min=-1.5,max=1.5" example:"0.3"`
+}
+
+// ChartTagsRequest replaces a player's tags on a chart; an empty list removes them
+type ChartTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=5,dive,required,max=32" example:"stamina,tech"`
+}
@@ -1,6 +1,9 @@
 package request
 
-import "paradigm-reboot-prober-go/internal/model"
+import (
+	"paradigm-reboot-prober-go/internal/model"
+	"time"
+)
 
 // CreateSongRequest represents the request to create a new song
 type CreateSongRequest struct {
@@ -14,3 +17,32 @@ type UpdateSongRequest struct {
 	model.SongBase
 	Charts []model.ChartInput `json:"charts" binding:"required,min=1,dive"`
 }
+
+// CreateSongAliasRequest represents the request to add an alias to a song
+type CreateSongAliasRequest struct {
+	Alias string `json:"alias" binding:"required,max=64" example:"nickname"`
+}
+
+// CreateSeasonRequest represents the request to create a season
+type CreateSeasonRequest struct {
+	Name     string     `json:"name" binding:"required,max=64" example:"2025 Summer"`
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// SongIDs are the songs that are new in this season.
+	SongIDs []int `json:"song_ids"`
+	// FromCurrent adds every song currently marked b15 to the season, which is
+	// how the first season is seeded from the legacy per-song flags.
+	FromCurrent bool `json:"from_current" example:"false"`
+}
+
+// UpdateSeasonRequest represents the request to rename or reschedule a season
+type UpdateSeasonRequest struct {
+	Name     string     `json:"name" binding:"required,max=64" example:"2025 Summer"`
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// SetSeasonSongsRequest replaces the member songs of a season
+type SetSeasonSongsRequest struct {
+	SongIDs []int `json:"song_ids" binding:"required"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Season is a period of the game during which a set of songs counts as "new"
+// (the B15 part of B50). Seasons may overlap; at any time the season with the
+// latest StartsAt that has started and not yet ended is the active one.
+//
+// Once at least one season exists, SongBase.B15 mirrors membership in the
+// active season and is kept in sync automatically, including when a season
+// starts or ends. Before the first season is created, B15 is edited directly.
+type Season struct {
+	ID       int       `gorm:"primaryKey" json:"id"`
+	Name     string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"name" example:"2025 Summer"`
+	StartsAt time.Time `gorm:"not null;index" json:"starts_at"`
+	// EndsAt is nil for an open-ended season.
+	EndsAt    *time.Time `json:"ends_at" extensions:"x-nullable=true"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Season) TableName() string {
+	return "seasons"
+}
+
+// ActiveAt reports whether the season covers t.
+func (s Season) ActiveAt(t time.Time) bool {
+	return !s.StartsAt.After(t) && (s.EndsAt == nil || s.EndsAt.After(t))
+}
+
+// SeasonSong records that a song is new in a season.
+type SeasonSong struct {
+	SeasonID int `gorm:"primaryKey;autoIncrement:false"`
+	SongID   int `gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+// TableName specifies the table name for GORM
+func (SeasonSong) TableName() string {
+	return "season_songs"
+}
+
+// SeasonInfo is a season together with its member songs.
+type SeasonInfo struct {
+	Season
+	// Active is true for the season that currently decides B15.
+	Active  bool  `json:"active"`
+	SongIDs []int `json:"song_ids"`
+}
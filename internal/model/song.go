@@ -1,18 +1,22 @@
 package model
 
-// SongBase represents the basic information of a song
+// SongBase represents the basic information of a song.
+//
+// B15 marks songs that are new in the current season. Once seasons exist it
+// mirrors membership in the active season (see Season), and setting it adds the
+// song to or removes it from the active season.
 type SongBase struct {
-	WikiID      string `gorm:"unique;not null" json:"wiki_id" binding:"required" example:"w123"`
-	Title       string `gorm:"not null" json:"title" binding:"required" example:"Song Title"`
-	Artist      string `gorm:"not null" json:"artist" binding:"required" example:"Artist Name"`
-	Genre       string `gorm:"not null" json:"genre" example:"Pop"`
-	Cover       string `gorm:"not null" json:"cover" example:"Cover_d3d3d3.jpg"`
-	Illustrator string `gorm:"not null" json:"illustrator" example:"Artist"`
-	Version     string `gorm:"not null" json:"version" example:"1.0.0"`
-	B15         bool   `gorm:"not null;index" json:"b15" example:"false"`
-	Album       string `gorm:"not null" json:"album" example:"First Album"`
-	BPM         string `gorm:"not null" json:"bpm" example:"180"`
-	Length      string `gorm:"not null" json:"length" example:"2:30"`
+	WikiID      string `gorm:"unique;not null" json:"wiki_id" yaml:"wiki_id" binding:"required" example:"w123"`
+	Title       string `gorm:"not null" json:"title" yaml:"title" binding:"required" example:"Song Title"`
+	Artist      string `gorm:"not null" json:"artist" yaml:"artist" binding:"required" example:"Artist Name"`
+	Genre       string `gorm:"not null" json:"genre" yaml:"genre" example:"Pop"`
+	Cover       string `gorm:"not null" json:"cover" yaml:"cover" example:"Cover_d3d3d3.jpg"`
+	Illustrator string `gorm:"not null" json:"illustrator" yaml:"illustrator" example:"Artist"`
+	Version     string `gorm:"not null" json:"version" yaml:"version" example:"1.0.0"`
+	B15         bool   `gorm:"not null;index" json:"b15" yaml:"b15" example:"false"`
+	Album       string `gorm:"not null" json:"album" yaml:"album" example:"First Album"`
+	BPM         string `gorm:"not null" json:"bpm" yaml:"bpm" example:"180"`
+	Length      string `gorm:"not null" json:"length" yaml:"length" example:"2:30"`
 }
 
 // Song represents the song entity
@@ -66,10 +70,10 @@ func (d Difficulty) Order() int {
 // SongBaseOverride holds optional per-chart overrides for SongBase fields.
 // A nil pointer means "use the song's original value".
 type SongBaseOverride struct {
-	OverrideTitle   *string `gorm:"column:override_title"   json:"override_title,omitempty"   example:"Alt Title"`
-	OverrideArtist  *string `gorm:"column:override_artist"  json:"override_artist,omitempty"  example:"Alt Artist"`
-	OverrideVersion *string `gorm:"column:override_version" json:"override_version,omitempty" example:"2.0.0"`
-	OverrideCover   *string `gorm:"column:override_cover"   json:"override_cover,omitempty"   example:"Cover_alt.jpg"`
+	OverrideTitle   *string `gorm:"column:override_title"   json:"override_title,omitempty"   yaml:"override_title,omitempty"   example:"Alt Title"`
+	OverrideArtist  *string `gorm:"column:override_artist"  json:"override_artist,omitempty"  yaml:"override_artist,omitempty"  example:"Alt Artist"`
+	OverrideVersion *string `gorm:"column:override_version" json:"override_version,omitempty" yaml:"override_version,omitempty" example:"2.0.0"`
+	OverrideCover   *string `gorm:"column:override_cover"   json:"override_cover,omitempty"   yaml:"override_cover,omitempty"   example:"Cover_alt.jpg"`
 }
 
 // WithOverride returns a copy of SongBase with non-nil override fields applied.
@@ -97,8 +101,11 @@ type Chart struct {
 	Difficulty   Difficulty `gorm:"type:varchar(20);not null;uniqueIndex:idx_song_difficulty,where:deleted_at IS NULL" json:"difficulty" example:"massive"`
 	Level        float64    `gorm:"not null" json:"level"`
 	FittingLevel *float64   `gorm:"column:fitting_level" json:"fitting_level" extensions:"x-nullable=true"`
-	LevelDesign  *string    `gorm:"column:level_design" json:"level_design"`
-	Notes        int        `gorm:"not null" json:"notes"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `gorm:"column:fitting_level_lower" json:"fitting_level_lower" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `gorm:"column:fitting_level_upper" json:"fitting_level_upper" extensions:"x-nullable=true"`
+	LevelDesign       *string  `gorm:"column:level_design" json:"level_design"`
+	Notes             int      `gorm:"not null" json:"notes"`
 	SongBaseOverride
 	Song *Song `gorm:"foreignKey:SongID;references:ID" json:"song,omitempty"`
 }
@@ -110,13 +117,57 @@ func (Chart) TableName() string {
 
 // ChartInput represents the details of a chart for create/update requests
 type ChartInput struct {
-	Difficulty  Difficulty `json:"difficulty" binding:"required,oneof=detected invaded massive reboot" example:"massive"`
-	Level       float64    `json:"level" binding:"required,gt=0" example:"14.5"`
-	LevelDesign string     `json:"level_design" example:"Designer"`
-	Notes       int        `json:"notes" binding:"required,min=0" example:"1000"`
+	Difficulty       Difficulty `json:"difficulty" yaml:"difficulty" binding:"required,oneof=detected invaded massive reboot" example:"massive"`
+	Level            float64    `json:"level" yaml:"level" binding:"required,gt=0" example:"14.5"`
+	LevelDesign      string     `json:"level_design" yaml:"level_design" example:"Designer"`
+	Notes            int        `json:"notes" yaml:"notes" binding:"required,min=0" example:"1000"`
+	SongBaseOverride `yaml:",inline"`
+}
+
+// ChartPatch holds the fields of a partial chart update. Nil fields are left
+// as they are; an empty override field clears the override.
+type ChartPatch struct {
+	Level       *float64 `json:"level" binding:"omitempty,gt=0" example:"14.5"`
+	Notes       *int     `json:"notes" binding:"omitempty,min=0" example:"1000"`
+	LevelDesign *string  `json:"level_design" example:"Designer"`
 	SongBaseOverride
 }
 
+// IsEmpty returns true if the patch changes nothing
+func (p ChartPatch) IsEmpty() bool {
+	return p.Level == nil && p.Notes == nil && p.LevelDesign == nil && p.OverrideTitle == nil &&
+		p.OverrideArtist == nil && p.OverrideVersion == nil && p.OverrideCover == nil
+}
+
+// Apply returns a copy of chart with the patch applied
+func (p ChartPatch) Apply(chart Chart) Chart {
+	if p.Level != nil {
+		chart.Level = *p.Level
+	}
+	if p.Notes != nil {
+		chart.Notes = *p.Notes
+	}
+	if p.LevelDesign != nil {
+		chart.LevelDesign = p.LevelDesign
+	}
+	chart.OverrideTitle = patchOverride(chart.OverrideTitle, p.OverrideTitle)
+	chart.OverrideArtist = patchOverride(chart.OverrideArtist, p.OverrideArtist)
+	chart.OverrideVersion = patchOverride(chart.OverrideVersion, p.OverrideVersion)
+	chart.OverrideCover = patchOverride(chart.OverrideCover, p.OverrideCover)
+	return chart
+}
+
+func patchOverride(current, patch *string) *string {
+	switch {
+	case patch == nil:
+		return current
+	case *patch == "":
+		return nil
+	default:
+		return patch
+	}
+}
+
 // ChartInfo represents the detailed information of a song's chart (flattened view)
 type ChartInfo struct {
 	SongBase
@@ -125,8 +176,27 @@ type ChartInfo struct {
 	Difficulty   Difficulty `json:"difficulty" example:"massive"`
 	Level        float64    `json:"level" example:"13.2"`
 	FittingLevel *float64   `json:"fitting_level" example:"13.4" extensions:"x-nullable=true"`
-	LevelDesign  *string    `json:"level_design" example:"Designer"`
-	Notes        int        `json:"notes" example:"850"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower" example:"13.25" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper" example:"13.55" extensions:"x-nullable=true"`
+	LevelDesign       *string  `json:"level_design" example:"Designer"`
+	Notes             int      `json:"notes" example:"850"`
+}
+
+// SongChanges lists the catalog rows that changed since a cursor
+type SongChanges struct {
+	// Cursor is passed as since on the next request
+	Cursor string `json:"cursor" example:"1767225600000000"`
+	// Created holds the rows (as in GET /songs) of charts added since the cursor
+	Created []ChartInfo `json:"created"`
+	// Updated holds the current rows of charts whose chart or song changed,
+	// including fitting_level updates and restored songs and charts
+	Updated []ChartInfo `json:"updated"`
+	// RetiredSongIDs lists songs retired since the cursor
+	RetiredSongIDs []int `json:"retired_song_ids"`
+	// RetiredChartIDs lists charts retired since the cursor, including the
+	// charts of retired songs
+	RetiredChartIDs []int `json:"retired_chart_ids"`
 }
 
 // ChartInfoSimple represents a simplified version of chart information
@@ -141,6 +211,12 @@ type ChartInfoSimple struct {
 	Level        float64    `json:"level"`
 	Cover        string     `json:"cover"`
 	FittingLevel *float64   `json:"fitting_level" extensions:"x-nullable=true"`
+	// Confidence interval of FittingLevel (see ChartStatistic).
+	FittingLevelLower *float64 `json:"fitting_level_lower" extensions:"x-nullable=true"`
+	FittingLevelUpper *float64 `json:"fitting_level_upper" extensions:"x-nullable=true"`
+	// Retired is true when the chart or its song has been retired by an admin.
+	// Retired charts no longer count towards B50 but remain in record history.
+	Retired bool `json:"retired"`
 }
 
 // ChartWithScore represents a chart with the user's best score
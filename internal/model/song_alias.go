@@ -0,0 +1,32 @@
+package model
+
+// SongAlias is an admin-managed community nickname for a song (e.g. an
+// abbreviation players use in chat). Aliases participate in song search and
+// are accepted wherever a song_addr / chart_addr is resolved.
+type SongAlias struct {
+	BaseModel
+	ID     int    `gorm:"primaryKey" json:"id"`
+	SongID int    `gorm:"not null;index" json:"song_id" example:"1"`
+	Alias  string `gorm:"not null" json:"alias" example:"nickname"`
+	// Normalized is the fuzzy-normalised form of Alias (see pkg/fuzzy.Normalize).
+	// It is unique among live aliases so that an alias resolves to exactly one song.
+	Normalized string `gorm:"not null;uniqueIndex:idx_song_alias_normalized,where:deleted_at IS NULL" json:"-"`
+}
+
+// TableName specifies the table name for GORM
+func (SongAlias) TableName() string {
+	return "song_aliases"
+}
+
+// SongSearchResult is a single ranked hit returned by the song search API.
+type SongSearchResult struct {
+	SongBase
+	SongID int `json:"song_id" example:"1"`
+	// Score is the match quality in (0, 1]; results are sorted by it descending.
+	Score float64 `json:"score" example:"0.95"`
+	// MatchedField is the field that produced the best match:
+	// title, override_title, artist, album or alias.
+	MatchedField string `json:"matched_field" example:"alias"`
+	// MatchedText is the original (un-normalised) text that matched.
+	MatchedText string `json:"matched_text" example:"nickname"`
+}
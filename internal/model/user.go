@@ -28,6 +28,13 @@ func (User) TableName() string {
 	return "prober_users"
 }
 
+// UserProfile is the current user's profile with their estimated skill,
+// which is nil before the fitting service first computed it.
+type UserProfile struct {
+	User
+	EstimatedSkill *EstimatedSkill `json:"estimated_skill" extensions:"x-nullable=true"`
+}
+
 // UserPublic represents user information safe for public responses (e.g., registration)
 type UserPublic struct {
 	ID             int     `json:"id" example:"1"`
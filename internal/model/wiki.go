@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// WikiSong is the metadata the community wiki publishes for a song. Empty
+// fields mean the wiki does not know the value.
+type WikiSong struct {
+	SongBase `yaml:",inline"`
+	Charts   []WikiChart `json:"charts" yaml:"charts"`
+}
+
+// WikiChart is the wiki's metadata for a single chart of a song.
+type WikiChart struct {
+	Difficulty  Difficulty `json:"difficulty" yaml:"difficulty" example:"massive"`
+	Level       float64    `json:"level" yaml:"level" example:"14.5"`
+	Notes       int        `json:"notes" yaml:"notes" example:"1000"`
+	LevelDesign string     `json:"level_design" yaml:"level_design" example:"Designer"`
+	// Cover is set when the chart uses different artwork than the song.
+	Cover string `json:"cover,omitempty" yaml:"cover,omitempty" example:"Cover_alt.jpg"`
+}
+
+// Review states of a SongMetadataProposal.
+const (
+	ProposalStatusPending  = "pending"
+	ProposalStatusAccepted = "accepted"
+	ProposalStatusRejected = "rejected"
+)
+
+// Fields a wiki sync may propose. Song-level fields use the SongBase json
+// names; ProposalFieldChartCover targets a chart's override_cover.
+const (
+	ProposalFieldArtist      = "artist"
+	ProposalFieldBPM         = "bpm"
+	ProposalFieldIllustrator = "illustrator"
+	ProposalFieldCover       = "cover"
+	ProposalFieldChartCover  = "override_cover"
+)
+
+// SongMetadataProposal is a suggested change to a song's metadata, collected
+// from an external source (the wiki) and applied only once an admin accepts it.
+type SongMetadataProposal struct {
+	ID     int `gorm:"primaryKey" json:"id"`
+	SongID int `gorm:"not null;index" json:"song_id" example:"1"`
+	// ChartID and Difficulty are set for chart-level fields (override_cover).
+	ChartID    *int       `json:"chart_id" example:"10" extensions:"x-nullable=true"`
+	Difficulty Difficulty `gorm:"type:varchar(20)" json:"difficulty" example:"massive"`
+	Field      string     `gorm:"type:varchar(32);not null" json:"field" example:"artist"`
+	// CurrentValue is the value at the time of the sync (nil = unset override).
+	// Accepting fails if the song has been edited since.
+	CurrentValue  *string `json:"current_value" example:"Old Artist" extensions:"x-nullable=true"`
+	ProposedValue string  `gorm:"not null" json:"proposed_value" example:"New Artist"`
+	Source        string  `gorm:"type:varchar(16);not null" json:"source" example:"wiki"`
+	Status        string  `gorm:"type:varchar(16);not null;index" json:"status" example:"pending"`
+	// ReviewedBy / ReviewedAt are set once the proposal is accepted or rejected.
+	ReviewedBy *string    `json:"reviewed_by" example:"admin" extensions:"x-nullable=true"`
+	ReviewedAt *time.Time `json:"reviewed_at" extensions:"x-nullable=true"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SongMetadataProposal) TableName() string {
+	return "song_metadata_proposals"
+}
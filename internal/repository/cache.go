@@ -1,13 +1,22 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/metrics"
 	"paradigm-reboot-prober-go/internal/model"
+	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
 // Default cache TTLs for each repository domain.
@@ -15,19 +24,366 @@ const (
 	SongCacheTTL   = 10 * time.Minute
 	UserCacheTTL   = 5 * time.Minute
 	RecordCacheTTL = 5 * time.Minute
+	// StatsCacheTTL bounds how stale chart statistics may be. They are not
+	// invalidated on uploads, only refreshed when the entries expire.
+	StatsCacheTTL = 10 * time.Minute
 )
 
-// repoCache is a type alias for the concrete cache type used across all repositories.
-type repoCache = ttlcache.Cache[string, any]
+// Cache is the store behind every repository cache: an in-process ttlcache
+// by default, or a server speaking the Redis protocol when several server
+// replicas must see each other's invalidations (see InitCache).
+//
+// Entries are never scanned. Each repository cache instead keys its entries
+// by generation counters, which it bumps to drop a whole group of entries
+// at once (see repoCache).
+type Cache interface {
+	// Get stores the value cached under key in dst, a pointer to the type
+	// the value was Set with, and reports whether the key was found and
+	// whether its ttl has passed, leaving it in its stale window.
+	Get(key string, dst any) (found, stale bool, err error)
+	// Set caches value under key: fresh for ttl, then stale for stale more.
+	Set(key string, value any, ttl, stale time.Duration) error
+	// Has reports whether key is cached, without reading the value.
+	Has(key string) (bool, error)
+	// Delete drops key.
+	Delete(key string) error
+	// Counters returns the current value of each named generation counter;
+	// a counter never bumped is 0. A backend may drop a counter, which then
+	// reads 0 again, once every entry written before its last bump has
+	// expired.
+	Counters(names ...string) ([]int64, error)
+	// Bump moves the named generation counter to a value it never had, so
+	// no entry still cached under an earlier generation becomes reachable.
+	Bump(name string) error
+}
+
+// cacheBackend is the Cache shared by the repositories created after
+// InitCache; nil gives each repository its own in-process cache.
+var cacheBackend Cache
+
+// InitCache selects the repository cache backend from config.GlobalConfig.Cache.
+// Call it once at startup, before the repositories are created.
+//
+// With the "memory" backend each repository keeps its own ttlcache; on
+// PostgreSQL, invalidations are then broadcast to the other replicas over
+// cache.notify_channel (see cacheNotifier). Other databases keep them local.
+func InitCache(db *gorm.DB) error {
+	cfg := config.GlobalConfig.Cache
+	switch cfg.Backend {
+	case "", "memory":
+		cacheBackend = nil
+		if cfg.NotifyChannel != "" && db.Dialector.Name() == "postgres" {
+			cacheNotify = newCacheNotifier(&pgNotifyTransport{db: db, channel: cfg.NotifyChannel})
+			cacheNotify.start()
+		}
+		return nil
+	case "redis":
+		c := NewRedisCache(RedisOptions{
+			Addr:     cfg.Addr,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+			Prefix:   cfg.Prefix,
+			Timeout:  config.CacheTimeoutDuration,
+		})
+		if err := c.Ping(); err != nil {
+			_ = c.Close()
+			return fmt.Errorf("connect to cache at %s: %w", cfg.Addr, err)
+		}
+		cacheBackend = c
+		return nil
+	default:
+		return fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
+
+// CloseCache stops the invalidation listener and releases the connections
+// of the shared cache backend, if any.
+func CloseCache() error {
+	if cacheNotify != nil {
+		cacheNotify.stop()
+	}
+	if c, ok := cacheBackend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// repoCache is the cache of one repository: a namespace of the backend
+// whose entries are fresh for ttl, then served stale for another stale
+// while they are refreshed (see cachedLoad).
+//
+// Every stored key carries two generations: the namespace's, bumped by
+// DeleteAll, and that of its scope — the key up to its first ':', which is
+// the username for record keys — bumped by Invalidate. Bumping a counter
+// makes the entries written under the old generation unreachable; they are
+// left to expire. Backend errors are logged and read as misses.
+type repoCache struct {
+	backend   Cache
+	namespace string
+	ttl       time.Duration
+	stale     time.Duration
+	notifier  *cacheNotifier // broadcasts DeleteAll and Invalidate; nil keeps them local
+
+	flights    singleflight.Group // loads of missing entries, by stored key
+	refreshing sync.Map           // stored keys of the stale entries being refreshed
+}
+
+// newRepoCache creates the cache of a repository on the shared backend, or,
+// without one, on a new in-process ttlcache.
+func newRepoCache(namespace string, defaultTTL time.Duration) *repoCache {
+	backend := cacheBackend
+	if backend == nil {
+		backend = newMemoryCache(defaultTTL, config.CacheStaleDuration)
+	}
+	c := &repoCache{
+		backend:   backend,
+		namespace: namespace,
+		ttl:       defaultTTL,
+		stale:     config.CacheStaleDuration,
+		notifier:  cacheNotify,
+	}
+	if c.notifier != nil {
+		c.notifier.register(c)
+	}
+	return c
+}
+
+// storedKey returns the backend key of key under the current generations.
+func (c *repoCache) storedKey(key string) (string, error) {
+	scope, rest, _ := strings.Cut(key, ":")
+	gens, err := c.backend.Counters(c.namespace, c.namespace+":"+scope)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s@%d:%s@%d:%s", c.namespace, gens[0], scope, gens[1], rest), nil
+}
+
+// cachedLoad returns the value cached under key in c, calling load on a
+// miss; load also reports whether its result may be cached, so that e.g.
+// a missing user is not. A nil c always loads, with ctx.
+//
+// Concurrent misses of a key share a single load. An entry past its TTL
+// but within the stale-while-revalidate window is returned as is while a
+// single background load refreshes it; invalidated entries are never
+// served stale, since invalidation changes their stored key.
+//
+// A shared load keeps the values of ctx but not its cancellation, so that
+// the request that started it going away does not fail the others; each
+// caller still stops waiting when its own ctx ends.
+//
+// The value may be shared with other callers: copy it before handing out
+// anything the caller could modify.
+func cachedLoad[T any](ctx context.Context, c *repoCache, key string, load func(ctx context.Context) (T, bool, error)) (T, error) {
+	if c == nil {
+		v, _, err := load(ctx)
+		return v, err
+	}
+	stored, err := c.storedKey(key)
+	if err != nil {
+		slog.WarnContext(ctx, "cache get failed", "namespace", c.namespace, "key", key, "error", err)
+		metrics.ObserveCacheRequest(c.namespace, metrics.CacheMiss)
+		v, _, err := load(ctx)
+		return v, err
+	}
+	loadCtx := context.WithoutCancel(ctx)
+	fill := func() (any, error) {
+		v, cacheable, err := load(loadCtx)
+		if err == nil && cacheable {
+			if err := c.backend.Set(stored, v, c.ttl, c.stale); err != nil {
+				slog.WarnContext(loadCtx, "cache set failed", "namespace", c.namespace, "key", key, "error", err)
+			}
+		}
+		return v, err
+	}
+
+	var cached T
+	found, stale, err := c.backend.Get(stored, &cached)
+	if err != nil {
+		slog.WarnContext(ctx, "cache get failed", "namespace", c.namespace, "key", key, "error", err)
+	}
+	switch {
+	case found && !stale:
+		metrics.ObserveCacheRequest(c.namespace, metrics.CacheHit)
+		return cached, nil
+	case found:
+		metrics.ObserveCacheRequest(c.namespace, metrics.CacheStale)
+		if _, busy := c.refreshing.LoadOrStore(stored, struct{}{}); !busy {
+			go func() {
+				defer c.refreshing.Delete(stored)
+				if _, err, _ := c.flights.Do(stored, fill); err != nil {
+					slog.WarnContext(loadCtx, "cache refresh failed", "namespace", c.namespace, "key", key, "error", err)
+				}
+			}()
+		}
+		return cached, nil
+	}
+
+	loaded := false
+	ch := c.flights.DoChan(stored, func() (any, error) {
+		loaded = true
+		return fill()
+	})
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if loaded {
+			metrics.ObserveCacheRequest(c.namespace, metrics.CacheMiss)
+		} else {
+			metrics.ObserveCacheRequest(c.namespace, metrics.CacheCoalesced)
+		}
+		result, _ := res.Val.(T)
+		return result, res.Err
+	}
+}
+
+// Has reports whether key is cached.
+func (c *repoCache) Has(key string) bool {
+	stored, err := c.storedKey(key)
+	if err == nil {
+		var ok bool
+		if ok, err = c.backend.Has(stored); err == nil {
+			return ok
+		}
+	}
+	slog.Warn("cache lookup failed", "namespace", c.namespace, "key", key, "error", err)
+	return false
+}
+
+// Delete drops key, on every replica.
+func (c *repoCache) Delete(key string) {
+	c.deleteLocal(key)
+	if c.notifier != nil {
+		c.notifier.publish(cacheEvent{Namespace: c.namespace, Key: key})
+	}
+}
+
+// deleteLocal is Delete without the broadcast.
+func (c *repoCache) deleteLocal(key string) {
+	stored, err := c.storedKey(key)
+	if err == nil {
+		err = c.backend.Delete(stored)
+	}
+	if err != nil {
+		slog.Error("cache delete failed", "namespace", c.namespace, "key", key, "error", err)
+	}
+}
+
+// DeleteAll drops every entry of the repository, on every replica.
+func (c *repoCache) DeleteAll() {
+	c.deleteAllLocal()
+	if c.notifier != nil {
+		c.notifier.publish(cacheEvent{Namespace: c.namespace})
+	}
+}
+
+// deleteAllLocal is DeleteAll without the broadcast.
+func (c *repoCache) deleteAllLocal() {
+	if err := c.backend.Bump(c.namespace); err != nil {
+		slog.Error("cache flush failed", "namespace", c.namespace, "error", err)
+	}
+}
+
+// Invalidate drops every entry whose key starts with scope+":", e.g. all
+// the cached records of a user, on every replica.
+func (c *repoCache) Invalidate(scope string) {
+	c.invalidateLocal(scope)
+	if c.notifier != nil {
+		c.notifier.publish(cacheEvent{Namespace: c.namespace, Scope: scope})
+	}
+}
+
+// invalidateLocal is Invalidate without the broadcast.
+func (c *repoCache) invalidateLocal(scope string) {
+	if err := c.backend.Bump(c.namespace + ":" + scope); err != nil {
+		slog.Error("cache invalidation failed", "namespace", c.namespace, "scope", scope, "error", err)
+	}
+}
+
+// memoryCache is the in-process Cache: values are kept as they are, so
+// repositories copy what they read before handing it out.
+//
+// A generation counter is dropped ttl+stale after its last bump, when the
+// entries written under its older generations have all expired, so that
+// counters do not pile up for every user who ever uploaded. Entries written
+// under its last generation may outlive it, so bumps draw from a sequence
+// shared by all counters rather than restarting at 1.
+type memoryCache struct {
+	items *ttlcache.Cache[string, memoryEntry]
 
-// newRepoCache creates a new cache instance with the given default TTL
-// and starts the automatic expired-item cleanup goroutine.
-func newRepoCache(defaultTTL time.Duration) *repoCache {
-	c := ttlcache.New[string, any](
-		ttlcache.WithTTL[string, any](defaultTTL),
+	mu         sync.Mutex
+	counters   *ttlcache.Cache[string, int64]
+	generation int64 // last generation handed out by Bump
+}
+
+// newMemoryCache creates a memoryCache for entries fresh for defaultTTL and
+// stale for stale more, and starts the automatic expired-item cleanup
+// goroutines.
+func newMemoryCache(defaultTTL, stale time.Duration) *memoryCache {
+	c := ttlcache.New[string, memoryEntry](
+		ttlcache.WithTTL[string, memoryEntry](defaultTTL),
+	)
+	counters := ttlcache.New[string, int64](
+		ttlcache.WithTTL[string, int64](defaultTTL+stale),
+		ttlcache.WithDisableTouchOnHit[string, int64](),
 	)
 	go c.Start() // non-blocking; runs until Stop() is called
-	return c
+	go counters.Start()
+	return &memoryCache{items: c, counters: counters}
+}
+
+// memoryEntry is a value of a memoryCache, stale from staleAt on.
+type memoryEntry struct {
+	value   any
+	staleAt time.Time
+}
+
+func (c *memoryCache) Get(key string, dst any) (bool, bool, error) {
+	item := c.items.Get(key)
+	if item == nil {
+		return false, false, nil
+	}
+	entry := item.Value()
+	out := reflect.ValueOf(dst).Elem()
+	if entry.value != nil {
+		out.Set(reflect.ValueOf(entry.value))
+	} else {
+		out.SetZero()
+	}
+	return true, !time.Now().Before(entry.staleAt), nil
+}
+
+func (c *memoryCache) Set(key string, value any, ttl, stale time.Duration) error {
+	c.items.Set(key, memoryEntry{value: value, staleAt: time.Now().Add(ttl)}, ttl+stale)
+	return nil
+}
+
+func (c *memoryCache) Has(key string) (bool, error) { return c.items.Has(key), nil }
+
+func (c *memoryCache) Delete(key string) error {
+	c.items.Delete(key)
+	return nil
+}
+
+func (c *memoryCache) Counters(names ...string) ([]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]int64, len(names))
+	for i, name := range names {
+		if item := c.counters.Get(name); item != nil {
+			out[i] = item.Value()
+		}
+	}
+	return out, nil
+}
+
+func (c *memoryCache) Bump(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.counters.Set(name, c.generation, ttlcache.DefaultTTL)
+	return nil
 }
 
 // b50CacheEntry wraps the two-slice return value of GetBest50Records
@@ -37,20 +393,6 @@ type b50CacheEntry struct {
 	B15 []model.PlayRecord
 }
 
-// invalidateByPrefix collects all keys that start with prefix and deletes them.
-func invalidateByPrefix(c *repoCache, prefix string) {
-	var keys []string
-	c.Range(func(item *ttlcache.Item[string, any]) bool {
-		if strings.HasPrefix(item.Key(), prefix) {
-			keys = append(keys, item.Key())
-		}
-		return true
-	})
-	for _, k := range keys {
-		c.Delete(k)
-	}
-}
-
 // ---------------------------------------------------------------------------
 // Cache key builders — centralised so patterns stay consistent and typo-free.
 // ---------------------------------------------------------------------------
@@ -70,6 +412,11 @@ func chartIDCacheKey(chartID int) string {
 func chartWikiDiffCacheKey(wikiID string, diff model.Difficulty) string {
 	return fmt.Sprintf("chart:wiki_diff:%s:%s", wikiID, diff)
 }
+func allAliasesCacheKey() string  { return "all_aliases" }
+func searchIndexCacheKey() string { return "search_index" }
+func aliasCacheKey(normalized string) string {
+	return "alias:" + normalized
+}
 
 // Record keys (all prefixed with username for per-user invalidation)
 func b50CacheKey(username string, underflow int, filter model.RecordFilter) string {
@@ -85,6 +432,19 @@ func allChartsCacheKey(username string, filter model.RecordFilter) string {
 	return fmt.Sprintf("%s:all_charts:%s", username, filterCacheKey(filter))
 }
 
+// Stats keys
+func chartStatisticCacheKey(chartID int) string {
+	return fmt.Sprintf("stats:fitting:%d", chartID)
+}
+func allChartStatisticsCacheKey() string { return "stats:fitting:all" }
+func chartScoresCacheKey(chartID int) string {
+	return fmt.Sprintf("stats:scores:%d", chartID)
+}
+func scoreCountsCacheKey(passScore int) string {
+	return fmt.Sprintf("stats:counts:%d", passScore)
+}
+func playerSkillCacheKey(username string) string { return "stats:skill:" + username }
+
 // filterCacheKey returns a deterministic string representation of a RecordFilter
 // for use as a cache key segment.
 func filterCacheKey(f model.RecordFilter) string {
@@ -114,5 +474,8 @@ func filterCacheKey(f model.RecordFilter) string {
 			parts = append(parts, "b15:false")
 		}
 	}
+	if f.SeasonID != nil {
+		parts = append(parts, fmt.Sprintf("season:%d", *f.SeasonID))
+	}
 	return strings.Join(parts, "_")
 }
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Reconnect delays of the cacheNotifier listener: the first retry waits
+// notifyRetryMin, each failed one doubles it up to notifyRetryMax.
+const (
+	notifyRetryMin = time.Second
+	notifyRetryMax = 30 * time.Second
+)
+
+// notifyPublishTimeout bounds the NOTIFY sent for one invalidation.
+const notifyPublishTimeout = 2 * time.Second
+
+// cacheEvent is one invalidation broadcast by a cacheNotifier, in a
+// repository cache namespace: a key (repoCache.Delete), a scope
+// (repoCache.Invalidate), or, with neither, the whole namespace
+// (repoCache.DeleteAll).
+type cacheEvent struct {
+	Origin    string `json:"o"`
+	Namespace string `json:"n"`
+	Scope     string `json:"s,omitempty"`
+	Key       string `json:"k,omitempty"`
+}
+
+// cacheNotifier shares the invalidations of in-process repository caches
+// between server replicas on PostgreSQL, over LISTEN/NOTIFY: every
+// Delete, DeleteAll and Invalidate is applied locally, then published; every
+// replica listens and applies the events of the others to its own caches.
+//
+// A notification sent while a listener is disconnected is lost, so a
+// listener that reconnects flushes every local cache first.
+type cacheNotifier struct {
+	origin    string // identifies this process, whose own events are skipped
+	transport notifyTransport
+	retryMin  time.Duration
+
+	mu     sync.Mutex
+	caches map[string][]*repoCache // by namespace
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// notifyTransport carries cacheEvent payloads between replicas.
+type notifyTransport interface {
+	publish(ctx context.Context, payload string) error
+	// listen delivers the payloads published from now on, calling ready
+	// once it is listening, until ctx ends or the connection fails.
+	listen(ctx context.Context, ready func(), deliver func(payload string)) error
+}
+
+// cacheNotify is the cacheNotifier the repositories created after InitCache
+// register with; nil keeps invalidations local.
+var cacheNotify *cacheNotifier
+
+func newCacheNotifier(transport notifyTransport) *cacheNotifier {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &cacheNotifier{
+		origin:    hex.EncodeToString(id),
+		transport: transport,
+		retryMin:  notifyRetryMin,
+		caches:    make(map[string][]*repoCache),
+	}
+}
+
+// register makes c receive the events published for its namespace.
+func (n *cacheNotifier) register(c *repoCache) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.caches[c.namespace] = append(n.caches[c.namespace], c)
+}
+
+// publish broadcasts an invalidation already applied locally. Failures are
+// logged: the other replicas then serve the entries until they expire.
+func (n *cacheNotifier) publish(ev cacheEvent) {
+	ev.Origin = n.origin
+	payload, err := json.Marshal(ev)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyPublishTimeout)
+		err = n.transport.publish(ctx, string(payload))
+		cancel()
+	}
+	if err != nil {
+		slog.Error("cache invalidation not broadcast", "namespace", ev.Namespace, "scope", ev.Scope, "key", ev.Key, "error", err)
+	}
+}
+
+// receive applies an event published by another replica.
+func (n *cacheNotifier) receive(payload string) {
+	var ev cacheEvent
+	if err := json.Unmarshal([]byte(payload), &ev); err != nil {
+		slog.Warn("malformed cache notification", "payload", payload, "error", err)
+		return
+	}
+	if ev.Origin == n.origin {
+		return
+	}
+	n.mu.Lock()
+	caches := n.caches[ev.Namespace]
+	n.mu.Unlock()
+	for _, c := range caches {
+		switch {
+		case ev.Key != "":
+			c.deleteLocal(ev.Key)
+		case ev.Scope != "":
+			c.invalidateLocal(ev.Scope)
+		default:
+			c.deleteAllLocal()
+		}
+	}
+}
+
+// flushAll drops every entry of every registered cache.
+func (n *cacheNotifier) flushAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, caches := range n.caches {
+		for _, c := range caches {
+			c.deleteAllLocal()
+		}
+	}
+}
+
+// start runs the listener until stop.
+func (n *cacheNotifier) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel, n.done = cancel, make(chan struct{})
+	go n.run(ctx)
+}
+
+// stop ends the listener and waits for it.
+func (n *cacheNotifier) stop() {
+	if n.cancel != nil {
+		n.cancel()
+		<-n.done
+	}
+}
+
+// run listens, reconnecting with backoff after failures. Local caches are
+// flushed on every connect, the first one included: entries cached before
+// LISTEN took effect (while the database was unreachable at boot, or
+// between sessions) may have missed invalidations.
+func (n *cacheNotifier) run(ctx context.Context) {
+	defer close(n.done)
+	retry, failed := n.retryMin, false
+	for {
+		err := n.transport.listen(ctx, func() {
+			n.flushAll()
+			if failed {
+				slog.Info("cache notifications resumed; local caches flushed")
+			}
+			failed, retry = false, n.retryMin
+		}, n.receive)
+		if ctx.Err() != nil {
+			return
+		}
+		failed = true
+		slog.Warn("cache notification listener disconnected", "error", err, "retry_in", retry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(2*retry, notifyRetryMax)
+	}
+}
+
+// pgNotifyTransport is a notifyTransport on a PostgreSQL channel.
+type pgNotifyTransport struct {
+	db      *gorm.DB
+	channel string
+}
+
+func (t *pgNotifyTransport) publish(ctx context.Context, payload string) error {
+	return t.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", t.channel, payload).Error
+}
+
+// listen holds a dedicated connection of the pool for the LISTEN session.
+// The connection is discarded afterwards rather than returned to the pool
+// still listening.
+func (t *pgNotifyTransport) listen(ctx context.Context, ready func(), deliver func(payload string)) error {
+	sqlDB, err := t.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	err = conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("LISTEN needs the pgx driver, got %T", driverConn)
+		}
+		pc := sc.Conn()
+		if _, err := pc.Exec(ctx, "LISTEN "+pgx.Identifier{t.channel}.Sanitize()); err != nil {
+			return errors.Join(err, driver.ErrBadConn)
+		}
+		ready()
+		for {
+			note, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				return errors.Join(err, driver.ErrBadConn)
+			}
+			deliver(note.Payload)
+		}
+	})
+	return err
+}
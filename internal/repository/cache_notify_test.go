@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"paradigm-reboot-prober-go/internal/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifyHub is an in-process stand-in for a PostgreSQL channel.
+type fakeNotifyHub struct {
+	mu        sync.Mutex
+	listeners map[*fakeNotifyTransport]chan string
+	pending   sync.WaitGroup // notifications not yet applied
+}
+
+type fakeNotifyTransport struct {
+	hub    *fakeNotifyHub
+	drop   chan struct{} // closing it breaks the current listen session
+	refuse atomic.Bool   // listen fails right away, as with the database down
+}
+
+func newFakeNotifyHub() *fakeNotifyHub {
+	return &fakeNotifyHub{listeners: map[*fakeNotifyTransport]chan string{}}
+}
+
+func (h *fakeNotifyHub) transport() *fakeNotifyTransport {
+	return &fakeNotifyTransport{hub: h, drop: make(chan struct{})}
+}
+
+func (h *fakeNotifyHub) connected(t *fakeNotifyTransport) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.listeners[t]
+	return ok
+}
+
+func (t *fakeNotifyTransport) publish(_ context.Context, payload string) error {
+	t.hub.mu.Lock()
+	defer t.hub.mu.Unlock()
+	for _, ch := range t.hub.listeners {
+		t.hub.pending.Add(1)
+		ch <- payload
+	}
+	return nil
+}
+
+func (t *fakeNotifyTransport) listen(ctx context.Context, ready func(), deliver func(string)) error {
+	if t.refuse.Load() {
+		return errors.New("connection refused")
+	}
+	ch := make(chan string, 64)
+	t.hub.mu.Lock()
+	t.hub.listeners[t] = ch
+	drop := t.drop
+	t.hub.mu.Unlock()
+	defer func() {
+		t.hub.mu.Lock()
+		delete(t.hub.listeners, t)
+		t.hub.mu.Unlock()
+	}()
+	ready()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-drop:
+			return errors.New("connection lost")
+		case payload := <-ch:
+			deliver(payload)
+			t.hub.pending.Done()
+		}
+	}
+}
+
+// disconnect breaks the current session; the next one stays up.
+func (t *fakeNotifyTransport) disconnect() {
+	t.hub.mu.Lock()
+	defer t.hub.mu.Unlock()
+	close(t.drop)
+	t.drop = make(chan struct{})
+}
+
+// startReplica creates a listening cacheNotifier on hub; the repositories
+// the test creates next register with it.
+func startReplica(t *testing.T, hub *fakeNotifyHub) (*cacheNotifier, *fakeNotifyTransport) {
+	t.Helper()
+	tr := hub.transport()
+	n := newCacheNotifier(tr)
+	n.retryMin = 10 * time.Millisecond
+	n.start()
+	t.Cleanup(n.stop)
+	t.Cleanup(func() { cacheNotify = nil })
+	require.Eventually(t, func() bool { return hub.connected(tr) }, time.Second, 5*time.Millisecond)
+	cacheNotify = n
+	return n, tr
+}
+
+// Replicas with in-process caches see each other's invalidations, and a
+// replica that missed notifications while disconnected flushes its caches.
+func TestCacheNotifier_Replicas(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	hub := newFakeNotifyHub()
+
+	_, trA := startReplica(t, hub)
+	songsA, recordsA := NewSongRepository(db), NewRecordRepository(db)
+	usersA := NewUserRepository(db)
+	startReplica(t, hub)
+	songsB, recordsB := NewSongRepository(db), NewRecordRepository(db)
+	usersB := NewUserRepository(db)
+	cacheNotify = nil
+
+	created, err := songsB.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{Title: "Notify", Artist: "A", Version: "1.0", WikiID: "notify"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyDetected, Level: 5.0},
+			{Difficulty: model.DifficultyMassive, Level: 10.0},
+		},
+	})
+	require.NoError(t, err)
+	upload := func(repo *RecordRepository, username string, chartID, score int) {
+		t.Helper()
+		_, err := repo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
+			Username:       username,
+		}, false)
+		require.NoError(t, err)
+	}
+	for _, u := range []string{"notifyaa", "notifybb"} {
+		_, err := usersB.CreateUser(ctx, &model.User{
+			UserBase:        model.UserBase{Username: u, Nickname: u, UploadToken: u + "_tok", IsActive: true},
+			EncodedPassword: "p",
+		})
+		require.NoError(t, err)
+		upload(recordsB, u, created.Charts[0].ID, 900000)
+	}
+
+	hub.pending.Wait()
+
+	keyA := b50CacheKey("notifyaa", 0, model.RecordFilter{})
+	keyB := b50CacheKey("notifybb", 0, model.RecordFilter{})
+	for _, u := range []string{"notifyaa", "notifybb"} {
+		_, _, err = recordsA.GetBest50Records(ctx, u, 0, model.RecordFilter{})
+		require.NoError(t, err)
+	}
+	require.True(t, recordsA.cache.Has(keyA))
+
+	// An upload on B evicts that user's records on A, and only theirs.
+	upload(recordsB, "notifyaa", created.Charts[1].ID, 1000000)
+	require.Eventually(t, func() bool { return !recordsA.cache.Has(keyA) }, time.Second, 5*time.Millisecond)
+	assert.True(t, recordsA.cache.Has(keyB))
+	b35, _, err := recordsA.GetBest50Records(ctx, "notifyaa", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	assert.Len(t, b35, 2)
+
+	// So do single keys: a profile edit on B is seen on A.
+	userA, err := usersA.GetUserByUsername(ctx, "notifyaa")
+	require.NoError(t, err)
+	userA.Nickname = "renamed"
+	_, err = usersB.UpdateUser(ctx, userA)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !usersA.cache.Has(userCacheKey("notifyaa")) }, time.Second, 5*time.Millisecond)
+
+	// Song cache flushes travel too.
+	_, err = songsA.GetAllSongs(ctx)
+	require.NoError(t, err)
+	require.True(t, songsA.cache.Has(allSongsCacheKey()))
+	songsB.InvalidateAll()
+	require.Eventually(t, func() bool { return !songsA.cache.Has(allSongsCacheKey()) }, time.Second, 5*time.Millisecond)
+
+	// A is disconnected and misses B's notification: on reconnecting it
+	// flushes everything.
+	_, err = songsA.GetAllSongs(ctx)
+	require.NoError(t, err)
+	require.True(t, recordsA.cache.Has(keyB))
+	hub.mu.Lock()
+	delete(hub.listeners, trA) // notifications are lost from now on
+	hub.mu.Unlock()
+	upload(recordsB, "notifybb", created.Charts[1].ID, 1000000)
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, recordsA.cache.Has(keyB), "missed while disconnected")
+	trA.disconnect()
+	require.Eventually(t, func() bool { return !recordsA.cache.Has(keyB) }, time.Second, 5*time.Millisecond)
+	assert.False(t, songsA.cache.Has(allSongsCacheKey()), "every cache is flushed on reconnect")
+	require.Eventually(t, func() bool { return hub.connected(trA) }, time.Second, 5*time.Millisecond)
+}
+
+// A replica whose first LISTEN fails fills its caches without hearing any
+// invalidation; its first successful connect flushes them.
+func TestCacheNotifier_FirstListenFails(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	hub := newFakeNotifyHub()
+	tr := hub.transport()
+	tr.refuse.Store(true)
+	n := newCacheNotifier(tr)
+	n.retryMin = 10 * time.Millisecond
+	n.start()
+	t.Cleanup(n.stop)
+	cacheNotify = n
+	t.Cleanup(func() { cacheNotify = nil })
+
+	songs := NewSongRepository(db)
+	_, err := songs.GetAllSongs(ctx)
+	require.NoError(t, err)
+	require.True(t, songs.cache.Has(allSongsCacheKey()))
+
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, songs.cache.Has(allSongsCacheKey()), "nothing flushes while listening fails")
+	tr.refuse.Store(false)
+	require.Eventually(t, func() bool { return !songs.cache.Has(allSongsCacheKey()) }, time.Second, 5*time.Millisecond,
+		"entries cached before the first connect are flushed")
+	assert.True(t, hub.connected(tr))
+}
+
+// SQLite deployments keep invalidations local.
+func TestInitCache_SQLiteIsLocal(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, InitCache(db))
+	t.Cleanup(func() { _ = CloseCache() })
+	assert.Nil(t, cacheNotify)
+	assert.Nil(t, cacheBackend)
+	assert.Nil(t, NewRecordRepository(db).cache.notifier)
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// redisIdleConns is the number of idle connections a RedisCache keeps open.
+const redisIdleConns = 16
+
+// RedisOptions configures a RedisCache.
+type RedisOptions struct {
+	Addr     string        // host:port
+	Password string        // sent with AUTH when not empty
+	DB       int           // selected with SELECT when not 0
+	Prefix   string        // prepended to every key
+	Timeout  time.Duration // dial and per-command timeout; 0 means none
+}
+
+// RedisCache is a Cache on a server speaking the Redis protocol (RESP),
+// shared by every process configured with the same server and prefix.
+//
+// Values are gob-encoded, so every exported field survives — including
+// those hidden from JSON, such as a user's password hash. Nil pointers and
+// empty top-level slices and maps come back as they were stored; slices
+// and maps nested in a value come back nil when they were empty.
+type RedisCache struct {
+	opts RedisOptions
+	idle chan *redisConn
+}
+
+// NewRedisCache returns a RedisCache for opts. Connections are opened on
+// first use.
+func NewRedisCache(opts RedisOptions) *RedisCache {
+	return &RedisCache{opts: opts, idle: make(chan *redisConn, redisIdleConns)}
+}
+
+// Ping checks that the server is reachable.
+func (c *RedisCache) Ping() error {
+	_, err := c.do("PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (c *RedisCache) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Entries are stored as the time they become stale, in Unix nanoseconds
+// (8 bytes, big-endian), followed by the encoded value; they expire at the
+// end of their stale window.
+
+func (c *RedisCache) Get(key string, dst any) (bool, bool, error) {
+	reply, err := c.do("GET", c.opts.Prefix+key)
+	if err != nil || reply == nil {
+		return false, false, err
+	}
+	data, ok := reply.([]byte)
+	if !ok || len(data) < 8 {
+		return false, false, fmt.Errorf("GET %s: unexpected reply %T", key, reply)
+	}
+	staleAt := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	if err := decodeCacheValue(data[8:], dst); err != nil {
+		return false, false, fmt.Errorf("decode %s: %w", key, err)
+	}
+	return true, !time.Now().Before(staleAt), nil
+}
+
+func (c *RedisCache) Set(key string, value any, ttl, stale time.Duration) error {
+	data, err := encodeCacheValue(value)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	staleAt := int64(math.MaxInt64) // no ttl: never stale
+	if ttl > 0 {
+		staleAt = time.Now().Add(ttl).UnixNano()
+	}
+	data = append(binary.BigEndian.AppendUint64(nil, uint64(staleAt)), data...)
+	args := []string{"SET", c.opts.Prefix + key, string(data)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max((ttl+stale).Milliseconds(), 1), 10))
+	}
+	_, err = c.do(args...)
+	return err
+}
+
+func (c *RedisCache) Has(key string) (bool, error) {
+	reply, err := c.do("EXISTS", c.opts.Prefix+key)
+	if err != nil {
+		return false, err
+	}
+	n, _ := reply.(int64)
+	return n > 0, nil
+}
+
+func (c *RedisCache) Delete(key string) error {
+	_, err := c.do("DEL", c.opts.Prefix+key)
+	return err
+}
+
+func (c *RedisCache) Counters(names ...string) ([]int64, error) {
+	args := make([]string, 0, len(names)+1)
+	args = append(args, "MGET")
+	for _, name := range names {
+		args = append(args, c.counterKey(name))
+	}
+	reply, err := c.do(args...)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != len(names) {
+		return nil, fmt.Errorf("MGET: unexpected reply %v", reply)
+	}
+	out := make([]int64, len(names))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		b, _ := v.([]byte)
+		if out[i], err = strconv.ParseInt(string(b), 10, 64); err != nil {
+			return nil, fmt.Errorf("counter %s: %w", names[i], err)
+		}
+	}
+	return out, nil
+}
+
+func (c *RedisCache) Bump(name string) error {
+	_, err := c.do("INCR", c.counterKey(name))
+	return err
+}
+
+// counterKey is the key of a generation counter; it has no TTL, so a
+// counter never restarts at a generation whose entries are still cached.
+func (c *RedisCache) counterKey(name string) string {
+	return c.opts.Prefix + "gen:" + name
+}
+
+// do sends one command and returns its reply: nil, a string (simple
+// string), an int64, a []byte (bulk string) or a []any (array). Server
+// errors are returned as a redisError; a connection that failed otherwise
+// is closed instead of going back to the pool.
+func (c *RedisCache) do(args ...string) (any, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(c.opts.Timeout, args...)
+	var serverErr redisError
+	if err != nil && !errors.As(err, &serverErr) {
+		_ = conn.Close()
+		return nil, err
+	}
+	select {
+	case c.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+	return reply, err
+}
+
+// conn takes an idle connection or dials a new one.
+func (c *RedisCache) conn() (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+	nc, err := net.DialTimeout("tcp", c.opts.Addr, c.opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.opts.Password != "" {
+		if _, err := conn.do(c.opts.Timeout, "AUTH", c.opts.Password); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("AUTH: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := conn.do(c.opts.Timeout, "SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("SELECT %d: %w", c.opts.DB, err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply of the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is one connection, used by one command at a time.
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (conn *redisConn) do(timeout time.Duration, args ...string) (any, error) {
+	if timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return readRESP(conn.r)
+}
+
+// readRESP reads one RESP reply.
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err // $-1: nil reply
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		out := make([]any, n)
+		for i := range out {
+			if out[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+}
+
+// Leading byte of an encoded cache value.
+const (
+	cacheValueGob   byte = iota // followed by the gob encoding
+	cacheValueNil               // nil pointer, slice or map
+	cacheValueEmpty             // empty, non-nil slice or map
+)
+
+// encodeCacheValue encodes value for RedisCache. gob can encode neither a
+// nil pointer nor tell an empty slice from a nil one, hence the leading byte.
+func encodeCacheValue(value any) ([]byte, error) {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid():
+		return []byte{cacheValueNil}, nil
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice || v.Kind() == reflect.Map:
+		if v.IsNil() {
+			return []byte{cacheValueNil}, nil
+		}
+		if v.Kind() != reflect.Pointer && v.Len() == 0 {
+			return []byte{cacheValueEmpty}, nil
+		}
+	}
+	buf := bytes.NewBuffer([]byte{cacheValueGob})
+	if err := gob.NewEncoder(buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCacheValue decodes data, from encodeCacheValue, into dst.
+func decodeCacheValue(data []byte, dst any) error {
+	if len(data) == 0 {
+		return errors.New("empty value")
+	}
+	out := reflect.ValueOf(dst).Elem()
+	switch data[0] {
+	case cacheValueNil:
+		out.SetZero()
+		return nil
+	case cacheValueEmpty:
+		switch out.Kind() {
+		case reflect.Slice:
+			out.Set(reflect.MakeSlice(out.Type(), 0, 0))
+		case reflect.Map:
+			out.Set(reflect.MakeMap(out.Type()))
+		default:
+			return fmt.Errorf("empty value for %s", out.Type())
+		}
+		return nil
+	case cacheValueGob:
+		out.SetZero()
+		return gob.NewDecoder(bytes.NewReader(data[1:])).Decode(dst)
+	default:
+		return fmt.Errorf("unknown value encoding %d", data[0])
+	}
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"paradigm-reboot-prober-go/internal/model"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-process server for the subset of the Redis protocol
+// RedisCache uses.
+type fakeRedis struct {
+	addr     string
+	password string
+
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	f := &fakeRedis{addr: ln.Addr().String(), password: password, values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		req, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		items, _ := req.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			b, _ := item.([]byte)
+			args[i] = string(b)
+		}
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			authed = args[1] == f.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = f.exec(cmd, args[1:])
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(cmd string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for k, at := range f.expires {
+		if !now.Before(at) {
+			delete(f.values, k)
+			delete(f.expires, k)
+		}
+	}
+	bulk := func(key string) string {
+		v, ok := f.values[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	}
+	switch cmd {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		return bulk(args[0])
+	case "MGET":
+		out := fmt.Sprintf("*%d\r\n", len(args))
+		for _, key := range args {
+			out += bulk(key)
+		}
+		return out
+	case "SET":
+		f.values[args[0]] = args[1]
+		delete(f.expires, args[0])
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[args[0]] = now.Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "EXISTS", "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := f.values[key]; ok {
+				n++
+				if cmd == "DEL" {
+					delete(f.values, key)
+					delete(f.expires, key)
+				}
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "INCR":
+		n, _ := strconv.ParseInt(f.values[args[0]], 10, 64)
+		f.values[args[0]] = strconv.FormatInt(n+1, 10)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	default:
+		return "-ERR unknown command '" + cmd + "'\r\n"
+	}
+}
+
+func (f *fakeRedis) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for k := range f.values {
+		out = append(out, k)
+	}
+	return out
+}
+
+// useFakeRedis makes the repositories created by the test share a RedisCache
+// on a new fakeRedis.
+func useFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	f := newFakeRedis(t, "")
+	c := NewRedisCache(RedisOptions{Addr: f.addr, Prefix: "test:", Timeout: time.Second})
+	cacheBackend = c
+	t.Cleanup(func() {
+		cacheBackend = nil
+		_ = c.Close()
+	})
+	return f
+}
+
+func TestRedisCache_Values(t *testing.T) {
+	f := newFakeRedis(t, "secret")
+	c := NewRedisCache(RedisOptions{Addr: f.addr, Password: "secret", DB: 2, Prefix: "p:", Timeout: time.Second})
+	defer func() { _ = c.Close() }()
+	require.NoError(t, c.Ping())
+
+	// Fields hidden from JSON survive the round trip.
+	user := &model.User{UserBase: model.UserBase{Username: "alice", Nickname: "A"}, EncodedPassword: "hash"}
+	require.NoError(t, c.Set("user:alice", user, time.Minute, 0))
+	var gotUser *model.User
+	ok, _, err := c.Get("user:alice", &gotUser)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, user, gotUser)
+	assert.Contains(t, f.keys(), "p:user:alice")
+
+	// Cached misses and empty results keep their shape.
+	require.NoError(t, c.Set("stat", (*model.ChartStatistic)(nil), time.Minute, 0))
+	stat := &model.ChartStatistic{}
+	ok, _, err = c.Get("stat", &stat)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, stat)
+
+	require.NoError(t, c.Set("scores", []int{}, time.Minute, 0))
+	var scores []int
+	ok, _, err = c.Get("scores", &scores)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotNil(t, scores)
+	assert.Empty(t, scores)
+
+	counts := map[int]model.ChartScoreCount{3: {ChartID: 3, PlayerCount: 7, PassCount: 2}}
+	require.NoError(t, c.Set("counts", counts, time.Minute, 0))
+	var gotCounts map[int]model.ChartScoreCount
+	ok, _, err = c.Get("counts", &gotCounts)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, counts, gotCounts)
+
+	// Has, Delete and expiry.
+	ok, err = c.Has("counts")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, c.Delete("counts"))
+	ok, _, err = c.Get("counts", &gotCounts)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set("short", 1, 10*time.Millisecond, 0))
+	time.Sleep(30 * time.Millisecond)
+	ok, err = c.Has("short")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Past its TTL, an entry is served stale until its window closes.
+	require.NoError(t, c.Set("swr", 7, 10*time.Millisecond, time.Minute))
+	var n int
+	ok, stale, err := c.Get("swr", &n)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, stale)
+	time.Sleep(30 * time.Millisecond)
+	ok, stale, err = c.Get("swr", &n)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, stale)
+	assert.Equal(t, 7, n)
+
+	// Generation counters start at 0 and never expire.
+	gens, err := c.Counters("a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 0}, gens)
+	require.NoError(t, c.Bump("b"))
+	require.NoError(t, c.Bump("b"))
+	gens, err = c.Counters("a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 2}, gens)
+
+	bad := NewRedisCache(RedisOptions{Addr: f.addr, Password: "wrong", Timeout: time.Second})
+	assert.Error(t, bad.Ping())
+}
+
+// Two replicas sharing the Redis backend see each other's invalidations:
+// an upload on one drops the B50 the other cached, and only for that user.
+func TestRedisCache_SharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	f := useFakeRedis(t)
+	db := setupTestDB(t)
+	songs := NewSongRepository(db)
+	users := NewUserRepository(db)
+	replicaA, replicaB := NewRecordRepository(db), NewRecordRepository(db)
+
+	created, err := songs.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{Title: "Shared", Artist: "A", Version: "1.0", WikiID: "shared"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyDetected, Level: 5.0},
+			{Difficulty: model.DifficultyMassive, Level: 10.0},
+		},
+	})
+	require.NoError(t, err)
+	for _, u := range []string{"replicaaa", "replicabb"} {
+		_, err := users.CreateUser(ctx, &model.User{
+			UserBase:        model.UserBase{Username: u, Nickname: u, UploadToken: u + "_tok", IsActive: true},
+			EncodedPassword: "p",
+		})
+		require.NoError(t, err)
+		score := 900000
+		_, err = replicaA.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: created.Charts[0].ID, Score: &score},
+			Username:       u,
+		}, false)
+		require.NoError(t, err)
+	}
+
+	b35, _, err := replicaA.GetBest50Records(ctx, "replicaaa", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	require.Len(t, b35, 1)
+	_, _, err = replicaA.GetBest50Records(ctx, "replicabb", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	keyA := b50CacheKey("replicaaa", 0, model.RecordFilter{})
+	keyB := b50CacheKey("replicabb", 0, model.RecordFilter{})
+	assert.True(t, replicaB.cache.Has(keyA), "the cache is shared")
+
+	before := len(f.keys())
+	score := 1000000
+	_, err = replicaB.CreateRecord(ctx, &model.PlayRecord{
+		PlayRecordBase: model.PlayRecordBase{ChartID: created.Charts[1].ID, Score: &score},
+		Username:       "replicaaa",
+	}, false)
+	require.NoError(t, err)
+	assert.False(t, replicaA.cache.Has(keyA), "invalidated on the other replica")
+	assert.True(t, replicaA.cache.Has(keyB), "other users keep their entries")
+	assert.Len(t, f.keys(), before, "invalidation bumps a counter; it deletes nothing")
+
+	b35, _, err = replicaA.GetBest50Records(ctx, "replicaaa", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	assert.Len(t, b35, 2)
+
+	// Cached users keep their password hash, which logins check.
+	_, err = users.GetUserByUsername(ctx, "replicaaa")
+	require.NoError(t, err)
+	require.True(t, users.cache.Has(userCacheKey("replicaaa")))
+	got, err := NewUserRepository(db).GetUserByUsername(ctx, "replicaaa")
+	require.NoError(t, err)
+	assert.Equal(t, "p", got.EncodedPassword)
+
+	// A flush on one replica's song repository reaches the other.
+	otherSongs := NewSongRepository(db)
+	all, err := otherSongs.GetAllSongs(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	_, err = songs.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{Title: "Second", Artist: "A", Version: "1.0", WikiID: "second"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 3.0}},
+	})
+	require.NoError(t, err)
+	all, err = otherSongs.GetAllSongs(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+// Repositories keep working, uncached, while the cache server is down.
+func TestRedisCache_Unavailable(t *testing.T) {
+	ctx := context.Background()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	cacheBackend = NewRedisCache(RedisOptions{Addr: addr, Timeout: 100 * time.Millisecond})
+	t.Cleanup(func() { cacheBackend = nil })
+
+	db := setupTestDB(t)
+	users := NewUserRepository(db)
+	_, err = users.CreateUser(ctx, &model.User{
+		UserBase:        model.UserBase{Username: "downuser", Nickname: "D", UploadToken: "down_tok", IsActive: true},
+		EncodedPassword: "p",
+	})
+	require.NoError(t, err)
+	got, err := users.GetUserByUsername(ctx, "downuser")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "D", got.Nickname)
+	assert.False(t, users.cache.Has(userCacheKey("downuser")))
+}
@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"paradigm-reboot-prober-go/internal/model"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +19,7 @@ import (
 // ---------------------------------------------------------------------------
 
 func TestSongCacheConsistency(t *testing.T) {
+	ctx := context.Background()
 	t.Run("GetAllSongs is cached and invalidated on CreateSong", func(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewSongRepository(db)
@@ -23,11 +29,11 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Song1", Artist: "Artist1", Version: "1.0", WikiID: "song1"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 5.0}},
 		}
-		_, err := repo.CreateSong(song)
+		_, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 
 		// First read → cache miss → DB query → populate cache
-		songs, err := repo.GetAllSongs()
+		songs, err := repo.GetAllSongs(ctx)
 		assert.NoError(t, err)
 		assert.Len(t, songs, 1)
 
@@ -35,7 +41,7 @@ func TestSongCacheConsistency(t *testing.T) {
 		assert.True(t, repo.cache.Has(allSongsCacheKey()), "all_songs should be cached after first read")
 
 		// Second read → cache hit → same data
-		songs2, err := repo.GetAllSongs()
+		songs2, err := repo.GetAllSongs(ctx)
 		assert.NoError(t, err)
 		assert.Len(t, songs2, 1)
 		assert.Equal(t, songs[0].Title, songs2[0].Title)
@@ -45,14 +51,14 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Song2", Artist: "Artist2", Version: "1.0", WikiID: "song2"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 10.0}},
 		}
-		_, err = repo.CreateSong(song2)
+		_, err = repo.CreateSong(ctx, song2)
 		assert.NoError(t, err)
 
 		// Verify cache was flushed
 		assert.False(t, repo.cache.Has(allSongsCacheKey()), "all_songs cache should be flushed after CreateSong")
 
 		// Re-read → should return 2 songs from DB
-		songs3, err := repo.GetAllSongs()
+		songs3, err := repo.GetAllSongs(ctx)
 		assert.NoError(t, err)
 		assert.Len(t, songs3, 2)
 	})
@@ -65,11 +71,11 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Original", Artist: "A", Version: "1.0", WikiID: "sid"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 5.0}},
 		}
-		created, err := repo.CreateSong(song)
+		created, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 
 		// Read by ID → cached
-		got, err := repo.GetSongByID(created.ID)
+		got, err := repo.GetSongByID(ctx, created.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, "Original", got.Title)
 		assert.True(t, repo.cache.Has(songIDCacheKey(1)))
@@ -79,14 +85,14 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Updated", Artist: "A", Version: "1.0", WikiID: "sid"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 5.0}},
 		}
-		_, err = repo.UpdateSong(created.ID, updatedSong)
+		_, _, err = repo.UpdateSong(ctx, created.ID, updatedSong, "admin")
 		assert.NoError(t, err)
 
 		// Cache should be flushed
 		assert.False(t, repo.cache.Has(songIDCacheKey(1)), "song cache should be flushed after UpdateSong")
 
 		// Re-read → should return updated data
-		got2, err := repo.GetSongByID(created.ID)
+		got2, err := repo.GetSongByID(ctx, created.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, "Updated", got2.Title)
 	})
@@ -99,11 +105,11 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Wiki", Artist: "A", Version: "1.0", WikiID: "mywiki"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 8.0}},
 		}
-		_, err := repo.CreateSong(song)
+		_, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 
 		// Read by wiki ID → cached
-		got, err := repo.GetSongByWikiID("mywiki")
+		got, err := repo.GetSongByWikiID(ctx, "mywiki")
 		assert.NoError(t, err)
 		assert.NotNil(t, got)
 		assert.True(t, repo.cache.Has(songWikiCacheKey("mywiki")))
@@ -113,7 +119,7 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Other", Artist: "B", Version: "1.0", WikiID: "other"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 3.0}},
 		}
-		_, err = repo.CreateSong(song2)
+		_, err = repo.CreateSong(ctx, song2)
 		assert.NoError(t, err)
 
 		assert.False(t, repo.cache.Has(songWikiCacheKey("mywiki")), "wiki cache should be flushed after CreateSong")
@@ -127,12 +133,12 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "ChartTest", Artist: "A", Version: "1.0", WikiID: "ct"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyInvaded, Level: 7.0}},
 		}
-		created, err := repo.CreateSong(song)
+		created, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 		chartID := created.Charts[0].ID
 
 		// Read chart by ID → cached
-		chart, err := repo.GetChartByID(chartID)
+		chart, err := repo.GetChartByID(ctx, chartID)
 		assert.NoError(t, err)
 		assert.NotNil(t, chart)
 		assert.Equal(t, 7.0, chart.Level)
@@ -142,11 +148,11 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "ChartTest", Artist: "A", Version: "1.0", WikiID: "ct"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyInvaded, Level: 9.0}},
 		}
-		_, err = repo.UpdateSong(created.ID, updatedSong)
+		_, _, err = repo.UpdateSong(ctx, created.ID, updatedSong, "admin")
 		assert.NoError(t, err)
 
 		// Re-read chart → should have updated level
-		chart2, err := repo.GetChartByID(chartID)
+		chart2, err := repo.GetChartByID(ctx, chartID)
 		assert.NoError(t, err)
 		assert.NotNil(t, chart2)
 		assert.Equal(t, 9.0, chart2.Level)
@@ -160,11 +166,11 @@ func TestSongCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "WikiDiff", Artist: "A", Version: "1.0", WikiID: "wd"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 12.0}},
 		}
-		_, err := repo.CreateSong(song)
+		_, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 
 		// Read → cached
-		chart, err := repo.GetChartByWikiIDAndDifficulty("wd", model.DifficultyMassive)
+		chart, err := repo.GetChartByWikiIDAndDifficulty(ctx, "wd", model.DifficultyMassive)
 		assert.NoError(t, err)
 		assert.NotNil(t, chart)
 
@@ -172,7 +178,7 @@ func TestSongCacheConsistency(t *testing.T) {
 		assert.True(t, repo.cache.Has(key), "chart wiki_diff lookup should be cached")
 
 		// Read again → cache hit
-		chart2, err := repo.GetChartByWikiIDAndDifficulty("wd", model.DifficultyMassive)
+		chart2, err := repo.GetChartByWikiIDAndDifficulty(ctx, "wd", model.DifficultyMassive)
 		assert.NoError(t, err)
 		assert.Equal(t, chart.ID, chart2.ID)
 	})
@@ -183,6 +189,7 @@ func TestSongCacheConsistency(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestUserCacheConsistency(t *testing.T) {
+	ctx := context.Background()
 	t.Run("GetUserByUsername cached and invalidated on UpdateUser", func(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewUserRepository(db)
@@ -191,30 +198,30 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "cacheuser", Nickname: "OldNick", UploadToken: "tok1", IsActive: true},
 			EncodedPassword: "pass",
 		}
-		_, err := repo.CreateUser(user)
+		_, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 
 		// Read → cache miss → stored in cache
-		got, err := repo.GetUserByUsername("cacheuser")
+		got, err := repo.GetUserByUsername(ctx, "cacheuser")
 		assert.NoError(t, err)
 		assert.Equal(t, "OldNick", got.Nickname)
 		assert.True(t, repo.cache.Has(userCacheKey("cacheuser")), "user should be cached after GetUserByUsername")
 
 		// Read again → cache hit
-		got2, err := repo.GetUserByUsername("cacheuser")
+		got2, err := repo.GetUserByUsername(ctx, "cacheuser")
 		assert.NoError(t, err)
 		assert.Equal(t, "OldNick", got2.Nickname)
 
 		// Update nickname
 		got.Nickname = "NewNick"
-		_, err = repo.UpdateUser(got)
+		_, err = repo.UpdateUser(ctx, got)
 		assert.NoError(t, err)
 
 		// Cache should be invalidated
 		assert.False(t, repo.cache.Has(userCacheKey("cacheuser")), "user cache should be invalidated after UpdateUser")
 
 		// Re-read → fresh from DB
-		got3, err := repo.GetUserByUsername("cacheuser")
+		got3, err := repo.GetUserByUsername(ctx, "cacheuser")
 		assert.NoError(t, err)
 		assert.Equal(t, "NewNick", got3.Nickname)
 	})
@@ -228,10 +235,10 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "useronexx", Nickname: "One", UploadToken: "t1", IsActive: true},
 			EncodedPassword: "p1",
 		}
-		_, err := repo.CreateUser(user1)
+		_, err := repo.CreateUser(ctx, user1)
 		assert.NoError(t, err)
 
-		_, err = repo.GetUserByUsername("useronexx")
+		_, err = repo.GetUserByUsername(ctx, "useronexx")
 		assert.NoError(t, err)
 		assert.True(t, repo.cache.Has(userCacheKey("useronexx")))
 
@@ -240,7 +247,7 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "usertwoxx", Nickname: "Two", UploadToken: "t2", IsActive: true},
 			EncodedPassword: "p2",
 		}
-		_, err = repo.CreateUser(user2)
+		_, err = repo.CreateUser(ctx, user2)
 		assert.NoError(t, err)
 
 		assert.True(t, repo.cache.Has(userCacheKey("useronexx")), "user1 cache should not be affected by user2 creation")
@@ -254,22 +261,22 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "txuserxx", Nickname: "Before", UploadToken: "tx1", IsActive: true},
 			EncodedPassword: "pass",
 		}
-		_, err := repo.CreateUser(user)
+		_, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 
 		// Cache the user
-		_, err = repo.GetUserByUsername("txuserxx")
+		_, err = repo.GetUserByUsername(ctx, "txuserxx")
 		assert.NoError(t, err)
 		assert.True(t, repo.cache.Has(userCacheKey("txuserxx")))
 
 		// Update within transaction
-		err = repo.WithTransaction(func(txRepo *UserRepository) error {
-			u, err := txRepo.GetUserByUsername("txuserxx")
+		err = repo.WithTransaction(ctx, func(txRepo *UserRepository) error {
+			u, err := txRepo.GetUserByUsername(ctx, "txuserxx")
 			if err != nil {
 				return err
 			}
 			u.Nickname = "After"
-			_, err = txRepo.UpdateUser(u)
+			_, err = txRepo.UpdateUser(ctx, u)
 			return err
 		})
 		assert.NoError(t, err)
@@ -278,7 +285,7 @@ func TestUserCacheConsistency(t *testing.T) {
 		assert.False(t, repo.cache.Has(userCacheKey("txuserxx")), "cache should be invalidated after TX commit with UpdateUser")
 
 		// Read fresh data
-		got, err := repo.GetUserByUsername("txuserxx")
+		got, err := repo.GetUserByUsername(ctx, "txuserxx")
 		assert.NoError(t, err)
 		assert.Equal(t, "After", got.Nickname)
 	})
@@ -291,21 +298,21 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "rolluser", Nickname: "Original", UploadToken: "ru1", IsActive: true},
 			EncodedPassword: "pass",
 		}
-		_, err := repo.CreateUser(user)
+		_, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 
 		// Cache the user
-		_, err = repo.GetUserByUsername("rolluser")
+		_, err = repo.GetUserByUsername(ctx, "rolluser")
 		assert.NoError(t, err)
 
 		// Transaction that updates then rolls back
-		txErr := repo.WithTransaction(func(txRepo *UserRepository) error {
-			u, err := txRepo.GetUserByUsername("rolluser")
+		txErr := repo.WithTransaction(ctx, func(txRepo *UserRepository) error {
+			u, err := txRepo.GetUserByUsername(ctx, "rolluser")
 			if err != nil {
 				return err
 			}
 			u.Nickname = "ShouldNotPersist"
-			_, err = txRepo.UpdateUser(u) // invalidates cache
+			_, err = txRepo.UpdateUser(ctx, u) // invalidates cache
 			if err != nil {
 				return err
 			}
@@ -314,7 +321,7 @@ func TestUserCacheConsistency(t *testing.T) {
 		assert.Error(t, txErr)
 
 		// Cache was invalidated pessimistically, re-read gives original data
-		got, err := repo.GetUserByUsername("rolluser")
+		got, err := repo.GetUserByUsername(ctx, "rolluser")
 		assert.NoError(t, err)
 		assert.Equal(t, "Original", got.Nickname, "after rollback, original data should be returned")
 	})
@@ -327,18 +334,18 @@ func TestUserCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "copyuser", Nickname: "Immutable", UploadToken: "cu1", IsActive: true},
 			EncodedPassword: "pass",
 		}
-		_, err := repo.CreateUser(user)
+		_, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 
 		// Cache the user
-		got1, err := repo.GetUserByUsername("copyuser")
+		got1, err := repo.GetUserByUsername(ctx, "copyuser")
 		assert.NoError(t, err)
 
 		// Mutate the returned copy
 		got1.Nickname = "Mutated"
 
 		// Read again → should still get original cached value
-		got2, err := repo.GetUserByUsername("copyuser")
+		got2, err := repo.GetUserByUsername(ctx, "copyuser")
 		assert.NoError(t, err)
 		assert.Equal(t, "Immutable", got2.Nickname, "cached value should not be affected by caller mutation")
 	})
@@ -349,6 +356,7 @@ func TestUserCacheConsistency(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestRecordCacheConsistency(t *testing.T) {
+	ctx := context.Background()
 	// Helper to create test fixtures: user, song, chart
 	setupFixtures := func(t *testing.T, db *gorm.DB) (*UserRepository, *SongRepository, *RecordRepository, *model.Song) {
 		t.Helper()
@@ -360,7 +368,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 			UserBase:        model.UserBase{Username: "recuser1", Nickname: "Rec", UploadToken: "rt1", IsActive: true},
 			EncodedPassword: "p",
 		}
-		_, err := userRepo.CreateUser(user)
+		_, err := userRepo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 
 		song := &model.Song{
@@ -370,7 +378,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 				{Difficulty: model.DifficultyMassive, Level: 10.0},
 			},
 		}
-		created, err := songRepo.CreateSong(song)
+		created, err := songRepo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 
 		return userRepo, songRepo, recordRepo, created
@@ -383,14 +391,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 		score := 950000
 
 		// Upload a record
-		_, err := recordRepo.CreateRecord(&model.PlayRecord{
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
 			Username:       "recuser1",
 		}, false)
 		assert.NoError(t, err)
 
 		// Query B50 → cached
-		b35, b15, err := recordRepo.GetBest50Records("recuser1", 0, model.RecordFilter{})
+		b35, b15, err := recordRepo.GetBest50Records(ctx, "recuser1", 0, model.RecordFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, b35, 1) // song is not b15
 		assert.Len(t, b15, 0)
@@ -400,7 +408,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 
 		// Upload another record → cache invalidated
 		score2 := 1000000
-		_, err = recordRepo.CreateRecord(&model.PlayRecord{
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: song.Charts[1].ID, Score: &score2},
 			Username:       "recuser1",
 		}, false)
@@ -409,7 +417,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 		assert.False(t, recordRepo.cache.Has(key), "B50 cache should be invalidated after CreateRecord")
 
 		// Re-query → should have 2 records
-		b35v2, _, err := recordRepo.GetBest50Records("recuser1", 0, model.RecordFilter{})
+		b35v2, _, err := recordRepo.GetBest50Records(ctx, "recuser1", 0, model.RecordFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, b35v2, 2)
 	})
@@ -420,14 +428,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 		chartID := song.Charts[0].ID
 		score := 900000
 
-		_, err := recordRepo.CreateRecord(&model.PlayRecord{
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
 			Username:       "recuser1",
 		}, false)
 		assert.NoError(t, err)
 
 		// Query best by chart → cached
-		best, err := recordRepo.GetBestRecordByChart("recuser1", chartID)
+		best, err := recordRepo.GetBestRecordByChart(ctx, "recuser1", chartID)
 		assert.NoError(t, err)
 		assert.NotNil(t, best)
 		assert.Equal(t, 900000, *best.Score)
@@ -437,7 +445,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 
 		// Upload higher score
 		score2 := 980000
-		_, err = recordRepo.CreateRecord(&model.PlayRecord{
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score2},
 			Username:       "recuser1",
 		}, false)
@@ -446,7 +454,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 		assert.False(t, recordRepo.cache.Has(key), "best_chart cache should be invalidated after new record")
 
 		// Re-query → should have higher score
-		best2, err := recordRepo.GetBestRecordByChart("recuser1", chartID)
+		best2, err := recordRepo.GetBestRecordByChart(ctx, "recuser1", chartID)
 		assert.NoError(t, err)
 		assert.NotNil(t, best2)
 		assert.Equal(t, 980000, *best2.Score)
@@ -458,27 +466,27 @@ func TestRecordCacheConsistency(t *testing.T) {
 		chartID1 := song.Charts[0].ID
 		score := 950000
 
-		_, err := recordRepo.CreateRecord(&model.PlayRecord{
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID1, Score: &score},
 			Username:       "recuser1",
 		}, false)
 		assert.NoError(t, err)
 
 		// Query best by song → cached
-		bests, err := recordRepo.GetBestRecordsBySong("recuser1", song.ID)
+		bests, err := recordRepo.GetBestRecordsBySong(ctx, "recuser1", song.ID)
 		assert.NoError(t, err)
 		assert.Len(t, bests, 1)
 
 		// Upload record for another chart of the same song → cache invalidated
 		score2 := 880000
-		_, err = recordRepo.CreateRecord(&model.PlayRecord{
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: song.Charts[1].ID, Score: &score2},
 			Username:       "recuser1",
 		}, false)
 		assert.NoError(t, err)
 
 		// Re-query → should have 2 best records (one per difficulty)
-		bests2, err := recordRepo.GetBestRecordsBySong("recuser1", song.ID)
+		bests2, err := recordRepo.GetBestRecordsBySong(ctx, "recuser1", song.ID)
 		assert.NoError(t, err)
 		assert.Len(t, bests2, 2)
 	})
@@ -491,7 +499,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 
 		// Create two users
 		for _, u := range []string{"xusraaaa", "xusrbbbb"} {
-			_, err := userRepo.CreateUser(&model.User{
+			_, err := userRepo.CreateUser(ctx, &model.User{
 				UserBase:        model.UserBase{Username: u, Nickname: u, UploadToken: u + "_tok", IsActive: true},
 				EncodedPassword: "p",
 			})
@@ -502,14 +510,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Iso", Artist: "A", Version: "1.0", WikiID: "iso"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyDetected, Level: 6.0}},
 		}
-		created, err := songRepo.CreateSong(song)
+		created, err := songRepo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 		chartID := created.Charts[0].ID
 
 		// Both users upload records
 		for _, u := range []string{"xusraaaa", "xusrbbbb"} {
 			score := 900000
-			_, err := recordRepo.CreateRecord(&model.PlayRecord{
+			_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 				PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
 				Username:       u,
 			}, false)
@@ -517,9 +525,9 @@ func TestRecordCacheConsistency(t *testing.T) {
 		}
 
 		// Cache B50 for both users
-		_, _, err = recordRepo.GetBest50Records("xusraaaa", 0, model.RecordFilter{})
+		_, _, err = recordRepo.GetBest50Records(ctx, "xusraaaa", 0, model.RecordFilter{})
 		assert.NoError(t, err)
-		_, _, err = recordRepo.GetBest50Records("xusrbbbb", 0, model.RecordFilter{})
+		_, _, err = recordRepo.GetBest50Records(ctx, "xusrbbbb", 0, model.RecordFilter{})
 		assert.NoError(t, err)
 
 		keyA := b50CacheKey("xusraaaa", 0, model.RecordFilter{})
@@ -529,7 +537,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 
 		// Upload a new record for user A only
 		scoreNew := 1000000
-		_, err = recordRepo.CreateRecord(&model.PlayRecord{
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &scoreNew},
 			Username:       "xusraaaa",
 		}, false)
@@ -547,7 +555,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 		recordRepo := NewRecordRepository(db)
 
 		for _, u := range []string{"batchusr1", "batchusr2"} {
-			_, err := userRepo.CreateUser(&model.User{
+			_, err := userRepo.CreateUser(ctx, &model.User{
 				UserBase:        model.UserBase{Username: u, Nickname: u, UploadToken: u + "_tok", IsActive: true},
 				EncodedPassword: "p",
 			})
@@ -558,14 +566,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 			SongBase: model.SongBase{Title: "Batch", Artist: "A", Version: "1.0", WikiID: "batch"},
 			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 11.0}},
 		}
-		created, err := songRepo.CreateSong(song)
+		created, err := songRepo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 		chartID := created.Charts[0].ID
 
 		// Seed initial records so B50 has data
 		for _, u := range []string{"batchusr1", "batchusr2"} {
 			score := 800000
-			_, err := recordRepo.CreateRecord(&model.PlayRecord{
+			_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 				PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
 				Username:       u,
 			}, false)
@@ -573,14 +581,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 		}
 
 		// Cache B50 for both
-		_, _, err = recordRepo.GetBest50Records("batchusr1", 0, model.RecordFilter{})
+		_, _, err = recordRepo.GetBest50Records(ctx, "batchusr1", 0, model.RecordFilter{})
 		assert.NoError(t, err)
-		_, _, err = recordRepo.GetBest50Records("batchusr2", 0, model.RecordFilter{})
+		_, _, err = recordRepo.GetBest50Records(ctx, "batchusr2", 0, model.RecordFilter{})
 		assert.NoError(t, err)
 
 		// Batch create for user batchusr1 only
 		score := 999000
-		_, err = recordRepo.BatchCreateRecords([]*model.PlayRecord{
+		_, err = recordRepo.BatchCreateRecords(ctx, []*model.PlayRecord{
 			{PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score}, Username: "batchusr1"},
 		}, false)
 		assert.NoError(t, err)
@@ -600,14 +608,14 @@ func TestRecordCacheConsistency(t *testing.T) {
 		chartID := song.Charts[0].ID
 		score := 950000
 
-		_, err := recordRepo.CreateRecord(&model.PlayRecord{
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: &score},
 			Username:       "recuser1",
 		}, false)
 		assert.NoError(t, err)
 
 		// Query all charts with scores → cached
-		results, err := recordRepo.GetAllChartsWithBestScores("recuser1", model.RecordFilter{})
+		results, err := recordRepo.GetAllChartsWithBestScores(ctx, "recuser1", model.RecordFilter{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, results)
 
@@ -616,7 +624,7 @@ func TestRecordCacheConsistency(t *testing.T) {
 
 		// Upload new record → cache invalidated
 		score2 := 1000000
-		_, err = recordRepo.CreateRecord(&model.PlayRecord{
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: song.Charts[1].ID, Score: &score2},
 			Username:       "recuser1",
 		}, false)
@@ -631,11 +639,12 @@ func TestRecordCacheConsistency(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestCacheMissAndHit(t *testing.T) {
+	ctx := context.Background()
 	t.Run("Nil result for non-existent user is not cached", func(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewUserRepository(db)
 
-		got, err := repo.GetUserByUsername("ghost")
+		got, err := repo.GetUserByUsername(ctx, "ghost")
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 
@@ -647,7 +656,7 @@ func TestCacheMissAndHit(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewSongRepository(db)
 
-		got, err := repo.GetSongByID(9999)
+		got, err := repo.GetSongByID(ctx, 9999)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 
@@ -658,7 +667,7 @@ func TestCacheMissAndHit(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewSongRepository(db)
 
-		got, err := repo.GetChartByID(9999)
+		got, err := repo.GetChartByID(ctx, 9999)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 
@@ -669,7 +678,7 @@ func TestCacheMissAndHit(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewSongRepository(db)
 
-		got, err := repo.GetChartByWikiIDAndDifficulty("nope", model.DifficultyMassive)
+		got, err := repo.GetChartByWikiIDAndDifficulty(ctx, "nope", model.DifficultyMassive)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 
@@ -681,7 +690,7 @@ func TestCacheMissAndHit(t *testing.T) {
 		db := setupTestDB(t)
 		repo := NewRecordRepository(db)
 
-		got, err := repo.GetBestRecordByChart("nobody", 9999)
+		got, err := repo.GetBestRecordByChart(ctx, "nobody", 9999)
 		assert.NoError(t, err)
 		assert.Nil(t, got)
 
@@ -690,6 +699,191 @@ func TestCacheMissAndHit(t *testing.T) {
 	})
 }
 
+// ---------------------------------------------------------------------------
+// Request coalescing and stale-while-revalidate
+// ---------------------------------------------------------------------------
+
+func TestCachedLoad(t *testing.T) {
+	ctx := context.Background()
+	// versioned returns a load that blocks until release is closed, then
+	// returns how many times it was called.
+	versioned := func(calls *atomic.Int32, started chan<- struct{}, release <-chan struct{}) func(context.Context) (int, bool, error) {
+		return func(context.Context) (int, bool, error) {
+			n := calls.Add(1)
+			started <- struct{}{}
+			<-release
+			return int(n), true, nil
+		}
+	}
+
+	t.Run("Concurrent misses share one load", func(t *testing.T) {
+		c := newRepoCache("test", time.Minute)
+		var calls atomic.Int32
+		started, release := make(chan struct{}, 10), make(chan struct{})
+		load := versioned(&calls, started, release)
+
+		var wg sync.WaitGroup
+		results := make([]int, 10)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := cachedLoad(ctx, c, "all", load)
+				assert.NoError(t, err)
+				results[i] = v
+			}()
+		}
+		<-started
+		time.Sleep(20 * time.Millisecond) // let the others join the load
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for _, v := range results {
+			assert.Equal(t, 1, v)
+		}
+		assert.True(t, c.Has("all"))
+	})
+
+	t.Run("Errors are shared but not cached", func(t *testing.T) {
+		c := newRepoCache("test", time.Minute)
+		_, err := cachedLoad(ctx, c, "broken", func(context.Context) (int, bool, error) { return 0, false, errors.New("db down") })
+		assert.EqualError(t, err, "db down")
+		assert.False(t, c.Has("broken"))
+	})
+
+	t.Run("Stale entries are served while one load refreshes them", func(t *testing.T) {
+		c := newRepoCache("test", 20*time.Millisecond)
+		c.stale = time.Hour
+		var calls atomic.Int32
+		started, release := make(chan struct{}, 10), make(chan struct{}, 10)
+		load := versioned(&calls, started, release)
+
+		release <- struct{}{}
+		v, err := cachedLoad(ctx, c, "all", load)
+		require.NoError(t, err)
+		require.Equal(t, 1, v)
+		<-started
+
+		time.Sleep(30 * time.Millisecond) // past the TTL
+		for range 5 {
+			v, err = cachedLoad(ctx, c, "all", load)
+			require.NoError(t, err)
+			assert.Equal(t, 1, v, "the stale value is served without waiting")
+		}
+		<-started
+		assert.Equal(t, int32(2), calls.Load(), "a single refresh runs")
+		release <- struct{}{}
+		assert.Eventually(t, func() bool {
+			v, _ := cachedLoad(ctx, c, "all", load)
+			return v == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Invalidated entries are not served stale", func(t *testing.T) {
+		c := newRepoCache("test", time.Minute)
+		c.stale = time.Hour
+		var calls atomic.Int32
+		load := func(context.Context) (int, bool, error) { return int(calls.Add(1)), true, nil }
+
+		v, _ := cachedLoad(ctx, c, "alice:b50", load)
+		assert.Equal(t, 1, v)
+		c.Invalidate("alice")
+		v, _ = cachedLoad(ctx, c, "alice:b50", load)
+		assert.Equal(t, 2, v)
+		c.DeleteAll()
+		v, _ = cachedLoad(ctx, c, "alice:b50", load)
+		assert.Equal(t, 3, v)
+	})
+
+	t.Run("A waiter stops when its context ends; the load goes on", func(t *testing.T) {
+		c := newRepoCache("test", time.Minute)
+		var calls atomic.Int32
+		started, release := make(chan struct{}, 10), make(chan struct{})
+		load := versioned(&calls, started, release)
+
+		waiter, cancel := context.WithCancel(ctx)
+		errs := make(chan error, 1)
+		go func() {
+			_, err := cachedLoad(waiter, c, "all", load)
+			errs <- err
+		}()
+		<-started
+		cancel()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+
+		close(release)
+		assert.Eventually(t, func() bool { return c.Has("all") }, time.Second, 5*time.Millisecond,
+			"the load started by the cancelled request still fills the cache")
+	})
+
+	t.Run("A nil cache always loads", func(t *testing.T) {
+		var calls atomic.Int32
+		load := func(context.Context) (int, bool, error) { return int(calls.Add(1)), true, nil }
+		_, _ = cachedLoad(ctx, nil, "all", load)
+		v, _ := cachedLoad(ctx, nil, "all", load)
+		assert.Equal(t, 2, v)
+	})
+}
+
+// The in-process backend drops a generation counter once the entries
+// written under its older generations have expired.
+func TestMemoryCacheCounters(t *testing.T) {
+	ttl, stale := 50*time.Millisecond, 30*time.Millisecond
+	c := newMemoryCache(ttl, stale)
+	t.Cleanup(c.items.Stop)
+	t.Cleanup(c.counters.Stop)
+
+	require.NoError(t, c.Set("ns@0:alice@0:b50", 1, ttl, stale))
+	require.NoError(t, c.Bump("ns:alice"))
+	require.NoError(t, c.Bump("ns:alice"))
+	gens, err := c.Counters("ns", "ns:alice")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 2}, gens)
+
+	// Reads do not keep a counter alive.
+	require.Eventually(t, func() bool {
+		gens, _ := c.Counters("ns:alice")
+		return gens[0] == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return c.counters.Len() == 0 }, time.Second, 5*time.Millisecond)
+	// Back at generation 0, nothing written before the bumps is left.
+	found, _, err := c.Get("ns@0:alice@0:b50", new(int))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	t.Run("A generation is never reused", func(t *testing.T) {
+		ctx := context.Background()
+		rc := newRepoCache("test", ttl)
+		rc.backend, rc.stale = c, stale
+		var calls atomic.Int32
+		load := func(context.Context) (int, bool, error) { return int(calls.Add(1)), true, nil }
+
+		// Entry written after the last bump: it outlives the counter when
+		// it keeps being read.
+		rc.Invalidate("alice")
+		v, _ := cachedLoad(ctx, rc, "alice:b50", load)
+		require.Equal(t, 1, v)
+		stored, err := rc.storedKey("alice:b50")
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			gens, _ := c.Counters("test:alice")
+			if gens[0] != 0 {
+				_, _, _ = c.Get(stored, new(int))
+				return false
+			}
+			return true
+		}, time.Second, 5*time.Millisecond)
+		require.True(t, c.items.Has(stored), "the old entry is still cached")
+
+		// An upload bumps the expired counter: the old entry stays unreachable.
+		rc.Invalidate("alice")
+		v, _ = cachedLoad(ctx, rc, "alice:b50", load)
+		assert.Equal(t, 2, v)
+	})
+}
+
 // ---------------------------------------------------------------------------
 // filterCacheKey determinism
 // ---------------------------------------------------------------------------
@@ -733,6 +927,12 @@ func TestFilterCacheKey(t *testing.T) {
 		assert.Equal(t, "min13.00_diff:massive_b15:true", key)
 	})
 
+	t.Run("Season filter", func(t *testing.T) {
+		f := model.RecordFilter{B15: boolPtr(true), SeasonID: intPtr(3)}
+		assert.Equal(t, "b15:true_season:3", filterCacheKey(f))
+		assert.NotEqual(t, "nofilter", filterCacheKey(model.RecordFilter{SeasonID: intPtr(3)}))
+	})
+
 	t.Run("Same filter produces same key regardless of difficulty order", func(t *testing.T) {
 		f1 := model.RecordFilter{
 			Difficulties: []model.Difficulty{model.DifficultyMassive, model.DifficultyDetected},
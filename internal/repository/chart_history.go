@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"paradigm-reboot-prober-go/internal/model"
+	"strconv"
+)
+
+// historyValue renders a field value for chart_histories.
+func historyValue(v string) *string { return &v }
+
+// levelValue renders a chart level without trailing zeros (e.g. "14.5").
+func levelValue(v float64) *string { return historyValue(strconv.FormatFloat(v, 'f', -1, 64)) }
+
+// ptrValue treats a nil *string as "unset" rather than "".
+func ptrValue(p *string) *string {
+	if p == nil {
+		return nil
+	}
+	return historyValue(*p)
+}
+
+func ptrEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// songChange builds a song-level history entry (ChartID is left nil).
+func songChange(field string, oldValue, newValue *string) model.ChartHistory {
+	return model.ChartHistory{Field: field, OldValue: oldValue, NewValue: newValue}
+}
+
+// chartChange builds a history entry for a specific chart.
+func chartChange(chart model.Chart, field string, oldValue, newValue *string) model.ChartHistory {
+	id := chart.ID
+	return model.ChartHistory{
+		ChartID:    &id,
+		Difficulty: chart.Difficulty,
+		Field:      field,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+	}
+}
+
+// diffSongBase returns a history entry for every SongBase field that differs.
+func diffSongBase(old, updated model.SongBase) []model.ChartHistory {
+	var changes []model.ChartHistory
+	for _, f := range []struct {
+		field      string
+		old, value string
+	}{
+		{"wiki_id", old.WikiID, updated.WikiID},
+		{"title", old.Title, updated.Title},
+		{"artist", old.Artist, updated.Artist},
+		{"genre", old.Genre, updated.Genre},
+		{"cover", old.Cover, updated.Cover},
+		{"illustrator", old.Illustrator, updated.Illustrator},
+		{"version", old.Version, updated.Version},
+		{"b15", strconv.FormatBool(old.B15), strconv.FormatBool(updated.B15)},
+		{"album", old.Album, updated.Album},
+		{"bpm", old.BPM, updated.BPM},
+		{"length", old.Length, updated.Length},
+	} {
+		if f.old != f.value {
+			changes = append(changes, songChange(f.field, historyValue(f.old), historyValue(f.value)))
+		}
+	}
+	return changes
+}
+
+// diffChart returns a history entry for every chart field that differs
+// between the stored chart and its update.
+func diffChart(old, updated model.Chart) []model.ChartHistory {
+	var changes []model.ChartHistory
+	if old.Level != updated.Level {
+		changes = append(changes, chartChange(old, model.ChartHistoryFieldLevel, levelValue(old.Level), levelValue(updated.Level)))
+	}
+	if old.Notes != updated.Notes {
+		changes = append(changes, chartChange(old, "notes",
+			historyValue(strconv.Itoa(old.Notes)), historyValue(strconv.Itoa(updated.Notes))))
+	}
+	// An unset level designer and an empty one are the same thing.
+	oldDesign, newDesign := "", ""
+	if old.LevelDesign != nil {
+		oldDesign = *old.LevelDesign
+	}
+	if updated.LevelDesign != nil {
+		newDesign = *updated.LevelDesign
+	}
+	if oldDesign != newDesign {
+		changes = append(changes, chartChange(old, "level_design", historyValue(oldDesign), historyValue(newDesign)))
+	}
+	for _, f := range []struct {
+		field      string
+		old, value *string
+	}{
+		{"override_title", old.OverrideTitle, updated.OverrideTitle},
+		{"override_artist", old.OverrideArtist, updated.OverrideArtist},
+		{"override_version", old.OverrideVersion, updated.OverrideVersion},
+		{"override_cover", old.OverrideCover, updated.OverrideCover},
+	} {
+		if !ptrEqual(f.old, f.value) {
+			changes = append(changes, chartChange(old, f.field, ptrValue(f.old), ptrValue(f.value)))
+		}
+	}
+	return changes
+}
+
+// DiffSong returns the history entries that updating existing to updated would
+// record, without touching the database. existing must have its charts loaded.
+func DiffSong(existing, updated *model.Song) []model.ChartHistory {
+	changes := diffSongBase(existing.SongBase, updated.SongBase)
+	existingCharts := make(map[model.Difficulty]model.Chart, len(existing.Charts))
+	for _, c := range existing.Charts {
+		existingCharts[c.Difficulty] = c
+	}
+	requested := make(map[model.Difficulty]bool, len(updated.Charts))
+	for _, c := range updated.Charts {
+		requested[c.Difficulty] = true
+		if old, ok := existingCharts[c.Difficulty]; ok {
+			changes = append(changes, diffChart(old, c)...)
+		} else {
+			changes = append(changes, chartChange(c, model.ChartHistoryFieldChart, nil, historyValue(string(c.Difficulty))))
+		}
+	}
+	for _, c := range existing.Charts {
+		if !requested[c.Difficulty] {
+			changes = append(changes, chartChange(c, model.ChartHistoryFieldChart, historyValue(string(c.Difficulty)), nil))
+		}
+	}
+	return changes
+}
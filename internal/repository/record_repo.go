@@ -1,14 +1,15 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/model"
 	"paradigm-reboot-prober-go/pkg/rating"
+	"slices"
 	"time"
 
-	"github.com/jellydator/ttlcache/v3"
 	"gorm.io/gorm"
 )
 
@@ -35,10 +36,25 @@ type RecordRepository struct {
 func NewRecordRepository(db *gorm.DB) *RecordRepository {
 	return &RecordRepository{
 		db:    db,
-		cache: newRepoCache(RecordCacheTTL),
+		cache: newRepoCache("record", RecordCacheTTL),
 	}
 }
 
+// seasonCondition returns the condition selecting new (B15) songs, or old ones
+// when isNew is false, on songsTable. Songs are new if they are marked b15
+// (membership in the active season) or, when filter.SeasonID is set, if they
+// are members of that season.
+func seasonCondition(songsTable string, isNew bool, filter model.RecordFilter) (string, []any) {
+	if filter.SeasonID == nil {
+		return songsTable + ".b15 = ?", []any{isNew}
+	}
+	op := "IN"
+	if !isNew {
+		op = "NOT IN"
+	}
+	return fmt.Sprintf("%s.id %s (%s)", songsTable, op, seasonMembersQuery), []any{*filter.SeasonID}
+}
+
 // applyRecordFilter applies optional level range, difficulty, and season (B15) filters
 // to a GORM query that has Chart and Chart.Song joined.
 func applyRecordFilter(query *gorm.DB, filter model.RecordFilter) *gorm.DB {
@@ -52,7 +68,8 @@ func applyRecordFilter(query *gorm.DB, filter model.RecordFilter) *gorm.DB {
 		query = query.Where(`"Chart".difficulty IN ?`, filter.Difficulties)
 	}
 	if filter.B15 != nil {
-		query = query.Where(`"Chart__Song".b15 = ?`, *filter.B15)
+		cond, args := seasonCondition(`"Chart__Song"`, *filter.B15, filter)
+		query = query.Where(cond, args...)
 	}
 	return query
 }
@@ -74,23 +91,97 @@ func applyCountFilter(query *gorm.DB, filter model.RecordFilter, chartIDColumn s
 		query = query.Where("charts.difficulty IN ?", filter.Difficulties)
 	}
 	if filter.B15 != nil {
+		cond, args := seasonCondition("songs", *filter.B15, filter)
 		query = query.Joins("JOIN songs ON songs.id = charts.song_id").
-			Where("songs.b15 = ?", *filter.B15)
+			Where(cond, args...)
 	}
 	return query
 }
 
+// joinChartsIncludingRetired joins Chart and Chart.Song without the soft-delete
+// scope, so that records on retired charts (or charts of retired songs) keep
+// their chart info in history views. Only the play_records scope is reapplied.
+func joinChartsIncludingRetired(query *gorm.DB) *gorm.DB {
+	return query.Unscoped().
+		Joins("Chart").
+		Joins("Chart.Song").
+		Where("play_records.deleted_at IS NULL")
+}
+
+// markB15 sets the b15 flag of the songs of freshly loaded records.
+func markB15(records []model.PlayRecord, b15 bool) {
+	for i := range records {
+		if records[i].Chart != nil && records[i].Chart.Song != nil {
+			records[i].Chart.Song.B15 = b15
+		}
+	}
+}
+
+// markSeasonMembers sets the b15 flag of the songs of freshly loaded records
+// to membership in filter.SeasonID, if set.
+func markSeasonMembers(db *gorm.DB, records []model.PlayRecord, filter model.RecordFilter) error {
+	if filter.SeasonID == nil || len(records) == 0 {
+		return nil
+	}
+	var songIDs []int
+	if err := db.Raw(seasonMembersQuery, *filter.SeasonID).Scan(&songIDs).Error; err != nil {
+		return err
+	}
+	members := make(map[int]bool, len(songIDs))
+	for _, id := range songIDs {
+		members[id] = true
+	}
+	for i := range records {
+		if records[i].Chart != nil && records[i].Chart.Song != nil {
+			records[i].Chart.Song.B15 = members[records[i].Chart.Song.ID]
+		}
+	}
+	return nil
+}
+
+// InvalidateAll drops every cached record entry. Call it when the set of new
+// (B15) songs changes, which affects every user's B50.
+func (r *RecordRepository) InvalidateAll() {
+	if r.cache != nil {
+		r.cache.DeleteAll()
+	}
+}
+
 // invalidateUserRecords removes all cached record entries for a given username.
 func (r *RecordRepository) invalidateUserRecords(username string) {
 	if r.cache != nil {
-		invalidateByPrefix(r.cache, username+":")
+		r.cache.Invalidate(username)
 	}
 }
 
+// InvalidateSongRecords removes the cached records of every user who has played
+// any chart of the song. Call it after catalog edits that change how records
+// are rated or displayed.
+func (r *RecordRepository) InvalidateSongRecords(ctx context.Context, songID int) error {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	if r.cache == nil {
+		return nil
+	}
+	var usernames []string
+	if err := db.Model(&model.PlayRecord{}).
+		Joins("JOIN charts ON charts.id = play_records.chart_id").
+		Where("charts.song_id = ?", songID).
+		Distinct().Pluck("play_records.username", &usernames).Error; err != nil {
+		return err
+	}
+	for _, username := range usernames {
+		r.invalidateUserRecords(username)
+	}
+	return nil
+}
+
 // CreateRecord creates a new play record and updates the best record if necessary
-func (r *RecordRepository) CreateRecord(record *model.PlayRecord, isReplaced bool) (*model.PlayRecord, error) {
+func (r *RecordRepository) CreateRecord(ctx context.Context, record *model.PlayRecord, isReplaced bool) (*model.PlayRecord, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
 	var result *model.PlayRecord
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 		var txErr error
 		result, txErr = r.createRecordInTx(tx, record, isReplaced)
 		return txErr
@@ -103,9 +194,11 @@ func (r *RecordRepository) CreateRecord(record *model.PlayRecord, isReplaced boo
 }
 
 // BatchCreateRecords creates multiple play records atomically in a single transaction
-func (r *RecordRepository) BatchCreateRecords(records []*model.PlayRecord, isReplaced bool) ([]*model.PlayRecord, error) {
+func (r *RecordRepository) BatchCreateRecords(ctx context.Context, records []*model.PlayRecord, isReplaced bool) ([]*model.PlayRecord, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
 	var results []*model.PlayRecord
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
 		for _, record := range records {
 			savedRecord, err := r.createRecordInTx(tx, record, isReplaced)
 			if err != nil {
@@ -135,6 +228,10 @@ func (r *RecordRepository) createRecordInTx(tx *gorm.DB, record *model.PlayRecor
 	if err := tx.Where("id = ?", record.ChartID).First(&chart).Error; err != nil {
 		return nil, errors.New("chart does not exist")
 	}
+	// Charts of a retired song are retired too
+	if err := tx.Select("id").First(&model.Song{}, chart.SongID).Error; err != nil {
+		return nil, errors.New("chart does not exist")
+	}
 
 	// Calculate rating
 	calculatedRating := rating.SingleRating(chart.Level, *record.Score)
@@ -163,60 +260,87 @@ func (r *RecordRepository) createRecordInTx(tx *gorm.DB, record *model.PlayRecor
 }
 
 // GetBest50Records retrieves the best 35 (old) and 15 (new) records for B50 calculation
-func (r *RecordRepository) GetBest50Records(username string, underflow int, filter model.RecordFilter) ([]model.PlayRecord, []model.PlayRecord, error) {
-	key := b50CacheKey(username, underflow, filter)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			entry := item.Value().(*b50CacheEntry)
-			b35 := make([]model.PlayRecord, len(entry.B35))
-			copy(b35, entry.B35)
-			b15 := make([]model.PlayRecord, len(entry.B15))
-			copy(b15, entry.B15)
-			return b35, b15, nil
+func (r *RecordRepository) GetBest50Records(ctx context.Context, username string, underflow int, filter model.RecordFilter) ([]model.PlayRecord, []model.PlayRecord, error) {
+	entry, err := cachedLoad(ctx, r.cache, b50CacheKey(username, underflow, filter), func(ctx context.Context) (*b50CacheEntry, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		b35, b15, err := queryBest50Records(db, username, underflow, filter)
+		if err != nil {
+			return nil, false, err
 		}
+		return &b50CacheEntry{B35: b35, B15: b15}, true, nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	b35 := make([]model.PlayRecord, len(entry.B35))
+	copy(b35, entry.B35)
+	b15 := make([]model.PlayRecord, len(entry.B15))
+	copy(b15, entry.B15)
+	return b35, b15, nil
+}
 
+// queryBest50Records is GetBest50Records without the cache.
+func queryBest50Records(db *gorm.DB, username string, underflow int, filter model.RecordFilter) ([]model.PlayRecord, []model.PlayRecord, error) {
 	var b35 []model.PlayRecord
 	var b15 []model.PlayRecord
-
-	// Base query for best records
-	baseQuery := r.db.Model(&model.PlayRecord{}).
-		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
-		Joins("Chart").
-		Joins("Chart.Song").
-		Where("best_play_records.username = ?", username)
-	baseQuery = applyRecordFilter(baseQuery, filter)
-
-	// B35: Not B15 songs
-	if err := baseQuery.Session(&gorm.Session{}).
-		Where(`"Chart__Song".b15 = ?`, false).
-		Order("rating desc, play_records.id desc").
-		Limit(config.GlobalConfig.Game.B35Limit + underflow).
-		Find(&b35).Error; err != nil {
-		return nil, nil, err
+	for _, part := range []struct {
+		isNew   bool
+		records *[]model.PlayRecord
+	}{{false, &b35}, {true, &b15}} {
+		ranks := best50Ranks(db, []string{username}, part.isNew, filter)
+		if err := db.Model(&model.PlayRecord{}).
+			Joins("Chart").
+			Joins("Chart.Song").
+			Joins("JOIN (?) AS best50 ON best50.id = play_records.id", ranks).
+			Where("best50.best50_rank <= ?", best50Limit(part.isNew)+underflow).
+			Order("best50.best50_rank").
+			Find(part.records).Error; err != nil {
+			return nil, nil, err
+		}
 	}
-
-	// B15: B15 songs
-	if err := baseQuery.Session(&gorm.Session{}).
-		Where(`"Chart__Song".b15 = ?`, true).
-		Order("rating desc, play_records.id desc").
-		Limit(config.GlobalConfig.Game.B15Limit + underflow).
-		Find(&b15).Error; err != nil {
-		return nil, nil, err
+	if filter.SeasonID != nil {
+		// Report the b15 flag of the requested season rather than the active one
+		markB15(b35, false)
+		markB15(b15, true)
 	}
+	return b35, b15, nil
+}
 
-	if r.cache != nil {
-		r.cache.Set(key, &b50CacheEntry{B35: b35, B15: b15}, ttlcache.DefaultTTL)
+// best50Ranks selects the B15 (isNew) or B35 candidates of each of
+// usernames: their best records on live charts matching filter. Its columns
+// are id, username, chart_id, rating and best50_rank, which numbers each
+// user's candidates from 1 by rating, ties going to the newer record; the
+// B50 of a user is their candidates up to best50Limit.
+func best50Ranks(db *gorm.DB, usernames []string, isNew bool, filter model.RecordFilter) *gorm.DB {
+	query := db.Model(&model.PlayRecord{}).
+		Select("play_records.id, best_play_records.username, play_records.chart_id, play_records.rating, "+
+			"ROW_NUMBER() OVER (PARTITION BY best_play_records.username ORDER BY play_records.rating DESC, play_records.id DESC) AS best50_rank").
+		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id AND best_play_records.deleted_at IS NULL").
+		// Retired charts and charts of retired songs never count towards B50.
+		// The aliases are those of Joins("Chart.Song"), which the filters use.
+		Joins(`JOIN charts "Chart" ON "Chart".id = play_records.chart_id AND "Chart".deleted_at IS NULL`).
+		Joins(`JOIN songs "Chart__Song" ON "Chart__Song".id = "Chart".song_id AND "Chart__Song".deleted_at IS NULL`).
+		Where("best_play_records.username IN ?", usernames)
+	cond, args := seasonCondition(`"Chart__Song"`, isNew, filter)
+	return applyRecordFilter(query, filter).Where(cond, args...)
+}
+
+// best50Limit is the number of B15 (isNew) or B35 records in a B50.
+func best50Limit(isNew bool) int {
+	if isNew {
+		return config.GlobalConfig.Game.B15Limit
 	}
-	return b35, b15, nil
+	return config.GlobalConfig.Game.B35Limit
 }
 
-// GetAllRecords retrieves all records for a user with pagination and sorting
-func (r *RecordRepository) GetAllRecords(username string, pageSize, pageIndex int, sortBy string, order bool, filter model.RecordFilter) ([]model.PlayRecord, error) {
+// GetAllRecords retrieves all records for a user with pagination and sorting.
+// Records on retired charts are included.
+func (r *RecordRepository) GetAllRecords(ctx context.Context, username string, pageSize, pageIndex int, sortBy string, order bool, filter model.RecordFilter) ([]model.PlayRecord, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var records []model.PlayRecord
-	query := r.db.Where("username = ?", username).
-		Joins("Chart").
-		Joins("Chart.Song")
+	query := joinChartsIncludingRetired(db.Where("username = ?", username))
 	query = applyRecordFilter(query, filter)
 
 	orderStr := "desc"
@@ -229,18 +353,21 @@ func (r *RecordRepository) GetAllRecords(username string, pageSize, pageIndex in
 	query = query.Order(safeSortBy + " " + orderStr)
 
 	// pageIndex is 0-indexed from the service layer
-	err := query.Offset(pageSize * pageIndex).Limit(pageSize).Find(&records).Error
-	return records, err
+	if err := query.Offset(pageSize * pageIndex).Limit(pageSize).Find(&records).Error; err != nil {
+		return records, err
+	}
+	return records, markSeasonMembers(db, records, filter)
 }
 
-// GetBestRecords retrieves the best records for a user with pagination and sorting
-func (r *RecordRepository) GetBestRecords(username string, pageSize, pageIndex int, sortBy string, order bool, filter model.RecordFilter) ([]model.PlayRecord, error) {
+// GetBestRecords retrieves the best records for a user with pagination and sorting.
+// Records on retired charts are included.
+func (r *RecordRepository) GetBestRecords(ctx context.Context, username string, pageSize, pageIndex int, sortBy string, order bool, filter model.RecordFilter) ([]model.PlayRecord, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var records []model.PlayRecord
-	query := r.db.Model(&model.PlayRecord{}).
+	query := joinChartsIncludingRetired(db.Model(&model.PlayRecord{}).
 		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
-		Joins("Chart").
-		Joins("Chart.Song").
-		Where("play_records.username = ?", username)
+		Where("play_records.username = ?", username))
 	query = applyRecordFilter(query, filter)
 
 	orderStr := "desc"
@@ -253,30 +380,40 @@ func (r *RecordRepository) GetBestRecords(username string, pageSize, pageIndex i
 	query = query.Order(safeSortBy + " " + orderStr)
 
 	// pageIndex is 0-indexed from the service layer
-	err := query.Offset(pageSize * pageIndex).Limit(pageSize).Find(&records).Error
-	return records, err
+	if err := query.Offset(pageSize * pageIndex).Limit(pageSize).Find(&records).Error; err != nil {
+		return records, err
+	}
+	return records, markSeasonMembers(db, records, filter)
 }
 
 // GetAllChartsWithBestScores retrieves all charts with the user's best score (if any)
-func (r *RecordRepository) GetAllChartsWithBestScores(username string, filter model.RecordFilter) ([]model.ChartWithScore, error) {
-	key := allChartsCacheKey(username, filter)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().([]model.ChartWithScore)
-			cp := make([]model.ChartWithScore, len(original))
-			copy(cp, original)
-			return cp, nil
-		}
+func (r *RecordRepository) GetAllChartsWithBestScores(ctx context.Context, username string, filter model.RecordFilter) ([]model.ChartWithScore, error) {
+	results, err := cachedLoad(ctx, r.cache, allChartsCacheKey(username, filter), func(ctx context.Context) ([]model.ChartWithScore, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		results, err := queryAllChartsWithBestScores(db, username, filter)
+		return results, err == nil, err
+	})
+	if err != nil {
+		return results, err
 	}
+	cp := make([]model.ChartWithScore, len(results))
+	copy(cp, results)
+	return cp, nil
+}
 
+// queryAllChartsWithBestScores is GetAllChartsWithBestScores without the cache.
+func queryAllChartsWithBestScores(db *gorm.DB, username string, filter model.RecordFilter) ([]model.ChartWithScore, error) {
 	var results []model.ChartWithScore
 
-	query := r.db.Table("charts").
+	query := db.Table("charts").
 		Select("charts.id, COALESCE(charts.override_title, songs.title) as title, COALESCE(charts.override_version, songs.version) as version, charts.difficulty, charts.level, COALESCE(play_records.score, 0) as score").
 		Joins("JOIN songs ON charts.song_id = songs.id").
 		Joins("LEFT JOIN play_records ON charts.id = play_records.chart_id AND play_records.username = ?", username).
 		Joins("LEFT JOIN best_play_records ON play_records.id = best_play_records.play_record_id").
-		Where("play_records.id IS NULL OR best_play_records.play_record_id IS NOT NULL")
+		Where("play_records.id IS NULL OR best_play_records.play_record_id IS NOT NULL").
+		// Table() bypasses the soft-delete scope; retired charts are not listed
+		Where("charts.deleted_at IS NULL AND songs.deleted_at IS NULL")
 
 	// Apply filters directly on charts / songs tables
 	if filter.MinLevel != nil {
@@ -289,24 +426,20 @@ func (r *RecordRepository) GetAllChartsWithBestScores(username string, filter mo
 		query = query.Where("charts.difficulty IN ?", filter.Difficulties)
 	}
 	if filter.B15 != nil {
-		query = query.Where("songs.b15 = ?", *filter.B15)
+		cond, args := seasonCondition("songs", *filter.B15, filter)
+		query = query.Where(cond, args...)
 	}
 
 	err := query.Scan(&results).Error
-	if err != nil {
-		return results, err
-	}
-
-	if r.cache != nil {
-		r.cache.Set(key, results, ttlcache.DefaultTTL)
-	}
-	return results, nil
+	return results, err
 }
 
 // CountBestRecords counts the number of best records for a user
-func (r *RecordRepository) CountBestRecords(username string, filter model.RecordFilter) (int64, error) {
+func (r *RecordRepository) CountBestRecords(ctx context.Context, username string, filter model.RecordFilter) (int64, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var count int64
-	query := r.db.Model(&model.BestPlayRecord{}).
+	query := db.Model(&model.BestPlayRecord{}).
 		Where("username = ?", username)
 	query = applyCountFilter(query, filter, "best_play_records.chart_id")
 	err := query.Count(&count).Error
@@ -314,9 +447,11 @@ func (r *RecordRepository) CountBestRecords(username string, filter model.Record
 }
 
 // CountAllRecords counts the total number of records for a user
-func (r *RecordRepository) CountAllRecords(username string, filter model.RecordFilter) (int64, error) {
+func (r *RecordRepository) CountAllRecords(ctx context.Context, username string, filter model.RecordFilter) (int64, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var count int64
-	query := r.db.Model(&model.PlayRecord{}).
+	query := db.Model(&model.PlayRecord{}).
 		Where("play_records.username = ?", username)
 	query = applyCountFilter(query, filter, "play_records.chart_id")
 	err := query.Count(&count).Error
@@ -324,41 +459,35 @@ func (r *RecordRepository) CountAllRecords(username string, filter model.RecordF
 }
 
 // GetBestRecordsBySong retrieves the best record per difficulty for a specific song
-func (r *RecordRepository) GetBestRecordsBySong(username string, songID int) ([]model.PlayRecord, error) {
-	key := bestSongCacheKey(username, songID)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().([]model.PlayRecord)
-			cp := make([]model.PlayRecord, len(original))
-			copy(cp, original)
-			return cp, nil
-		}
-	}
-
-	var records []model.PlayRecord
-	err := r.db.Model(&model.PlayRecord{}).
-		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
-		Joins("Chart").
-		Joins("Chart.Song").
-		Where(`play_records.username = ? AND "Chart".song_id = ?`, username, songID).
-		Order("rating desc").
-		Find(&records).Error
+func (r *RecordRepository) GetBestRecordsBySong(ctx context.Context, username string, songID int) ([]model.PlayRecord, error) {
+	records, err := cachedLoad(ctx, r.cache, bestSongCacheKey(username, songID), func(ctx context.Context) ([]model.PlayRecord, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var records []model.PlayRecord
+		err := db.Model(&model.PlayRecord{}).
+			Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
+			Joins("Chart").
+			Joins("Chart.Song").
+			Where(`play_records.username = ? AND "Chart".song_id = ?`, username, songID).
+			Order("rating desc").
+			Find(&records).Error
+		return records, err == nil, err
+	})
 	if err != nil {
 		return records, err
 	}
-
-	if r.cache != nil {
-		r.cache.Set(key, records, ttlcache.DefaultTTL)
-	}
-	return records, err
+	cp := make([]model.PlayRecord, len(records))
+	copy(cp, records)
+	return cp, nil
 }
 
-// GetAllRecordsBySong retrieves all records for a specific song with pagination and sorting
-func (r *RecordRepository) GetAllRecordsBySong(username string, songID int, pageSize, pageIndex int, sortBy string, order bool) ([]model.PlayRecord, error) {
+// GetAllRecordsBySong retrieves all records for a specific song with pagination and sorting.
+// Records on retired charts of the song are included.
+func (r *RecordRepository) GetAllRecordsBySong(ctx context.Context, username string, songID int, pageSize, pageIndex int, sortBy string, order bool) ([]model.PlayRecord, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var records []model.PlayRecord
-	query := r.db.Where("play_records.username = ?", username).
-		Joins("Chart").
-		Joins("Chart.Song").
+	query := joinChartsIncludingRetired(db.Where("play_records.username = ?", username)).
 		Where(`"Chart".song_id = ?`, songID)
 
 	orderStr := "desc"
@@ -374,9 +503,11 @@ func (r *RecordRepository) GetAllRecordsBySong(username string, songID int, page
 }
 
 // CountAllRecordsBySong counts the total number of records for a specific song
-func (r *RecordRepository) CountAllRecordsBySong(username string, songID int) (int64, error) {
+func (r *RecordRepository) CountAllRecordsBySong(ctx context.Context, username string, songID int) (int64, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var count int64
-	err := r.db.Model(&model.PlayRecord{}).
+	err := db.Model(&model.PlayRecord{}).
 		Joins("JOIN charts ON charts.id = play_records.chart_id").
 		Where("play_records.username = ? AND charts.song_id = ?", username, songID).
 		Count(&count).Error
@@ -384,44 +515,39 @@ func (r *RecordRepository) CountAllRecordsBySong(username string, songID int) (i
 }
 
 // GetBestRecordByChart retrieves the best record for a specific chart
-func (r *RecordRepository) GetBestRecordByChart(username string, chartID int) (*model.PlayRecord, error) {
-	key := bestChartCacheKey(username, chartID)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().(*model.PlayRecord)
-			cp := *original
-			return &cp, nil
-		}
-	}
-
-	var record model.PlayRecord
-	err := r.db.Model(&model.PlayRecord{}).
-		Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
-		Joins("Chart").
-		Joins("Chart.Song").
-		Where("play_records.username = ? AND play_records.chart_id = ?", username, chartID).
-		First(&record).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+func (r *RecordRepository) GetBestRecordByChart(ctx context.Context, username string, chartID int) (*model.PlayRecord, error) {
+	// A missing record is not cached
+	record, err := cachedLoad(ctx, r.cache, bestChartCacheKey(username, chartID), func(ctx context.Context) (*model.PlayRecord, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var record model.PlayRecord
+		err := db.Model(&model.PlayRecord{}).
+			Joins("JOIN best_play_records ON best_play_records.play_record_id = play_records.id").
+			Joins("Chart").
+			Joins("Chart.Song").
+			Where("play_records.username = ? AND play_records.chart_id = ?", username, chartID).
+			First(&record).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
 		}
+		return &record, true, nil
+	})
+	if record == nil || err != nil {
 		return nil, err
 	}
-
-	if r.cache != nil {
-		r.cache.Set(key, &record, ttlcache.DefaultTTL)
-		cp := record
-		return &cp, nil
-	}
-	return &record, nil
+	cp := *record
+	return &cp, nil
 }
 
 // GetAllRecordsByChart retrieves all records for a specific chart with pagination and sorting
-func (r *RecordRepository) GetAllRecordsByChart(username string, chartID int, pageSize, pageIndex int, sortBy string, order bool) ([]model.PlayRecord, error) {
+func (r *RecordRepository) GetAllRecordsByChart(ctx context.Context, username string, chartID int, pageSize, pageIndex int, sortBy string, order bool) ([]model.PlayRecord, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var records []model.PlayRecord
-	query := r.db.Where("play_records.username = ? AND play_records.chart_id = ?", username, chartID).
-		Joins("Chart").
-		Joins("Chart.Song")
+	query := joinChartsIncludingRetired(db.Where("play_records.username = ? AND play_records.chart_id = ?", username, chartID))
 
 	orderStr := "desc"
 	if !order {
@@ -436,9 +562,11 @@ func (r *RecordRepository) GetAllRecordsByChart(username string, chartID int, pa
 }
 
 // CountAllRecordsByChart counts the total number of records for a specific chart
-func (r *RecordRepository) CountAllRecordsByChart(username string, chartID int) (int64, error) {
+func (r *RecordRepository) CountAllRecordsByChart(ctx context.Context, username string, chartID int) (int64, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var count int64
-	err := r.db.Model(&model.PlayRecord{}).
+	err := db.Model(&model.PlayRecord{}).
 		Where("username = ? AND chart_id = ?", username, chartID).
 		Count(&count).Error
 	return count, err
@@ -471,3 +599,45 @@ func RecalculateRatingsByChart(tx *gorm.DB, chartID int, newLevel float64) error
 	}
 	return nil
 }
+
+// B50Summary is a user's B50 reduced to its rating sum and the charts it contains.
+type B50Summary struct {
+	Sum      int
+	ChartIDs []int // sorted ascending
+}
+
+// b50SummaryBatchSize bounds the number of usernames per IN clause.
+const b50SummaryBatchSize = 500
+
+// b50Summaries computes the B50 of each given user (without filters or
+// underflow) with best50Ranks, the selection behind GetBest50Records.
+func b50Summaries(db *gorm.DB, usernames []string) (map[string]B50Summary, error) {
+	result := make(map[string]B50Summary, len(usernames))
+	for batch := range slices.Chunk(usernames, b50SummaryBatchSize) {
+		for _, isNew := range []bool{false, true} {
+			var rows []struct {
+				Username string
+				ChartID  int
+				Rating   int
+			}
+			if err := db.Table("(?) AS best50", best50Ranks(db, batch, isNew, model.RecordFilter{})).
+				Select("username, chart_id, rating").
+				Where("best50_rank <= ?", best50Limit(isNew)).
+				Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				summary := result[row.Username]
+				summary.Sum += row.Rating
+				summary.ChartIDs = append(summary.ChartIDs, row.ChartID)
+				result[row.Username] = summary
+			}
+		}
+	}
+	for _, username := range usernames {
+		summary := result[username]
+		slices.Sort(summary.ChartIDs)
+		result[username] = summary
+	}
+	return result, nil
+}
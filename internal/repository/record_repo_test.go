@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"paradigm-reboot-prober-go/internal/model"
 	"paradigm-reboot-prober-go/pkg/rating"
 	"testing"
@@ -9,6 +10,7 @@ import (
 )
 
 func TestRecordRepository_CreateRecord(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewRecordRepository(db)
 	songRepo := NewSongRepository(db)
@@ -17,7 +19,7 @@ func TestRecordRepository_CreateRecord(t *testing.T) {
 		SongBase: model.SongBase{WikiID: "rec_song", Title: "Record Song"},
 		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
 	}
-	createdSong, _ := songRepo.CreateSong(song)
+	createdSong, _ := songRepo.CreateSong(ctx, song)
 	chartID := createdSong.Charts[0].ID
 
 	tests := []struct {
@@ -39,7 +41,7 @@ func TestRecordRepository_CreateRecord(t *testing.T) {
 				PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(tt.score)},
 				Username:       "testuser",
 			}
-			saved, err := repo.CreateRecord(record, tt.isReplaced)
+			saved, err := repo.CreateRecord(ctx, record, tt.isReplaced)
 			assert.NoError(t, err)
 			assert.NotNil(t, saved)
 			assert.Equal(t, tt.score, *saved.Score)
@@ -61,28 +63,29 @@ func TestRecordRepository_CreateRecord(t *testing.T) {
 }
 
 func TestRecordRepository_GetBest50Records(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewRecordRepository(db)
 	songRepo := NewSongRepository(db)
 
-	songB15, _ := songRepo.CreateSong(&model.Song{
+	songB15, _ := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "b15_song", Title: "B15 Song", B15: true},
 		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0}},
 	})
-	songOld, _ := songRepo.CreateSong(&model.Song{
+	songOld, _ := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "old_song", Title: "Old Song", B15: false},
 		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0}},
 	})
 
 	for _, chartID := range []int{songB15.Charts[0].ID, songOld.Charts[0].ID} {
-		_, err := repo.CreateRecord(&model.PlayRecord{
+		_, err := repo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1000000)},
 			Username:       "user_b50",
 		}, false)
 		assert.NoError(t, err)
 	}
 
-	b35, b15, err := repo.GetBest50Records("user_b50", 0, model.RecordFilter{})
+	b35, b15, err := repo.GetBest50Records(ctx, "user_b50", 0, model.RecordFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, b15, 1)
 	assert.Len(t, b35, 1)
@@ -91,18 +94,19 @@ func TestRecordRepository_GetBest50Records(t *testing.T) {
 }
 
 func TestRecordRepository_PerSongQueries(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewRecordRepository(db)
 	songRepo := NewSongRepository(db)
 
-	song1, _ := songRepo.CreateSong(&model.Song{
+	song1, _ := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "song_a", Title: "Song A"},
 		Charts: []model.Chart{
 			{Difficulty: model.DifficultyDetected, Level: 5.0, Notes: 200},
 			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
 		},
 	})
-	song2, _ := songRepo.CreateSong(&model.Song{
+	song2, _ := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "song_b", Title: "Song B"},
 		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14.0, Notes: 900}},
 	})
@@ -115,7 +119,7 @@ func TestRecordRepository_PerSongQueries(t *testing.T) {
 		{song2.Charts[0].ID, 1000000},
 	}
 	for _, s := range seedRecords {
-		_, err := repo.CreateRecord(&model.PlayRecord{
+		_, err := repo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: s.chartID, Score: intPtr(s.score)},
 			Username:       "user_song",
 		}, false)
@@ -128,27 +132,27 @@ func TestRecordRepository_PerSongQueries(t *testing.T) {
 		wantCount int
 	}{
 		{"BestBySong song1", func() (int, error) {
-			r, e := repo.GetBestRecordsBySong("user_song", song1.ID)
+			r, e := repo.GetBestRecordsBySong(ctx, "user_song", song1.ID)
 			return len(r), e
 		}, 2},
 		{"BestBySong song2", func() (int, error) {
-			r, e := repo.GetBestRecordsBySong("user_song", song2.ID)
+			r, e := repo.GetBestRecordsBySong(ctx, "user_song", song2.ID)
 			return len(r), e
 		}, 1},
 		{"AllBySong song1", func() (int, error) {
-			r, e := repo.GetAllRecordsBySong("user_song", song1.ID, 10, 0, "rating", true)
+			r, e := repo.GetAllRecordsBySong(ctx, "user_song", song1.ID, 10, 0, "rating", true)
 			return len(r), e
 		}, 3},
 		{"CountBySong song1", func() (int, error) {
-			c, e := repo.CountAllRecordsBySong("user_song", song1.ID)
+			c, e := repo.CountAllRecordsBySong(ctx, "user_song", song1.ID)
 			return int(c), e
 		}, 3},
 		{"CountBySong song2", func() (int, error) {
-			c, e := repo.CountAllRecordsBySong("user_song", song2.ID)
+			c, e := repo.CountAllRecordsBySong(ctx, "user_song", song2.ID)
 			return int(c), e
 		}, 1},
 		{"BestBySong nonexistent user", func() (int, error) {
-			r, e := repo.GetBestRecordsBySong("nobody", song1.ID)
+			r, e := repo.GetBestRecordsBySong(ctx, "nobody", song1.ID)
 			return len(r), e
 		}, 0},
 	}
@@ -163,11 +167,12 @@ func TestRecordRepository_PerSongQueries(t *testing.T) {
 }
 
 func TestRecordRepository_PerChartQueries(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewRecordRepository(db)
 	songRepo := NewSongRepository(db)
 
-	song, _ := songRepo.CreateSong(&model.Song{
+	song, _ := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "chart_q", Title: "Chart Query Song"},
 		Charts: []model.Chart{
 			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
@@ -182,14 +187,14 @@ func TestRecordRepository_PerChartQueries(t *testing.T) {
 		{massiveID, 1000000}, {massiveID, 1005000}, {massiveID, 900000},
 		{detectedID, 1000000},
 	} {
-		_, _ = repo.CreateRecord(&model.PlayRecord{
+		_, _ = repo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: s.chartID, Score: intPtr(s.score)},
 			Username:       "user_chart",
 		}, false)
 	}
 
 	t.Run("GetBestRecordByChart", func(t *testing.T) {
-		record, err := repo.GetBestRecordByChart("user_chart", massiveID)
+		record, err := repo.GetBestRecordByChart(ctx, "user_chart", massiveID)
 		assert.NoError(t, err)
 		assert.NotNil(t, record)
 		assert.Equal(t, 1005000, *record.Score)
@@ -198,7 +203,7 @@ func TestRecordRepository_PerChartQueries(t *testing.T) {
 	})
 
 	t.Run("GetBestRecordByChart no record", func(t *testing.T) {
-		record, err := repo.GetBestRecordByChart("nobody", massiveID)
+		record, err := repo.GetBestRecordByChart(ctx, "nobody", massiveID)
 		assert.NoError(t, err)
 		assert.Nil(t, record)
 	})
@@ -209,23 +214,23 @@ func TestRecordRepository_PerChartQueries(t *testing.T) {
 		wantCount int
 	}{
 		{"All massive", func() (int, error) {
-			r, e := repo.GetAllRecordsByChart("user_chart", massiveID, 10, 0, "score", true)
+			r, e := repo.GetAllRecordsByChart(ctx, "user_chart", massiveID, 10, 0, "score", true)
 			return len(r), e
 		}, 3},
 		{"Pagination page0", func() (int, error) {
-			r, e := repo.GetAllRecordsByChart("user_chart", massiveID, 2, 0, "score", true)
+			r, e := repo.GetAllRecordsByChart(ctx, "user_chart", massiveID, 2, 0, "score", true)
 			return len(r), e
 		}, 2},
 		{"Pagination page1", func() (int, error) {
-			r, e := repo.GetAllRecordsByChart("user_chart", massiveID, 2, 1, "score", true)
+			r, e := repo.GetAllRecordsByChart(ctx, "user_chart", massiveID, 2, 1, "score", true)
 			return len(r), e
 		}, 1},
 		{"Count massive", func() (int, error) {
-			c, e := repo.CountAllRecordsByChart("user_chart", massiveID)
+			c, e := repo.CountAllRecordsByChart(ctx, "user_chart", massiveID)
 			return int(c), e
 		}, 3},
 		{"Count detected", func() (int, error) {
-			c, e := repo.CountAllRecordsByChart("user_chart", detectedID)
+			c, e := repo.CountAllRecordsByChart(ctx, "user_chart", detectedID)
 			return int(c), e
 		}, 1},
 	}
@@ -240,11 +245,12 @@ func TestRecordRepository_PerChartQueries(t *testing.T) {
 }
 
 func TestRecalculateRatingsByChart(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	songRepo := NewSongRepository(db)
 	recordRepo := NewRecordRepository(db)
 
-	song, err := songRepo.CreateSong(&model.Song{
+	song, err := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "recalc_song", Title: "Recalc Song"},
 		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
 	})
@@ -253,7 +259,7 @@ func TestRecalculateRatingsByChart(t *testing.T) {
 
 	scores := []int{1000000, 1005000, 900000}
 	for _, score := range scores {
-		_, err := recordRepo.CreateRecord(&model.PlayRecord{
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(score)},
 			Username:       "user_recalc",
 		}, false)
@@ -286,12 +292,13 @@ func TestRecalculateRatingsByChart(t *testing.T) {
 }
 
 func TestRecordRepository_RecordFilter(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewRecordRepository(db)
 	songRepo := NewSongRepository(db)
 
 	// Song 1 (b15=false): detected/10.0, invaded/12.5, massive/14.0
-	song1, err := songRepo.CreateSong(&model.Song{
+	song1, err := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "filter_s1", Title: "Filter Song 1", B15: false},
 		Charts: []model.Chart{
 			{Difficulty: model.DifficultyDetected, Level: 10.0, Notes: 200},
@@ -302,7 +309,7 @@ func TestRecordRepository_RecordFilter(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Song 2 (b15=true): massive/13.5, reboot/15.0
-	song2, err := songRepo.CreateSong(&model.Song{
+	song2, err := songRepo.CreateSong(ctx, &model.Song{
 		SongBase: model.SongBase{WikiID: "filter_s2", Title: "Filter Song 2", B15: true},
 		Charts: []model.Chart{
 			{Difficulty: model.DifficultyMassive, Level: 13.5, Notes: 700},
@@ -313,7 +320,7 @@ func TestRecordRepository_RecordFilter(t *testing.T) {
 
 	// Create one play record per chart
 	for _, chart := range append(song1.Charts, song2.Charts...) {
-		_, err := repo.CreateRecord(&model.PlayRecord{
+		_, err := repo.CreateRecord(ctx, &model.PlayRecord{
 			PlayRecordBase: model.PlayRecordBase{ChartID: chart.ID, Score: intPtr(1000000)},
 			Username:       "filter_user",
 		}, false)
@@ -344,28 +351,28 @@ func TestRecordRepository_RecordFilter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bestRecords, err := repo.GetBestRecords("filter_user", 100, 0, "rating", true, tt.filter)
+			bestRecords, err := repo.GetBestRecords(ctx, "filter_user", 100, 0, "rating", true, tt.filter)
 			assert.NoError(t, err)
 			assert.Len(t, bestRecords, tt.wantBestCount, "GetBestRecords")
 
-			bestCount, err := repo.CountBestRecords("filter_user", tt.filter)
+			bestCount, err := repo.CountBestRecords(ctx, "filter_user", tt.filter)
 			assert.NoError(t, err)
 			assert.Equal(t, int64(tt.wantBestCount), bestCount, "CountBestRecords")
 
-			allRecords, err := repo.GetAllRecords("filter_user", 100, 0, "rating", true, tt.filter)
+			allRecords, err := repo.GetAllRecords(ctx, "filter_user", 100, 0, "rating", true, tt.filter)
 			assert.NoError(t, err)
 			assert.Len(t, allRecords, tt.wantAllCount, "GetAllRecords")
 
-			allCount, err := repo.CountAllRecords("filter_user", tt.filter)
+			allCount, err := repo.CountAllRecords(ctx, "filter_user", tt.filter)
 			assert.NoError(t, err)
 			assert.Equal(t, int64(tt.wantAllCount), allCount, "CountAllRecords")
 
-			b35, b15, err := repo.GetBest50Records("filter_user", 0, tt.filter)
+			b35, b15, err := repo.GetBest50Records(ctx, "filter_user", 0, tt.filter)
 			assert.NoError(t, err)
 			assert.Len(t, b35, tt.wantB35Count, "B35")
 			assert.Len(t, b15, tt.wantB15Count, "B15")
 
-			charts, err := repo.GetAllChartsWithBestScores("filter_user", tt.filter)
+			charts, err := repo.GetAllChartsWithBestScores(ctx, "filter_user", tt.filter)
 			assert.NoError(t, err)
 			assert.Len(t, charts, tt.wantChartsCount, "AllChartsWithBestScores")
 		})
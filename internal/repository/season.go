@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"paradigm-reboot-prober-go/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoActiveSeason is returned when a song is marked b15 while seasons exist
+// but none of them is active, so there is no season to add the song to.
+var ErrNoActiveSeason = errors.New("no season is active, b15 cannot be set")
+
+// seasonMembersQuery selects the member song IDs of a season; use it as
+// `songs.id IN (?)` with the season ID as argument.
+const seasonMembersQuery = "SELECT song_id FROM season_songs WHERE season_id = ?"
+
+// activeSeason returns the season active at now, or nil if none is.
+func activeSeason(db *gorm.DB, now time.Time) (*model.Season, error) {
+	var season model.Season
+	err := db.Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Order("starts_at desc, id desc").
+		First(&season).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &season, nil
+}
+
+// hasSeasons reports whether any season has been defined. Without seasons,
+// songs.b15 is edited directly.
+func hasSeasons(db *gorm.DB) (bool, error) {
+	var count int64
+	if err := db.Model(&model.Season{}).Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// syncSongSeasonInTx adds the song to, or removes it from, the active season
+// so that its membership matches b15. It does nothing before the first season
+// is created.
+func syncSongSeasonInTx(tx *gorm.DB, songID int, b15 bool) error {
+	ok, err := hasSeasons(tx)
+	if err != nil || !ok {
+		return err
+	}
+	season, err := activeSeason(tx, time.Now())
+	if err != nil {
+		return err
+	}
+	if season == nil {
+		if b15 {
+			return ErrNoActiveSeason
+		}
+		return nil
+	}
+	if b15 {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.SeasonSong{SeasonID: season.ID, SongID: songID}).Error
+	}
+	return tx.Where("season_id = ? AND song_id = ?", season.ID, songID).
+		Delete(&model.SeasonSong{}).Error
+}
+
+// GetAllSeasons retrieves all seasons, oldest first
+func (r *SongRepository) GetAllSeasons(ctx context.Context) ([]model.Season, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var seasons []model.Season
+	if err := db.Order("starts_at, id").Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// GetSeasonByID retrieves a season by its ID
+func (r *SongRepository) GetSeasonByID(ctx context.Context, seasonID int) (*model.Season, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var season model.Season
+	if err := db.First(&season, seasonID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &season, nil
+}
+
+// GetSeasonByName retrieves a season by its name
+func (r *SongRepository) GetSeasonByName(ctx context.Context, name string) (*model.Season, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var season model.Season
+	if err := db.Where("name = ?", name).First(&season).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &season, nil
+}
+
+// GetActiveSeason retrieves the season active at now, or nil if none is
+func (r *SongRepository) GetActiveSeason(ctx context.Context, now time.Time) (*model.Season, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	return activeSeason(db, now)
+}
+
+// GetSeasonSongIDs retrieves the member song IDs of a season, ascending
+func (r *SongRepository) GetSeasonSongIDs(ctx context.Context, seasonID int) ([]int, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	songIDs := make([]int, 0)
+	if err := db.Model(&model.SeasonSong{}).
+		Where("season_id = ?", seasonID).
+		Order("song_id").
+		Pluck("song_id", &songIDs).Error; err != nil {
+		return nil, err
+	}
+	return songIDs, nil
+}
+
+// CountExistingSongs counts how many of songIDs belong to existing (live or
+// retired) songs
+func (r *SongRepository) CountExistingSongs(ctx context.Context, songIDs []int) (int64, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var count int64
+	if len(songIDs) == 0 {
+		return 0, nil
+	}
+	err := db.Unscoped().Model(&model.Song{}).Where("id IN ?", songIDs).Count(&count).Error
+	return count, err
+}
+
+// GetB15SongIDs retrieves the IDs of all songs currently marked b15
+func (r *SongRepository) GetB15SongIDs(ctx context.Context) ([]int, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var songIDs []int
+	if err := db.Unscoped().Model(&model.Song{}).Where("b15 = ?", true).Pluck("id", &songIDs).Error; err != nil {
+		return nil, err
+	}
+	return songIDs, nil
+}
+
+// CreateSeason creates a season with the given member songs
+func (r *SongRepository) CreateSeason(ctx context.Context, season *model.Season, songIDs []int) (*model.Season, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(season).Error; err != nil {
+			return err
+		}
+		return setSeasonSongsInTx(tx, season.ID, songIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return season, nil
+}
+
+// UpdateSeason saves the name and schedule of a season
+func (r *SongRepository) UpdateSeason(ctx context.Context, season *model.Season) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	return db.Model(season).Select("name", "starts_at", "ends_at").Updates(season).Error
+}
+
+// SetSeasonSongs replaces the member songs of a season
+func (r *SongRepository) SetSeasonSongs(ctx context.Context, seasonID int, songIDs []int) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		return setSeasonSongsInTx(tx, seasonID, songIDs)
+	})
+}
+
+func setSeasonSongsInTx(tx *gorm.DB, seasonID int, songIDs []int) error {
+	if err := tx.Where("season_id = ?", seasonID).Delete(&model.SeasonSong{}).Error; err != nil {
+		return err
+	}
+	if len(songIDs) == 0 {
+		return nil
+	}
+	members := make([]model.SeasonSong, 0, len(songIDs))
+	for _, id := range songIDs {
+		members = append(members, model.SeasonSong{SeasonID: seasonID, SongID: id})
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members).Error
+}
+
+// DeleteSeason deletes a season and its membership. Returns false if no such
+// season exists.
+func (r *SongRepository) DeleteSeason(ctx context.Context, seasonID int) (bool, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	var deleted bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("season_id = ?", seasonID).Delete(&model.SeasonSong{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Season{}, seasonID)
+		deleted = result.RowsAffected > 0
+		return result.Error
+	})
+	return deleted, err
+}
+
+// ApplyActiveSeason sets songs.b15 to membership in the season active at now
+// (all false if seasons exist but none is active). It does nothing before the
+// first season is created. Returns the active season (nil if none) and whether
+// any song changed; the song cache is flushed in that case.
+func (r *SongRepository) ApplyActiveSeason(ctx context.Context, now time.Time) (*model.Season, bool, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	var season *model.Season
+	var changed bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ok, err := hasSeasons(tx)
+		if err != nil || !ok {
+			return err
+		}
+		if season, err = activeSeason(tx, now); err != nil {
+			return err
+		}
+
+		// Retired songs are included so that restoring them shows the right flag
+		songs := tx.Unscoped().Model(&model.Song{})
+		var off, on *gorm.DB
+		if season == nil {
+			off = songs.Session(&gorm.Session{}).Where("b15 = ?", true).Update("b15", false)
+		} else {
+			off = songs.Session(&gorm.Session{}).
+				Where("b15 = ? AND id NOT IN (?)", true, tx.Raw(seasonMembersQuery, season.ID)).
+				Update("b15", false)
+			if off.Error == nil {
+				on = songs.Session(&gorm.Session{}).
+					Where("b15 = ? AND id IN (?)", false, tx.Raw(seasonMembersQuery, season.ID)).
+					Update("b15", true)
+			}
+		}
+		if off.Error != nil {
+			return off.Error
+		}
+		changed = off.RowsAffected > 0
+		if on != nil {
+			if on.Error != nil {
+				return on.Error
+			}
+			changed = changed || on.RowsAffected > 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed && r.cache != nil {
+		r.cache.DeleteAll()
+	}
+	return season, changed, nil
+}
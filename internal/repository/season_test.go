@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongRepository_Seasons(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+
+	var songs []*model.Song
+	for i, wikiID := range []string{"season_a", "season_b", "season_c"} {
+		song, err := songRepo.CreateSong(ctx, &model.Song{
+			SongBase: model.SongBase{WikiID: wikiID, Title: wikiID, B15: i == 0},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0 - float64(i)}},
+		})
+		require.NoError(t, err)
+		songs = append(songs, song)
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: song.Charts[0].ID, Score: intPtr(1000000)},
+			Username:       "season_user",
+		}, false)
+		require.NoError(t, err)
+	}
+	a, b, c := songs[0].ID, songs[1].ID, songs[2].ID
+	b15Of := func(songID int) bool {
+		var song model.Song
+		require.NoError(t, db.Unscoped().First(&song, songID).Error)
+		return song.B15
+	}
+	now := time.Now()
+
+	t.Run("Without seasons b15 is left alone", func(t *testing.T) {
+		season, changed, err := songRepo.ApplyActiveSeason(ctx, now)
+		require.NoError(t, err)
+		assert.Nil(t, season)
+		assert.False(t, changed)
+		assert.True(t, b15Of(a))
+
+		// Editing b15 needs no season either
+		_, _, err = songRepo.UpdateSong(ctx, c, &model.Song{
+			SongBase: model.SongBase{WikiID: "season_c", Title: "season_c", B15: true},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 13.0}},
+		}, "admin")
+		require.NoError(t, err)
+		assert.True(t, b15Of(c))
+	})
+
+	past, err := songRepo.CreateSeason(ctx, &model.Season{
+		Name:     "Past",
+		StartsAt: now.Add(-48 * time.Hour),
+		EndsAt:   timePtr(now.Add(-24 * time.Hour)),
+	}, []int{a})
+	require.NoError(t, err)
+	current, err := songRepo.CreateSeason(ctx, &model.Season{
+		Name:     "Current",
+		StartsAt: now.Add(-24 * time.Hour),
+	}, []int{b})
+	require.NoError(t, err)
+
+	t.Run("Active season decides b15", func(t *testing.T) {
+		active, err := songRepo.GetActiveSeason(ctx, now)
+		require.NoError(t, err)
+		require.NotNil(t, active)
+		assert.Equal(t, current.ID, active.ID)
+
+		season, changed, err := songRepo.ApplyActiveSeason(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, current.ID, season.ID)
+		assert.True(t, changed)
+		assert.Equal(t, []bool{false, true, false}, []bool{b15Of(a), b15Of(b), b15Of(c)})
+
+		_, changed, err = songRepo.ApplyActiveSeason(ctx, now)
+		require.NoError(t, err)
+		assert.False(t, changed, "applying again is a no-op")
+
+		// Before "Current" started, "Past" was active
+		season, _, err = songRepo.ApplyActiveSeason(ctx, now.Add(-36*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, past.ID, season.ID)
+		assert.True(t, b15Of(a))
+		_, _, err = songRepo.ApplyActiveSeason(ctx, now)
+		require.NoError(t, err)
+	})
+
+	t.Run("b15 edits change active season membership", func(t *testing.T) {
+		_, _, err := songRepo.UpdateSong(ctx, c, &model.Song{
+			SongBase: model.SongBase{WikiID: "season_c", Title: "season_c", B15: true},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 13.0}},
+		}, "admin")
+		require.NoError(t, err)
+		ids, err := songRepo.GetSeasonSongIDs(ctx, current.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{b, c}, ids)
+
+		_, _, err = songRepo.UpdateSong(ctx, c, &model.Song{
+			SongBase: model.SongBase{WikiID: "season_c", Title: "season_c", B15: false},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 13.0}},
+		}, "admin")
+		require.NoError(t, err)
+		ids, err = songRepo.GetSeasonSongIDs(ctx, current.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{b}, ids)
+	})
+
+	t.Run("B50 split by a past season", func(t *testing.T) {
+		recordRepo.InvalidateAll()
+		b35, b15, err := recordRepo.GetBest50Records(ctx, "season_user", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, b15, 1)
+		assert.Equal(t, b, b15[0].Chart.Song.ID)
+		assert.Len(t, b35, 2)
+
+		b35, b15, err = recordRepo.GetBest50Records(ctx, "season_user", 0, model.RecordFilter{SeasonID: &past.ID})
+		require.NoError(t, err)
+		require.Len(t, b15, 1)
+		assert.Equal(t, a, b15[0].Chart.Song.ID)
+		assert.True(t, b15[0].Chart.Song.B15)
+		require.Len(t, b35, 2)
+		for _, r := range b35 {
+			assert.False(t, r.Chart.Song.B15)
+		}
+
+		records, err := recordRepo.GetBestRecords(ctx, "season_user", 10, 0, "rating", true,
+			model.RecordFilter{B15: boolPtr(true), SeasonID: &past.ID})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, a, records[0].Chart.Song.ID)
+		count, err := recordRepo.CountBestRecords(ctx, "season_user", model.RecordFilter{B15: boolPtr(false), SeasonID: &past.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("No active season", func(t *testing.T) {
+		require.NoError(t, songRepo.UpdateSeason(ctx, &model.Season{
+			ID: current.ID, Name: "Current", StartsAt: current.StartsAt, EndsAt: timePtr(now.Add(-time.Hour)),
+		}))
+		season, changed, err := songRepo.ApplyActiveSeason(ctx, now)
+		require.NoError(t, err)
+		assert.Nil(t, season)
+		assert.True(t, changed)
+		assert.False(t, b15Of(b))
+
+		_, _, err = songRepo.UpdateSong(ctx, c, &model.Song{
+			SongBase: model.SongBase{WikiID: "season_c", Title: "season_c", B15: true},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 13.0}},
+		}, "admin")
+		assert.ErrorIs(t, err, ErrNoActiveSeason)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		deleted, err := songRepo.DeleteSeason(ctx, past.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		ids, err := songRepo.GetSeasonSongIDs(ctx, past.ID)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		deleted, err = songRepo.DeleteSeason(ctx, past.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+}
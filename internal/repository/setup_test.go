@@ -6,6 +6,7 @@ import (
 	"paradigm-reboot-prober-go/internal/model"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -28,6 +29,15 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&model.Chart{},
 		&model.PlayRecord{},
 		&model.BestPlayRecord{},
+		&model.SongAlias{},
+		&model.ChartHistory{},
+		&model.SongMetadataProposal{},
+		&model.Season{},
+		&model.SeasonSong{},
+		&model.ChartVote{},
+		&model.ChartTag{},
+		&model.ChartStatistic{},
+		&model.FittingPlayerSkill{},
 	)
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
@@ -41,3 +51,5 @@ func intPtr(v int) *int { return &v }
 func float64Ptr(v float64) *float64 { return &v }
 
 func boolPtr(v bool) *bool { return &v }
+
+func timePtr(v time.Time) *time.Time { return &v }
@@ -1,13 +1,19 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/pkg/fuzzy"
+	"time"
 
-	"github.com/jellydator/ttlcache/v3"
 	"gorm.io/gorm"
 )
 
+// ErrChartModified is returned by PatchChart when the chart was changed after
+// the caller read it.
+var ErrChartModified = errors.New("chart was modified")
+
 type SongRepository struct {
 	db    *gorm.DB
 	cache *repoCache
@@ -16,242 +22,796 @@ type SongRepository struct {
 func NewSongRepository(db *gorm.DB) *SongRepository {
 	return &SongRepository{
 		db:    db,
-		cache: newRepoCache(SongCacheTTL),
+		cache: newRepoCache("song", SongCacheTTL),
 	}
 }
 
 // GetAllSongs retrieves all songs
-func (r *SongRepository) GetAllSongs() ([]model.Song, error) {
-	key := allSongsCacheKey()
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().([]model.Song)
-			cp := make([]model.Song, len(original))
-			copy(cp, original)
-			return cp, nil
-		}
+func (r *SongRepository) GetAllSongs(ctx context.Context) ([]model.Song, error) {
+	songs, err := cachedLoad(ctx, r.cache, allSongsCacheKey(), func(ctx context.Context) ([]model.Song, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var songs []model.Song
+		err := db.Preload("Charts").Find(&songs).Error
+		return songs, err == nil, err
+	})
+	if err != nil {
+		return nil, err
 	}
+	cp := make([]model.Song, len(songs))
+	copy(cp, songs)
+	return cp, nil
+}
 
+// GetSongsChangedBetween retrieves live songs that were created or updated in
+// (since, until], or that have a live chart which was, with their live charts.
+// It bypasses the cache so that writes made by other processes (e.g. fitting)
+// are seen.
+func (r *SongRepository) GetSongsChangedBetween(ctx context.Context, since, until time.Time) ([]model.Song, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	changedCharts := db.Model(&model.Chart{}).
+		Select("song_id").
+		Where("updated_at > ? AND updated_at <= ?", since, until)
 	var songs []model.Song
-	if err := r.db.Preload("Charts").Find(&songs).Error; err != nil {
+	err := db.Preload("Charts").
+		Where("(updated_at > ? AND updated_at <= ?) OR id IN (?)", since, until, changedCharts).
+		Order("id").
+		Find(&songs).Error
+	if err != nil {
 		return nil, err
 	}
+	return songs, nil
+}
 
-	if r.cache != nil {
-		r.cache.Set(key, songs, ttlcache.DefaultTTL)
+// GetRetiredBetween retrieves the IDs of songs and charts retired in
+// (since, until]. Charts of a retired song are included in chartIDs.
+func (r *SongRepository) GetRetiredBetween(ctx context.Context, since, until time.Time) (songIDs, chartIDs []int, err error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	songIDs, chartIDs = make([]int, 0), make([]int, 0)
+	retiredSongs := func() *gorm.DB {
+		return db.Unscoped().Model(&model.Song{}).Where("deleted_at > ? AND deleted_at <= ?", since, until)
 	}
-	return songs, nil
+	if err = retiredSongs().Order("id").Pluck("id", &songIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	err = db.Unscoped().Model(&model.Chart{}).
+		Where("(deleted_at > ? AND deleted_at <= ?) OR song_id IN (?)", since, until, retiredSongs().Select("id")).
+		Order("id").
+		Pluck("id", &chartIDs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return songIDs, chartIDs, nil
 }
 
 // GetSongByID retrieves a song by its ID
-func (r *SongRepository) GetSongByID(songID int) (*model.Song, error) {
-	key := songIDCacheKey(songID)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().(*model.Song)
-			cp := *original
-			return &cp, nil
+func (r *SongRepository) GetSongByID(ctx context.Context, songID int) (*model.Song, error) {
+	song, err := cachedLoad(ctx, r.cache, songIDCacheKey(songID), func(ctx context.Context) (*model.Song, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var song model.Song
+		if err := db.Preload("Charts").Where("id = ?", songID).First(&song).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return &song, true, nil
+	})
+	if song == nil || err != nil {
+		return nil, err
+	}
+	cp := *song
+	return &cp, nil
+}
+
+// GetSongByWikiID retrieves a song by its Wiki ID
+func (r *SongRepository) GetSongByWikiID(ctx context.Context, wikiID string) (*model.Song, error) {
+	song, err := cachedLoad(ctx, r.cache, songWikiCacheKey(wikiID), func(ctx context.Context) (*model.Song, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var song model.Song
+		if err := db.Preload("Charts").Where("wiki_id = ?", wikiID).First(&song).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
 		}
+		return &song, true, nil
+	})
+	if song == nil || err != nil {
+		return nil, err
 	}
+	cp := *song
+	return &cp, nil
+}
 
-	var song model.Song
-	if err := r.db.Preload("Charts").Where("id = ?", songID).First(&song).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+// GetChartByID retrieves a chart by its numeric ID with Song preloaded
+func (r *SongRepository) GetChartByID(ctx context.Context, chartID int) (*model.Chart, error) {
+	chart, err := cachedLoad(ctx, r.cache, chartIDCacheKey(chartID), func(ctx context.Context) (*model.Chart, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var chart model.Chart
+		if err := db.Preload("Song").Where("id = ?", chartID).First(&chart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return &chart, true, nil
+	})
+	if chart == nil || err != nil {
+		return nil, err
+	}
+	cp := *chart
+	return &cp, nil
+}
+
+// GetChartByWikiIDAndDifficulty finds a chart by the song's wiki_id and chart difficulty
+func (r *SongRepository) GetChartByWikiIDAndDifficulty(ctx context.Context, wikiID string, difficulty model.Difficulty) (*model.Chart, error) {
+	chart, err := cachedLoad(ctx, r.cache, chartWikiDiffCacheKey(wikiID, difficulty), func(ctx context.Context) (*model.Chart, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var chart model.Chart
+		if err := db.Joins("JOIN songs ON songs.id = charts.song_id").
+			Preload("Song").
+			Where("songs.wiki_id = ? AND charts.difficulty = ?", wikiID, difficulty).
+			First(&chart).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
 		}
+		return &chart, true, nil
+	})
+	if chart == nil || err != nil {
 		return nil, err
 	}
+	cp := *chart
+	return &cp, nil
+}
 
+// CreateSong creates a new song with its charts
+func (r *SongRepository) CreateSong(ctx context.Context, song *model.Song) (*model.Song, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// GORM handles association creation automatically if configured correctly
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+		if song.B15 {
+			return syncSongSeasonInTx(tx, song.ID, true)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Flush all song/chart caches — song creation affects GetAllSongs
 	if r.cache != nil {
-		r.cache.Set(key, &song, ttlcache.DefaultTTL)
-		cp := song
-		return &cp, nil
+		r.cache.DeleteAll()
 	}
-	return &song, nil
+	return song, nil
 }
 
-// GetSongByWikiID retrieves a song by its Wiki ID
-func (r *SongRepository) GetSongByWikiID(wikiID string) (*model.Song, error) {
-	key := songWikiCacheKey(wikiID)
+// UpdateSong updates an existing song and its charts. Every changed field is
+// recorded in chart_histories, attributed to changedBy, and returned.
+func (r *SongRepository) UpdateSong(ctx context.Context, songID int, updatedSong *model.Song, changedBy string) (*model.Song, []model.ChartHistory, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	var result *model.Song
+	var changes []model.ChartHistory
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		result, changes, txErr = updateSongInTx(tx, songID, updatedSong, changedBy)
+		return txErr
+	})
+
+	// Flush all song/chart caches after successful TX
+	if err == nil && r.cache != nil {
+		r.cache.DeleteAll()
+	}
+
+	return result, changes, err
+}
+
+// InvalidateAll flushes the song cache.
+func (r *SongRepository) InvalidateAll() {
 	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().(*model.Song)
-			cp := *original
-			return &cp, nil
+		r.cache.DeleteAll()
+	}
+}
+
+// WithTransaction executes fn within a database transaction, passing a transactional
+// copy of SongRepository. If fn returns an error the transaction is rolled back.
+// Unlike UserRepository, the transactional repo bypasses the cache entirely so that
+// uncommitted (or rolled back) rows are never cached; the shared cache is flushed
+// once the transaction commits.
+func (r *SongRepository) WithTransaction(ctx context.Context, fn func(txRepo *SongRepository) error) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return fn(&SongRepository{db: tx})
+	})
+	if err == nil && r.cache != nil {
+		r.cache.DeleteAll()
+	}
+	return err
+}
+
+// SongUpdatePreview is the outcome of a rolled-back UpdateSong: the history
+// entries that would have been recorded and the B50 of every user holding a
+// best record on the song, before and after the update.
+type SongUpdatePreview struct {
+	Changes []model.ChartHistory
+	Before  map[string]B50Summary
+	After   map[string]B50Summary
+}
+
+// errPreviewRollback aborts the preview transaction after the impact has been measured.
+var errPreviewRollback = errors.New("preview rollback")
+
+// PreviewUpdateSong applies the update inside a transaction, measures its effect
+// on affected users' B50 (including the rating recalculation) and rolls it back.
+func (r *SongRepository) PreviewUpdateSong(ctx context.Context, songID int, updatedSong *model.Song, changedBy string) (*SongUpdatePreview, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	preview := &SongUpdatePreview{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var chartIDs []int
+		if err := tx.Unscoped().Model(&model.Chart{}).Where("song_id = ?", songID).Pluck("id", &chartIDs).Error; err != nil {
+			return err
+		}
+		var usernames []string
+		if len(chartIDs) > 0 {
+			if err := tx.Model(&model.BestPlayRecord{}).
+				Where("chart_id IN ?", chartIDs).
+				Distinct().Pluck("username", &usernames).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if preview.Before, err = b50Summaries(tx, usernames); err != nil {
+			return err
+		}
+		if _, preview.Changes, err = updateSongInTx(tx, songID, updatedSong, changedBy); err != nil {
+			return err
 		}
+		if preview.After, err = b50Summaries(tx, usernames); err != nil {
+			return err
+		}
+		return errPreviewRollback
+	})
+	if !errors.Is(err, errPreviewRollback) {
+		return nil, err
 	}
+	return preview, nil
+}
 
-	var song model.Song
-	if err := r.db.Preload("Charts").Where("wiki_id = ?", wikiID).First(&song).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+// GetSongHistory retrieves the change history of a song and its charts, newest first
+func (r *SongRepository) GetSongHistory(ctx context.Context, songID int) ([]model.ChartHistory, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var history []model.ChartHistory
+	if err := db.Where("song_id = ?", songID).Order("id desc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// updateSongInTx applies updatedSong to the song within tx, recalculating ratings
+// for charts whose level changed, and records the changes in chart_histories.
+func updateSongInTx(tx *gorm.DB, songID int, updatedSong *model.Song, changedBy string) (*model.Song, []model.ChartHistory, error) {
+	var existingSong model.Song
+	if err := tx.Preload("Charts").First(&existingSong, songID).Error; err != nil {
+		return nil, nil, err
+	}
+	changes := diffSongBase(existingSong.SongBase, updatedSong.SongBase)
+	if existingSong.B15 != updatedSong.B15 {
+		if err := syncSongSeasonInTx(tx, songID, updatedSong.B15); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Update basic attributes
+	existingSong.Title = updatedSong.Title
+	existingSong.Artist = updatedSong.Artist
+	existingSong.Genre = updatedSong.Genre
+	existingSong.Cover = updatedSong.Cover
+	existingSong.Illustrator = updatedSong.Illustrator
+	existingSong.Version = updatedSong.Version
+	existingSong.BPM = updatedSong.BPM
+	existingSong.B15 = updatedSong.B15
+	existingSong.Album = updatedSong.Album
+	existingSong.Length = updatedSong.Length
+	existingSong.WikiID = updatedSong.WikiID
+
+	if err := tx.Save(&existingSong).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// Update Charts
+	// Strategy: Map existing charts by Difficulty, update if exists, create if new
+	existingChartsMap := make(map[model.Difficulty]*model.Chart)
+	for i := range existingSong.Charts {
+		chart := &existingSong.Charts[i]
+		existingChartsMap[chart.Difficulty] = chart
+	}
+
+	for _, newChart := range updatedSong.Charts {
+		if existingChart, exists := existingChartsMap[newChart.Difficulty]; exists {
+			// Update existing chart
+			changes = append(changes, diffChart(*existingChart, newChart)...)
+			levelChanged := existingChart.Level != newChart.Level
+			existingChart.Level = newChart.Level
+			existingChart.LevelDesign = newChart.LevelDesign
+			existingChart.Notes = newChart.Notes
+			existingChart.SongBaseOverride = newChart.SongBaseOverride
+			if err := tx.Save(existingChart).Error; err != nil {
+				return nil, nil, err
+			}
+			// Recalculate ratings for all play records when level changes
+			if levelChanged {
+				if err := RecalculateRatingsByChart(tx, existingChart.ID, newChart.Level); err != nil {
+					return nil, nil, err
+				}
+			}
+		} else {
+			// Create new chart
+			newChart.SongID = existingSong.ID
+			if err := tx.Create(&newChart).Error; err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, chartChange(newChart, model.ChartHistoryFieldChart, nil, historyValue(string(newChart.Difficulty))))
+			existingSong.Charts = append(existingSong.Charts, newChart)
+		}
+	}
+	// Delete charts not in the update request
+	requestedDifficulties := make(map[model.Difficulty]bool)
+	for _, c := range updatedSong.Charts {
+		requestedDifficulties[c.Difficulty] = true
+	}
+	remainingCharts := make([]model.Chart, 0, len(existingSong.Charts))
+	for i := range existingSong.Charts {
+		chart := &existingSong.Charts[i]
+		if !requestedDifficulties[chart.Difficulty] {
+			if err := tx.Delete(chart).Error; err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, chartChange(*chart, model.ChartHistoryFieldChart, historyValue(string(chart.Difficulty)), nil))
+		} else {
+			remainingCharts = append(remainingCharts, *chart)
+		}
+	}
+	existingSong.Charts = remainingCharts
+
+	for i := range changes {
+		changes[i].SongID = existingSong.ID
+		changes[i].ChangedBy = changedBy
+	}
+	if len(changes) > 0 {
+		if err := tx.Create(&changes).Error; err != nil {
+			return nil, nil, err
 		}
+	}
+	return &existingSong, changes, nil
+}
+
+// GetAllAliases retrieves all song aliases
+func (r *SongRepository) GetAllAliases(ctx context.Context) ([]model.SongAlias, error) {
+	aliases, err := cachedLoad(ctx, r.cache, allAliasesCacheKey(), func(ctx context.Context) ([]model.SongAlias, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var aliases []model.SongAlias
+		err := db.Order("id").Find(&aliases).Error
+		return aliases, err == nil, err
+	})
+	if err != nil {
 		return nil, err
 	}
+	cp := make([]model.SongAlias, len(aliases))
+	copy(cp, aliases)
+	return cp, nil
+}
+
+// SearchField is one searchable text of a song with its fuzzy keys.
+type SearchField struct {
+	Field string // "title", "alias", "override_title", "artist" or "album"
+	Text  string
+	Keys  []fuzzy.Key
+}
 
+// SongSearchEntry is a song with its searchable fields, in the order title,
+// aliases, chart override titles, artist, album.
+type SongSearchEntry struct {
+	Song   model.SongBase
+	SongID int
+	Fields []SearchField
+}
+
+// GetSearchIndex returns every song with the fuzzy keys of its searchable
+// texts. Transliterating them is costly, so the index is built once per
+// catalog version: it is cached with the songs and flushed along with them.
+// The index is shared; callers must not modify it.
+func (r *SongRepository) GetSearchIndex(ctx context.Context) ([]SongSearchEntry, error) {
+	return cachedLoad(ctx, r.cache, searchIndexCacheKey(), func(ctx context.Context) ([]SongSearchEntry, bool, error) {
+		songs, err := r.GetAllSongs(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		aliases, err := r.GetAllAliases(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		aliasesBySong := make(map[int][]string)
+		for _, a := range aliases {
+			aliasesBySong[a.SongID] = append(aliasesBySong[a.SongID], a.Alias)
+		}
+
+		index := make([]SongSearchEntry, 0, len(songs))
+		for _, song := range songs {
+			entry := SongSearchEntry{Song: song.SongBase, SongID: song.ID}
+			add := func(field, text string) {
+				if text != "" {
+					entry.Fields = append(entry.Fields, SearchField{Field: field, Text: text, Keys: fuzzy.Keys(text)})
+				}
+			}
+			add("title", song.Title)
+			for _, alias := range aliasesBySong[song.ID] {
+				add("alias", alias)
+			}
+			for _, chart := range song.Charts {
+				if chart.OverrideTitle != nil {
+					add("override_title", *chart.OverrideTitle)
+				}
+			}
+			add("artist", song.Artist)
+			add("album", song.Album)
+			index = append(index, entry)
+		}
+		return index, true, nil
+	})
+}
+
+// GetAliasesBySongID retrieves all aliases of a song
+func (r *SongRepository) GetAliasesBySongID(ctx context.Context, songID int) ([]model.SongAlias, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var aliases []model.SongAlias
+	if err := db.Where("song_id = ?", songID).Order("id").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// GetAliasByNormalized finds a live alias by its normalised form
+func (r *SongRepository) GetAliasByNormalized(ctx context.Context, normalized string) (*model.SongAlias, error) {
+	alias, err := cachedLoad(ctx, r.cache, aliasCacheKey(normalized), func(ctx context.Context) (*model.SongAlias, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var alias model.SongAlias
+		if err := db.Where("normalized = ?", normalized).First(&alias).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return &alias, true, nil
+	})
+	if alias == nil || err != nil {
+		return nil, err
+	}
+	cp := *alias
+	return &cp, nil
+}
+
+// CreateAlias creates a new song alias. It returns gorm.ErrDuplicatedKey
+// when a live alias with the same normalised form exists.
+func (r *SongRepository) CreateAlias(ctx context.Context, alias *model.SongAlias) (*model.SongAlias, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	if err := db.Create(alias).Error; err != nil {
+		if t, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+			err = t.Translate(err)
+		}
+		return nil, err
+	}
 	if r.cache != nil {
-		r.cache.Set(key, &song, ttlcache.DefaultTTL)
-		cp := song
-		return &cp, nil
+		r.cache.DeleteAll()
 	}
-	return &song, nil
+	return alias, nil
 }
 
-// GetChartByID retrieves a chart by its numeric ID with Song preloaded
-func (r *SongRepository) GetChartByID(chartID int) (*model.Chart, error) {
-	key := chartIDCacheKey(chartID)
+// DeleteAlias soft-deletes the alias with the given ID belonging to songID.
+// Returns false if no such alias exists.
+func (r *SongRepository) DeleteAlias(ctx context.Context, songID, aliasID int) (bool, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	result := db.Where("id = ? AND song_id = ?", aliasID, songID).Delete(&model.SongAlias{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
 	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().(*model.Chart)
-			cp := *original
-			return &cp, nil
-		}
+		r.cache.DeleteAll()
 	}
+	return true, nil
+}
 
-	var chart model.Chart
-	if err := r.db.Preload("Song").Where("id = ?", chartID).First(&chart).Error; err != nil {
+// GetRetiredSongByID retrieves a retired (soft-deleted) song by its ID
+func (r *SongRepository) GetRetiredSongByID(ctx context.Context, songID int) (*model.Song, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var song model.Song
+	if err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", songID).First(&song).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	return &song, nil
+}
 
-	if r.cache != nil {
-		r.cache.Set(key, &chart, ttlcache.DefaultTTL)
-		cp := chart
-		return &cp, nil
+// GetRetiredSongByWikiID retrieves a retired (soft-deleted) song by its Wiki ID
+func (r *SongRepository) GetRetiredSongByWikiID(ctx context.Context, wikiID string) (*model.Song, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var song model.Song
+	if err := db.Unscoped().Where("wiki_id = ? AND deleted_at IS NOT NULL", wikiID).First(&song).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return &chart, nil
+	return &song, nil
 }
 
-// GetChartByWikiIDAndDifficulty finds a chart by the song's wiki_id and chart difficulty
-func (r *SongRepository) GetChartByWikiIDAndDifficulty(wikiID string, difficulty model.Difficulty) (*model.Chart, error) {
-	key := chartWikiDiffCacheKey(wikiID, difficulty)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			original := item.Value().(*model.Chart)
-			cp := *original
-			return &cp, nil
+// GetRetiredChartByID retrieves a retired (soft-deleted) chart by its ID
+func (r *SongRepository) GetRetiredChartByID(ctx context.Context, chartID int) (*model.Chart, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var chart model.Chart
+	if err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", chartID).First(&chart).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
+		return nil, err
 	}
+	return &chart, nil
+}
 
+// GetRetiredChartByWikiIDAndDifficulty retrieves the most recently retired chart
+// of the given difficulty of a (live or retired) song
+func (r *SongRepository) GetRetiredChartByWikiIDAndDifficulty(ctx context.Context, wikiID string, difficulty model.Difficulty) (*model.Chart, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var chart model.Chart
-	if err := r.db.Joins("JOIN songs ON songs.id = charts.song_id").
-		Preload("Song").
-		Where("songs.wiki_id = ? AND charts.difficulty = ?", wikiID, difficulty).
+	if err := db.Unscoped().
+		Joins("JOIN songs ON songs.id = charts.song_id").
+		Where("songs.wiki_id = ? AND charts.difficulty = ? AND charts.deleted_at IS NOT NULL", wikiID, difficulty).
+		Order("charts.deleted_at desc").
 		First(&chart).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	return &chart, nil
+}
 
-	if r.cache != nil {
-		r.cache.Set(key, &chart, ttlcache.DefaultTTL)
-		cp := chart
-		return &cp, nil
+// RetireSong soft-deletes a song. Its charts are left untouched so that
+// restoring the song brings them back as they were.
+func (r *SongRepository) RetireSong(ctx context.Context, songID int, changedBy string) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var song model.Song
+		if err := tx.First(&song, songID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&song).Error; err != nil {
+			return err
+		}
+		change := songChange(model.ChartHistoryFieldSong, historyValue(song.WikiID), nil)
+		change.SongID, change.ChangedBy = song.ID, changedBy
+		return tx.Create(&change).Error
+	})
+	if err == nil && r.cache != nil {
+		r.cache.DeleteAll()
 	}
-	return &chart, nil
+	return err
 }
 
-// CreateSong creates a new song with its charts
-func (r *SongRepository) CreateSong(song *model.Song) (*model.Song, error) {
-	// GORM handles association creation automatically if configured correctly
-	if err := r.db.Create(song).Error; err != nil {
-		return nil, err
+// RestoreSong brings a retired song back
+func (r *SongRepository) RestoreSong(ctx context.Context, songID int, changedBy string) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var song model.Song
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", songID).First(&song).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&song).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		change := songChange(model.ChartHistoryFieldSong, nil, historyValue(song.WikiID))
+		change.SongID, change.ChangedBy = song.ID, changedBy
+		return tx.Create(&change).Error
+	})
+	if err == nil && r.cache != nil {
+		r.cache.DeleteAll()
 	}
-	// Flush all song/chart caches — song creation affects GetAllSongs
-	if r.cache != nil {
+	return err
+}
+
+// RetireChart soft-deletes a single chart
+func (r *SongRepository) RetireChart(ctx context.Context, chartID int, changedBy string) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var chart model.Chart
+		if err := tx.First(&chart, chartID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&chart).Error; err != nil {
+			return err
+		}
+		change := chartChange(chart, model.ChartHistoryFieldChart, historyValue(string(chart.Difficulty)), nil)
+		change.SongID, change.ChangedBy = chart.SongID, changedBy
+		return tx.Create(&change).Error
+	})
+	if err == nil && r.cache != nil {
 		r.cache.DeleteAll()
 	}
-	return song, nil
+	return err
 }
 
-// UpdateSong updates an existing song and its charts
-func (r *SongRepository) UpdateSong(songID int, updatedSong *model.Song) (*model.Song, error) {
-	var result *model.Song
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		var existingSong model.Song
-		if err := tx.Preload("Charts").First(&existingSong, songID).Error; err != nil {
+// RestoreChart brings a retired chart back. It fails on the partial unique
+// index idx_song_difficulty if the song already has a live chart of the same difficulty.
+func (r *SongRepository) RestoreChart(ctx context.Context, chartID int, changedBy string) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var chart model.Chart
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", chartID).First(&chart).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&chart).Update("deleted_at", nil).Error; err != nil {
 			return err
 		}
+		change := chartChange(chart, model.ChartHistoryFieldChart, nil, historyValue(string(chart.Difficulty)))
+		change.SongID, change.ChangedBy = chart.SongID, changedBy
+		return tx.Create(&change).Error
+	})
+	if err == nil && r.cache != nil {
+		r.cache.DeleteAll()
+	}
+	return err
+}
 
-		// Update basic attributes
-		existingSong.Title = updatedSong.Title
-		existingSong.Artist = updatedSong.Artist
-		existingSong.Genre = updatedSong.Genre
-		existingSong.Cover = updatedSong.Cover
-		existingSong.Illustrator = updatedSong.Illustrator
-		existingSong.Version = updatedSong.Version
-		existingSong.BPM = updatedSong.BPM
-		existingSong.B15 = updatedSong.B15
-		existingSong.Album = updatedSong.Album
-		existingSong.Length = updatedSong.Length
-		existingSong.WikiID = updatedSong.WikiID
-
-		if err := tx.Save(&existingSong).Error; err != nil {
+// PatchChart applies patch to a live chart, recording every changed field in
+// the chart history attributed to changedBy. If ifUnmodifiedAt is non-nil the
+// chart must still carry that updated_at, otherwise ErrChartModified is
+// returned. Ratings are recalculated only when the level changes, and a patch
+// that changes nothing writes nothing.
+func (r *SongRepository) PatchChart(ctx context.Context, chartID int, patch model.ChartPatch, ifUnmodifiedAt *time.Time, changedBy string) (*model.Chart, []model.ChartHistory, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	var result model.Chart
+	var changes []model.ChartHistory
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing model.Chart
+		if err := tx.First(&existing, chartID).Error; err != nil {
 			return err
 		}
+		if ifUnmodifiedAt != nil && !existing.UpdatedAt.Equal(*ifUnmodifiedAt) {
+			return ErrChartModified
+		}
 
-		// Update Charts
-		// Strategy: Map existing charts by Difficulty, update if exists, create if new
-		existingChartsMap := make(map[model.Difficulty]*model.Chart)
-		for i := range existingSong.Charts {
-			chart := &existingSong.Charts[i]
-			existingChartsMap[chart.Difficulty] = chart
-		}
-
-		for _, newChart := range updatedSong.Charts {
-			if existingChart, exists := existingChartsMap[newChart.Difficulty]; exists {
-				// Update existing chart
-				levelChanged := existingChart.Level != newChart.Level
-				existingChart.Level = newChart.Level
-				existingChart.LevelDesign = newChart.LevelDesign
-				existingChart.Notes = newChart.Notes
-				existingChart.SongBaseOverride = newChart.SongBaseOverride
-				if err := tx.Save(existingChart).Error; err != nil {
-					return err
-				}
-				// Recalculate ratings for all play records when level changes
-				if levelChanged {
-					if err := RecalculateRatingsByChart(tx, existingChart.ID, newChart.Level); err != nil {
-						return err
-					}
-				}
-			} else {
-				// Create new chart
-				newChart.SongID = existingSong.ID
-				if err := tx.Create(&newChart).Error; err != nil {
-					return err
-				}
-				existingSong.Charts = append(existingSong.Charts, newChart)
-			}
+		result = patch.Apply(existing)
+		changes = diffChart(existing, result)
+		if len(changes) == 0 {
+			return nil
 		}
-		// Delete charts not in the update request
-		requestedDifficulties := make(map[model.Difficulty]bool)
-		for _, c := range updatedSong.Charts {
-			requestedDifficulties[c.Difficulty] = true
-		}
-		remainingCharts := make([]model.Chart, 0, len(existingSong.Charts))
-		for i := range existingSong.Charts {
-			chart := &existingSong.Charts[i]
-			if !requestedDifficulties[chart.Difficulty] {
-				if err := tx.Delete(chart).Error; err != nil {
-					return err
-				}
-			} else {
-				remainingCharts = append(remainingCharts, *chart)
+		// The updated_at condition guards against a concurrent write between
+		// the read above and this update.
+		update := tx.Model(&result).
+			Where("updated_at = ?", existing.UpdatedAt).
+			Select("level", "notes", "level_design", "override_title", "override_artist", "override_version", "override_cover").
+			Updates(&result)
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrChartModified
+		}
+		if result.Level != existing.Level {
+			if err := RecalculateRatingsByChart(tx, chartID, result.Level); err != nil {
+				return err
 			}
 		}
-		existingSong.Charts = remainingCharts
-		result = &existingSong
-		return nil
+		// Reload so that updated_at carries the precision the database stores
+		if err := tx.First(&result, chartID).Error; err != nil {
+			return err
+		}
+		for i := range changes {
+			changes[i].SongID = existing.SongID
+			changes[i].ChangedBy = changedBy
+		}
+		return tx.Create(&changes).Error
 	})
-
-	// Flush all song/chart caches after successful TX
-	if err == nil && r.cache != nil {
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) > 0 && r.cache != nil {
 		r.cache.DeleteAll()
 	}
+	return &result, changes, nil
+}
 
-	return result, err
+// CreateProposals stores new song metadata proposals
+func (r *SongRepository) CreateProposals(ctx context.Context, proposals []model.SongMetadataProposal) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	if len(proposals) == 0 {
+		return nil
+	}
+	return db.Create(&proposals).Error
+}
+
+// GetProposals retrieves metadata proposals, newest first. An empty status or
+// a zero songID matches all.
+func (r *SongRepository) GetProposals(ctx context.Context, status string, songID int) ([]model.SongMetadataProposal, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	query := db.Model(&model.SongMetadataProposal{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if songID != 0 {
+		query = query.Where("song_id = ?", songID)
+	}
+	var proposals []model.SongMetadataProposal
+	if err := query.Order("id desc").Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// GetProposalByID retrieves a metadata proposal by its ID
+func (r *SongRepository) GetProposalByID(ctx context.Context, proposalID int) (*model.SongMetadataProposal, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var proposal model.SongMetadataProposal
+	if err := db.First(&proposal, proposalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// ReviewProposal moves a pending proposal to status. Returns false if the
+// proposal does not exist or has already been reviewed.
+func (r *SongRepository) ReviewProposal(ctx context.Context, proposalID int, status, reviewedBy string) (bool, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	result := db.Model(&model.SongMetadataProposal{}).
+		Where("id = ? AND status = ?", proposalID, model.ProposalStatusPending).
+		Updates(map[string]any{
+			"status":      status,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
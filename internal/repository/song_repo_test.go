@@ -1,14 +1,23 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/pkg/fuzzy"
 	"paradigm-reboot-prober-go/pkg/rating"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestSongRepository_CreateSong(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
@@ -28,7 +37,7 @@ func TestSongRepository_CreateSong(t *testing.T) {
 			},
 		}
 
-		created, err := repo.CreateSong(song)
+		created, err := repo.CreateSong(ctx, song)
 		assert.NoError(t, err)
 		assert.NotZero(t, created.ID)
 		assert.Len(t, created.Charts, 1)
@@ -37,6 +46,7 @@ func TestSongRepository_CreateSong(t *testing.T) {
 }
 
 func TestSongRepository_UpdateSong(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
@@ -51,7 +61,7 @@ func TestSongRepository_UpdateSong(t *testing.T) {
 			{Difficulty: model.DifficultyInvaded, Level: 10.0, Notes: 500},
 		},
 	}
-	_, err := repo.CreateSong(song)
+	_, err := repo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 
 	t.Run("Update Song Metadata and Charts", func(t *testing.T) {
@@ -72,7 +82,7 @@ func TestSongRepository_UpdateSong(t *testing.T) {
 			},
 		}
 
-		result, err := repo.UpdateSong(song.ID, updatedSong)
+		result, _, err := repo.UpdateSong(ctx, song.ID, updatedSong, "admin")
 		assert.NoError(t, err)
 		assert.Equal(t, "New Title", result.Title)
 
@@ -100,6 +110,7 @@ func TestSongRepository_UpdateSong(t *testing.T) {
 // violation. This relies on the partial unique index on (song_id, difficulty)
 // scoped to `WHERE deleted_at IS NULL`.
 func TestSongRepository_UpdateSong_ReAddSoftDeletedDifficulty(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
@@ -109,14 +120,14 @@ func TestSongRepository_UpdateSong_ReAddSoftDeletedDifficulty(t *testing.T) {
 			{Difficulty: model.DifficultyMassive, Level: 10.0, Notes: 500},
 		},
 	}
-	_, err := repo.CreateSong(song)
+	_, err := repo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 
 	// Step 1: remove the Massive chart via UpdateSong (soft delete).
-	_, err = repo.UpdateSong(song.ID, &model.Song{
+	_, _, err = repo.UpdateSong(ctx, song.ID, &model.Song{
 		SongBase: model.SongBase{WikiID: "soft_delete_readd", Title: "T"},
 		Charts:   []model.Chart{},
-	})
+	}, "admin")
 	assert.NoError(t, err)
 
 	// The chart should still exist in the DB but be soft-deleted.
@@ -128,12 +139,12 @@ func TestSongRepository_UpdateSong_ReAddSoftDeletedDifficulty(t *testing.T) {
 
 	// Step 2: add the Massive difficulty back. This must not conflict with
 	// the soft-deleted row on the (song_id, difficulty) unique index.
-	_, err = repo.UpdateSong(song.ID, &model.Song{
+	_, _, err = repo.UpdateSong(ctx, song.ID, &model.Song{
 		SongBase: model.SongBase{WikiID: "soft_delete_readd", Title: "T"},
 		Charts: []model.Chart{
 			{Difficulty: model.DifficultyMassive, Level: 12.5, Notes: 600},
 		},
-	})
+	}, "admin")
 	assert.NoError(t, err)
 
 	// Verify the fresh (non-deleted) chart has the new values.
@@ -145,29 +156,31 @@ func TestSongRepository_UpdateSong_ReAddSoftDeletedDifficulty(t *testing.T) {
 }
 
 func TestSongRepository_GetSong(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
 	song := &model.Song{
 		SongBase: model.SongBase{WikiID: "find_me", Title: "Find Me"},
 	}
-	_, err := repo.CreateSong(song)
+	_, err := repo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 
 	t.Run("Get By ID", func(t *testing.T) {
-		found, err := repo.GetSongByID(song.ID)
+		found, err := repo.GetSongByID(ctx, song.ID)
 		assert.NoError(t, err)
 		assert.Equal(t, "Find Me", found.Title)
 	})
 
 	t.Run("Get By WikiID", func(t *testing.T) {
-		found, err := repo.GetSongByWikiID("find_me")
+		found, err := repo.GetSongByWikiID(ctx, "find_me")
 		assert.NoError(t, err)
 		assert.Equal(t, "Find Me", found.Title)
 	})
 }
 
 func TestSongRepository_GetChartByID(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
@@ -177,12 +190,12 @@ func TestSongRepository_GetChartByID(t *testing.T) {
 			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
 		},
 	}
-	created, err := repo.CreateSong(song)
+	created, err := repo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 	chartID := created.Charts[0].ID
 
 	t.Run("Found", func(t *testing.T) {
-		chart, err := repo.GetChartByID(chartID)
+		chart, err := repo.GetChartByID(ctx, chartID)
 		assert.NoError(t, err)
 		assert.NotNil(t, chart)
 		assert.Equal(t, model.DifficultyMassive, chart.Difficulty)
@@ -191,13 +204,14 @@ func TestSongRepository_GetChartByID(t *testing.T) {
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
-		chart, err := repo.GetChartByID(99999)
+		chart, err := repo.GetChartByID(ctx, 99999)
 		assert.NoError(t, err)
 		assert.Nil(t, chart)
 	})
 }
 
 func TestSongRepository_GetChartByWikiIDAndDifficulty(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewSongRepository(db)
 
@@ -208,11 +222,11 @@ func TestSongRepository_GetChartByWikiIDAndDifficulty(t *testing.T) {
 			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
 		},
 	}
-	_, err := repo.CreateSong(song)
+	_, err := repo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 
 	t.Run("Found", func(t *testing.T) {
-		chart, err := repo.GetChartByWikiIDAndDifficulty("felys", model.DifficultyMassive)
+		chart, err := repo.GetChartByWikiIDAndDifficulty(ctx, "felys", model.DifficultyMassive)
 		assert.NoError(t, err)
 		assert.NotNil(t, chart)
 		assert.Equal(t, model.DifficultyMassive, chart.Difficulty)
@@ -222,19 +236,20 @@ func TestSongRepository_GetChartByWikiIDAndDifficulty(t *testing.T) {
 	})
 
 	t.Run("Wrong Difficulty", func(t *testing.T) {
-		chart, err := repo.GetChartByWikiIDAndDifficulty("felys", model.DifficultyReboot)
+		chart, err := repo.GetChartByWikiIDAndDifficulty(ctx, "felys", model.DifficultyReboot)
 		assert.NoError(t, err)
 		assert.Nil(t, chart)
 	})
 
 	t.Run("Wrong WikiID", func(t *testing.T) {
-		chart, err := repo.GetChartByWikiIDAndDifficulty("nonexistent", model.DifficultyMassive)
+		chart, err := repo.GetChartByWikiIDAndDifficulty(ctx, "nonexistent", model.DifficultyMassive)
 		assert.NoError(t, err)
 		assert.Nil(t, chart)
 	})
 }
 
 func TestSongRepository_UpdateSong_RecalculatesRatings(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	songRepo := NewSongRepository(db)
 	recordRepo := NewRecordRepository(db)
@@ -246,17 +261,17 @@ func TestSongRepository_UpdateSong_RecalculatesRatings(t *testing.T) {
 			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
 		},
 	}
-	created, err := songRepo.CreateSong(song)
+	created, err := songRepo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 	chartID := created.Charts[0].ID
 
 	// Create play records
-	_, err = recordRepo.CreateRecord(&model.PlayRecord{
+	_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 		PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1000000)},
 		Username:       "user_lvlchg",
 	}, false)
 	assert.NoError(t, err)
-	_, err = recordRepo.CreateRecord(&model.PlayRecord{
+	_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
 		PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1005000)},
 		Username:       "user_lvlchg",
 	}, false)
@@ -270,7 +285,7 @@ func TestSongRepository_UpdateSong_RecalculatesRatings(t *testing.T) {
 				{Difficulty: model.DifficultyMassive, Level: newLevel, Notes: 1000},
 			},
 		}
-		_, err := songRepo.UpdateSong(created.ID, updatedSong)
+		_, _, err := songRepo.UpdateSong(ctx, created.ID, updatedSong, "admin")
 		assert.NoError(t, err)
 
 		// Verify ratings updated to new level
@@ -295,7 +310,7 @@ func TestSongRepository_UpdateSong_RecalculatesRatings(t *testing.T) {
 				{Difficulty: model.DifficultyMassive, Level: 16.0, Notes: 1200},
 			},
 		}
-		_, err := songRepo.UpdateSong(created.ID, updatedSong)
+		_, _, err := songRepo.UpdateSong(ctx, created.ID, updatedSong, "admin")
 		assert.NoError(t, err)
 
 		var after []model.PlayRecord
@@ -306,3 +321,479 @@ func TestSongRepository_UpdateSong_RecalculatesRatings(t *testing.T) {
 		}
 	})
 }
+
+func TestSongRepository_Aliases(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	repo := NewSongRepository(db)
+
+	song, err := repo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "alias_song", Title: "Alias Song"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 12.0, Notes: 500}},
+	})
+	assert.NoError(t, err)
+
+	// Prime the cache so we can check CreateAlias invalidates it
+	aliases, err := repo.GetAllAliases(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, aliases)
+
+	alias, err := repo.CreateAlias(ctx, &model.SongAlias{SongID: song.ID, Alias: "AS", Normalized: "as"})
+	assert.NoError(t, err)
+	assert.NotZero(t, alias.ID)
+
+	t.Run("GetAllAliases sees new alias", func(t *testing.T) {
+		aliases, err := repo.GetAllAliases(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, aliases, 1)
+	})
+
+	t.Run("GetAliasByNormalized", func(t *testing.T) {
+		found, err := repo.GetAliasByNormalized(ctx, "as")
+		assert.NoError(t, err)
+		assert.NotNil(t, found)
+		assert.Equal(t, song.ID, found.SongID)
+
+		missing, err := repo.GetAliasByNormalized(ctx, "missing")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("Duplicate normalized alias rejected", func(t *testing.T) {
+		_, err := repo.CreateAlias(ctx, &model.SongAlias{SongID: song.ID, Alias: "as", Normalized: "as"})
+		assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	})
+
+	t.Run("DeleteAlias", func(t *testing.T) {
+		deleted, err := repo.DeleteAlias(ctx, song.ID+1, alias.ID)
+		assert.NoError(t, err)
+		assert.False(t, deleted, "alias belongs to a different song")
+
+		deleted, err = repo.DeleteAlias(ctx, song.ID, alias.ID)
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+
+		found, err := repo.GetAliasByNormalized(ctx, "as")
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		byID, err := repo.GetAliasesBySongID(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, byID)
+	})
+}
+
+func TestSongRepository_UpdateSong_RecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	repo := NewSongRepository(db)
+
+	song, err := repo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "history", Title: "Old Title"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyDetected, Level: 5.0, Notes: 100},
+			{Difficulty: model.DifficultyMassive, Level: 14.5, Notes: 900},
+		},
+	})
+	assert.NoError(t, err)
+	massiveID := song.Charts[1].ID
+
+	_, _, err = repo.UpdateSong(ctx, song.ID, &model.Song{
+		SongBase: model.SongBase{WikiID: "history", Title: "New Title"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyMassive, Level: 14.7, Notes: 900},
+			{Difficulty: model.DifficultyReboot, Level: 16.0, Notes: 1200},
+		},
+	}, "admin")
+	assert.NoError(t, err)
+
+	history, err := repo.GetSongHistory(ctx, song.ID)
+	assert.NoError(t, err)
+
+	byField := make(map[string]model.ChartHistory)
+	for _, h := range history {
+		assert.Equal(t, "admin", h.ChangedBy)
+		assert.Equal(t, song.ID, h.SongID)
+		byField[h.Field+":"+string(h.Difficulty)] = h
+	}
+	assert.Len(t, history, 4, "title, massive level, reboot created, detected removed")
+
+	title := byField["title:"]
+	assert.Nil(t, title.ChartID)
+	assert.Equal(t, "Old Title", *title.OldValue)
+	assert.Equal(t, "New Title", *title.NewValue)
+
+	level := byField["level:massive"]
+	assert.Equal(t, massiveID, *level.ChartID)
+	assert.Equal(t, "14.5", *level.OldValue)
+	assert.Equal(t, "14.7", *level.NewValue)
+
+	created := byField["chart:reboot"]
+	assert.NotNil(t, created.ChartID)
+	assert.Nil(t, created.OldValue)
+	assert.Equal(t, "reboot", *created.NewValue)
+
+	removed := byField["chart:detected"]
+	assert.Equal(t, "detected", *removed.OldValue)
+	assert.Nil(t, removed.NewValue)
+
+	t.Run("No-op update records nothing", func(t *testing.T) {
+		_, _, err := repo.UpdateSong(ctx, song.ID, &model.Song{
+			SongBase: model.SongBase{WikiID: "history", Title: "New Title"},
+			Charts: []model.Chart{
+				{Difficulty: model.DifficultyMassive, Level: 14.7, Notes: 900},
+				{Difficulty: model.DifficultyReboot, Level: 16.0, Notes: 1200},
+			},
+		}, "admin")
+		assert.NoError(t, err)
+		after, err := repo.GetSongHistory(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Len(t, after, len(history))
+	})
+}
+
+func TestSongRepository_PreviewUpdateSong(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "preview", Title: "Preview"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
+	})
+	assert.NoError(t, err)
+	chartID := song.Charts[0].ID
+	_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+		PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1000000)},
+		Username:       "previewer",
+	}, false)
+	assert.NoError(t, err)
+
+	preview, err := songRepo.PreviewUpdateSong(ctx, song.ID, &model.Song{
+		SongBase: model.SongBase{WikiID: "preview", Title: "Preview"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.5, Notes: 1000}},
+	}, "admin")
+	assert.NoError(t, err)
+
+	assert.Len(t, preview.Changes, 1)
+	assert.Equal(t, rating.SingleRating(15.0, 1000000), preview.Before["previewer"].Sum)
+	assert.Equal(t, rating.SingleRating(15.5, 1000000), preview.After["previewer"].Sum)
+	assert.Equal(t, []int{chartID}, preview.After["previewer"].ChartIDs)
+
+	// Nothing was committed
+	var chart model.Chart
+	db.First(&chart, chartID)
+	assert.Equal(t, 15.0, chart.Level)
+	var pr model.PlayRecord
+	db.Where("chart_id = ?", chartID).First(&pr)
+	assert.Equal(t, rating.SingleRating(15.0, 1000000), pr.Rating)
+	history, err := songRepo.GetSongHistory(ctx, song.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	t.Run("Summaries match GetBest50Records", func(t *testing.T) {
+		game := config.GlobalConfig.Game
+		t.Cleanup(func() { config.GlobalConfig.Game = game })
+		config.GlobalConfig.Game.B35Limit, config.GlobalConfig.Game.B15Limit = 1, 1
+
+		// An old song that falls out of previewer's single B35 slot and two
+		// new ones competing for the single B15 slot; rival plays fewer.
+		upload := func(username string, chartID, score int) {
+			_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
+				PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(score)},
+				Username:       username,
+			}, false)
+			require.NoError(t, err)
+		}
+		upload("rival", chartID, 990000)
+		for i, s := range []struct {
+			level float64
+			b15   bool
+		}{{14.0, false}, {13.0, true}, {14.5, true}} {
+			other, err := songRepo.CreateSong(ctx, &model.Song{
+				SongBase: model.SongBase{WikiID: fmt.Sprintf("preview_other_%d", i), Title: "Other", B15: s.b15},
+				Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: s.level, Notes: 1000}},
+			})
+			require.NoError(t, err)
+			upload("previewer", other.Charts[0].ID, 1000000)
+			if i == 1 {
+				upload("rival", other.Charts[0].ID, 1000000)
+			}
+		}
+
+		preview, err := songRepo.PreviewUpdateSong(ctx, song.ID, &model.Song{
+			SongBase: model.SongBase{WikiID: "preview", Title: "Preview"},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
+		}, "admin")
+		require.NoError(t, err)
+		require.Len(t, preview.Before, 2)
+		for _, username := range []string{"previewer", "rival"} {
+			b35, b15, err := recordRepo.GetBest50Records(ctx, username, 0, model.RecordFilter{})
+			require.NoError(t, err)
+			var want B50Summary
+			for _, record := range append(b35, b15...) {
+				want.Sum += record.Rating
+				want.ChartIDs = append(want.ChartIDs, record.ChartID)
+			}
+			slices.Sort(want.ChartIDs)
+			require.Len(t, want.ChartIDs, 2)
+			assert.Equal(t, want, preview.Before[username], username)
+		}
+	})
+
+	t.Run("Unknown song", func(t *testing.T) {
+		_, err := songRepo.PreviewUpdateSong(ctx, 99999, &model.Song{}, "admin")
+		assert.Error(t, err)
+	})
+}
+
+func TestSongRepository_RetireAndRestore(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "retire", Title: "Retire"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyInvaded, Level: 13.0, Notes: 800},
+			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
+		},
+	})
+	assert.NoError(t, err)
+	invadedID, massiveID := song.Charts[0].ID, song.Charts[1].ID
+	for _, chartID := range []int{invadedID, massiveID} {
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1000000)},
+			Username:       "retiree",
+		}, false)
+		assert.NoError(t, err)
+	}
+
+	t.Run("Retire chart", func(t *testing.T) {
+		assert.NoError(t, songRepo.RetireChart(ctx, massiveID, "admin"))
+
+		live, err := songRepo.GetSongByID(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Len(t, live.Charts, 1)
+
+		// Excluded from B50 and the all-charts view
+		b35, b15, err := recordRepo.GetBest50Records(ctx, "retiree", 0, model.RecordFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, append(b35, b15...), 1)
+		assert.Equal(t, invadedID, b35[0].ChartID)
+		charts, err := recordRepo.GetAllChartsWithBestScores(ctx, "retiree", model.RecordFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, charts, 1)
+
+		// Still visible, with chart info, in the record history
+		all, err := recordRepo.GetAllRecords(ctx, "retiree", 10, 0, "rating", true, model.RecordFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, all, 2)
+		for _, r := range all {
+			assert.Equal(t, song.ID, r.Chart.SongID)
+			assert.Equal(t, r.ChartID == massiveID, model.ToPlayRecordInfo(&r).Chart.Retired)
+		}
+
+		// New uploads are rejected
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: massiveID, Score: intPtr(1000000)},
+			Username:       "retiree",
+		}, false)
+		assert.Error(t, err)
+
+		retired, err := songRepo.GetRetiredChartByWikiIDAndDifficulty(ctx, "retire", model.DifficultyMassive)
+		assert.NoError(t, err)
+		assert.Equal(t, massiveID, retired.ID)
+	})
+
+	t.Run("Restore chart", func(t *testing.T) {
+		assert.NoError(t, songRepo.RestoreChart(ctx, massiveID, "admin"))
+		live, err := songRepo.GetSongByID(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Len(t, live.Charts, 2)
+
+		retired, err := songRepo.GetRetiredChartByID(ctx, massiveID)
+		assert.NoError(t, err)
+		assert.Nil(t, retired)
+		assert.Error(t, songRepo.RestoreChart(ctx, massiveID, "admin"))
+	})
+
+	t.Run("Retire and restore song", func(t *testing.T) {
+		assert.NoError(t, songRepo.RetireSong(ctx, song.ID, "admin"))
+		// The service layer drops cached B50s after catalog edits
+		assert.NoError(t, recordRepo.InvalidateSongRecords(ctx, song.ID))
+
+		live, err := songRepo.GetSongByID(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, live)
+		b35, b15, err := recordRepo.GetBest50Records(ctx, "retiree", 0, model.RecordFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, append(b35, b15...))
+		all, err := recordRepo.GetAllRecords(ctx, "retiree", 10, 0, "rating", true, model.RecordFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, all, 2)
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: invadedID, Score: intPtr(1000000)},
+			Username:       "retiree",
+		}, false)
+		assert.Error(t, err)
+
+		retired, err := songRepo.GetRetiredSongByWikiID(ctx, "retire")
+		assert.NoError(t, err)
+		assert.Equal(t, song.ID, retired.ID)
+
+		assert.NoError(t, songRepo.RestoreSong(ctx, song.ID, "admin"))
+		live, err = songRepo.GetSongByID(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Len(t, live.Charts, 2)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		history, err := songRepo.GetSongHistory(ctx, song.ID)
+		assert.NoError(t, err)
+		assert.Len(t, history, 4)
+		// Newest first: song restored, song retired, chart restored, chart retired
+		assert.Equal(t, model.ChartHistoryFieldSong, history[0].Field)
+		assert.Nil(t, history[0].OldValue)
+		assert.Equal(t, "retire", *history[0].NewValue)
+		assert.Equal(t, model.ChartHistoryFieldChart, history[3].Field)
+		assert.Equal(t, massiveID, *history[3].ChartID)
+		assert.Equal(t, "massive", *history[3].OldValue)
+		assert.Nil(t, history[3].NewValue)
+	})
+
+	t.Run("Unknown IDs", func(t *testing.T) {
+		assert.Error(t, songRepo.RetireSong(ctx, 99999, "admin"))
+		assert.Error(t, songRepo.RetireChart(ctx, 99999, "admin"))
+		assert.Error(t, songRepo.RestoreSong(ctx, song.ID, "admin"))
+	})
+}
+
+func TestSongRepository_PatchChart(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+
+	designer, alt := "Designer", "Alt Title"
+	created, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "patch_me", Title: "Patch Me"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000, LevelDesign: &designer},
+			{Difficulty: model.DifficultyInvaded, Level: 12.0, Notes: 600},
+		},
+	})
+	require.NoError(t, err)
+	chartID := created.Charts[0].ID
+	_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+		PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(1005000)},
+		Username:       "patcher",
+	}, false)
+	require.NoError(t, err)
+	// Mark the stored rating so that a recalculation is observable
+	require.NoError(t, db.Model(&model.PlayRecord{}).Where("chart_id = ?", chartID).UpdateColumn("rating", 1).Error)
+	storedRating := func() int {
+		var record model.PlayRecord
+		require.NoError(t, db.Where("chart_id = ?", chartID).First(&record).Error)
+		return record.Rating
+	}
+
+	t.Run("Only supplied fields change and level is untouched", func(t *testing.T) {
+		chart, changes, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{
+			Notes:            intPtr(1001),
+			SongBaseOverride: model.SongBaseOverride{OverrideTitle: &alt},
+		}, nil, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, 1001, chart.Notes)
+		assert.Equal(t, 15.0, chart.Level)
+		assert.Equal(t, "Designer", *chart.LevelDesign)
+		assert.Equal(t, "Alt Title", *chart.OverrideTitle)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, 1, storedRating(), "ratings are not recalculated without a level change")
+
+		history, err := songRepo.GetSongHistory(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, "admin", history[0].ChangedBy)
+	})
+
+	t.Run("Level change recalculates ratings", func(t *testing.T) {
+		chart, changes, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{Level: float64Ptr(15.5)}, nil, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, 15.5, chart.Level)
+		require.Len(t, changes, 1)
+		assert.Equal(t, model.ChartHistoryFieldLevel, changes[0].Field)
+		assert.Equal(t, rating.SingleRating(15.5, 1005000), storedRating())
+	})
+
+	t.Run("Empty override clears it and no-op writes nothing", func(t *testing.T) {
+		empty := ""
+		chart, _, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{
+			SongBaseOverride: model.SongBaseOverride{OverrideTitle: &empty},
+		}, nil, "admin")
+		require.NoError(t, err)
+		assert.Nil(t, chart.OverrideTitle)
+
+		again, changes, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1001)}, nil, "admin")
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+		assert.True(t, chart.UpdatedAt.Equal(again.UpdatedAt))
+	})
+
+	t.Run("If-Match on updated_at", func(t *testing.T) {
+		var current model.Chart
+		require.NoError(t, db.First(&current, chartID).Error)
+
+		stale := current.UpdatedAt.Add(-time.Second)
+		_, _, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1002)}, &stale, "admin")
+		assert.ErrorIs(t, err, ErrChartModified)
+
+		chart, _, err := songRepo.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1002)}, &current.UpdatedAt, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, 1002, chart.Notes)
+
+		// The version read before the first write is now stale
+		_, _, err = songRepo.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1003)}, &current.UpdatedAt, "admin")
+		assert.ErrorIs(t, err, ErrChartModified)
+	})
+
+	t.Run("Retired or missing chart", func(t *testing.T) {
+		require.NoError(t, songRepo.RetireChart(ctx, created.Charts[1].ID, "admin"))
+		_, _, err := songRepo.PatchChart(ctx, created.Charts[1].ID, model.ChartPatch{Notes: intPtr(1)}, nil, "admin")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestSongRepository_GetSearchIndex(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	repo := NewSongRepository(db)
+
+	song, err := repo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "index_song", Title: "夜に駆ける", Artist: "YOASOBI"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 12.0, Notes: 500}},
+	})
+	require.NoError(t, err)
+
+	index, err := repo.GetSearchIndex(ctx)
+	require.NoError(t, err)
+	require.Len(t, index, 1)
+	assert.Equal(t, song.ID, index[0].SongID)
+	fields := index[0].Fields
+	require.Len(t, fields, 2, "empty album is skipped")
+	assert.Equal(t, "title", fields[0].Field)
+	assert.Equal(t, fuzzy.Keys("夜に駆ける"), fields[0].Keys)
+	assert.Equal(t, "artist", fields[1].Field)
+	assert.True(t, repo.cache.Has(searchIndexCacheKey()))
+
+	// Catalog writes flush the index with the songs.
+	_, err = repo.CreateAlias(ctx, &model.SongAlias{SongID: song.ID, Alias: "夜駆け", Normalized: fuzzy.Normalize("夜駆け")})
+	require.NoError(t, err)
+	assert.False(t, repo.cache.Has(searchIndexCacheKey()))
+	index, err = repo.GetSearchIndex(ctx)
+	require.NoError(t, err)
+	require.Len(t, index[0].Fields, 3)
+	assert.Equal(t, SearchField{Field: "alias", Text: "夜駆け", Keys: fuzzy.Keys("夜駆け")}, index[0].Fields[1])
+}
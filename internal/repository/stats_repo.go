@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"paradigm-reboot-prober-go/internal/model"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// StatsRepository reads chart-level aggregates: the chart_statistics written
+// by the fitting service and score aggregates over best_play_records. Results
+// are cached for StatsCacheTTL and never expose who set a score. It also
+// reads the player skills the fitting service keeps in fitting_player_skills.
+type StatsRepository struct {
+	db    *gorm.DB
+	cache *repoCache
+}
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{
+		db:    db,
+		cache: newRepoCache("stats", StatsCacheTTL),
+	}
+}
+
+// InvalidateAll flushes the stats cache.
+func (r *StatsRepository) InvalidateAll() {
+	if r.cache != nil {
+		r.cache.DeleteAll()
+	}
+}
+
+// GetChartStatistic retrieves the fitting statistics of a chart, or nil if the
+// chart has not been fitted yet
+func (r *StatsRepository) GetChartStatistic(ctx context.Context, chartID int) (*model.ChartStatistic, error) {
+	// Misses are cached too, so charts that were never fitted do not hit the database
+	stat, err := cachedLoad(ctx, r.cache, chartStatisticCacheKey(chartID), func(ctx context.Context) (*model.ChartStatistic, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var row model.ChartStatistic
+		err := db.Where("chart_id = ?", chartID).First(&row).Error
+		switch {
+		case err == nil:
+			return &row, true, nil
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, true, nil
+		default:
+			return nil, false, err
+		}
+	})
+	if stat == nil || err != nil {
+		return nil, err
+	}
+	cp := *stat
+	return &cp, nil
+}
+
+// GetPlayerSkill retrieves the skill of a player as of the last fitting run,
+// or nil if the player has not been fitted yet
+func (r *StatsRepository) GetPlayerSkill(ctx context.Context, username string) (*model.FittingPlayerSkill, error) {
+	skill, err := cachedLoad(ctx, r.cache, playerSkillCacheKey(username), func(ctx context.Context) (*model.FittingPlayerSkill, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var row model.FittingPlayerSkill
+		err := db.Where("username = ?", username).First(&row).Error
+		switch {
+		case err == nil:
+			return &row, true, nil
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, true, nil
+		default:
+			return nil, false, err
+		}
+	})
+	if skill == nil || err != nil {
+		return nil, err
+	}
+	cp := *skill
+	return &cp, nil
+}
+
+// GetAllChartStatistics retrieves the fitting statistics of every fitted chart,
+// keyed by chart ID
+func (r *StatsRepository) GetAllChartStatistics(ctx context.Context) (map[int]model.ChartStatistic, error) {
+	// Callers only read the map, so it is shared
+	return cachedLoad(ctx, r.cache, allChartStatisticsCacheKey(), func(ctx context.Context) (map[int]model.ChartStatistic, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var rows []model.ChartStatistic
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, false, err
+		}
+		stats := make(map[int]model.ChartStatistic, len(rows))
+		for _, row := range rows {
+			stats[row.ChartID] = row
+		}
+		return stats, true, nil
+	})
+}
+
+// GetChartScores retrieves the best score of every player on a chart,
+// ascending
+func (r *StatsRepository) GetChartScores(ctx context.Context, chartID int) ([]int, error) {
+	scores, err := cachedLoad(ctx, r.cache, chartScoresCacheKey(chartID), func(ctx context.Context) ([]int, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		scores := make([]int, 0)
+		err := db.Model(&model.BestPlayRecord{}).
+			Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
+			Where("best_play_records.chart_id = ?", chartID).
+			Order("play_records.score").
+			Pluck("play_records.score", &scores).Error
+		return scores, err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(scores), nil
+}
+
+// GetScoreCounts counts, per chart, the players with a best record and those
+// whose best score is at least passScore. Charts without records are absent.
+func (r *StatsRepository) GetScoreCounts(ctx context.Context, passScore int) (map[int]model.ChartScoreCount, error) {
+	// Callers only read the map, so it is shared
+	return cachedLoad(ctx, r.cache, scoreCountsCacheKey(passScore), func(ctx context.Context) (map[int]model.ChartScoreCount, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var rows []model.ChartScoreCount
+		err := db.Model(&model.BestPlayRecord{}).
+			Select("best_play_records.chart_id AS chart_id, COUNT(*) AS player_count, "+
+				"SUM(CASE WHEN play_records.score >= ? THEN 1 ELSE 0 END) AS pass_count", passScore).
+			Joins("JOIN play_records ON play_records.id = best_play_records.play_record_id").
+			Group("best_play_records.chart_id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, false, err
+		}
+		counts := make(map[int]model.ChartScoreCount, len(rows))
+		for _, row := range rows {
+			counts[row.ChartID] = row
+		}
+		return counts, true, nil
+	})
+}
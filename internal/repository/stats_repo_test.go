@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRepository(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+	statsRepo := NewStatsRepository(db)
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "stats_song", Title: "Stats Song"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyInvaded, Level: 13.5},
+			{Difficulty: model.DifficultyMassive, Level: 15.2},
+		},
+	})
+	require.NoError(t, err)
+	invaded, massive := song.Charts[0].ID, song.Charts[1].ID
+
+	upload := func(username string, chartID, score int) {
+		_, err := recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: chartID, Score: intPtr(score)},
+			Username:       username,
+		}, false)
+		require.NoError(t, err)
+	}
+	upload("stats_a", massive, 990000)
+	upload("stats_a", massive, 1005000) // only the best score counts
+	upload("stats_b", massive, 1002000)
+	upload("stats_c", massive, 950000)
+	upload("stats_a", invaded, 1009000)
+
+	t.Run("GetChartScores", func(t *testing.T) {
+		scores, err := statsRepo.GetChartScores(ctx, massive)
+		require.NoError(t, err)
+		assert.Equal(t, []int{950000, 1002000, 1005000}, scores)
+
+		// Results are cached until they expire
+		upload("stats_d", massive, 1000000)
+		scores, err = statsRepo.GetChartScores(ctx, massive)
+		require.NoError(t, err)
+		assert.Len(t, scores, 3)
+
+		statsRepo.InvalidateAll()
+		scores, err = statsRepo.GetChartScores(ctx, massive)
+		require.NoError(t, err)
+		assert.Equal(t, []int{950000, 1000000, 1002000, 1005000}, scores)
+
+		scores, err = statsRepo.GetChartScores(ctx, 99999)
+		require.NoError(t, err)
+		assert.Empty(t, scores)
+	})
+
+	t.Run("GetScoreCounts", func(t *testing.T) {
+		counts, err := statsRepo.GetScoreCounts(ctx, 1000000)
+		require.NoError(t, err)
+		assert.Equal(t, model.ChartScoreCount{ChartID: massive, PlayerCount: 4, PassCount: 3}, counts[massive])
+		assert.Equal(t, model.ChartScoreCount{ChartID: invaded, PlayerCount: 1, PassCount: 1}, counts[invaded])
+
+		counts, err = statsRepo.GetScoreCounts(ctx, 1003000)
+		require.NoError(t, err)
+		assert.Equal(t, 1, counts[massive].PassCount)
+	})
+
+	t.Run("GetChartStatistic", func(t *testing.T) {
+		stat, err := statsRepo.GetChartStatistic(ctx, massive)
+		require.NoError(t, err)
+		assert.Nil(t, stat)
+
+		require.NoError(t, db.Create(&model.ChartStatistic{
+			ChartID:             massive,
+			OfficialLevel:       15.2,
+			FittingLevel:        float64Ptr(15.4),
+			SampleCount:         40,
+			EffectiveSampleSize: 22.5,
+			StdDev:              0.3,
+			LastComputedAt:      time.Now(),
+		}).Error)
+
+		// The miss was cached
+		stat, err = statsRepo.GetChartStatistic(ctx, massive)
+		require.NoError(t, err)
+		assert.Nil(t, stat)
+
+		statsRepo.InvalidateAll()
+		stat, err = statsRepo.GetChartStatistic(ctx, massive)
+		require.NoError(t, err)
+		require.NotNil(t, stat)
+		assert.Equal(t, 40, stat.SampleCount)
+
+		all, err := statsRepo.GetAllChartStatistics(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+		assert.InDelta(t, 22.5, all[massive].EffectiveSampleSize, 1e-9)
+	})
+
+	t.Run("GetPlayerSkill", func(t *testing.T) {
+		skill, err := statsRepo.GetPlayerSkill(ctx, "stats_a")
+		require.NoError(t, err)
+		assert.Nil(t, skill)
+
+		require.NoError(t, db.Create(&model.FittingPlayerSkill{
+			Username: "stats_a", AvgRating: 152.5, NumRecords: 2, TopK: 2, UpdatedAt: time.Now(),
+		}).Error)
+
+		// The miss was cached
+		skill, err = statsRepo.GetPlayerSkill(ctx, "stats_a")
+		require.NoError(t, err)
+		assert.Nil(t, skill)
+
+		statsRepo.InvalidateAll()
+		skill, err = statsRepo.GetPlayerSkill(ctx, "stats_a")
+		require.NoError(t, err)
+		require.NotNil(t, skill)
+		assert.Equal(t, 152.5, skill.AvgRating)
+		assert.Equal(t, 2, skill.TopK)
+
+		// Callers get a copy
+		skill.AvgRating = 0
+		skill, err = statsRepo.GetPlayerSkill(ctx, "stats_a")
+		require.NoError(t, err)
+		assert.Equal(t, 152.5, skill.AvgRating)
+	})
+}
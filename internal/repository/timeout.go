@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/config"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// readDB returns db bound to ctx for the queries of one read, which then
+// fail with context.DeadlineExceeded past database.read_timeout. Call
+// cancel once the results are consumed.
+func readDB(ctx context.Context, db *gorm.DB) (*gorm.DB, context.CancelFunc) {
+	return withTimeout(ctx, db, config.DatabaseReadTimeoutDuration)
+}
+
+// writeDB is readDB for a write or a transaction, under
+// database.write_timeout.
+func writeDB(ctx context.Context, db *gorm.DB) (*gorm.DB, context.CancelFunc) {
+	return withTimeout(ctx, db, config.DatabaseWriteTimeoutDuration)
+}
+
+func withTimeout(ctx context.Context, db *gorm.DB, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	if timeout <= 0 {
+		return db.WithContext(ctx), func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return db.WithContext(ctx), cancel
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setQueryTimeouts sets the repository query timeouts for the test.
+func setQueryTimeouts(t *testing.T, read, write time.Duration) {
+	t.Helper()
+	prevRead, prevWrite := config.DatabaseReadTimeoutDuration, config.DatabaseWriteTimeoutDuration
+	config.DatabaseReadTimeoutDuration, config.DatabaseWriteTimeoutDuration = read, write
+	t.Cleanup(func() {
+		config.DatabaseReadTimeoutDuration, config.DatabaseWriteTimeoutDuration = prevRead, prevWrite
+	})
+}
+
+func TestQueryTimeouts(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songs := NewSongRepository(db)
+	users := NewUserRepository(db)
+	_, err := songs.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{Title: "Timeout", Artist: "A", Version: "1.0", WikiID: "timeout"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 10.0}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Reads past the read timeout fail", func(t *testing.T) {
+		setQueryTimeouts(t, time.Nanosecond, 0)
+		_, err := songs.GetSongHistory(ctx, 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		repo := NewSongRepository(db)
+		_, err = repo.GetAllSongs(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, repo.cache.Has(allSongsCacheKey()), "failed loads are not cached")
+
+		// Writes have their own timeout
+		_, err = users.CreateUser(ctx, &model.User{
+			UserBase:        model.UserBase{Username: "timeoutuser", UploadToken: "timeout_tok", IsActive: true},
+			EncodedPassword: "p",
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Writes past the write timeout fail", func(t *testing.T) {
+		setQueryTimeouts(t, 0, time.Nanosecond)
+		_, err := users.CreateUser(ctx, &model.User{
+			UserBase:        model.UserBase{Username: "lateuser", UploadToken: "late_tok", IsActive: true},
+			EncodedPassword: "p",
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		got, err := users.GetUserByUsername(ctx, "lateuser")
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("A cancelled request stops its queries", func(t *testing.T) {
+		setQueryTimeouts(t, 0, 0)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := songs.GetSongHistory(cancelled, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
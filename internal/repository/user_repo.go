@@ -1,10 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"paradigm-reboot-prober-go/internal/model"
 
-	"github.com/jellydator/ttlcache/v3"
 	"gorm.io/gorm"
 )
 
@@ -16,43 +16,38 @@ type UserRepository struct {
 func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{
 		db:    db,
-		cache: newRepoCache(UserCacheTTL),
+		cache: newRepoCache("user", UserCacheTTL),
 	}
 }
 
 // GetUserByUsername retrieves a user by their username
-func (r *UserRepository) GetUserByUsername(username string) (*model.User, error) {
-	key := userCacheKey(username)
-	if r.cache != nil {
-		if item := r.cache.Get(key); item != nil {
-			// Return a shallow copy to prevent callers from mutating cached data
-			original := item.Value().(*model.User)
-			cp := *original
-			return &cp, nil
-		}
-	}
-
-	var user model.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+func (r *UserRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	user, err := cachedLoad(ctx, r.cache, userCacheKey(username), func(ctx context.Context) (*model.User, bool, error) {
+		db, cancel := readDB(ctx, r.db)
+		defer cancel()
+		var user model.User
+		if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, nil
+			}
+			return nil, false, err
 		}
+		return &user, true, nil
+	})
+	if user == nil || err != nil {
 		return nil, err
 	}
-
-	if r.cache != nil {
-		r.cache.Set(key, &user, ttlcache.DefaultTTL)
-		// Return a copy so the caller cannot mutate the cached object
-		cp := user
-		return &cp, nil
-	}
-	return &user, nil
+	// Return a copy so the caller cannot mutate the cached object
+	cp := *user
+	return &cp, nil
 }
 
 // GetUserByUploadToken retrieves a user by their upload token
-func (r *UserRepository) GetUserByUploadToken(token string) (*model.User, error) {
+func (r *UserRepository) GetUserByUploadToken(ctx context.Context, token string) (*model.User, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
 	var user model.User
-	if err := r.db.Where("upload_token = ?", token).First(&user).Error; err != nil {
+	if err := db.Where("upload_token = ?", token).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -62,21 +57,25 @@ func (r *UserRepository) GetUserByUploadToken(token string) (*model.User, error)
 }
 
 // CreateUser creates a new user
-func (r *UserRepository) CreateUser(user *model.User) (*model.User, error) {
+func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
 	// Set default nickname if not provided
 	if user.Nickname == "" {
 		user.Nickname = user.Username
 	}
 
-	if err := r.db.Create(user).Error; err != nil {
+	if err := db.Create(user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
 // UpdateUser updates an existing user's information (PUT semantics)
-func (r *UserRepository) UpdateUser(user *model.User) (*model.User, error) {
-	if err := r.db.Save(user).Error; err != nil {
+func (r *UserRepository) UpdateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	if err := db.Save(user).Error; err != nil {
 		return nil, err
 	}
 	// Invalidate cache for this user after successful DB write
@@ -90,8 +89,10 @@ func (r *UserRepository) UpdateUser(user *model.User) (*model.User, error) {
 // copy of UserRepository. If fn returns an error the transaction is rolled back.
 // The transactional repo shares the same cache so writes inside the TX trigger
 // invalidation on the shared cache.
-func (r *UserRepository) WithTransaction(fn func(txRepo *UserRepository) error) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *UserRepository) WithTransaction(ctx context.Context, fn func(txRepo *UserRepository) error) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
 		return fn(&UserRepository{db: tx, cache: r.cache})
 	})
 }
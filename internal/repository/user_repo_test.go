@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"paradigm-reboot-prober-go/internal/model"
 	"testing"
@@ -9,6 +10,7 @@ import (
 )
 
 func TestUserRepository_CreateUser(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
@@ -24,7 +26,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 			},
 			EncodedPassword: "encoded_password",
 		}
-		createdUser, err := repo.CreateUser(user)
+		createdUser, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 		assert.NotNil(t, createdUser)
 		assert.Equal(t, "testuser", createdUser.Username)
@@ -43,7 +45,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 			},
 			EncodedPassword: "pass",
 		}
-		createdUser, err := repo.CreateUser(user)
+		createdUser, err := repo.CreateUser(ctx, user)
 		assert.NoError(t, err)
 		assert.Equal(t, "user_no_nick", createdUser.Nickname)
 	})
@@ -57,7 +59,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 			},
 			EncodedPassword: "pass",
 		}
-		_, err := repo.CreateUser(user1)
+		_, err := repo.CreateUser(ctx, user1)
 		assert.NoError(t, err)
 
 		user2 := &model.User{
@@ -68,12 +70,13 @@ func TestUserRepository_CreateUser(t *testing.T) {
 			},
 			EncodedPassword: "pass",
 		}
-		_, err = repo.CreateUser(user2)
+		_, err = repo.CreateUser(ctx, user2)
 		assert.Error(t, err) // Should fail due to unique constraint
 	})
 }
 
 func TestUserRepository_GetUserByUsername(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
@@ -86,24 +89,25 @@ func TestUserRepository_GetUserByUsername(t *testing.T) {
 		},
 		EncodedPassword: "pass",
 	}
-	_, err := repo.CreateUser(user)
+	_, err := repo.CreateUser(ctx, user)
 	assert.NoError(t, err)
 
 	t.Run("User Found", func(t *testing.T) {
-		foundUser, err := repo.GetUserByUsername("findme")
+		foundUser, err := repo.GetUserByUsername(ctx, "findme")
 		assert.NoError(t, err)
 		assert.NotNil(t, foundUser)
 		assert.Equal(t, "findme", foundUser.Username)
 	})
 
 	t.Run("User Not Found", func(t *testing.T) {
-		foundUser, err := repo.GetUserByUsername("ghost")
+		foundUser, err := repo.GetUserByUsername(ctx, "ghost")
 		assert.NoError(t, err)
 		assert.Nil(t, foundUser)
 	})
 }
 
 func TestUserRepository_UpdateUser(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
@@ -117,12 +121,12 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 		},
 		EncodedPassword: "pass",
 	}
-	_, err := repo.CreateUser(user)
+	_, err := repo.CreateUser(ctx, user)
 	assert.NoError(t, err)
 
 	t.Run("Update Fields", func(t *testing.T) {
 		// Fetch user first
-		userToUpdate, _ := repo.GetUserByUsername("update_target")
+		userToUpdate, _ := repo.GetUserByUsername(ctx, "update_target")
 
 		newNick := "Updated Nick"
 		newQQ := "123456"
@@ -130,37 +134,38 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 		userToUpdate.Nickname = newNick
 		userToUpdate.QQAccount = &newQQ
 
-		updatedUser, err := repo.UpdateUser(userToUpdate)
+		updatedUser, err := repo.UpdateUser(ctx, userToUpdate)
 		assert.NoError(t, err)
 		assert.Equal(t, "Updated Nick", updatedUser.Nickname)
 
 		// Verify
-		fetchedUser, _ := repo.GetUserByUsername("update_target")
+		fetchedUser, _ := repo.GetUserByUsername(ctx, "update_target")
 		assert.Equal(t, "Updated Nick", fetchedUser.Nickname)
 		assert.NotNil(t, fetchedUser.QQAccount)
 		assert.Equal(t, "123456", *fetchedUser.QQAccount)
 	})
 
 	t.Run("Idempotency Check (PUT semantics)", func(t *testing.T) {
-		userToUpdate, _ := repo.GetUserByUsername("update_target")
+		userToUpdate, _ := repo.GetUserByUsername(ctx, "update_target")
 		newAccount := "new_account"
 		userToUpdate.Account = &newAccount
 
-		_, err := repo.UpdateUser(userToUpdate)
+		_, err := repo.UpdateUser(ctx, userToUpdate)
 		assert.NoError(t, err)
 
-		updatedUser, _ := repo.GetUserByUsername("update_target")
+		updatedUser, _ := repo.GetUserByUsername(ctx, "update_target")
 		assert.Equal(t, "new_account", *updatedUser.Account)
 		assert.Equal(t, "Updated Nick", updatedUser.Nickname) // Should persist from previous update
 	})
 }
 
 func TestUserRepository_WithTransaction(t *testing.T) {
+	ctx := context.Background()
 	db := setupTestDB(t)
 	repo := NewUserRepository(db)
 
 	t.Run("Successful transaction commits", func(t *testing.T) {
-		err := repo.WithTransaction(func(txRepo *UserRepository) error {
+		err := repo.WithTransaction(ctx, func(txRepo *UserRepository) error {
 			user := &model.User{
 				UserBase: model.UserBase{
 					Username:    "tx_commit",
@@ -169,19 +174,19 @@ func TestUserRepository_WithTransaction(t *testing.T) {
 				},
 				EncodedPassword: "pass",
 			}
-			_, err := txRepo.CreateUser(user)
+			_, err := txRepo.CreateUser(ctx, user)
 			return err
 		})
 		assert.NoError(t, err)
 
-		found, err := repo.GetUserByUsername("tx_commit")
+		found, err := repo.GetUserByUsername(ctx, "tx_commit")
 		assert.NoError(t, err)
 		assert.NotNil(t, found)
 		assert.Equal(t, "tx_commit", found.Username)
 	})
 
 	t.Run("Error rolls back transaction", func(t *testing.T) {
-		err := repo.WithTransaction(func(txRepo *UserRepository) error {
+		err := repo.WithTransaction(ctx, func(txRepo *UserRepository) error {
 			user := &model.User{
 				UserBase: model.UserBase{
 					Username:    "tx_rollback",
@@ -190,7 +195,7 @@ func TestUserRepository_WithTransaction(t *testing.T) {
 				},
 				EncodedPassword: "pass",
 			}
-			if _, err := txRepo.CreateUser(user); err != nil {
+			if _, err := txRepo.CreateUser(ctx, user); err != nil {
 				return err
 			}
 			return errors.New("forced rollback")
@@ -199,7 +204,7 @@ func TestUserRepository_WithTransaction(t *testing.T) {
 		assert.Equal(t, "forced rollback", err.Error())
 
 		// User should NOT exist after rollback
-		found, err := repo.GetUserByUsername("tx_rollback")
+		found, err := repo.GetUserByUsername(ctx, "tx_rollback")
 		assert.NoError(t, err)
 		assert.Nil(t, found)
 	})
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"paradigm-reboot-prober-go/internal/model"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VoteRepository struct {
+	db *gorm.DB
+}
+
+func NewVoteRepository(db *gorm.DB) *VoteRepository {
+	return &VoteRepository{db: db}
+}
+
+// HasBestRecord reports whether the user has a best record on the chart
+func (r *VoteRepository) HasBestRecord(ctx context.Context, username string, chartID int) (bool, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var count int64
+	err := db.Model(&model.BestPlayRecord{}).
+		Where("username = ? AND chart_id = ?", username, chartID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpsertVote creates or replaces the user's vote on a chart
+func (r *VoteRepository) UpsertVote(ctx context.Context, vote *model.ChartVote) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chart_id"}, {Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"delta", "updated_at"}),
+	}).Create(vote).Error
+}
+
+// DeleteVote removes the user's vote on a chart. Returns false if there was none.
+func (r *VoteRepository) DeleteVote(ctx context.Context, username string, chartID int) (bool, error) {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	result := db.Where("username = ? AND chart_id = ?", username, chartID).Delete(&model.ChartVote{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUserVote retrieves the user's vote on a chart, or nil if there is none
+func (r *VoteRepository) GetUserVote(ctx context.Context, username string, chartID int) (*model.ChartVote, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	var vote model.ChartVote
+	if err := db.Where("username = ? AND chart_id = ?", username, chartID).First(&vote).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &vote, nil
+}
+
+// GetUserTags retrieves the user's tags on a chart, alphabetically
+func (r *VoteRepository) GetUserTags(ctx context.Context, username string, chartID int) ([]string, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	tags := make([]string, 0)
+	err := db.Model(&model.ChartTag{}).
+		Where("username = ? AND chart_id = ?", username, chartID).
+		Order("tag").
+		Pluck("tag", &tags).Error
+	return tags, err
+}
+
+// SetTags replaces the user's tags on a chart
+func (r *VoteRepository) SetTags(ctx context.Context, username string, chartID int, tags []string) error {
+	db, cancel := writeDB(ctx, r.db)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ? AND chart_id = ?", username, chartID).Delete(&model.ChartTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]model.ChartTag, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, model.ChartTag{ChartID: chartID, Username: username, Tag: tag})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// GetVoteStats aggregates votes and tags per chart. With chartIDs nil every
+// chart that has votes or tags is included.
+func (r *VoteRepository) GetVoteStats(ctx context.Context, chartIDs []int) (map[int]*model.ChartVoteStats, error) {
+	db, cancel := readDB(ctx, r.db)
+	defer cancel()
+	scope := func(db *gorm.DB) *gorm.DB {
+		if chartIDs != nil {
+			return db.Where("chart_id IN ?", chartIDs)
+		}
+		return db
+	}
+
+	var votes []struct {
+		ChartID   int
+		VoteCount int
+		MeanDelta float64
+	}
+	if err := db.Model(&model.ChartVote{}).
+		Scopes(scope).
+		Select("chart_id, COUNT(*) AS vote_count, AVG(delta) AS mean_delta").
+		Group("chart_id").
+		Scan(&votes).Error; err != nil {
+		return nil, err
+	}
+	var tags []struct {
+		ChartID int
+		Tag     string
+		Count   int
+	}
+	if err := db.Model(&model.ChartTag{}).
+		Scopes(scope).
+		Select("chart_id, tag, COUNT(*) AS count").
+		Group("chart_id, tag").
+		Scan(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make(map[int]*model.ChartVoteStats)
+	get := func(chartID int) *model.ChartVoteStats {
+		s, ok := stats[chartID]
+		if !ok {
+			s = &model.ChartVoteStats{ChartID: chartID, Tags: []model.TagCount{}}
+			stats[chartID] = s
+		}
+		return s
+	}
+	for _, v := range votes {
+		s := get(v.ChartID)
+		s.VoteCount = v.VoteCount
+		mean := v.MeanDelta
+		s.MeanDelta = &mean
+	}
+	for _, t := range tags {
+		s := get(t.ChartID)
+		s.Tags = append(s.Tags, model.TagCount{Tag: t.Tag, Count: t.Count})
+	}
+	for _, s := range stats {
+		slices.SortFunc(s.Tags, func(a, b model.TagCount) int {
+			if c := cmp.Compare(b.Count, a.Count); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Tag, b.Tag)
+		})
+	}
+	return stats, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoteRepository(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	songRepo := NewSongRepository(db)
+	recordRepo := NewRecordRepository(db)
+	voteRepo := NewVoteRepository(db)
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "vote_song", Title: "Vote Song"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyInvaded, Level: 13.5},
+			{Difficulty: model.DifficultyMassive, Level: 15.2},
+		},
+	})
+	require.NoError(t, err)
+	invaded, massive := song.Charts[0].ID, song.Charts[1].ID
+
+	t.Run("HasBestRecord", func(t *testing.T) {
+		ok, err := voteRepo.HasBestRecord(ctx, "voter_a", massive)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = recordRepo.CreateRecord(ctx, &model.PlayRecord{
+			PlayRecordBase: model.PlayRecordBase{ChartID: massive, Score: intPtr(990000)},
+			Username:       "voter_a",
+		}, false)
+		require.NoError(t, err)
+		ok, err = voteRepo.HasBestRecord(ctx, "voter_a", massive)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("UpsertVote replaces the previous vote", func(t *testing.T) {
+		require.NoError(t, voteRepo.UpsertVote(ctx, &model.ChartVote{ChartID: massive, Username: "voter_a", Delta: 0.5}))
+		require.NoError(t, voteRepo.UpsertVote(ctx, &model.ChartVote{ChartID: massive, Username: "voter_a", Delta: -0.2}))
+
+		vote, err := voteRepo.GetUserVote(ctx, "voter_a", massive)
+		require.NoError(t, err)
+		require.NotNil(t, vote)
+		assert.InDelta(t, -0.2, vote.Delta, 1e-9)
+
+		var count int64
+		db.Model(&model.ChartVote{}).Where("chart_id = ?", massive).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		vote, err = voteRepo.GetUserVote(ctx, "voter_b", massive)
+		require.NoError(t, err)
+		assert.Nil(t, vote)
+	})
+
+	t.Run("SetTags replaces the user's tags", func(t *testing.T) {
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_a", massive, []string{"tech", "stamina"}))
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_a", massive, []string{"stamina", "sight-read-trap"}))
+
+		tags, err := voteRepo.GetUserTags(ctx, "voter_a", massive)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sight-read-trap", "stamina"}, tags)
+
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_b", invaded, []string{"tech"}))
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_b", invaded, nil))
+		tags, err = voteRepo.GetUserTags(ctx, "voter_b", invaded)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("GetVoteStats aggregates per chart", func(t *testing.T) {
+		require.NoError(t, voteRepo.UpsertVote(ctx, &model.ChartVote{ChartID: massive, Username: "voter_b", Delta: 0.6}))
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_b", massive, []string{"stamina"}))
+		require.NoError(t, voteRepo.SetTags(ctx, "voter_c", invaded, []string{"tech"}))
+
+		stats, err := voteRepo.GetVoteStats(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+
+		st := stats[massive]
+		assert.Equal(t, 2, st.VoteCount)
+		require.NotNil(t, st.MeanDelta)
+		assert.InDelta(t, 0.2, *st.MeanDelta, 1e-9)
+		assert.Equal(t, []model.TagCount{{Tag: "stamina", Count: 2}, {Tag: "sight-read-trap", Count: 1}}, st.Tags)
+
+		// Tags without votes still produce an entry
+		st = stats[invaded]
+		assert.Equal(t, 0, st.VoteCount)
+		assert.Nil(t, st.MeanDelta)
+		assert.Equal(t, []model.TagCount{{Tag: "tech", Count: 1}}, st.Tags)
+
+		stats, err = voteRepo.GetVoteStats(ctx, []int{invaded})
+		require.NoError(t, err)
+		assert.Len(t, stats, 1)
+		assert.Contains(t, stats, invaded)
+	})
+
+	t.Run("DeleteVote", func(t *testing.T) {
+		deleted, err := voteRepo.DeleteVote(ctx, "voter_b", massive)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = voteRepo.DeleteVote(ctx, "voter_b", massive)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+}
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/controller"
@@ -8,6 +10,7 @@ import (
 	"paradigm-reboot-prober-go/internal/middleware"
 	"paradigm-reboot-prober-go/internal/repository"
 	"paradigm-reboot-prober-go/internal/service"
+	"paradigm-reboot-prober-go/internal/wiki"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -44,8 +47,8 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// CORS middleware
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Content-Encoding", "If-None-Match"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Content-Encoding", "If-None-Match", "If-Match"},
 		ExposeHeaders:    []string{"Content-Length", "Content-Encoding", "ETag"},
 		AllowCredentials: false,
 	}))
@@ -60,16 +63,30 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	userRepo := repository.NewUserRepository(db)
 	songRepo := repository.NewSongRepository(db)
 	recordRepo := repository.NewRecordRepository(db)
+	voteRepo := repository.NewVoteRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+
+	// Song metadata provider for src=wiki and wiki syncs (nil when disabled)
+	wikiProvider, err := wiki.NewProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize wiki provider: %v", err)
+	}
 
 	// Initialize Services
 	userService := service.NewUserService(userRepo)
-	songService := service.NewSongService(songRepo)
+	songService := service.NewSongService(songRepo, recordRepo, wikiProvider)
+	// Keep songs' b15 flags in line with the active season as seasons start and end
+	go songService.RunSeasonRollover(context.Background(), service.SeasonRolloverInterval)
 	recordService := service.NewRecordService(recordRepo, songRepo)
+	voteService := service.NewVoteService(voteRepo, songRepo)
+	statsService := service.NewStatsService(statsRepo, songRepo, recordRepo)
 
 	// Initialize Controllers
-	userCtrl := controller.NewUserController(userService)
+	userCtrl := controller.NewUserController(userService, statsService)
 	songCtrl := controller.NewSongController(songService)
-	recordCtrl := controller.NewRecordController(recordService, userService, songService)
+	recordCtrl := controller.NewRecordController(recordService, userService, songService, statsService)
+	voteCtrl := controller.NewVoteController(voteService, songService)
+	statsCtrl := controller.NewStatsController(statsService, songService)
 
 	r.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -86,7 +103,14 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		v2.POST("/user/login", middleware.RateLimitMiddleware(LoginEndpointRequestPerMinute, time.Minute), userCtrl.Login)
 		v2.POST("/user/refresh", userCtrl.RefreshToken)
 		v2.GET("/songs", songCtrl.GetAllCharts)
+		v2.GET("/songs/search", songCtrl.SearchSongs)
+		v2.GET("/songs/changes", songCtrl.GetSongChanges)
 		v2.GET("/songs/:song_id", songCtrl.GetSingleSongInfo)
+		v2.GET("/songs/:song_id/aliases", songCtrl.GetSongAliases)
+		v2.GET("/seasons", songCtrl.GetSeasons)
+		v2.GET("/seasons/:season_id", songCtrl.GetSeason)
+		v2.GET("/charts/tiers", voteCtrl.GetTierList)
+		v2.GET("/charts/stats", statsCtrl.GetChartStatsList)
 
 		// Routes with optional auth
 		optionalAuth := v2.Group("")
@@ -95,6 +119,8 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 			optionalAuth.GET("/records/:username", recordCtrl.GetPlayRecords)
 			optionalAuth.GET("/records/:username/song/:song_addr", recordCtrl.GetSongRecords)
 			optionalAuth.GET("/records/:username/chart/:chart_addr", recordCtrl.GetChartRecords)
+			optionalAuth.GET("/charts/:chart_addr/votes", voteCtrl.GetChartVotes)
+			optionalAuth.GET("/charts/:chart_addr/stats", statsCtrl.GetChartStats)
 
 			// Record upload: under optional auth so upload-token-based auth works
 			// (handler performs its own authorization check)
@@ -111,12 +137,35 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 			auth.POST("/user/me/upload-token", userCtrl.RefreshUploadToken)
 			auth.PUT("/user/me/password", userCtrl.ChangePassword)
 
+			// Community votes and tags
+			auth.PUT("/charts/:chart_addr/vote", voteCtrl.VoteChart)
+			auth.DELETE("/charts/:chart_addr/vote", voteCtrl.DeleteChartVote)
+			auth.PUT("/charts/:chart_addr/tags", voteCtrl.SetChartTags)
+
 			// Admin routes (with admin middleware)
 			admin := auth.Group("")
 			admin.Use(middleware.AdminMiddleware(userService))
 			{
 				admin.POST("/songs", songCtrl.CreateSong)
 				admin.PUT("/songs", songCtrl.UpdateSong)
+				admin.GET("/songs/:song_id/history", songCtrl.GetSongHistory)
+				admin.DELETE("/songs/:song_id", songCtrl.RetireSong)
+				admin.POST("/songs/:song_id/restore", songCtrl.RestoreSong)
+				admin.PATCH("/charts/:chart_addr", songCtrl.PatchChart)
+				admin.DELETE("/charts/:chart_addr", songCtrl.RetireChart)
+				admin.POST("/charts/:chart_addr/restore", songCtrl.RestoreChart)
+				admin.POST("/songs/:song_id/aliases", songCtrl.CreateSongAlias)
+				admin.DELETE("/songs/:song_id/aliases/:alias_id", songCtrl.DeleteSongAlias)
+				admin.GET("/catalog", songCtrl.ExportCatalog)
+				admin.POST("/catalog", songCtrl.ImportCatalog)
+				admin.POST("/songs/:song_id/wiki-sync", songCtrl.SyncSongFromWiki)
+				admin.GET("/wiki/proposals", songCtrl.GetMetadataProposals)
+				admin.POST("/wiki/proposals/:proposal_id/accept", songCtrl.AcceptMetadataProposal)
+				admin.POST("/wiki/proposals/:proposal_id/reject", songCtrl.RejectMetadataProposal)
+				admin.POST("/seasons", songCtrl.CreateSeason)
+				admin.PUT("/seasons/:season_id", songCtrl.UpdateSeason)
+				admin.PUT("/seasons/:season_id/songs", songCtrl.SetSeasonSongs)
+				admin.DELETE("/seasons/:season_id", songCtrl.DeleteSeason)
 				admin.POST("/user/reset-password", userCtrl.ResetPassword)
 			}
 		}
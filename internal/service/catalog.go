@@ -0,0 +1,244 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// catalogValidator checks catalog files against the same `binding` tags that
+// gin enforces on the song create/update endpoints.
+var catalogValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+}()
+
+// errCatalogDryRun rolls back an import transaction once the plan has been computed.
+var errCatalogDryRun = errors.New("catalog dry run")
+
+// CatalogImportOptions controls ImportCatalog.
+type CatalogImportOptions struct {
+	// Apply commits the plan; otherwise it is only computed and rolled back.
+	Apply bool
+	// Prune retires live songs that are missing from the catalog.
+	Prune bool
+}
+
+// DecodeCatalog parses and validates a catalog file in the given format.
+// Unknown fields are rejected so that typos do not silently drop data.
+func DecodeCatalog(data []byte, format string) (*model.Catalog, error) {
+	var catalog model.Catalog
+	var err error
+	switch format {
+	case model.CatalogFormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&catalog)
+	case model.CatalogFormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&catalog)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q, expected yaml or json", format)
+	}
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("catalog is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	if err := catalogValidator.Struct(&catalog); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	return &catalog, nil
+}
+
+// EncodeCatalog renders a catalog in the given format.
+func EncodeCatalog(catalog *model.Catalog, format string) ([]byte, error) {
+	switch format {
+	case model.CatalogFormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(catalog); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case model.CatalogFormatJSON:
+		return json.MarshalIndent(catalog, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q, expected yaml or json", format)
+	}
+}
+
+// ExportCatalog returns every live song with its live charts, ordered by song ID
+// and then by difficulty (easiest first).
+func (s *SongService) ExportCatalog(ctx context.Context) (*model.Catalog, error) {
+	songs, err := s.songRepo.GetAllSongs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(songs, func(a, b model.Song) int { return cmp.Compare(a.ID, b.ID) })
+
+	catalog := &model.Catalog{Songs: make([]model.CatalogSong, 0, len(songs))}
+	for _, song := range songs {
+		charts := slices.Clone(song.Charts)
+		slices.SortFunc(charts, func(a, b model.Chart) int {
+			return cmp.Compare(a.Difficulty.Order(), b.Difficulty.Order())
+		})
+		entry := model.CatalogSong{SongBase: song.SongBase}
+		for _, chart := range charts {
+			input := model.ChartInput{
+				Difficulty:       chart.Difficulty,
+				Level:            chart.Level,
+				Notes:            chart.Notes,
+				SongBaseOverride: chart.SongBaseOverride,
+			}
+			if chart.LevelDesign != nil {
+				input.LevelDesign = *chart.LevelDesign
+			}
+			entry.Charts = append(entry.Charts, input)
+		}
+		catalog.Songs = append(catalog.Songs, entry)
+	}
+	return catalog, nil
+}
+
+// ImportCatalog diffs catalog against the database, matching songs by wiki_id.
+// New songs are created, existing songs are updated through the same path as
+// UpdateSong (history, rating recalculation, removal of charts missing from the
+// entry), and with opts.Prune songs missing from the catalog are retired.
+// Everything runs in one transaction, which is rolled back unless opts.Apply is set.
+func (s *SongService) ImportCatalog(ctx context.Context, catalog *model.Catalog, opts CatalogImportOptions, changedBy string) (*model.CatalogPlan, error) {
+	// Validate every entry before touching the database
+	songs := make([]*model.Song, 0, len(catalog.Songs))
+	inCatalog := make(map[string]bool, len(catalog.Songs))
+	for _, entry := range catalog.Songs {
+		if inCatalog[entry.WikiID] {
+			return nil, fmt.Errorf("duplicate wiki_id in catalog: %s", entry.WikiID)
+		}
+		inCatalog[entry.WikiID] = true
+		song, err := songFromInput(entry.SongBase, entry.Charts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.WikiID, err)
+		}
+		songs = append(songs, song)
+	}
+
+	plan := &model.CatalogPlan{Changes: []model.CatalogChange{}}
+	var touched []int
+	err := s.songRepo.WithTransaction(ctx, func(tx *repository.SongRepository) error {
+		existing, err := tx.GetAllSongs(ctx)
+		if err != nil {
+			return err
+		}
+		byWikiID := make(map[string]*model.Song, len(existing))
+		for i := range existing {
+			byWikiID[existing[i].WikiID] = &existing[i]
+		}
+
+		for _, song := range songs {
+			current, ok := byWikiID[song.WikiID]
+			if !ok {
+				retired, err := tx.GetRetiredSongByWikiID(ctx, song.WikiID)
+				if err != nil {
+					return err
+				}
+				if retired != nil {
+					return fmt.Errorf("wiki_id %s belongs to retired song %d, restore it first: %w", song.WikiID, retired.ID, ErrConflict)
+				}
+				if _, err := tx.CreateSong(ctx, song); err != nil {
+					return fmt.Errorf("%s: %w", song.WikiID, err)
+				}
+				plan.Changes = append(plan.Changes, model.CatalogChange{Action: model.CatalogActionCreate, WikiID: song.WikiID})
+				continue
+			}
+
+			// Leave unchanged songs alone so their updated_at (and the /songs ETag) stay put
+			if len(repository.DiffSong(current, song)) == 0 {
+				continue
+			}
+			_, changes, err := tx.UpdateSong(ctx, current.ID, song, changedBy)
+			if err != nil {
+				return fmt.Errorf("%s: %w", song.WikiID, err)
+			}
+			for _, change := range changes {
+				plan.Changes = append(plan.Changes, catalogChange(song.WikiID, change))
+			}
+			touched = append(touched, current.ID)
+		}
+
+		if opts.Prune {
+			for _, song := range existing {
+				if inCatalog[song.WikiID] {
+					continue
+				}
+				if err := tx.RetireSong(ctx, song.ID, changedBy); err != nil {
+					return err
+				}
+				plan.Changes = append(plan.Changes, model.CatalogChange{
+					Action: model.CatalogActionRetire, WikiID: song.WikiID, SongID: song.ID,
+				})
+				touched = append(touched, song.ID)
+			}
+		}
+
+		if !opts.Apply {
+			return errCatalogDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errCatalogDryRun) {
+		return plan, nil
+	}
+	if err != nil {
+		if !errors.Is(err, ErrConflict) {
+			slog.ErrorContext(ctx, "failed to import catalog", "error", err)
+		}
+		return nil, err
+	}
+
+	plan.Applied = true
+	slog.InfoContext(ctx, "catalog imported", "songs", len(songs), "changes", len(plan.Changes), "changed_by", changedBy)
+	for _, songID := range touched {
+		s.invalidateSongRecords(ctx, songID)
+	}
+	return plan, nil
+}
+
+// catalogChange maps a recorded history entry to a plan line.
+func catalogChange(wikiID string, h model.ChartHistory) model.CatalogChange {
+	change := model.CatalogChange{
+		Action:     model.CatalogActionUpdate,
+		WikiID:     wikiID,
+		SongID:     h.SongID,
+		Difficulty: h.Difficulty,
+		Field:      h.Field,
+		OldValue:   h.OldValue,
+		NewValue:   h.NewValue,
+	}
+	switch {
+	case h.Field == model.ChartHistoryFieldLevel:
+		change.Action = model.CatalogActionLevelChange
+	case h.Field == model.ChartHistoryFieldChart && h.OldValue == nil:
+		change = model.CatalogChange{Action: model.CatalogActionCreate, WikiID: wikiID, SongID: h.SongID, Difficulty: h.Difficulty}
+	case h.Field == model.ChartHistoryFieldChart:
+		change = model.CatalogChange{Action: model.CatalogActionRetire, WikiID: wikiID, SongID: h.SongID, Difficulty: h.Difficulty}
+	}
+	return change
+}
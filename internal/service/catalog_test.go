@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/pkg/rating"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCatalog(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		catalog, err := DecodeCatalog([]byte(`
+songs:
+  - wiki_id: w1
+    title: Title
+    artist: Artist
+    b15: true
+    charts:
+      - difficulty: massive
+        level: 14.5
+        notes: 900
+        override_title: Alt
+`), model.CatalogFormatYAML)
+		require.NoError(t, err)
+		require.Len(t, catalog.Songs, 1)
+		song := catalog.Songs[0]
+		assert.Equal(t, "w1", song.WikiID)
+		assert.True(t, song.B15)
+		require.Len(t, song.Charts, 1)
+		assert.Equal(t, 14.5, song.Charts[0].Level)
+		assert.Equal(t, "Alt", *song.Charts[0].OverrideTitle)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		catalog, err := DecodeCatalog([]byte(`{"songs":[{"wiki_id":"w1","title":"T","artist":"A",
+			"charts":[{"difficulty":"reboot","level":16,"notes":1200}]}]}`), model.CatalogFormatJSON)
+		require.NoError(t, err)
+		assert.Equal(t, model.DifficultyReboot, catalog.Songs[0].Charts[0].Difficulty)
+	})
+
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"Unknown format", "toml", `songs = []`},
+		{"Empty file", model.CatalogFormatYAML, ``},
+		{"No songs", model.CatalogFormatYAML, `songs: []`},
+		{"Unknown field", model.CatalogFormatYAML, "songs:\n  - wiki_id: w1\n    title: T\n    artist: A\n    tittle: oops\n    charts: [{difficulty: massive, level: 1, notes: 1}]"},
+		{"Missing title", model.CatalogFormatJSON, `{"songs":[{"wiki_id":"w1","artist":"A","charts":[{"difficulty":"massive","level":1,"notes":1}]}]}`},
+		{"Invalid difficulty", model.CatalogFormatJSON, `{"songs":[{"wiki_id":"w1","title":"T","artist":"A","charts":[{"difficulty":"hard","level":1,"notes":1}]}]}`},
+		{"No charts", model.CatalogFormatJSON, `{"songs":[{"wiki_id":"w1","title":"T","artist":"A","charts":[]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCatalog([]byte(tt.data), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSongService_Catalog(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	charts, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "existing", Title: "Existing", Artist: "A"},
+		Charts: []model.ChartInput{
+			{Difficulty: model.DifficultyInvaded, Level: 12.0, Notes: 700},
+			{Difficulty: model.DifficultyMassive, Level: 14.5, Notes: 900},
+		},
+	})
+	require.NoError(t, err)
+	existingID, massiveID := charts[0].SongID, charts[1].ID
+	_, err = songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "stale", Title: "Stale", Artist: "S"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 13.0, Notes: 800}},
+	})
+	require.NoError(t, err)
+	_, err = recordService.CreateRecords(ctx, "alice", []model.PlayRecordBase{
+		{ChartID: massiveID, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+
+	t.Run("Export then import is a no-op", func(t *testing.T) {
+		catalog, err := songService.ExportCatalog(ctx)
+		require.NoError(t, err)
+		require.Len(t, catalog.Songs, 2)
+		assert.Equal(t, "existing", catalog.Songs[0].WikiID)
+		// Charts are ordered easiest first
+		assert.Equal(t, model.DifficultyInvaded, catalog.Songs[0].Charts[0].Difficulty)
+
+		for _, format := range []string{model.CatalogFormatYAML, model.CatalogFormatJSON} {
+			data, err := EncodeCatalog(catalog, format)
+			require.NoError(t, err)
+			decoded, err := DecodeCatalog(data, format)
+			require.NoError(t, err, format)
+			assert.Equal(t, catalog, decoded, format)
+
+			plan, err := songService.ImportCatalog(ctx, decoded, CatalogImportOptions{Apply: true}, "admin")
+			require.NoError(t, err)
+			assert.Empty(t, plan.Changes, format)
+		}
+		history, err := songService.GetSongHistory(ctx, existingID)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	catalog := &model.Catalog{Songs: []model.CatalogSong{
+		{
+			SongBase: model.SongBase{WikiID: "existing", Title: "Existing (Remaster)", Artist: "A"},
+			Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 900}},
+		},
+		{
+			SongBase: model.SongBase{WikiID: "new", Title: "New", Artist: "N"},
+			Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 14.0, Notes: 1000}},
+		},
+	}}
+	actions := func(plan *model.CatalogPlan) []string {
+		var out []string
+		for _, c := range plan.Changes {
+			out = append(out, c.Action+" "+c.WikiID+" "+string(c.Difficulty))
+		}
+		return out
+	}
+	expected := []string{
+		"update existing ",
+		"level_change existing massive",
+		"retire existing invaded",
+		"create new ",
+		"retire stale ",
+	}
+
+	t.Run("Plan is rolled back", func(t *testing.T) {
+		plan, err := songService.ImportCatalog(ctx, catalog, CatalogImportOptions{Prune: true}, "admin")
+		require.NoError(t, err)
+		assert.False(t, plan.Applied)
+		assert.Equal(t, expected, actions(plan))
+		assert.Equal(t, "14.5", *plan.Changes[1].OldValue)
+		assert.Equal(t, "15", *plan.Changes[1].NewValue)
+
+		_, err = songService.ResolveSongID(ctx, "new")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = songService.ResolveSongID(ctx, "stale")
+		assert.NoError(t, err)
+		history, err := songService.GetSongHistory(ctx, existingID)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		// Prime alice's B50 cache
+		before, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, before, 1)
+
+		plan, err := songService.ImportCatalog(ctx, catalog, CatalogImportOptions{Apply: true, Prune: true}, "admin")
+		require.NoError(t, err)
+		assert.True(t, plan.Applied)
+		assert.Equal(t, expected, actions(plan))
+
+		_, err = songService.ResolveSongID(ctx, "new")
+		assert.NoError(t, err)
+		_, err = songService.ResolveSongID(ctx, "stale")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// Ratings were recalculated and the cached B50 dropped
+		after, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		assert.Equal(t, rating.SingleRating(15.0, 1000000), after[0].Rating)
+
+		history, err := songService.GetSongHistory(ctx, existingID)
+		require.NoError(t, err)
+		assert.Len(t, history, 3)
+		assert.Equal(t, "admin", history[0].ChangedBy)
+	})
+
+	t.Run("Retired wiki_id conflicts and rolls back", func(t *testing.T) {
+		_, err := songService.ImportCatalog(ctx, &model.Catalog{Songs: []model.CatalogSong{
+			{
+				SongBase: model.SongBase{WikiID: "another", Title: "Another", Artist: "X"},
+				Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 10.0, Notes: 100}},
+			},
+			{
+				SongBase: model.SongBase{WikiID: "stale", Title: "Stale", Artist: "S"},
+				Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 13.0, Notes: 800}},
+			},
+		}}, CatalogImportOptions{Apply: true}, "admin")
+		assert.ErrorIs(t, err, ErrConflict)
+
+		// The song created before the conflict was rolled back
+		_, err = songService.ResolveSongID(ctx, "another")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Invalid entries abort the whole import", func(t *testing.T) {
+		bad := &model.Catalog{Songs: []model.CatalogSong{
+			{
+				SongBase: model.SongBase{WikiID: "another", Title: "Another", Artist: "X"},
+				Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 10.0, Notes: 100}},
+			},
+			{
+				SongBase: model.SongBase{WikiID: "dup", Title: "Dup", Artist: "X"},
+				Charts: []model.ChartInput{
+					{Difficulty: model.DifficultyMassive, Level: 10.0, Notes: 100},
+					{Difficulty: model.DifficultyMassive, Level: 11.0, Notes: 100},
+				},
+			},
+		}}
+		_, err := songService.ImportCatalog(ctx, bad, CatalogImportOptions{Apply: true}, "admin")
+		assert.Error(t, err)
+
+		bad.Songs[1] = bad.Songs[0]
+		_, err = songService.ImportCatalog(ctx, bad, CatalogImportOptions{Apply: true}, "admin")
+		assert.Error(t, err)
+
+		_, err = songService.ResolveSongID(ctx, "another")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -6,4 +6,10 @@ var (
 	ErrNotFound     = errors.New("not found")
 	ErrForbidden    = errors.New("forbidden")
 	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	// ErrUpstream marks failures of an external dependency such as the wiki.
+	ErrUpstream = errors.New("upstream error")
+	// ErrPreconditionFailed marks a conditional write (If-Match) whose
+	// precondition no longer holds.
+	ErrPreconditionFailed = errors.New("precondition failed")
 )
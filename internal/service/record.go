@@ -27,7 +27,7 @@ func (s *RecordService) CreateRecords(ctx context.Context, username string, reco
 			Username:       username,
 		})
 	}
-	results, err := s.recordRepo.BatchCreateRecords(playRecords, isReplaced)
+	results, err := s.recordRepo.BatchCreateRecords(ctx, playRecords, isReplaced)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create records", "error", err, "count", len(records))
 		return nil, err
@@ -37,11 +37,11 @@ func (s *RecordService) CreateRecords(ctx context.Context, username string, reco
 }
 
 func (s *RecordService) GetAllRecords(ctx context.Context, username string, pageSize, pageIndex int, sortBy string, order string, filter model.RecordFilter) ([]model.PlayRecord, error) {
-	return s.recordRepo.GetAllRecords(username, pageSize, pageIndex, sortBy, order == "desc", filter)
+	return s.recordRepo.GetAllRecords(ctx, username, pageSize, pageIndex, sortBy, order == "desc", filter)
 }
 
 func (s *RecordService) GetBest50Records(ctx context.Context, username string, underflow int, filter model.RecordFilter) ([]*model.PlayRecord, error) {
-	b35, b15, err := s.recordRepo.GetBest50Records(username, underflow, filter)
+	b35, b15, err := s.recordRepo.GetBest50Records(ctx, username, underflow, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -58,41 +58,41 @@ func (s *RecordService) GetBest50Records(ctx context.Context, username string, u
 }
 
 func (s *RecordService) GetBestRecords(ctx context.Context, username string, pageSize, pageIndex int, sortBy string, order string, filter model.RecordFilter) ([]model.PlayRecord, error) {
-	return s.recordRepo.GetBestRecords(username, pageSize, pageIndex, sortBy, order == "desc", filter)
+	return s.recordRepo.GetBestRecords(ctx, username, pageSize, pageIndex, sortBy, order == "desc", filter)
 }
 
 func (s *RecordService) GetAllChartsWithBestScores(ctx context.Context, username string, filter model.RecordFilter) ([]model.ChartWithScore, error) {
-	return s.recordRepo.GetAllChartsWithBestScores(username, filter)
+	return s.recordRepo.GetAllChartsWithBestScores(ctx, username, filter)
 }
 
 func (s *RecordService) CountBestRecords(ctx context.Context, username string, filter model.RecordFilter) (int64, error) {
-	return s.recordRepo.CountBestRecords(username, filter)
+	return s.recordRepo.CountBestRecords(ctx, username, filter)
 }
 
 func (s *RecordService) CountAllRecords(ctx context.Context, username string, filter model.RecordFilter) (int64, error) {
-	return s.recordRepo.CountAllRecords(username, filter)
+	return s.recordRepo.CountAllRecords(ctx, username, filter)
 }
 
 func (s *RecordService) GetBestRecordsBySong(ctx context.Context, username string, songID int) ([]model.PlayRecord, error) {
-	return s.recordRepo.GetBestRecordsBySong(username, songID)
+	return s.recordRepo.GetBestRecordsBySong(ctx, username, songID)
 }
 
 func (s *RecordService) GetAllRecordsBySong(ctx context.Context, username string, songID int, pageSize, pageIndex int, sortBy, order string) ([]model.PlayRecord, error) {
-	return s.recordRepo.GetAllRecordsBySong(username, songID, pageSize, pageIndex, sortBy, order == "desc")
+	return s.recordRepo.GetAllRecordsBySong(ctx, username, songID, pageSize, pageIndex, sortBy, order == "desc")
 }
 
 func (s *RecordService) CountAllRecordsBySong(ctx context.Context, username string, songID int) (int64, error) {
-	return s.recordRepo.CountAllRecordsBySong(username, songID)
+	return s.recordRepo.CountAllRecordsBySong(ctx, username, songID)
 }
 
 func (s *RecordService) GetBestRecordByChart(ctx context.Context, username string, chartID int) (*model.PlayRecord, error) {
-	return s.recordRepo.GetBestRecordByChart(username, chartID)
+	return s.recordRepo.GetBestRecordByChart(ctx, username, chartID)
 }
 
 func (s *RecordService) GetAllRecordsByChart(ctx context.Context, username string, chartID int, pageSize, pageIndex int, sortBy, order string) ([]model.PlayRecord, error) {
-	return s.recordRepo.GetAllRecordsByChart(username, chartID, pageSize, pageIndex, sortBy, order == "desc")
+	return s.recordRepo.GetAllRecordsByChart(ctx, username, chartID, pageSize, pageIndex, sortBy, order == "desc")
 }
 
 func (s *RecordService) CountAllRecordsByChart(ctx context.Context, username string, chartID int) (int64, error) {
-	return s.recordRepo.CountAllRecordsByChart(username, chartID)
+	return s.recordRepo.CountAllRecordsByChart(ctx, username, chartID)
 }
@@ -23,7 +23,7 @@ func TestRecordService(t *testing.T) {
 			{Difficulty: model.DifficultyMassive, Level: 15.0},
 		},
 	}
-	createdSong, _ := songRepo.CreateSong(song)
+	createdSong, _ := songRepo.CreateSong(ctx, song)
 	chartID := createdSong.Charts[0].ID
 
 	t.Run("CreateRecords", func(t *testing.T) {
@@ -92,7 +92,7 @@ func TestRecordService_PerSongAndChartMethods(t *testing.T) {
 			{Difficulty: model.DifficultyInvaded, Level: 12.0},
 		},
 	}
-	createdSong, err := songRepo.CreateSong(song)
+	createdSong, err := songRepo.CreateSong(ctx, song)
 	assert.NoError(t, err)
 	chart1ID := createdSong.Charts[0].ID // massive
 	chart2ID := createdSong.Charts[1].ID // invaded
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SeasonRolloverInterval is how often RunSeasonRollover checks whether the
+// active season has changed because a season started or ended.
+const SeasonRolloverInterval = time.Minute
+
+func (s *SongService) seasonInfo(ctx context.Context, season model.Season, active *model.Season) (model.SeasonInfo, error) {
+	songIDs, err := s.songRepo.GetSeasonSongIDs(ctx, season.ID)
+	if err != nil {
+		return model.SeasonInfo{}, err
+	}
+	return model.SeasonInfo{
+		Season:  season,
+		Active:  active != nil && active.ID == season.ID,
+		SongIDs: songIDs,
+	}, nil
+}
+
+// GetSeasons returns all seasons with their member songs, oldest first.
+func (s *SongService) GetSeasons(ctx context.Context) ([]model.SeasonInfo, error) {
+	seasons, err := s.songRepo.GetAllSeasons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.songRepo.GetActiveSeason(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]model.SeasonInfo, 0, len(seasons))
+	for _, season := range seasons {
+		info, err := s.seasonInfo(ctx, season, active)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// ResolveSeason parses a season address (numeric ID, name, or "active") and
+// returns the season.
+func (s *SongService) ResolveSeason(ctx context.Context, seasonAddr string) (*model.Season, error) {
+	var season *model.Season
+	var err error
+	if seasonAddr == "active" {
+		season, err = s.songRepo.GetActiveSeason(ctx, time.Now())
+	} else if id, convErr := strconv.Atoi(seasonAddr); convErr == nil {
+		season, err = s.songRepo.GetSeasonByID(ctx, id)
+	} else {
+		season, err = s.songRepo.GetSeasonByName(ctx, seasonAddr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, fmt.Errorf("season %w", ErrNotFound)
+	}
+	return season, nil
+}
+
+// GetSeason returns a season with its member songs.
+func (s *SongService) GetSeason(ctx context.Context, seasonAddr string) (*model.SeasonInfo, error) {
+	season, err := s.ResolveSeason(ctx, seasonAddr)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.songRepo.GetActiveSeason(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.seasonInfo(ctx, *season, active)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func validateSeasonSchedule(startsAt time.Time, endsAt *time.Time) error {
+	if endsAt != nil && !endsAt.After(startsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// validateSeasonSongs deduplicates songIDs and checks that every song exists.
+func (s *SongService) validateSeasonSongs(ctx context.Context, songIDs []int) ([]int, error) {
+	songIDs = slices.Clone(songIDs)
+	slices.Sort(songIDs)
+	songIDs = slices.Compact(songIDs)
+	count, err := s.songRepo.CountExistingSongs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+	if int(count) != len(songIDs) {
+		return nil, errors.New("song_ids contains songs that do not exist")
+	}
+	return songIDs, nil
+}
+
+func (s *SongService) checkSeasonName(ctx context.Context, name string, seasonID int) error {
+	existing, err := s.songRepo.GetSeasonByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != seasonID {
+		return fmt.Errorf("season %q already exists: %w", name, ErrConflict)
+	}
+	return nil
+}
+
+// CreateSeason creates a season. With FromCurrent, every song currently marked
+// b15 becomes a member, which seeds the first season from the legacy flags.
+func (s *SongService) CreateSeason(ctx context.Context, req *request.CreateSeasonRequest) (*model.SeasonInfo, error) {
+	if err := validateSeasonSchedule(req.StartsAt, req.EndsAt); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeasonName(ctx, req.Name, 0); err != nil {
+		return nil, err
+	}
+	songIDs := req.SongIDs
+	if req.FromCurrent {
+		current, err := s.songRepo.GetB15SongIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		songIDs = append(slices.Clone(songIDs), current...)
+	}
+	songIDs, err := s.validateSeasonSongs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	season, err := s.songRepo.CreateSeason(ctx, &model.Season{
+		Name:     req.Name,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}, songIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create season", "error", err, "name", req.Name)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "season created", "season_id", season.ID, "name", season.Name, "songs", len(songIDs))
+	s.ApplyActiveSeason(ctx)
+	return s.GetSeason(ctx, strconv.Itoa(season.ID))
+}
+
+// UpdateSeason renames or reschedules a season.
+func (s *SongService) UpdateSeason(ctx context.Context, seasonID int, req *request.UpdateSeasonRequest) (*model.SeasonInfo, error) {
+	season, err := s.songRepo.GetSeasonByID(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, fmt.Errorf("season %w", ErrNotFound)
+	}
+	if err := validateSeasonSchedule(req.StartsAt, req.EndsAt); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeasonName(ctx, req.Name, seasonID); err != nil {
+		return nil, err
+	}
+
+	season.Name = req.Name
+	season.StartsAt = req.StartsAt
+	season.EndsAt = req.EndsAt
+	if err := s.songRepo.UpdateSeason(ctx, season); err != nil {
+		slog.ErrorContext(ctx, "failed to update season", "error", err, "season_id", seasonID)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "season updated", "season_id", seasonID, "name", season.Name)
+	s.ApplyActiveSeason(ctx)
+	return s.GetSeason(ctx, strconv.Itoa(seasonID))
+}
+
+// SetSeasonSongs replaces the member songs of a season.
+func (s *SongService) SetSeasonSongs(ctx context.Context, seasonID int, songIDs []int) (*model.SeasonInfo, error) {
+	season, err := s.songRepo.GetSeasonByID(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, fmt.Errorf("season %w", ErrNotFound)
+	}
+	songIDs, err = s.validateSeasonSongs(ctx, songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.songRepo.SetSeasonSongs(ctx, seasonID, songIDs); err != nil {
+		slog.ErrorContext(ctx, "failed to set season songs", "error", err, "season_id", seasonID)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "season songs set", "season_id", seasonID, "songs", len(songIDs))
+	s.ApplyActiveSeason(ctx)
+	return s.GetSeason(ctx, strconv.Itoa(seasonID))
+}
+
+// DeleteSeason deletes a season. Deleting the last season leaves the b15
+// flags as they are and makes them directly editable again.
+func (s *SongService) DeleteSeason(ctx context.Context, seasonID int) error {
+	deleted, err := s.songRepo.DeleteSeason(ctx, seasonID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete season", "error", err, "season_id", seasonID)
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("season %w", ErrNotFound)
+	}
+	slog.InfoContext(ctx, "season deleted", "season_id", seasonID)
+	s.ApplyActiveSeason(ctx)
+	return nil
+}
+
+// ApplyActiveSeason brings songs.b15 in line with the currently active season
+// and drops cached records when the set of new songs changed. Caches are also
+// dropped when the active season differs from the one seen last, so that an
+// instance notices a rollover another instance already applied.
+// Failures are logged only: the next call retries.
+func (s *SongService) ApplyActiveSeason(ctx context.Context) {
+	season, changed, err := s.songRepo.ApplyActiveSeason(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to apply active season", "error", err)
+		return
+	}
+	activeID := int64(0)
+	if season != nil {
+		activeID = int64(season.ID)
+	}
+	if prev := s.activeSeasonID.Swap(activeID); prev != activeID || changed {
+		s.songRepo.InvalidateAll()
+		s.recordRepo.InvalidateAll()
+		slog.InfoContext(ctx, "active season applied", "season_id", activeID, "songs_changed", changed)
+	}
+}
+
+// RunSeasonRollover applies the active season immediately and then every
+// interval until ctx is cancelled, so that seasons start and end on schedule.
+func (s *SongService) RunSeasonRollover(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.ApplyActiveSeason(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
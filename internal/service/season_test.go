@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"paradigm-reboot-prober-go/internal/repository"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongService_Seasons(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	ctx := context.Background()
+
+	var ids []int
+	for i, wikiID := range []string{"old_new", "fresh", "classic"} {
+		song, err := songRepo.CreateSong(ctx, &model.Song{
+			SongBase: model.SongBase{WikiID: wikiID, Title: wikiID, B15: i == 0},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 14.0}},
+		})
+		require.NoError(t, err)
+		ids = append(ids, song.ID)
+	}
+	b15Of := func(songID int) bool {
+		song, err := songRepo.GetSongByID(ctx, songID)
+		require.NoError(t, err)
+		return song.B15
+	}
+	now := time.Now()
+
+	first, err := songService.CreateSeason(ctx, &request.CreateSeasonRequest{
+		Name:        "Launch",
+		StartsAt:    now.Add(-72 * time.Hour),
+		SongIDs:     []int{ids[1]},
+		FromCurrent: true,
+	})
+	require.NoError(t, err)
+
+	t.Run("CreateSeason seeds from current b15 songs", func(t *testing.T) {
+		assert.True(t, first.Active)
+		assert.Equal(t, []int{ids[0], ids[1]}, first.SongIDs)
+		assert.True(t, b15Of(ids[1]), "members become b15")
+		assert.False(t, b15Of(ids[2]))
+	})
+
+	t.Run("CreateSeason validation", func(t *testing.T) {
+		_, err := songService.CreateSeason(ctx, &request.CreateSeasonRequest{Name: "Launch", StartsAt: now})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		_, err = songService.CreateSeason(ctx, &request.CreateSeasonRequest{
+			Name: "Backwards", StartsAt: now, EndsAt: ptrTime(now.Add(-time.Hour)),
+		})
+		assert.Error(t, err)
+
+		_, err = songService.CreateSeason(ctx, &request.CreateSeasonRequest{
+			Name: "Ghost", StartsAt: now, SongIDs: []int{99999},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("A scheduled season takes over once it starts", func(t *testing.T) {
+		next, err := songService.CreateSeason(ctx, &request.CreateSeasonRequest{
+			Name:     "Next",
+			StartsAt: now.Add(time.Hour),
+			SongIDs:  []int{ids[2], ids[2]},
+		})
+		require.NoError(t, err)
+		assert.False(t, next.Active)
+		assert.Equal(t, []int{ids[2]}, next.SongIDs)
+		assert.False(t, b15Of(ids[2]), "season has not started yet")
+
+		next, err = songService.UpdateSeason(ctx, next.ID, &request.UpdateSeasonRequest{
+			Name:     "Next",
+			StartsAt: now.Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		assert.True(t, next.Active)
+		assert.Equal(t, []bool{false, false, true}, []bool{b15Of(ids[0]), b15Of(ids[1]), b15Of(ids[2])})
+
+		active, err := songService.GetSeason(ctx, "active")
+		require.NoError(t, err)
+		assert.Equal(t, next.ID, active.ID)
+		byName, err := songService.GetSeason(ctx, "Next")
+		require.NoError(t, err)
+		assert.Equal(t, next.ID, byName.ID)
+
+		_, err = songService.UpdateSeason(ctx, next.ID, &request.UpdateSeasonRequest{Name: "Launch", StartsAt: now})
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = songService.UpdateSeason(ctx, 99999, &request.UpdateSeasonRequest{Name: "Nope", StartsAt: now})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("SetSeasonSongs updates b15", func(t *testing.T) {
+		active, err := songService.GetSeason(ctx, "active")
+		require.NoError(t, err)
+		info, err := songService.SetSeasonSongs(ctx, active.ID, []int{ids[0], ids[2]})
+		require.NoError(t, err)
+		assert.Equal(t, []int{ids[0], ids[2]}, info.SongIDs)
+		assert.Equal(t, []bool{true, false, true}, []bool{b15Of(ids[0]), b15Of(ids[1]), b15Of(ids[2])})
+
+		_, err = songService.SetSeasonSongs(ctx, 99999, nil)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetSeasons", func(t *testing.T) {
+		seasons, err := songService.GetSeasons(ctx)
+		require.NoError(t, err)
+		require.Len(t, seasons, 2)
+		assert.Equal(t, "Launch", seasons[0].Name)
+		assert.False(t, seasons[0].Active)
+		assert.True(t, seasons[1].Active)
+	})
+
+	t.Run("DeleteSeason falls back to the remaining season", func(t *testing.T) {
+		active, err := songService.GetSeason(ctx, "active")
+		require.NoError(t, err)
+		require.NoError(t, songService.DeleteSeason(ctx, active.ID))
+		assert.Equal(t, []bool{true, true, false}, []bool{b15Of(ids[0]), b15Of(ids[1]), b15Of(ids[2])})
+
+		assert.ErrorIs(t, songService.DeleteSeason(ctx, active.ID), ErrNotFound)
+		_, err = songService.ResolveSeason(ctx, strconv.Itoa(active.ID))
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func ptrTime(v time.Time) *time.Time { return &v }
@@ -29,6 +29,15 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&model.Chart{},
 		&model.PlayRecord{},
 		&model.BestPlayRecord{},
+		&model.SongAlias{},
+		&model.ChartHistory{},
+		&model.SongMetadataProposal{},
+		&model.Season{},
+		&model.SeasonSong{},
+		&model.ChartVote{},
+		&model.ChartTag{},
+		&model.ChartStatistic{},
+		&model.FittingPlayerSkill{},
 	)
 	if err != nil {
 		t.Fatalf("failed to migrate database: %v", err)
@@ -38,3 +47,5 @@ func setupTestDB(t *testing.T) *gorm.DB {
 }
 
 func intPtr(v int) *int { return &v }
+
+func float64Ptr(v float64) *float64 { return &v }
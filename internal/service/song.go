@@ -11,9 +11,13 @@ import (
 	"paradigm-reboot-prober-go/internal/model"
 	"paradigm-reboot-prober-go/internal/model/request"
 	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/internal/wiki"
+	"paradigm-reboot-prober-go/pkg/fuzzy"
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -45,29 +49,46 @@ func compareVersion(a, b string) int {
 }
 
 type SongService struct {
-	songRepo *repository.SongRepository
+	songRepo   *repository.SongRepository
+	recordRepo *repository.RecordRepository
+	// wiki serves src=wiki lookups and wiki syncs; nil when no provider is configured
+	wiki wiki.SongMetadataProvider
+	// activeSeasonID is the active season last applied (0 = none)
+	activeSeasonID atomic.Int64
 }
 
-func NewSongService(songRepo *repository.SongRepository) *SongService {
-	return &SongService{songRepo: songRepo}
+func NewSongService(songRepo *repository.SongRepository, recordRepo *repository.RecordRepository, wikiProvider wiki.SongMetadataProvider) *SongService {
+	return &SongService{songRepo: songRepo, recordRepo: recordRepo, wiki: wikiProvider}
+}
+
+// toChartInfo flattens a chart and its song into one catalog row.
+func toChartInfo(song *model.Song, chart *model.Chart) model.ChartInfo {
+	return model.ChartInfo{
+		SongBase:          song.WithOverride(chart.SongBaseOverride),
+		SongID:            song.ID,
+		ID:                chart.ID,
+		Difficulty:        chart.Difficulty,
+		Level:             chart.Level,
+		FittingLevel:      chart.FittingLevel,
+		FittingLevelLower: chart.FittingLevelLower,
+		FittingLevelUpper: chart.FittingLevelUpper,
+		LevelDesign:       chart.LevelDesign,
+		Notes:             chart.Notes,
+	}
 }
 
 func buildChartInfos(songs []model.Song) []model.ChartInfo {
 	var charts []model.ChartInfo
-	for _, song := range songs {
-		for _, chart := range song.Charts {
-			charts = append(charts, model.ChartInfo{
-				SongBase:     song.WithOverride(chart.SongBaseOverride),
-				SongID:       song.ID,
-				ID:           chart.ID,
-				Difficulty:   chart.Difficulty,
-				Level:        chart.Level,
-				FittingLevel: chart.FittingLevel,
-				LevelDesign:  chart.LevelDesign,
-				Notes:        chart.Notes,
-			})
+	for i := range songs {
+		for j := range songs[i].Charts {
+			charts = append(charts, toChartInfo(&songs[i], &songs[i].Charts[j]))
 		}
 	}
+	sortChartInfos(charts)
+	return charts
+}
+
+func sortChartInfos(charts []model.ChartInfo) {
 	// Default sort: Version DESC (newest first), SongID ASC (as order in album), then Difficulty DESC (hardest first)
 	slices.SortFunc(charts, func(a, b model.ChartInfo) int {
 		if c := compareVersion(a.Version, b.Version); c != 0 {
@@ -78,7 +99,6 @@ func buildChartInfos(songs []model.Song) []model.ChartInfo {
 		}
 		return cmp.Compare(b.Difficulty.Order(), a.Difficulty.Order())
 	})
-	return charts
 }
 
 func computeSongsETag(songs []model.Song) string {
@@ -97,7 +117,7 @@ func computeSongsETag(songs []model.Song) string {
 }
 
 func (s *SongService) GetAllCharts(ctx context.Context) ([]model.ChartInfo, error) {
-	songs, err := s.songRepo.GetAllSongs()
+	songs, err := s.songRepo.GetAllSongs(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +125,7 @@ func (s *SongService) GetAllCharts(ctx context.Context) ([]model.ChartInfo, erro
 }
 
 func (s *SongService) GetAllChartsWithETag(ctx context.Context) ([]model.ChartInfo, string, error) {
-	songs, err := s.songRepo.GetAllSongs()
+	songs, err := s.songRepo.GetAllSongs(ctx)
 	if err != nil {
 		return nil, "", err
 	}
@@ -114,11 +134,11 @@ func (s *SongService) GetAllChartsWithETag(ctx context.Context) ([]model.ChartIn
 	return charts, etag, nil
 }
 
-// ResolveSongID parses a song_addr (numeric ID or wiki_id) and returns the song_id.
-// Returns an error if the song doesn't exist.
+// ResolveSongID parses a song_addr (numeric ID, wiki_id or alias) and returns the song_id.
+// Returns an error wrapping ErrNotFound if the song doesn't exist.
 func (s *SongService) ResolveSongID(ctx context.Context, songAddr string) (int, error) {
 	if id, err := strconv.Atoi(songAddr); err == nil {
-		song, err := s.songRepo.GetSongByID(id)
+		song, err := s.songRepo.GetSongByID(ctx, id)
 		if err != nil {
 			return 0, err
 		}
@@ -128,21 +148,30 @@ func (s *SongService) ResolveSongID(ctx context.Context, songAddr string) (int,
 		return song.ID, nil
 	}
 
-	song, err := s.songRepo.GetSongByWikiID(songAddr)
+	song, err := s.songRepo.GetSongByWikiID(ctx, songAddr)
 	if err != nil {
 		return 0, err
 	}
-	if song == nil {
+	if song != nil {
+		return song.ID, nil
+	}
+
+	alias, err := s.songRepo.GetAliasByNormalized(ctx, fuzzy.Normalize(songAddr))
+	if err != nil {
+		return 0, err
+	}
+	if alias == nil {
 		return 0, fmt.Errorf("song %w", ErrNotFound)
 	}
-	return song.ID, nil
+	return alias.SongID, nil
 }
 
-// ResolveChartID parses a chart_addr (numeric ID or "wiki_id:difficulty") and returns the chart_id.
-// Returns an error if the chart doesn't exist or the difficulty is invalid.
+// ResolveChartID parses a chart_addr (numeric ID or "wiki_id:difficulty", where wiki_id may
+// also be an alias) and returns the chart_id.
+// Returns an error wrapping ErrNotFound if the chart doesn't exist or the address is malformed.
 func (s *SongService) ResolveChartID(ctx context.Context, chartAddr string) (int, error) {
 	if id, err := strconv.Atoi(chartAddr); err == nil {
-		chart, err := s.songRepo.GetChartByID(id)
+		chart, err := s.songRepo.GetChartByID(ctx, id)
 		if err != nil {
 			return 0, err
 		}
@@ -155,26 +184,46 @@ func (s *SongService) ResolveChartID(ctx context.Context, chartAddr string) (int
 	// Split on the last ':' to handle wiki_id:difficulty format
 	lastColon := strings.LastIndex(chartAddr, ":")
 	if lastColon < 0 {
-		return 0, errors.New("invalid chart address format, expected wiki_id:difficulty")
+		return 0, fmt.Errorf("invalid chart address format, expected wiki_id:difficulty: %w", ErrNotFound)
 	}
 	wikiID := chartAddr[:lastColon]
 	diffStr := chartAddr[lastColon+1:]
 
 	if wikiID == "" {
-		return 0, errors.New("invalid chart address: empty wiki_id")
+		return 0, fmt.Errorf("invalid chart address: empty wiki_id: %w", ErrNotFound)
 	}
 	if !model.ValidDifficulty(diffStr) {
-		return 0, errors.New("invalid difficulty: " + diffStr)
+		return 0, fmt.Errorf("invalid difficulty %s: %w", diffStr, ErrNotFound)
 	}
 
-	chart, err := s.songRepo.GetChartByWikiIDAndDifficulty(wikiID, model.Difficulty(diffStr))
+	chart, err := s.songRepo.GetChartByWikiIDAndDifficulty(ctx, wikiID, model.Difficulty(diffStr))
 	if err != nil {
 		return 0, err
 	}
-	if chart == nil {
+	if chart != nil {
+		return chart.ID, nil
+	}
+
+	// Fall back to alias:difficulty
+	alias, err := s.songRepo.GetAliasByNormalized(ctx, fuzzy.Normalize(wikiID))
+	if err != nil {
+		return 0, err
+	}
+	if alias == nil {
 		return 0, fmt.Errorf("chart %w", ErrNotFound)
 	}
-	return chart.ID, nil
+	song, err := s.songRepo.GetSongByID(ctx, alias.SongID)
+	if err != nil {
+		return 0, err
+	}
+	if song != nil {
+		for _, c := range song.Charts {
+			if c.Difficulty == model.Difficulty(diffStr) {
+				return c.ID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("chart %w", ErrNotFound)
 }
 
 func (s *SongService) GetSingleSong(ctx context.Context, songID int, src string) (*model.Song, error) {
@@ -183,9 +232,9 @@ func (s *SongService) GetSingleSong(ctx context.Context, songID int, src string)
 
 	switch src {
 	case "prp":
-		song, err = s.songRepo.GetSongByID(songID)
+		song, err = s.songRepo.GetSongByID(ctx, songID)
 	case "wiki":
-		return nil, errors.New("wiki source not implemented yet")
+		return s.getWikiSong(ctx, songID)
 	default:
 		return nil, errors.New("unsupported source type")
 	}
@@ -204,7 +253,7 @@ func (s *SongService) GetSingleSongByWikiID(ctx context.Context, wikiID string)
 	var song *model.Song
 	var err error
 
-	song, err = s.songRepo.GetSongByWikiID(wikiID)
+	song, err = s.songRepo.GetSongByWikiID(ctx, wikiID)
 
 	if err != nil {
 		return nil, err
@@ -217,33 +266,12 @@ func (s *SongService) GetSingleSongByWikiID(ctx context.Context, wikiID string)
 }
 
 func (s *SongService) CreateSong(ctx context.Context, req *request.CreateSongRequest) ([]model.ChartInfo, error) {
-	// Check for duplicate difficulties
-	seenDifficulties := make(map[model.Difficulty]bool)
-	for _, chartInput := range req.Charts {
-		if seenDifficulties[chartInput.Difficulty] {
-			return nil, fmt.Errorf("duplicate chart difficulty: %s", chartInput.Difficulty)
-		}
-		seenDifficulties[chartInput.Difficulty] = true
-	}
-
-	// Map request to model.Song
-	song := &model.Song{
-		SongBase: req.SongBase,
-	}
-
-	// Map charts
-	for _, chartInput := range req.Charts {
-		chart := model.Chart{
-			Difficulty:       chartInput.Difficulty,
-			Level:            chartInput.Level,
-			LevelDesign:      &chartInput.LevelDesign,
-			Notes:            chartInput.Notes,
-			SongBaseOverride: chartInput.SongBaseOverride,
-		}
-		song.Charts = append(song.Charts, chart)
+	song, err := songFromCreateRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
-	createdSong, err := s.songRepo.CreateSong(song)
+	createdSong, err := s.songRepo.CreateSong(ctx, song)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create song", "error", err, "title", req.Title)
 		return nil, err
@@ -254,14 +282,16 @@ func (s *SongService) CreateSong(ctx context.Context, req *request.CreateSongReq
 	var charts []model.ChartInfo
 	for _, chart := range createdSong.Charts {
 		info := model.ChartInfo{
-			SongBase:     createdSong.WithOverride(chart.SongBaseOverride),
-			SongID:       createdSong.ID,
-			ID:           chart.ID,
-			Difficulty:   chart.Difficulty,
-			Level:        chart.Level,
-			FittingLevel: chart.FittingLevel,
-			LevelDesign:  chart.LevelDesign,
-			Notes:        chart.Notes,
+			SongBase:          createdSong.WithOverride(chart.SongBaseOverride),
+			SongID:            createdSong.ID,
+			ID:                chart.ID,
+			Difficulty:        chart.Difficulty,
+			Level:             chart.Level,
+			FittingLevel:      chart.FittingLevel,
+			FittingLevelLower: chart.FittingLevelLower,
+			FittingLevelUpper: chart.FittingLevelUpper,
+			LevelDesign:       chart.LevelDesign,
+			Notes:             chart.Notes,
 		}
 		charts = append(charts, info)
 	}
@@ -269,10 +299,20 @@ func (s *SongService) CreateSong(ctx context.Context, req *request.CreateSongReq
 	return charts, nil
 }
 
-func (s *SongService) UpdateSong(ctx context.Context, req *request.UpdateSongRequest) ([]model.ChartInfo, error) {
+// songFromCreateRequest validates a create request and maps it to a model.Song.
+func songFromCreateRequest(req *request.CreateSongRequest) (*model.Song, error) {
+	return songFromInput(req.SongBase, req.Charts)
+}
+
+// songFromUpdateRequest validates an update request and maps it to a model.Song.
+func songFromUpdateRequest(req *request.UpdateSongRequest) (*model.Song, error) {
+	return songFromInput(req.SongBase, req.Charts)
+}
+
+func songFromInput(base model.SongBase, charts []model.ChartInput) (*model.Song, error) {
 	// Check for duplicate difficulties
 	seenDifficulties := make(map[model.Difficulty]bool)
-	for _, chartInput := range req.Charts {
+	for _, chartInput := range charts {
 		if seenDifficulties[chartInput.Difficulty] {
 			return nil, fmt.Errorf("duplicate chart difficulty: %s", chartInput.Difficulty)
 		}
@@ -281,11 +321,11 @@ func (s *SongService) UpdateSong(ctx context.Context, req *request.UpdateSongReq
 
 	// Map request to model.Song
 	song := &model.Song{
-		SongBase: req.SongBase,
+		SongBase: base,
 	}
 
 	// Map charts
-	for _, chartInput := range req.Charts {
+	for _, chartInput := range charts {
 		chart := model.Chart{
 			Difficulty:       chartInput.Difficulty,
 			Level:            chartInput.Level,
@@ -295,8 +335,18 @@ func (s *SongService) UpdateSong(ctx context.Context, req *request.UpdateSongReq
 		}
 		song.Charts = append(song.Charts, chart)
 	}
+	return song, nil
+}
+
+// UpdateSong applies req to the song, recording every change in the chart
+// history attributed to changedBy.
+func (s *SongService) UpdateSong(ctx context.Context, req *request.UpdateSongRequest, changedBy string) ([]model.ChartInfo, error) {
+	song, err := songFromUpdateRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
-	updatedSong, err := s.songRepo.UpdateSong(req.ID, song)
+	updatedSong, _, err := s.songRepo.UpdateSong(ctx, req.ID, song, changedBy)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("song %w", ErrNotFound)
@@ -306,21 +356,390 @@ func (s *SongService) UpdateSong(ctx context.Context, req *request.UpdateSongReq
 	}
 	slog.InfoContext(ctx, "song updated", "song_id", updatedSong.ID, "title", updatedSong.Title)
 
+	// Ratings and song metadata are embedded in cached record responses
+	s.invalidateSongRecords(ctx, updatedSong.ID)
+
 	// Convert to response format
 	var charts []model.ChartInfo
 	for _, chart := range updatedSong.Charts {
 		info := model.ChartInfo{
-			SongBase:     updatedSong.WithOverride(chart.SongBaseOverride),
-			SongID:       updatedSong.ID,
-			ID:           chart.ID,
-			Difficulty:   chart.Difficulty,
-			Level:        chart.Level,
-			FittingLevel: chart.FittingLevel,
-			LevelDesign:  chart.LevelDesign,
-			Notes:        chart.Notes,
+			SongBase:          updatedSong.WithOverride(chart.SongBaseOverride),
+			SongID:            updatedSong.ID,
+			ID:                chart.ID,
+			Difficulty:        chart.Difficulty,
+			Level:             chart.Level,
+			FittingLevel:      chart.FittingLevel,
+			FittingLevelLower: chart.FittingLevelLower,
+			FittingLevelUpper: chart.FittingLevelUpper,
+			LevelDesign:       chart.LevelDesign,
+			Notes:             chart.Notes,
 		}
 		charts = append(charts, info)
 	}
 
 	return charts, nil
 }
+
+// PreviewSongUpdate reports how req would change the B50 of every affected user
+// without committing anything.
+func (s *SongService) PreviewSongUpdate(ctx context.Context, req *request.UpdateSongRequest, changedBy string) (*model.RatingImpactReport, error) {
+	song, err := songFromUpdateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.songRepo.PreviewUpdateSong(ctx, req.ID, song, changedBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("song %w", ErrNotFound)
+		}
+		slog.ErrorContext(ctx, "failed to preview song update", "error", err, "song_id", req.ID)
+		return nil, err
+	}
+
+	report := &model.RatingImpactReport{
+		SongID:        req.ID,
+		Changes:       preview.Changes,
+		AffectedUsers: len(preview.Before),
+		Users:         []model.UserRatingImpact{},
+	}
+	if report.Changes == nil {
+		report.Changes = []model.ChartHistory{}
+	}
+	for username, before := range preview.Before {
+		after := preview.After[username]
+		impact := model.UserRatingImpact{
+			Username:   username,
+			OldB50Sum:  before.Sum,
+			NewB50Sum:  after.Sum,
+			Delta:      after.Sum - before.Sum,
+			B50Changed: !slices.Equal(before.ChartIDs, after.ChartIDs),
+		}
+		if impact.Delta == 0 && !impact.B50Changed {
+			continue
+		}
+		if impact.B50Changed {
+			report.B50ChangedUsers++
+		}
+		if impact.Delta != 0 {
+			report.RatingChangedUsers++
+		}
+		report.TotalDelta += impact.Delta
+		report.MaxGain = max(report.MaxGain, impact.Delta)
+		report.MaxLoss = min(report.MaxLoss, impact.Delta)
+		report.Users = append(report.Users, impact)
+	}
+	slices.SortFunc(report.Users, func(a, b model.UserRatingImpact) int {
+		if c := cmp.Compare(abs(b.Delta), abs(a.Delta)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Username, b.Username)
+	})
+
+	slog.InfoContext(ctx, "song update previewed",
+		"song_id", req.ID,
+		"changes", len(report.Changes),
+		"affected_users", report.AffectedUsers,
+		"rating_changed_users", report.RatingChangedUsers,
+	)
+	return report, nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// GetSongHistory returns the change history of a song and its charts, newest first.
+func (s *SongService) GetSongHistory(ctx context.Context, songID int) ([]model.ChartHistory, error) {
+	return s.songRepo.GetSongHistory(ctx, songID)
+}
+
+// Relative weight of each searchable song field. Titles and aliases are what
+// players usually type; artist/album hits are useful but should rank lower.
+var searchFieldWeights = map[string]float64{
+	"title":          1.0,
+	"alias":          1.0,
+	"override_title": 0.95,
+	"artist":         0.7,
+	"album":          0.6,
+}
+
+// SearchSongs ranks songs against query across title, per-chart override titles,
+// artist, album and aliases. Matching is script-insensitive: kana queries match
+// either kana form, romaji matches kana, and pinyin (or its initials) matches Han text.
+// At most limit results are returned, best first.
+func (s *SongService) SearchSongs(ctx context.Context, query string, limit int) ([]model.SongSearchResult, error) {
+	q := fuzzy.NewQuery(query)
+	if q.Empty() {
+		return []model.SongSearchResult{}, nil
+	}
+
+	index, err := s.songRepo.GetSearchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.SongSearchResult, 0)
+	for _, entry := range index {
+		best := model.SongSearchResult{SongBase: entry.Song, SongID: entry.SongID}
+		for _, f := range entry.Fields {
+			if score := q.ScoreKeys(f.Keys) * searchFieldWeights[f.Field]; score > best.Score {
+				best.Score = score
+				best.MatchedField = f.Field
+				best.MatchedText = f.Text
+			}
+		}
+		if best.Score > 0 {
+			results = append(results, best)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b model.SongSearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SongID, b.SongID)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	slog.DebugContext(ctx, "song search", "query", query, "results", len(results))
+	return results, nil
+}
+
+// GetSongAliases returns all aliases of a song.
+func (s *SongService) GetSongAliases(ctx context.Context, songID int) ([]model.SongAlias, error) {
+	return s.songRepo.GetAliasesBySongID(ctx, songID)
+}
+
+// CreateSongAlias adds a community alias to a song. Aliases must be unique
+// (after normalisation) across all songs so that they resolve unambiguously.
+func (s *SongService) CreateSongAlias(ctx context.Context, songID int, alias string) (*model.SongAlias, error) {
+	normalized := fuzzy.Normalize(alias)
+	if normalized == "" {
+		return nil, errors.New("alias must contain at least one letter or digit")
+	}
+
+	song, err := s.songRepo.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if song == nil {
+		return nil, fmt.Errorf("song %w", ErrNotFound)
+	}
+
+	existing, err := s.songRepo.GetAliasByNormalized(ctx, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("alias already assigned to song %d: %w", existing.SongID, ErrConflict)
+	}
+
+	created, err := s.songRepo.CreateAlias(ctx, &model.SongAlias{
+		SongID:     songID,
+		Alias:      strings.TrimSpace(alias),
+		Normalized: normalized,
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// Added by a concurrent request since the check above
+		return nil, fmt.Errorf("alias already assigned: %w", ErrConflict)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create song alias", "error", err, "song_id", songID, "alias", alias)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "song alias created", "song_id", songID, "alias_id", created.ID, "alias", created.Alias)
+	return created, nil
+}
+
+// DeleteSongAlias removes an alias from a song.
+func (s *SongService) DeleteSongAlias(ctx context.Context, songID, aliasID int) error {
+	deleted, err := s.songRepo.DeleteAlias(ctx, songID, aliasID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete song alias", "error", err, "song_id", songID, "alias_id", aliasID)
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("alias %w", ErrNotFound)
+	}
+	slog.InfoContext(ctx, "song alias deleted", "song_id", songID, "alias_id", aliasID)
+	return nil
+}
+
+// invalidateSongRecords drops cached records of every player of the song.
+// Failures are logged only: the cache entries expire on their own.
+func (s *SongService) invalidateSongRecords(ctx context.Context, songID int) {
+	if err := s.recordRepo.InvalidateSongRecords(ctx, songID); err != nil {
+		slog.ErrorContext(ctx, "failed to invalidate record caches", "error", err, "song_id", songID)
+	}
+}
+
+// RetireSong retires (soft-deletes) a song. Its charts disappear from the catalog
+// and stop counting towards B50, but existing records remain in players' history.
+func (s *SongService) RetireSong(ctx context.Context, songID int, changedBy string) error {
+	if err := s.songRepo.RetireSong(ctx, songID, changedBy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("song %w", ErrNotFound)
+		}
+		slog.ErrorContext(ctx, "failed to retire song", "error", err, "song_id", songID)
+		return err
+	}
+	slog.InfoContext(ctx, "song retired", "song_id", songID)
+	s.invalidateSongRecords(ctx, songID)
+	return nil
+}
+
+// RestoreSong restores a retired song addressed by numeric ID or wiki_id.
+func (s *SongService) RestoreSong(ctx context.Context, songAddr string, changedBy string) (int, error) {
+	var song *model.Song
+	var err error
+	if id, convErr := strconv.Atoi(songAddr); convErr == nil {
+		song, err = s.songRepo.GetRetiredSongByID(ctx, id)
+	} else {
+		song, err = s.songRepo.GetRetiredSongByWikiID(ctx, songAddr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if song == nil {
+		return 0, fmt.Errorf("retired song %w", ErrNotFound)
+	}
+
+	if err := s.songRepo.RestoreSong(ctx, song.ID, changedBy); err != nil {
+		slog.ErrorContext(ctx, "failed to restore song", "error", err, "song_id", song.ID)
+		return 0, err
+	}
+	slog.InfoContext(ctx, "song restored", "song_id", song.ID)
+	s.invalidateSongRecords(ctx, song.ID)
+	return song.ID, nil
+}
+
+// RetireChart retires (soft-deletes) a single chart.
+func (s *SongService) RetireChart(ctx context.Context, chartID int, changedBy string) error {
+	chart, err := s.songRepo.GetChartByID(ctx, chartID)
+	if err != nil {
+		return err
+	}
+	if chart == nil {
+		return fmt.Errorf("chart %w", ErrNotFound)
+	}
+
+	if err := s.songRepo.RetireChart(ctx, chartID, changedBy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("chart %w", ErrNotFound)
+		}
+		slog.ErrorContext(ctx, "failed to retire chart", "error", err, "chart_id", chartID)
+		return err
+	}
+	slog.InfoContext(ctx, "chart retired", "chart_id", chartID, "song_id", chart.SongID)
+	s.invalidateSongRecords(ctx, chart.SongID)
+	return nil
+}
+
+// RestoreChart restores a retired chart addressed by numeric ID or
+// "wiki_id:difficulty" (the most recently retired one). It fails with
+// ErrConflict if the song already has a live chart of that difficulty.
+func (s *SongService) RestoreChart(ctx context.Context, chartAddr string, changedBy string) (int, error) {
+	var chart *model.Chart
+	var err error
+	if id, convErr := strconv.Atoi(chartAddr); convErr == nil {
+		chart, err = s.songRepo.GetRetiredChartByID(ctx, id)
+	} else {
+		lastColon := strings.LastIndex(chartAddr, ":")
+		if lastColon <= 0 || !model.ValidDifficulty(chartAddr[lastColon+1:]) {
+			return 0, errors.New("invalid chart address format, expected chart ID or wiki_id:difficulty")
+		}
+		chart, err = s.songRepo.GetRetiredChartByWikiIDAndDifficulty(ctx, chartAddr[:lastColon], model.Difficulty(chartAddr[lastColon+1:]))
+	}
+	if err != nil {
+		return 0, err
+	}
+	if chart == nil {
+		return 0, fmt.Errorf("retired chart %w", ErrNotFound)
+	}
+
+	// Refuse to restore over a live chart of the same difficulty
+	// (the partial unique index would reject it anyway).
+	if song, err := s.songRepo.GetSongByID(ctx, chart.SongID); err != nil {
+		return 0, err
+	} else if song != nil {
+		for _, c := range song.Charts {
+			if c.Difficulty == chart.Difficulty {
+				return 0, fmt.Errorf("song already has a live %s chart (id %d): %w", c.Difficulty, c.ID, ErrConflict)
+			}
+		}
+	}
+
+	if err := s.songRepo.RestoreChart(ctx, chart.ID, changedBy); err != nil {
+		slog.ErrorContext(ctx, "failed to restore chart", "error", err, "chart_id", chart.ID)
+		return 0, err
+	}
+	slog.InfoContext(ctx, "chart restored", "chart_id", chart.ID, "song_id", chart.SongID)
+	s.invalidateSongRecords(ctx, chart.SongID)
+	return chart.ID, nil
+}
+
+// ChartETag returns the entity tag of a chart: its updated_at in RFC 3339, so
+// that the updated_at field of any chart response can be sent as If-Match.
+func ChartETag(chart *model.Chart) string {
+	return `"` + chart.UpdatedAt.UTC().Format(time.RFC3339Nano) + `"`
+}
+
+// parseChartIfMatch turns an If-Match header into the updated_at the chart
+// must still have. An empty header or "*" imposes no condition.
+func parseChartIfMatch(ifMatch string) (*time.Time, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return nil, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	t, err := time.Parse(time.RFC3339Nano, tag)
+	if err != nil {
+		return nil, fmt.Errorf("If-Match %q is not a chart updated_at: %w", ifMatch, ErrPreconditionFailed)
+	}
+	return &t, nil
+}
+
+// PatchChart changes only the fields set in patch. ifMatch is the request's
+// If-Match header; when set, the write is refused with ErrPreconditionFailed
+// if the chart was changed since that version. Returns the updated chart row
+// and its ETag.
+func (s *SongService) PatchChart(ctx context.Context, chartID int, patch model.ChartPatch, ifMatch, changedBy string) (*model.ChartInfo, string, error) {
+	if patch.IsEmpty() {
+		return nil, "", errors.New("no chart fields to update")
+	}
+	ifUnmodifiedAt, err := parseChartIfMatch(ifMatch)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chart, changes, err := s.songRepo.PatchChart(ctx, chartID, patch, ifUnmodifiedAt, changedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, "", fmt.Errorf("chart %w", ErrNotFound)
+		case errors.Is(err, repository.ErrChartModified):
+			return nil, "", fmt.Errorf("chart was modified since it was read: %w", ErrPreconditionFailed)
+		}
+		slog.ErrorContext(ctx, "failed to patch chart", "error", err, "chart_id", chartID)
+		return nil, "", err
+	}
+	if len(changes) > 0 {
+		slog.InfoContext(ctx, "chart patched", "chart_id", chartID, "song_id", chart.SongID, "changes", len(changes))
+		s.invalidateSongRecords(ctx, chart.SongID)
+	}
+
+	song, err := s.songRepo.GetSongByID(ctx, chart.SongID)
+	if err != nil {
+		return nil, "", err
+	}
+	if song == nil {
+		return nil, "", fmt.Errorf("song %w", ErrNotFound)
+	}
+	info := toChartInfo(song, chart)
+	return &info, ChartETag(chart), nil
+}
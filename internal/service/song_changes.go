@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"paradigm-reboot-prober-go/internal/model"
+	"strconv"
+	"time"
+)
+
+// SongChangesOverlap widens every change query backwards from the cursor, so
+// that rows written by transactions still in flight when the previous cursor
+// was issued are not missed. Rows may therefore repeat across consecutive
+// responses; clients apply them idempotently by chart ID.
+const SongChangesOverlap = 5 * time.Second
+
+// ErrInvalidCursor is returned for a since cursor that was not issued by GetSongChanges.
+var ErrInvalidCursor = errors.New("invalid since cursor")
+
+// encodeSongChangesCursor and decodeSongChangesCursor convert between a time
+// and the opaque cursor handed to clients (microseconds since the Unix epoch,
+// the precision PostgreSQL stores).
+func encodeSongChangesCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 10)
+}
+
+func decodeSongChangesCursor(cursor string) (time.Time, error) {
+	us, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || us < 0 {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.UnixMicro(us), nil
+}
+
+// GetSongChanges returns the catalog rows created, updated or retired since
+// cursor, together with the cursor for the next call. An empty cursor returns
+// every live row as created, which bootstraps a local catalog.
+func (s *SongService) GetSongChanges(ctx context.Context, cursor string) (*model.SongChanges, error) {
+	// Truncate to the cursor precision so that no row falls between two cursors
+	until := time.Now().Truncate(time.Microsecond)
+	var since time.Time
+	if cursor != "" {
+		t, err := decodeSongChangesCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		since = t.Add(-SongChangesOverlap)
+	}
+
+	songs, err := s.songRepo.GetSongsChangedBetween(ctx, since, until)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get changed songs", "error", err)
+		return nil, err
+	}
+	changes := &model.SongChanges{
+		Cursor:          encodeSongChangesCursor(until),
+		Created:         []model.ChartInfo{},
+		Updated:         []model.ChartInfo{},
+		RetiredSongIDs:  []int{},
+		RetiredChartIDs: []int{},
+	}
+	for i := range songs {
+		song := &songs[i]
+		// A song change (metadata, b15, restore) changes every row of the song
+		songChanged := song.UpdatedAt.After(since)
+		for j := range song.Charts {
+			chart := &song.Charts[j]
+			switch {
+			case chart.CreatedAt.After(since):
+				changes.Created = append(changes.Created, toChartInfo(song, chart))
+			case songChanged || chart.UpdatedAt.After(since):
+				changes.Updated = append(changes.Updated, toChartInfo(song, chart))
+			}
+		}
+	}
+	sortChartInfos(changes.Created)
+	sortChartInfos(changes.Updated)
+
+	if cursor != "" {
+		changes.RetiredSongIDs, changes.RetiredChartIDs, err = s.songRepo.GetRetiredBetween(ctx, since, until)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get retired songs", "error", err)
+			return nil, err
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongService_GetSongChanges(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	ctx := context.Background()
+
+	var songs []*model.Song
+	for _, wikiID := range []string{"steady", "edited", "retired"} {
+		song, err := songRepo.CreateSong(ctx, &model.Song{
+			SongBase: model.SongBase{WikiID: wikiID, Title: wikiID, Version: "1.0"},
+			Charts: []model.Chart{
+				{Difficulty: model.DifficultyInvaded, Level: 12.0},
+				{Difficulty: model.DifficultyMassive, Level: 14.0},
+			},
+		})
+		require.NoError(t, err)
+		songs = append(songs, song)
+	}
+	// Move the seeded catalog out of the cursor overlap window
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Exec("UPDATE songs SET created_at = ?, updated_at = ?", past, past).Error)
+	require.NoError(t, db.Exec("UPDATE charts SET created_at = ?, updated_at = ?", past, past).Error)
+	chartIDs := func(rows []model.ChartInfo) []int {
+		ids := []int{}
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+
+	t.Run("Without a cursor the whole catalog is created", func(t *testing.T) {
+		changes, err := songService.GetSongChanges(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, changes.Created, 6)
+		assert.Empty(t, changes.Updated)
+		assert.Empty(t, changes.RetiredSongIDs)
+		assert.NotEmpty(t, changes.Cursor)
+	})
+
+	t.Run("Nothing changed", func(t *testing.T) {
+		changes, err := songService.GetSongChanges(ctx, encodeSongChangesCursor(time.Now()))
+		require.NoError(t, err)
+		assert.Empty(t, changes.Created)
+		assert.Empty(t, changes.Updated)
+		assert.Empty(t, changes.RetiredSongIDs)
+		assert.Empty(t, changes.RetiredChartIDs)
+	})
+
+	t.Run("Created, updated and retired", func(t *testing.T) {
+		changes, err := songService.GetSongChanges(ctx, "")
+		require.NoError(t, err)
+		cursor := changes.Cursor
+
+		// Fitting touches a single chart of "steady"
+		require.NoError(t, db.Model(&model.Chart{}).Where("id = ?", songs[0].Charts[1].ID).
+			Update("fitting_level", 14.2).Error)
+		// A song edit changes every row of "edited"
+		require.NoError(t, db.Model(&model.Song{}).Where("id = ?", songs[1].ID).Update("artist", "New").Error)
+		require.NoError(t, songRepo.RetireSong(ctx, songs[2].ID, "admin"))
+		added, err := songRepo.CreateSong(ctx, &model.Song{
+			SongBase: model.SongBase{WikiID: "added", Title: "Added", Version: "2.0"},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0}},
+		})
+		require.NoError(t, err)
+
+		changes, err = songService.GetSongChanges(ctx, cursor)
+		require.NoError(t, err)
+		assert.Equal(t, []int{added.Charts[0].ID}, chartIDs(changes.Created))
+		assert.ElementsMatch(t, []int{songs[0].Charts[1].ID, songs[1].Charts[0].ID, songs[1].Charts[1].ID},
+			chartIDs(changes.Updated))
+		for _, row := range changes.Updated {
+			if row.ID == songs[0].Charts[1].ID {
+				require.NotNil(t, row.FittingLevel)
+				assert.Equal(t, 14.2, *row.FittingLevel)
+			}
+		}
+		assert.Equal(t, []int{songs[2].ID}, changes.RetiredSongIDs)
+		assert.Equal(t, []int{songs[2].Charts[0].ID, songs[2].Charts[1].ID}, changes.RetiredChartIDs)
+
+		// A restored song comes back as updated
+		require.NoError(t, songRepo.RestoreSong(ctx, songs[2].ID, "admin"))
+		changes, err = songService.GetSongChanges(ctx, cursor)
+		require.NoError(t, err)
+		assert.Empty(t, changes.RetiredSongIDs)
+		assert.Contains(t, chartIDs(changes.Updated), songs[2].Charts[0].ID)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := songService.GetSongChanges(ctx, "yesterday")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
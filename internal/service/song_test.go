@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"paradigm-reboot-prober-go/config"
 	"paradigm-reboot-prober-go/internal/model"
 	"paradigm-reboot-prober-go/internal/model/request"
 	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/pkg/rating"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +18,7 @@ import (
 func TestSongService(t *testing.T) {
 	db := setupTestDB(t)
 	songRepo := repository.NewSongRepository(db)
-	songService := NewSongService(songRepo)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
 	ctx := context.Background()
 
 	t.Run("CreateSong", func(t *testing.T) {
@@ -78,7 +81,7 @@ func TestSongService(t *testing.T) {
 				},
 			},
 		}
-		charts, err := songService.UpdateSong(ctx, req)
+		charts, err := songService.UpdateSong(ctx, req, "admin")
 		assert.NoError(t, err)
 		assert.Len(t, charts, 1)
 		assert.Equal(t, "Updated Song", charts[0].Title)
@@ -89,7 +92,7 @@ func TestSongService(t *testing.T) {
 func TestSongService_ResolveSongID(t *testing.T) {
 	db := setupTestDB(t)
 	songRepo := repository.NewSongRepository(db)
-	songService := NewSongService(songRepo)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
 	ctx := context.Background()
 
 	// Create a song
@@ -136,7 +139,7 @@ func TestSongService_ResolveSongID(t *testing.T) {
 func TestSongService_ResolveChartID(t *testing.T) {
 	db := setupTestDB(t)
 	songRepo := repository.NewSongRepository(db)
-	songService := NewSongService(songRepo)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
 	ctx := context.Background()
 
 	// Create a song with multiple charts
@@ -209,7 +212,7 @@ func ptr(s string) *string { return &s }
 func TestSongService_ChartOverride(t *testing.T) {
 	db := setupTestDB(t)
 	songRepo := repository.NewSongRepository(db)
-	songService := NewSongService(songRepo)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
 	ctx := context.Background()
 
 	t.Run("CreateSong with override fields", func(t *testing.T) {
@@ -308,7 +311,7 @@ func TestSongService_ChartOverride(t *testing.T) {
 				},
 			},
 		}
-		charts, err := songService.UpdateSong(ctx, req)
+		charts, err := songService.UpdateSong(ctx, req, "admin")
 		assert.NoError(t, err)
 
 		var rebootChart model.ChartInfo
@@ -351,7 +354,7 @@ func TestCompareVersion(t *testing.T) {
 func TestGetAllCharts_DefaultSortOrder(t *testing.T) {
 	db := setupTestDB(t)
 	songRepo := repository.NewSongRepository(db)
-	songService := NewSongService(songRepo)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
 	ctx := context.Background()
 
 	// Create songs in deliberately wrong order with mixed difficulties.
@@ -425,3 +428,426 @@ func TestGetAllCharts_DefaultSortOrder(t *testing.T) {
 		assert.Equal(t, e.difficulty, charts[i].Difficulty, "index %d difficulty", i)
 	}
 }
+
+func TestSongService_Aliases(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
+	ctx := context.Background()
+
+	charts, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "alias_song", Title: "Alias Song", Artist: "Artist"},
+		Charts: []model.ChartInput{
+			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
+		},
+	})
+	require.NoError(t, err)
+	songID := charts[0].SongID
+	chartID := charts[0].ID
+
+	_, err = songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "other_song", Title: "Other Song", Artist: "Artist"},
+		Charts: []model.ChartInput{
+			{Difficulty: model.DifficultyMassive, Level: 12.0, Notes: 800},
+		},
+	})
+	require.NoError(t, err)
+
+	var aliasID int
+	t.Run("CreateSongAlias", func(t *testing.T) {
+		alias, err := songService.CreateSongAlias(ctx, songID, " Nick Name ")
+		require.NoError(t, err)
+		assert.Equal(t, "Nick Name", alias.Alias)
+		assert.Equal(t, "nickname", alias.Normalized)
+		aliasID = alias.ID
+	})
+
+	t.Run("CreateSongAlias conflict after normalisation", func(t *testing.T) {
+		_, err := songService.CreateSongAlias(ctx, songID+1, "ＮＩＣＫＮＡＭＥ")
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("CreateSongAlias unknown song", func(t *testing.T) {
+		_, err := songService.CreateSongAlias(ctx, 99999, "ghost")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("CreateSongAlias without letters", func(t *testing.T) {
+		_, err := songService.CreateSongAlias(ctx, songID, "!!!")
+		assert.Error(t, err)
+	})
+
+	t.Run("ResolveSongID by alias", func(t *testing.T) {
+		id, err := songService.ResolveSongID(ctx, "nick name")
+		assert.NoError(t, err)
+		assert.Equal(t, songID, id)
+	})
+
+	t.Run("ResolveChartID by alias:difficulty", func(t *testing.T) {
+		id, err := songService.ResolveChartID(ctx, "NickName:massive")
+		assert.NoError(t, err)
+		assert.Equal(t, chartID, id)
+
+		_, err = songService.ResolveChartID(ctx, "nickname:reboot")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetSongAliases", func(t *testing.T) {
+		aliases, err := songService.GetSongAliases(ctx, songID)
+		assert.NoError(t, err)
+		assert.Len(t, aliases, 1)
+	})
+
+	t.Run("DeleteSongAlias", func(t *testing.T) {
+		assert.ErrorIs(t, songService.DeleteSongAlias(ctx, songID+1, aliasID), ErrNotFound)
+		assert.NoError(t, songService.DeleteSongAlias(ctx, songID, aliasID))
+		assert.ErrorIs(t, songService.DeleteSongAlias(ctx, songID, aliasID), ErrNotFound)
+
+		_, err := songService.ResolveSongID(ctx, "nickname")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// The alias can be reused once deleted
+		_, err = songService.CreateSongAlias(ctx, songID+1, "nickname")
+		assert.NoError(t, err)
+	})
+}
+
+func TestSongService_SearchSongs(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	songService := NewSongService(songRepo, repository.NewRecordRepository(db), nil)
+	ctx := context.Background()
+
+	create := func(wikiID, title, artist, album string, override *string) int {
+		charts, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+			SongBase: model.SongBase{WikiID: wikiID, Title: title, Artist: artist, Album: album},
+			Charts: []model.ChartInput{{
+				Difficulty:       model.DifficultyMassive,
+				Level:            13.0,
+				Notes:            900,
+				SongBaseOverride: model.SongBaseOverride{OverrideTitle: override},
+			}},
+		})
+		require.NoError(t, err)
+		return charts[0].SongID
+	}
+	nightID := create("night", "Night", "Alpha", "First Album", nil)
+	nightmareID := create("nightmare", "Nightmare", "Beta", "Second Album", nil)
+	sakuraID := create("sakura", "サクラ", "Gamma", "", nil)
+	chineseID := create("zhongwen", "中文歌曲", "Delta", "", nil)
+	overrideID := create("override", "Base Title", "Epsilon", "", ptr("Secret Name"))
+	_, err := songService.CreateSongAlias(ctx, chineseID, "ZWGQ")
+	require.NoError(t, err)
+
+	firstID := func(t *testing.T, q string) int {
+		results, err := songService.SearchSongs(ctx, q, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, results, "query %q", q)
+		return results[0].SongID
+	}
+
+	t.Run("Exact title beats prefix", func(t *testing.T) {
+		results, err := songService.SearchSongs(ctx, "night", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, nightID, results[0].SongID)
+		assert.Equal(t, nightmareID, results[1].SongID)
+		assert.Equal(t, "title", results[0].MatchedField)
+		assert.Greater(t, results[0].Score, results[1].Score)
+	})
+
+	t.Run("Romaji matches katakana", func(t *testing.T) {
+		assert.Equal(t, sakuraID, firstID(t, "sakura"))
+		assert.Equal(t, sakuraID, firstID(t, "さくら"))
+	})
+
+	t.Run("Pinyin matches Chinese", func(t *testing.T) {
+		assert.Equal(t, chineseID, firstID(t, "zhongwen"))
+	})
+
+	t.Run("Alias match", func(t *testing.T) {
+		results, err := songService.SearchSongs(ctx, "zwgq", 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, results)
+		assert.Equal(t, chineseID, results[0].SongID)
+		assert.Equal(t, "alias", results[0].MatchedField)
+		assert.Equal(t, "ZWGQ", results[0].MatchedText)
+	})
+
+	t.Run("Override title match", func(t *testing.T) {
+		assert.Equal(t, overrideID, firstID(t, "secret"))
+	})
+
+	t.Run("Artist and album match", func(t *testing.T) {
+		assert.Equal(t, nightmareID, firstID(t, "beta"))
+		assert.Equal(t, nightmareID, firstID(t, "second album"))
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		results, err := songService.SearchSongs(ctx, "night", 1)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("No match and empty query", func(t *testing.T) {
+		results, err := songService.SearchSongs(ctx, "zzzzqqqq", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		results, err = songService.SearchSongs(ctx, "   ", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestSongService_UpdateSongPreviewAndHistory(t *testing.T) {
+	db := setupTestDB(t)
+	config.GlobalConfig.Game.B35Limit = 1 // small B50 so composition changes are easy to trigger
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	target, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "rerate", Title: "Rerate"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
+	})
+	require.NoError(t, err)
+	other, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "other", Title: "Other"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 15.2, Notes: 1000}},
+	})
+	require.NoError(t, err)
+	targetChart, otherChart := target[0].ID, other[0].ID
+
+	// alice: B50 (size 1) currently holds the other chart; the rerate will swap it in.
+	_, err = recordService.CreateRecords(ctx, "alice", []model.PlayRecordBase{
+		{ChartID: targetChart, Score: intPtr(1000000)},
+		{ChartID: otherChart, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+	// bob: only plays the rerated chart.
+	_, err = recordService.CreateRecords(ctx, "bob", []model.PlayRecordBase{
+		{ChartID: targetChart, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+	// carol: unaffected.
+	_, err = recordService.CreateRecords(ctx, "carol", []model.PlayRecordBase{
+		{ChartID: otherChart, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+
+	req := &request.UpdateSongRequest{
+		ID:       target[0].SongID,
+		SongBase: model.SongBase{WikiID: "rerate", Title: "Rerate"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 16.0, Notes: 1000}},
+	}
+	oldRating := rating.SingleRating(15.0, 1000000)
+	newRating := rating.SingleRating(16.0, 1000000)
+	otherRating := rating.SingleRating(15.2, 1000000)
+
+	t.Run("Preview", func(t *testing.T) {
+		report, err := songService.PreviewSongUpdate(ctx, req, "admin")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, report.AffectedUsers)
+		assert.Equal(t, 2, report.RatingChangedUsers)
+		assert.Equal(t, 1, report.B50ChangedUsers)
+		require.Len(t, report.Changes, 1)
+		assert.Equal(t, model.ChartHistoryFieldLevel, report.Changes[0].Field)
+
+		require.Len(t, report.Users, 2)
+		// bob gains more (15.0 → 16.0) than alice (15.2 → 16.0), so he is listed first
+		assert.Equal(t, "bob", report.Users[0].Username)
+		assert.Equal(t, newRating-oldRating, report.Users[0].Delta)
+		assert.False(t, report.Users[0].B50Changed)
+		assert.Equal(t, "alice", report.Users[1].Username)
+		assert.Equal(t, otherRating, report.Users[1].OldB50Sum)
+		assert.Equal(t, newRating, report.Users[1].NewB50Sum)
+		assert.True(t, report.Users[1].B50Changed)
+
+		assert.Equal(t, newRating-oldRating, report.MaxGain)
+		assert.Equal(t, 0, report.MaxLoss)
+		assert.Equal(t, (newRating-oldRating)+(newRating-otherRating), report.TotalDelta)
+
+		// Nothing committed
+		history, err := songService.GetSongHistory(ctx, req.ID)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("Preview unknown song", func(t *testing.T) {
+		bad := *req
+		bad.ID = 99999
+		_, err := songService.PreviewSongUpdate(ctx, &bad, "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Update records history and refreshes cached records", func(t *testing.T) {
+		// Prime bob's B50 cache
+		before, err := recordService.GetBest50Records(ctx, "bob", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, before, 1)
+		assert.Equal(t, oldRating, before[0].Rating)
+
+		_, err = songService.UpdateSong(ctx, req, "admin")
+		require.NoError(t, err)
+
+		after, err := recordService.GetBest50Records(ctx, "bob", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		assert.Equal(t, newRating, after[0].Rating)
+
+		history, err := songService.GetSongHistory(ctx, req.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "admin", history[0].ChangedBy)
+		assert.Equal(t, "15", *history[0].OldValue)
+		assert.Equal(t, "16", *history[0].NewValue)
+	})
+}
+
+func TestSongService_RetireAndRestore(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	charts, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "retire", Title: "Retire"},
+		Charts: []model.ChartInput{
+			{Difficulty: model.DifficultyInvaded, Level: 13.0, Notes: 800},
+			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000},
+		},
+	})
+	require.NoError(t, err)
+	songID, invadedID, massiveID := charts[0].SongID, charts[0].ID, charts[1].ID
+	_, err = recordService.CreateRecords(ctx, "alice", []model.PlayRecordBase{
+		{ChartID: invadedID, Score: intPtr(1000000)},
+		{ChartID: massiveID, Score: intPtr(1000000)},
+	}, false)
+	require.NoError(t, err)
+
+	t.Run("Retire chart refreshes cached B50", func(t *testing.T) {
+		before, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, before, 2)
+
+		require.NoError(t, songService.RetireChart(ctx, massiveID, "admin"))
+
+		after, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		assert.Equal(t, invadedID, after[0].ChartID)
+
+		assert.ErrorIs(t, songService.RetireChart(ctx, massiveID, "admin"), ErrNotFound)
+	})
+
+	t.Run("Restore chart by wiki_id:difficulty", func(t *testing.T) {
+		id, err := songService.RestoreChart(ctx, "retire:massive", "admin")
+		require.NoError(t, err)
+		assert.Equal(t, massiveID, id)
+
+		after, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		assert.Len(t, after, 2)
+
+		_, err = songService.RestoreChart(ctx, "retire:massive", "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = songService.RestoreChart(ctx, "retire", "admin")
+		assert.Error(t, err)
+	})
+
+	t.Run("Restore chart conflicts with a live chart of the same difficulty", func(t *testing.T) {
+		require.NoError(t, songService.RetireChart(ctx, massiveID, "admin"))
+		_, err := songService.UpdateSong(ctx, &request.UpdateSongRequest{
+			ID:       songID,
+			SongBase: model.SongBase{WikiID: "retire", Title: "Retire"},
+			Charts: []model.ChartInput{
+				{Difficulty: model.DifficultyInvaded, Level: 13.0, Notes: 800},
+				{Difficulty: model.DifficultyMassive, Level: 15.5, Notes: 1100},
+			},
+		}, "admin")
+		require.NoError(t, err)
+
+		_, err = songService.RestoreChart(ctx, strconv.Itoa(massiveID), "admin")
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("Retire and restore song", func(t *testing.T) {
+		require.NoError(t, songService.RetireSong(ctx, songID, "admin"))
+		_, err := songService.ResolveSongID(ctx, "retire")
+		assert.Error(t, err)
+		b50, err := recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, b50)
+
+		id, err := songService.RestoreSong(ctx, "retire", "admin")
+		require.NoError(t, err)
+		assert.Equal(t, songID, id)
+		b50, err = recordService.GetBest50Records(ctx, "alice", 0, model.RecordFilter{})
+		require.NoError(t, err)
+		assert.Len(t, b50, 1) // alice has no record on the replacement massive chart
+
+		_, err = songService.RestoreSong(ctx, strconv.Itoa(songID), "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, songService.RetireSong(ctx, 99999, "admin"), ErrNotFound)
+	})
+}
+
+func TestSongService_PatchChart(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, nil)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "patched", Title: "Patched"},
+		Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000}},
+	})
+	require.NoError(t, err)
+	chartID := song.Charts[0].ID
+	_, err = recordService.CreateRecords(ctx, "carol", []model.PlayRecordBase{{ChartID: chartID, Score: intPtr(1005000)}}, false)
+	require.NoError(t, err)
+
+	// Warm the B50 cache
+	b50, err := recordService.GetBest50Records(ctx, "carol", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	require.Len(t, b50, 1)
+
+	info, etag, err := songService.PatchChart(ctx, chartID, model.ChartPatch{Level: float64Ptr(15.5)}, "", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, 15.5, info.Level)
+	assert.Equal(t, "Patched", info.Title)
+	assert.NotEmpty(t, etag)
+
+	b50, err = recordService.GetBest50Records(ctx, "carol", 0, model.RecordFilter{})
+	require.NoError(t, err)
+	require.Len(t, b50, 1)
+	assert.Equal(t, rating.SingleRating(15.5, 1005000), b50[0].Rating, "cached B50 is refreshed")
+
+	t.Run("If-Match", func(t *testing.T) {
+		_, next, err := songService.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1001)}, etag, "admin")
+		require.NoError(t, err)
+		assert.NotEqual(t, etag, next)
+
+		_, _, err = songService.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1002)}, etag, "admin")
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+		_, _, err = songService.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1002)}, `"garbage"`, "admin")
+		assert.ErrorIs(t, err, ErrPreconditionFailed)
+		_, _, err = songService.PatchChart(ctx, chartID, model.ChartPatch{Notes: intPtr(1002)}, "*", "admin")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, _, err := songService.PatchChart(ctx, chartID, model.ChartPatch{}, "", "admin")
+		assert.Error(t, err)
+		_, _, err = songService.PatchChart(ctx, 99999, model.ChartPatch{Notes: intPtr(1)}, "", "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"slices"
+	"sort"
+)
+
+var (
+	// scorePercentiles are the percentiles reported in a score distribution.
+	scorePercentiles = []int{10, 25, 50, 75, 90, 99}
+	// scoreHistogramBounds are the lower bounds of the histogram buckets,
+	// following the score bands of the rating formula.
+	scoreHistogramBounds = []int{0, 900000, 950000, 980000, 1000000, 1005000, 1009000}
+)
+
+type StatsService struct {
+	statsRepo  *repository.StatsRepository
+	songRepo   *repository.SongRepository
+	recordRepo *repository.RecordRepository
+}
+
+func NewStatsService(statsRepo *repository.StatsRepository, songRepo *repository.SongRepository, recordRepo *repository.RecordRepository) *StatsService {
+	return &StatsService{
+		statsRepo:  statsRepo,
+		songRepo:   songRepo,
+		recordRepo: recordRepo,
+	}
+}
+
+// buildScoreDistribution summarises the ascending, non-empty best scores of a
+// chart.
+func buildScoreDistribution(scores []int, passScore int) *model.ScoreDistribution {
+	n := len(scores)
+	passCount := n - sort.SearchInts(scores, passScore)
+	dist := &model.ScoreDistribution{
+		PlayerCount: n,
+		PassCount:   passCount,
+		PassRate:    float64(passCount) / float64(n),
+		Percentiles: make([]model.ScorePercentile, 0, len(scorePercentiles)),
+		Histogram:   make([]model.ScoreBucket, 0, len(scoreHistogramBounds)),
+	}
+	for _, p := range scorePercentiles {
+		// Nearest-rank method
+		idx := int(math.Ceil(float64(p)/100*float64(n))) - 1
+		dist.Percentiles = append(dist.Percentiles, model.ScorePercentile{Percentile: p, Score: scores[max(idx, 0)]})
+	}
+	for i, bound := range scoreHistogramBounds {
+		end := n
+		if i+1 < len(scoreHistogramBounds) {
+			end = sort.SearchInts(scores, scoreHistogramBounds[i+1])
+		}
+		dist.Histogram = append(dist.Histogram, model.ScoreBucket{
+			MinScore: bound,
+			Count:    end - sort.SearchInts(scores, bound),
+		})
+	}
+	return dist
+}
+
+// rankScore places score among the ascending, non-empty best scores of a
+// chart. The percentile is rounded to one decimal.
+func rankScore(scores []int, score int) *model.ChartRank {
+	atOrBelow := sort.SearchInts(scores, score+1)
+	return &model.ChartRank{
+		Score:      score,
+		Rank:       len(scores) - atOrBelow + 1,
+		Percentile: math.Round(float64(atOrBelow)/float64(len(scores))*1000) / 10,
+	}
+}
+
+// GetChartStats returns the public statistics of a chart: its fitting output
+// and, when enough players have a record on it, the distribution of their best
+// scores. When username is set and the user has a best record on the chart,
+// their rank is included as well.
+func (s *StatsService) GetChartStats(ctx context.Context, chartID int, username string) (*model.ChartStats, error) {
+	chart, err := s.songRepo.GetChartByID(ctx, chartID)
+	if err != nil {
+		return nil, err
+	}
+	if chart == nil {
+		return nil, fmt.Errorf("chart %w", ErrNotFound)
+	}
+	stat, err := s.statsRepo.GetChartStatistic(ctx, chartID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get chart statistic", "error", err, "chart_id", chartID)
+		return nil, err
+	}
+	scores, err := s.statsRepo.GetChartScores(ctx, chartID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get chart scores", "error", err, "chart_id", chartID)
+		return nil, err
+	}
+
+	result := &model.ChartStats{
+		ChartID:           chart.ID,
+		Level:             chart.Level,
+		FittingLevel:      chart.FittingLevel,
+		FittingLevelLower: chart.FittingLevelLower,
+		FittingLevelUpper: chart.FittingLevelUpper,
+		Fitting:           stat,
+	}
+	if len(scores) < config.GlobalConfig.Stats.MinPlayers {
+		return result, nil
+	}
+	result.Distribution = buildScoreDistribution(scores, config.GlobalConfig.Stats.PassScore)
+
+	if username != "" {
+		best, err := s.recordRepo.GetBestRecordByChart(ctx, username, chartID)
+		if err != nil {
+			return nil, err
+		}
+		// The score list is cached and may predate the caller's current best
+		// score; add it so the rank reflects what the caller sees elsewhere.
+		if best != nil && best.Score != nil {
+			if i, found := slices.BinarySearch(scores, *best.Score); !found {
+				scores = slices.Insert(scores, i, *best.Score)
+			}
+			result.MyRank = rankScore(scores, *best.Score)
+		}
+	}
+	return result, nil
+}
+
+// GetEstimatedSkill returns a player's skill as of the last fitting run, or
+// nil if the fitting service has not computed it yet.
+func (s *StatsService) GetEstimatedSkill(ctx context.Context, username string) (*model.EstimatedSkill, error) {
+	skill, err := s.statsRepo.GetPlayerSkill(ctx, username)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get player skill", "error", err, "username", username)
+		return nil, err
+	}
+	if skill == nil {
+		return nil, nil
+	}
+	estimated := skill.ToEstimatedSkill()
+	return &estimated, nil
+}
+
+// GetChartStatsList returns an overview of the statistics of every live chart,
+// hardest official level first.
+func (s *StatsService) GetChartStatsList(ctx context.Context) ([]model.ChartStatsSummary, error) {
+	songs, err := s.songRepo.GetAllSongs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.statsRepo.GetAllChartStatistics(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get chart statistics", "error", err)
+		return nil, err
+	}
+	counts, err := s.statsRepo.GetScoreCounts(ctx, config.GlobalConfig.Stats.PassScore)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get chart score counts", "error", err)
+		return nil, err
+	}
+
+	var rows []model.ChartStatsSummary
+	for i := range songs {
+		song := &songs[i]
+		for j := range song.Charts {
+			chart := &song.Charts[j]
+			base := song.WithOverride(chart.SongBaseOverride)
+			row := model.ChartStatsSummary{
+				ChartID:           chart.ID,
+				SongID:            song.ID,
+				WikiID:            base.WikiID,
+				Title:             base.Title,
+				Difficulty:        chart.Difficulty,
+				Level:             chart.Level,
+				FittingLevel:      chart.FittingLevel,
+				FittingLevelLower: chart.FittingLevelLower,
+				FittingLevelUpper: chart.FittingLevelUpper,
+			}
+			if stat, ok := stats[chart.ID]; ok {
+				row.SampleCount = &stat.SampleCount
+				row.EffectiveSampleSize = &stat.EffectiveSampleSize
+				row.StdDev = &stat.StdDev
+			}
+			if count, ok := counts[chart.ID]; ok {
+				row.PlayerCount = count.PlayerCount
+				if count.PlayerCount >= config.GlobalConfig.Stats.MinPlayers {
+					rate := float64(count.PassCount) / float64(count.PlayerCount)
+					row.PassRate = &rate
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b model.ChartStatsSummary) int {
+		if c := cmp.Compare(b.Level, a.Level); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChartID, b.ChartID)
+	})
+	if rows == nil {
+		rows = []model.ChartStatsSummary{}
+	}
+	return rows, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildScoreDistribution(t *testing.T) {
+	scores := []int{850000, 920000, 960000, 990000, 1000000, 1001000, 1004000, 1006000, 1009500, 1010000}
+	dist := buildScoreDistribution(scores, 1000000)
+
+	assert.Equal(t, 10, dist.PlayerCount)
+	assert.Equal(t, 6, dist.PassCount)
+	assert.InDelta(t, 0.6, dist.PassRate, 1e-9)
+	assert.Equal(t, []model.ScorePercentile{
+		{Percentile: 10, Score: 850000},
+		{Percentile: 25, Score: 960000},
+		{Percentile: 50, Score: 1000000},
+		{Percentile: 75, Score: 1006000},
+		{Percentile: 90, Score: 1009500},
+		{Percentile: 99, Score: 1010000},
+	}, dist.Percentiles)
+	assert.Equal(t, []model.ScoreBucket{
+		{MinScore: 0, Count: 1},
+		{MinScore: 900000, Count: 1},
+		{MinScore: 950000, Count: 1},
+		{MinScore: 980000, Count: 1},
+		{MinScore: 1000000, Count: 3},
+		{MinScore: 1005000, Count: 1},
+		{MinScore: 1009000, Count: 2},
+	}, dist.Histogram)
+}
+
+func TestRankScore(t *testing.T) {
+	scores := []int{950000, 1000000, 1000000, 1005000}
+
+	assert.Equal(t, &model.ChartRank{Score: 1005000, Rank: 1, Percentile: 100}, rankScore(scores, 1005000))
+	assert.Equal(t, &model.ChartRank{Score: 1000000, Rank: 2, Percentile: 75}, rankScore(scores, 1000000))
+	assert.Equal(t, &model.ChartRank{Score: 950000, Rank: 4, Percentile: 25}, rankScore(scores, 950000))
+}
+
+func TestStatsService(t *testing.T) {
+	db := setupTestDB(t)
+	config.GlobalConfig.Stats.MinPlayers = 3
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	statsService := NewStatsService(repository.NewStatsRepository(db), songRepo, recordRepo)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	song, err := songRepo.CreateSong(ctx, &model.Song{
+		SongBase: model.SongBase{WikiID: "stats_svc", Title: "Stats Svc"},
+		Charts: []model.Chart{
+			{Difficulty: model.DifficultyInvaded, Level: 13.0},
+			{Difficulty: model.DifficultyMassive, Level: 15.0},
+		},
+	})
+	require.NoError(t, err)
+	invaded, massive := song.Charts[0].ID, song.Charts[1].ID
+	for username, score := range map[string]int{"s1": 960000, "s2": 1001000, "s3": 1007000} {
+		_, err := recordService.CreateRecords(ctx, username, []model.PlayRecordBase{
+			{ChartID: massive, Score: intPtr(score)},
+		}, false)
+		require.NoError(t, err)
+	}
+	_, err = recordService.CreateRecords(ctx, "s1", []model.PlayRecordBase{
+		{ChartID: invaded, Score: intPtr(1009000)},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&model.ChartStatistic{
+		ChartID:             massive,
+		OfficialLevel:       15.0,
+		FittingLevel:        float64Ptr(15.3),
+		SampleCount:         3,
+		EffectiveSampleSize: 2.4,
+		StdDev:              0.2,
+		LastComputedAt:      time.Now(),
+	}).Error)
+
+	t.Run("GetChartStats", func(t *testing.T) {
+		stats, err := statsService.GetChartStats(ctx, massive, "s2")
+		require.NoError(t, err)
+		require.NotNil(t, stats.Fitting)
+		assert.Equal(t, 3, stats.Fitting.SampleCount)
+		require.NotNil(t, stats.Distribution)
+		assert.Equal(t, 3, stats.Distribution.PlayerCount)
+		assert.Equal(t, 2, stats.Distribution.PassCount)
+		require.NotNil(t, stats.MyRank)
+		assert.Equal(t, 2, stats.MyRank.Rank)
+
+		anon, err := statsService.GetChartStats(ctx, massive, "")
+		require.NoError(t, err)
+		assert.Nil(t, anon.MyRank)
+
+		// A player without a record gets no rank
+		stranger, err := statsService.GetChartStats(ctx, massive, "nobody")
+		require.NoError(t, err)
+		assert.Nil(t, stranger.MyRank)
+	})
+
+	t.Run("Too few players withholds the distribution", func(t *testing.T) {
+		stats, err := statsService.GetChartStats(ctx, invaded, "s1")
+		require.NoError(t, err)
+		assert.Nil(t, stats.Fitting)
+		assert.Nil(t, stats.Distribution)
+		assert.Nil(t, stats.MyRank)
+	})
+
+	t.Run("A new best score is ranked before the cache expires", func(t *testing.T) {
+		_, err := recordService.CreateRecords(ctx, "s1", []model.PlayRecordBase{
+			{ChartID: massive, Score: intPtr(1008000)},
+		}, false)
+		require.NoError(t, err)
+		stats, err := statsService.GetChartStats(ctx, massive, "s1")
+		require.NoError(t, err)
+		require.NotNil(t, stats.MyRank)
+		assert.Equal(t, 1, stats.MyRank.Rank)
+	})
+
+	t.Run("GetChartStats of an unknown chart", func(t *testing.T) {
+		_, err := statsService.GetChartStats(ctx, 99999, "")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetChartStatsList", func(t *testing.T) {
+		rows, err := statsService.GetChartStatsList(ctx)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, massive, rows[0].ChartID)
+		assert.Equal(t, "stats_svc", rows[0].WikiID)
+		require.NotNil(t, rows[0].SampleCount)
+		assert.Equal(t, 3, *rows[0].SampleCount)
+		assert.Equal(t, 3, rows[0].PlayerCount)
+		require.NotNil(t, rows[0].PassRate)
+
+		assert.Equal(t, invaded, rows[1].ChartID)
+		assert.Nil(t, rows[1].SampleCount)
+		assert.Equal(t, 1, rows[1].PlayerCount)
+		assert.Nil(t, rows[1].PassRate)
+	})
+}
@@ -26,8 +26,11 @@ func NewUserService(userRepo *repository.UserRepository) *UserService {
 func (s *UserService) Login(ctx context.Context, username, plainPassword string) (string, string, error) {
 	username = strings.ToLower(username)
 
-	user, err := s.userRepo.GetUserByUsername(username)
-	if err != nil || user == nil {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
 		return "", "", errors.New("incorrect username or password")
 	}
 
@@ -73,8 +76,11 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshTokenString strin
 	}
 
 	// Verify user still exists and is active
-	user, err := s.userRepo.GetUserByUsername(username)
-	if err != nil || user == nil {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
 		return "", "", errors.New("user not found")
 	}
 	if !user.IsActive {
@@ -98,12 +104,12 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshTokenString strin
 	return newAccessToken, newRefreshToken, nil
 }
 
-func (s *UserService) GetUser(username string) (*model.User, error) {
-	return s.userRepo.GetUserByUsername(username)
+func (s *UserService) GetUser(ctx context.Context, username string) (*model.User, error) {
+	return s.userRepo.GetUserByUsername(ctx, username)
 }
 
-func (s *UserService) GetUserByUploadToken(token string) (*model.User, error) {
-	return s.userRepo.GetUserByUploadToken(token)
+func (s *UserService) GetUserByUploadToken(ctx context.Context, token string) (*model.User, error) {
+	return s.userRepo.GetUserByUploadToken(ctx, token)
 }
 
 func generateHexToken(n int) (string, error) {
@@ -121,7 +127,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *request.CreateUserReq
 		return nil, errors.New("invalid username format")
 	}
 
-	existingUser, err := s.userRepo.GetUserByUsername(req.Username)
+	existingUser, err := s.userRepo.GetUserByUsername(ctx, req.Username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing user: %w", err)
 	}
@@ -153,7 +159,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *request.CreateUserReq
 		EncodedPassword: encodedPassword,
 	}
 
-	createdUser, err := s.userRepo.CreateUser(user)
+	createdUser, err := s.userRepo.CreateUser(ctx, user)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create user", "error", err, "username", req.Username)
 		return nil, err
@@ -164,8 +170,8 @@ func (s *UserService) CreateUser(ctx context.Context, req *request.CreateUserReq
 
 func (s *UserService) RefreshUploadToken(ctx context.Context, username string) (string, error) {
 	var token string
-	err := s.userRepo.WithTransaction(func(tx *repository.UserRepository) error {
-		user, err := tx.GetUserByUsername(username)
+	err := s.userRepo.WithTransaction(ctx, func(tx *repository.UserRepository) error {
+		user, err := tx.GetUserByUsername(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -178,7 +184,7 @@ func (s *UserService) RefreshUploadToken(ctx context.Context, username string) (
 			return errors.New("generate token failed")
 		}
 		user.UploadToken = uploadToken
-		if _, err := tx.UpdateUser(user); err != nil {
+		if _, err := tx.UpdateUser(ctx, user); err != nil {
 			return err
 		}
 		token = uploadToken
@@ -189,8 +195,8 @@ func (s *UserService) RefreshUploadToken(ctx context.Context, username string) (
 
 func (s *UserService) UpdateUser(ctx context.Context, username string, req *request.UpdateUserRequest) (*model.User, error) {
 	var result *model.User
-	err := s.userRepo.WithTransaction(func(tx *repository.UserRepository) error {
-		user, err := tx.GetUserByUsername(username)
+	err := s.userRepo.WithTransaction(ctx, func(tx *repository.UserRepository) error {
+		user, err := tx.GetUserByUsername(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -217,15 +223,15 @@ func (s *UserService) UpdateUser(ctx context.Context, username string, req *requ
 			user.AnonymousProbe = *req.AnonymousProbe
 		}
 
-		result, err = tx.UpdateUser(user)
+		result, err = tx.UpdateUser(ctx, user)
 		return err
 	})
 	return result, err
 }
 
 func (s *UserService) ChangePassword(ctx context.Context, username string, req *request.ChangePasswordRequest) error {
-	return s.userRepo.WithTransaction(func(tx *repository.UserRepository) error {
-		user, err := tx.GetUserByUsername(username)
+	return s.userRepo.WithTransaction(ctx, func(tx *repository.UserRepository) error {
+		user, err := tx.GetUserByUsername(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -244,7 +250,7 @@ func (s *UserService) ChangePassword(ctx context.Context, username string, req *
 		}
 
 		user.EncodedPassword = encodedPassword
-		_, err = tx.UpdateUser(user)
+		_, err = tx.UpdateUser(ctx, user)
 		return err
 	})
 }
@@ -252,8 +258,8 @@ func (s *UserService) ChangePassword(ctx context.Context, username string, req *
 func (s *UserService) ResetPassword(ctx context.Context, req *request.ResetPasswordRequest) error {
 	req.Username = strings.ToLower(req.Username)
 
-	return s.userRepo.WithTransaction(func(tx *repository.UserRepository) error {
-		user, err := tx.GetUserByUsername(req.Username)
+	return s.userRepo.WithTransaction(ctx, func(tx *repository.UserRepository) error {
+		user, err := tx.GetUserByUsername(ctx, req.Username)
 		if err != nil {
 			return err
 		}
@@ -267,13 +273,13 @@ func (s *UserService) ResetPassword(ctx context.Context, req *request.ResetPassw
 		}
 
 		user.EncodedPassword = encodedPassword
-		_, err = tx.UpdateUser(user)
+		_, err = tx.UpdateUser(ctx, user)
 		return err
 	})
 }
 
 func (s *UserService) CheckProbeAuthority(ctx context.Context, username string, currentUser *model.User) error {
-	targetUser, err := s.userRepo.GetUserByUsername(username)
+	targetUser, err := s.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
 		return fmt.Errorf("failed to query user: %w", err)
 	}
@@ -68,14 +68,14 @@ func TestUserService(t *testing.T) {
 	})
 
 	t.Run("RefreshUploadToken", func(t *testing.T) {
-		user, _ := userService.GetUser("testuser")
+		user, _ := userService.GetUser(ctx, "testuser")
 		oldToken := user.UploadToken
 		newToken, err := userService.RefreshUploadToken(ctx, "testuser")
 		assert.NoError(t, err)
 		assert.NotEmpty(t, newToken)
 		assert.NotEqual(t, oldToken, newToken)
 
-		updatedUser, _ := userService.GetUser("testuser")
+		updatedUser, _ := userService.GetUser(ctx, "testuser")
 		assert.Equal(t, newToken, updatedUser.UploadToken)
 	})
 }
@@ -183,14 +183,14 @@ func TestUserService_GetUserByUploadToken(t *testing.T) {
 	assert.NotEmpty(t, createdUser.UploadToken)
 
 	t.Run("Found", func(t *testing.T) {
-		user, err := userService.GetUserByUploadToken(createdUser.UploadToken)
+		user, err := userService.GetUserByUploadToken(ctx, createdUser.UploadToken)
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, "tokenuser", user.Username)
 	})
 
 	t.Run("Not found", func(t *testing.T) {
-		user, err := userService.GetUserByUploadToken("nonexistent_token")
+		user, err := userService.GetUserByUploadToken(ctx, "nonexistent_token")
 		assert.NoError(t, err)
 		assert.Nil(t, user)
 	})
@@ -249,7 +249,7 @@ func TestUserService_CheckProbeAuthority(t *testing.T) {
 
 	t.Run("Same user", func(t *testing.T) {
 		// Even if anonymous probe is disabled, the same user can access their own records
-		privateUserObj, _ := userService.GetUser("private_user")
+		privateUserObj, _ := userService.GetUser(ctx, "private_user")
 		err := userService.CheckProbeAuthority(ctx, "private_user", privateUserObj)
 		assert.NoError(t, err)
 	})
@@ -368,7 +368,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		assert.Equal(t, "12345", *updated.QQAccount)
 
 		// Verify persisted
-		fetched, _ := userService.GetUser("upduser_svc")
+		fetched, _ := userService.GetUser(ctx, "upduser_svc")
 		assert.Equal(t, "NewNick", fetched.Nickname)
 	})
 
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tierListTopTags is how many of a chart's most used tags a tier list shows.
+const tierListTopTags = 3
+
+type VoteService struct {
+	voteRepo *repository.VoteRepository
+	songRepo *repository.SongRepository
+}
+
+func NewVoteService(voteRepo *repository.VoteRepository, songRepo *repository.SongRepository) *VoteService {
+	return &VoteService{
+		voteRepo: voteRepo,
+		songRepo: songRepo,
+	}
+}
+
+// NormalizeTag lowercases a tag and joins its words with hyphens, so that
+// "Sight Read Trap" and "sight_read_trap" are the same tag. Letters of any
+// script are kept. Returns "" if nothing is left.
+func NormalizeTag(tag string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+		} else {
+			pendingHyphen = true
+		}
+	}
+	return b.String()
+}
+
+// checkVoter makes sure the chart is live and the user has a best record on it.
+func (s *VoteService) checkVoter(ctx context.Context, username string, chartID int) error {
+	chart, err := s.songRepo.GetChartByID(ctx, chartID)
+	if err != nil {
+		return err
+	}
+	if chart == nil {
+		return fmt.Errorf("chart %w", ErrNotFound)
+	}
+	ok, err := s.voteRepo.HasBestRecord(ctx, username, chartID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("only players with a record on this chart can vote: %w", ErrForbidden)
+	}
+	return nil
+}
+
+// Vote records or replaces the user's level vote on a chart.
+func (s *VoteService) Vote(ctx context.Context, username string, chartID int, delta float64) error {
+	if delta < -model.MaxVoteDelta || delta > model.MaxVoteDelta {
+		return fmt.Errorf("delta must be within ±%g", model.MaxVoteDelta)
+	}
+	if err := s.checkVoter(ctx, username, chartID); err != nil {
+		return err
+	}
+	if err := s.voteRepo.UpsertVote(ctx, &model.ChartVote{ChartID: chartID, Username: username, Delta: delta}); err != nil {
+		slog.ErrorContext(ctx, "failed to save chart vote", "error", err, "chart_id", chartID)
+		return err
+	}
+	slog.InfoContext(ctx, "chart vote saved", "chart_id", chartID, "delta", delta)
+	return nil
+}
+
+// DeleteVote withdraws the user's level vote on a chart.
+func (s *VoteService) DeleteVote(ctx context.Context, username string, chartID int) error {
+	deleted, err := s.voteRepo.DeleteVote(ctx, username, chartID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete chart vote", "error", err, "chart_id", chartID)
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("vote %w", ErrNotFound)
+	}
+	slog.InfoContext(ctx, "chart vote deleted", "chart_id", chartID)
+	return nil
+}
+
+// SetTags replaces the user's tags on a chart and returns them normalised.
+func (s *VoteService) SetTags(ctx context.Context, username string, chartID int, tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		n := NormalizeTag(tag)
+		if n == "" || utf8.RuneCountInString(n) > 32 {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		normalized = append(normalized, n)
+	}
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > model.MaxChartTagsPerUser {
+		return nil, fmt.Errorf("at most %d tags per chart", model.MaxChartTagsPerUser)
+	}
+	if len(normalized) > 0 {
+		if err := s.checkVoter(ctx, username, chartID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.voteRepo.SetTags(ctx, username, chartID, normalized); err != nil {
+		slog.ErrorContext(ctx, "failed to set chart tags", "error", err, "chart_id", chartID)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "chart tags set", "chart_id", chartID, "tags", normalized)
+	return normalized, nil
+}
+
+// summarize combines a chart's level with its vote statistics.
+func summarize(chart *model.Chart, stats *model.ChartVoteStats) model.ChartVoteSummary {
+	summary := model.ChartVoteSummary{
+		ChartVoteStats:    model.ChartVoteStats{ChartID: chart.ID, Tags: []model.TagCount{}},
+		Level:             chart.Level,
+		FittingLevel:      chart.FittingLevel,
+		FittingLevelLower: chart.FittingLevelLower,
+		FittingLevelUpper: chart.FittingLevelUpper,
+	}
+	if stats != nil {
+		summary.ChartVoteStats = *stats
+	}
+	if summary.MeanDelta != nil {
+		level := chart.Level + *summary.MeanDelta
+		summary.CommunityLevel = &level
+	}
+	return summary
+}
+
+// GetChartVotes returns the community votes and tags of a chart. When username
+// is set, the caller's own vote and tags are included.
+func (s *VoteService) GetChartVotes(ctx context.Context, chartID int, username string) (*model.ChartVoteSummary, error) {
+	chart, err := s.songRepo.GetChartByID(ctx, chartID)
+	if err != nil {
+		return nil, err
+	}
+	if chart == nil {
+		return nil, fmt.Errorf("chart %w", ErrNotFound)
+	}
+	stats, err := s.voteRepo.GetVoteStats(ctx, []int{chartID})
+	if err != nil {
+		return nil, err
+	}
+	summary := summarize(chart, stats[chartID])
+
+	if username != "" {
+		vote, err := s.voteRepo.GetUserVote(ctx, username, chartID)
+		if err != nil {
+			return nil, err
+		}
+		if vote != nil {
+			summary.MyVote = &vote.Delta
+		}
+		if summary.MyTags, err = s.voteRepo.GetUserTags(ctx, username, chartID); err != nil {
+			return nil, err
+		}
+	}
+	return &summary, nil
+}
+
+// TierListFilter selects the charts of a tier list.
+type TierListFilter struct {
+	MinLevel *float64
+	MaxLevel *float64
+	// MinVotes excludes charts with fewer level votes.
+	MinVotes int
+	// Tag, when set, keeps only charts carrying that tag.
+	Tag string
+}
+
+// GetTierList groups the live charts with community votes by official level
+// (hardest level first) and orders each group by community level, so that
+// the charts that feel hardest for their level come first.
+func (s *VoteService) GetTierList(ctx context.Context, filter TierListFilter) ([]model.TierListGroup, error) {
+	if filter.Tag != "" {
+		if filter.Tag = NormalizeTag(filter.Tag); filter.Tag == "" {
+			return nil, errors.New("invalid tag")
+		}
+	}
+	songs, err := s.songRepo.GetAllSongs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.voteRepo.GetVoteStats(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[float64][]model.TierListEntry)
+	for i := range songs {
+		song := &songs[i]
+		for j := range song.Charts {
+			chart := &song.Charts[j]
+			st := stats[chart.ID]
+			if st == nil || st.VoteCount < max(filter.MinVotes, 1) ||
+				(filter.MinLevel != nil && chart.Level < *filter.MinLevel) ||
+				(filter.MaxLevel != nil && chart.Level > *filter.MaxLevel) ||
+				(filter.Tag != "" && !slices.ContainsFunc(st.Tags, func(t model.TagCount) bool { return t.Tag == filter.Tag })) {
+				continue
+			}
+			base := song.WithOverride(chart.SongBaseOverride)
+			entry := model.TierListEntry{
+				ChartVoteSummary: summarize(chart, st),
+				SongID:           song.ID,
+				WikiID:           base.WikiID,
+				Title:            base.Title,
+				Difficulty:       chart.Difficulty,
+				Cover:            base.Cover,
+			}
+			if len(entry.Tags) > tierListTopTags {
+				entry.Tags = entry.Tags[:tierListTopTags]
+			}
+			groups[chart.Level] = append(groups[chart.Level], entry)
+		}
+	}
+
+	tiers := make([]model.TierListGroup, 0, len(groups))
+	for level, entries := range groups {
+		slices.SortFunc(entries, func(a, b model.TierListEntry) int {
+			if c := cmp.Compare(*b.CommunityLevel, *a.CommunityLevel); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.ChartID, b.ChartID)
+		})
+		tiers = append(tiers, model.TierListGroup{Level: level, Charts: entries})
+	}
+	slices.SortFunc(tiers, func(a, b model.TierListGroup) int { return cmp.Compare(b.Level, a.Level) })
+	return tiers, nil
+}
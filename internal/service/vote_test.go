@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := map[string]string{
+		"stamina":          "stamina",
+		"Sight Read Trap":  "sight-read-trap",
+		"sight_read_trap":  "sight-read-trap",
+		"  --Tech!! ":      "tech",
+		"16分 交互":           "16分-交互",
+		"!!!":              "",
+		"Speed-Change 2.0": "speed-change-2-0",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, NormalizeTag(in), in)
+	}
+}
+
+func TestVoteService(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	voteService := NewVoteService(repository.NewVoteRepository(db), songRepo)
+	recordService := NewRecordService(recordRepo, songRepo)
+	ctx := context.Background()
+
+	var charts []int
+	for _, s := range []struct {
+		wikiID string
+		level  float64
+	}{{"tier_a", 15.2}, {"tier_b", 15.2}, {"tier_c", 14.8}} {
+		song, err := songRepo.CreateSong(ctx, &model.Song{
+			SongBase: model.SongBase{WikiID: s.wikiID, Title: s.wikiID},
+			Charts:   []model.Chart{{Difficulty: model.DifficultyMassive, Level: s.level}},
+		})
+		require.NoError(t, err)
+		charts = append(charts, song.Charts[0].ID)
+	}
+	for _, username := range []string{"p1", "p2", "p3"} {
+		records := make([]model.PlayRecordBase, 0, len(charts))
+		for _, chartID := range charts {
+			records = append(records, model.PlayRecordBase{ChartID: chartID, Score: intPtr(1000000)})
+		}
+		_, err := recordService.CreateRecords(ctx, username, records, false)
+		require.NoError(t, err)
+	}
+
+	t.Run("Only players with a record may vote", func(t *testing.T) {
+		err := voteService.Vote(ctx, "stranger", charts[0], 0.5)
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = voteService.SetTags(ctx, "stranger", charts[0], []string{"tech"})
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		err = voteService.Vote(ctx, "p1", 99999, 0.5)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Vote validation", func(t *testing.T) {
+		assert.Error(t, voteService.Vote(ctx, "p1", charts[0], model.MaxVoteDelta+0.1))
+		assert.ErrorIs(t, voteService.DeleteVote(ctx, "p1", charts[0]), ErrNotFound)
+	})
+
+	t.Run("SetTags normalises and caps", func(t *testing.T) {
+		tags, err := voteService.SetTags(ctx, "p1", charts[0], []string{"Stamina", "stamina", "Sight Read Trap"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sight-read-trap", "stamina"}, tags)
+
+		_, err = voteService.SetTags(ctx, "p1", charts[0], []string{"a", "b", "c", "d", "e", "f"})
+		assert.Error(t, err)
+		_, err = voteService.SetTags(ctx, "p1", charts[0], []string{"???"})
+		assert.Error(t, err)
+	})
+
+	// charts[0]: +0.5, +0.3 → 15.6; charts[1]: -0.2 → 15.0; charts[2]: +1.0 → 15.8
+	require.NoError(t, voteService.Vote(ctx, "p1", charts[0], 0.5))
+	require.NoError(t, voteService.Vote(ctx, "p2", charts[0], 0.3))
+	require.NoError(t, voteService.Vote(ctx, "p1", charts[1], -0.2))
+	require.NoError(t, voteService.Vote(ctx, "p1", charts[2], 1.0))
+	_, err := voteService.SetTags(ctx, "p2", charts[0], []string{"stamina"})
+	require.NoError(t, err)
+
+	t.Run("GetChartVotes", func(t *testing.T) {
+		summary, err := voteService.GetChartVotes(ctx, charts[0], "p1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.VoteCount)
+		require.NotNil(t, summary.CommunityLevel)
+		assert.InDelta(t, 15.6, *summary.CommunityLevel, 1e-9)
+		require.NotNil(t, summary.MyVote)
+		assert.InDelta(t, 0.5, *summary.MyVote, 1e-9)
+		assert.Equal(t, []string{"sight-read-trap", "stamina"}, summary.MyTags)
+		assert.Equal(t, model.TagCount{Tag: "stamina", Count: 2}, summary.Tags[0])
+
+		anon, err := voteService.GetChartVotes(ctx, charts[0], "")
+		require.NoError(t, err)
+		assert.Nil(t, anon.MyVote)
+		assert.Nil(t, anon.MyTags)
+
+		_, err = voteService.GetChartVotes(ctx, 99999, "")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetTierList groups by level and orders by community level", func(t *testing.T) {
+		tiers, err := voteService.GetTierList(ctx, TierListFilter{})
+		require.NoError(t, err)
+		require.Len(t, tiers, 2)
+		assert.Equal(t, 15.2, tiers[0].Level)
+		require.Len(t, tiers[0].Charts, 2)
+		assert.Equal(t, charts[0], tiers[0].Charts[0].ChartID)
+		assert.Equal(t, charts[1], tiers[0].Charts[1].ChartID)
+		assert.Equal(t, "tier_a", tiers[0].Charts[0].WikiID)
+		assert.Equal(t, 14.8, tiers[1].Level)
+	})
+
+	t.Run("GetTierList filters", func(t *testing.T) {
+		tiers, err := voteService.GetTierList(ctx, TierListFilter{MinVotes: 2})
+		require.NoError(t, err)
+		require.Len(t, tiers, 1)
+		assert.Len(t, tiers[0].Charts, 1)
+
+		tiers, err = voteService.GetTierList(ctx, TierListFilter{MaxLevel: float64Ptr(15.0)})
+		require.NoError(t, err)
+		require.Len(t, tiers, 1)
+		assert.Equal(t, 14.8, tiers[0].Level)
+
+		tiers, err = voteService.GetTierList(ctx, TierListFilter{Tag: "Stamina"})
+		require.NoError(t, err)
+		require.Len(t, tiers, 1)
+		assert.Equal(t, charts[0], tiers[0].Charts[0].ChartID)
+
+		_, err = voteService.GetTierList(ctx, TierListFilter{Tag: "!!"})
+		assert.Error(t, err)
+	})
+
+	t.Run("DeleteVote", func(t *testing.T) {
+		require.NoError(t, voteService.DeleteVote(ctx, "p1", charts[1]))
+		tiers, err := voteService.GetTierList(ctx, TierListFilter{MinLevel: float64Ptr(15.0)})
+		require.NoError(t, err)
+		require.Len(t, tiers, 1)
+		assert.Len(t, tiers[0].Charts, 1)
+	})
+}
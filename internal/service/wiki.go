@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/internal/wiki"
+	"slices"
+)
+
+var errWikiDisabled = errors.New("wiki source is not configured")
+
+// fetchWikiSong looks the song up on the wiki, mapping provider errors to
+// ErrNotFound (no wiki page) or ErrUpstream (the wiki is unreachable or broken).
+func (s *SongService) fetchWikiSong(ctx context.Context, wikiID string) (*model.WikiSong, error) {
+	if s.wiki == nil {
+		return nil, errWikiDisabled
+	}
+	ws, err := s.wiki.GetSong(ctx, wikiID)
+	if err != nil {
+		if errors.Is(err, wiki.ErrSongNotFound) {
+			return nil, fmt.Errorf("song %q not found on wiki: %w", wikiID, ErrNotFound)
+		}
+		slog.ErrorContext(ctx, "wiki lookup failed", "error", err, "wiki_id", wikiID)
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
+	return ws, nil
+}
+
+// getWikiSong returns the stored song with the metadata published on the wiki
+// laid over it. Fields the wiki leaves empty keep their stored values.
+func (s *SongService) getWikiSong(ctx context.Context, songID int) (*model.Song, error) {
+	song, err := s.songRepo.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if song == nil {
+		return nil, fmt.Errorf("song doesn't exist: %w", ErrNotFound)
+	}
+	ws, err := s.fetchWikiSong(ctx, song.WikiID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The song (and its Charts slice) may be shared with the repository cache
+	song.Charts = slices.Clone(song.Charts)
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&song.Title, ws.Title},
+		{&song.Artist, ws.Artist},
+		{&song.Genre, ws.Genre},
+		{&song.Cover, ws.Cover},
+		{&song.Illustrator, ws.Illustrator},
+		{&song.Version, ws.Version},
+		{&song.Album, ws.Album},
+		{&song.BPM, ws.BPM},
+		{&song.Length, ws.Length},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	for i := range song.Charts {
+		chart := &song.Charts[i]
+		wc := findWikiChart(ws, chart.Difficulty)
+		if wc == nil {
+			continue
+		}
+		if wc.Level > 0 {
+			chart.Level = wc.Level
+		}
+		if wc.Notes > 0 {
+			chart.Notes = wc.Notes
+		}
+		if wc.LevelDesign != "" {
+			chart.LevelDesign = &wc.LevelDesign
+		}
+		if wc.Cover != "" {
+			chart.OverrideCover = &wc.Cover
+		}
+	}
+	return song, nil
+}
+
+func findWikiChart(ws *model.WikiSong, difficulty model.Difficulty) *model.WikiChart {
+	for i := range ws.Charts {
+		if ws.Charts[i].Difficulty == difficulty {
+			return &ws.Charts[i]
+		}
+	}
+	return nil
+}
+
+// songFieldPtr returns a pointer to the SongBase field a song-level proposal targets.
+func songFieldPtr(song *model.Song, field string) *string {
+	switch field {
+	case model.ProposalFieldArtist:
+		return &song.Artist
+	case model.ProposalFieldBPM:
+		return &song.BPM
+	case model.ProposalFieldIllustrator:
+		return &song.Illustrator
+	case model.ProposalFieldCover:
+		return &song.Cover
+	}
+	return nil
+}
+
+// wikiProposals compares the song with its wiki page and returns a pending
+// proposal for every syncable field where the wiki disagrees.
+func wikiProposals(song *model.Song, ws *model.WikiSong) []model.SongMetadataProposal {
+	var proposals []model.SongMetadataProposal
+	for _, field := range []string{
+		model.ProposalFieldArtist,
+		model.ProposalFieldBPM,
+		model.ProposalFieldIllustrator,
+		model.ProposalFieldCover,
+	} {
+		current := *songFieldPtr(song, field)
+		proposed := *songFieldPtr(&model.Song{SongBase: ws.SongBase}, field)
+		if proposed == "" || proposed == current {
+			continue
+		}
+		proposals = append(proposals, model.SongMetadataProposal{
+			SongID:        song.ID,
+			Field:         field,
+			CurrentValue:  &current,
+			ProposedValue: proposed,
+		})
+	}
+
+	for _, chart := range song.Charts {
+		wc := findWikiChart(ws, chart.Difficulty)
+		if wc == nil || wc.Cover == "" {
+			continue
+		}
+		// No override is needed when the chart already shows that cover
+		effective := song.WithOverride(chart.SongBaseOverride).Cover
+		if chart.OverrideCover == nil && wc.Cover == effective ||
+			chart.OverrideCover != nil && *chart.OverrideCover == wc.Cover {
+			continue
+		}
+		proposals = append(proposals, model.SongMetadataProposal{
+			SongID:        song.ID,
+			ChartID:       &chart.ID,
+			Difficulty:    chart.Difficulty,
+			Field:         model.ProposalFieldChartCover,
+			CurrentValue:  chart.OverrideCover,
+			ProposedValue: wc.Cover,
+		})
+	}
+	return proposals
+}
+
+// SyncSongFromWiki compares a song with its wiki page and files a pending
+// proposal for every differing artist, BPM, illustrator or cover. Nothing is
+// changed until an admin accepts a proposal. Proposals identical to one that
+// is still pending are not filed again. Returns the newly created proposals.
+func (s *SongService) SyncSongFromWiki(ctx context.Context, songID int) ([]model.SongMetadataProposal, error) {
+	song, err := s.songRepo.GetSongByID(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	if song == nil {
+		return nil, fmt.Errorf("song %w", ErrNotFound)
+	}
+	ws, err := s.fetchWikiSong(ctx, song.WikiID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.songRepo.GetProposals(ctx, model.ProposalStatusPending, songID)
+	if err != nil {
+		return nil, err
+	}
+	proposals := make([]model.SongMetadataProposal, 0)
+	for _, p := range wikiProposals(song, ws) {
+		if slices.ContainsFunc(pending, func(q model.SongMetadataProposal) bool {
+			return q.Field == p.Field && q.ProposedValue == p.ProposedValue &&
+				(q.ChartID == nil) == (p.ChartID == nil) && (q.ChartID == nil || *q.ChartID == *p.ChartID)
+		}) {
+			continue
+		}
+		p.Source = "wiki"
+		p.Status = model.ProposalStatusPending
+		proposals = append(proposals, p)
+	}
+
+	if err := s.songRepo.CreateProposals(ctx, proposals); err != nil {
+		slog.ErrorContext(ctx, "failed to store wiki proposals", "error", err, "song_id", songID)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "song synced from wiki", "song_id", songID, "wiki_id", song.WikiID, "proposals", len(proposals))
+	return proposals, nil
+}
+
+// GetMetadataProposals lists metadata proposals, newest first. An empty status
+// or a zero songID matches all.
+func (s *SongService) GetMetadataProposals(ctx context.Context, status string, songID int) ([]model.SongMetadataProposal, error) {
+	switch status {
+	case "", model.ProposalStatusPending, model.ProposalStatusAccepted, model.ProposalStatusRejected:
+	default:
+		return nil, fmt.Errorf("invalid proposal status: %s", status)
+	}
+	proposals, err := s.songRepo.GetProposals(ctx, status, songID)
+	if err != nil {
+		return nil, err
+	}
+	if proposals == nil {
+		proposals = []model.SongMetadataProposal{}
+	}
+	return proposals, nil
+}
+
+// AcceptMetadataProposal applies a pending proposal to the song, recording the
+// change in the song history attributed to reviewedBy. It fails with
+// ErrConflict if the proposal was already reviewed or the field has been
+// edited since the sync; re-syncing files a fresh proposal in that case.
+func (s *SongService) AcceptMetadataProposal(ctx context.Context, proposalID int, reviewedBy string) (*model.SongMetadataProposal, error) {
+	var proposal *model.SongMetadataProposal
+	err := s.songRepo.WithTransaction(ctx, func(tx *repository.SongRepository) error {
+		var err error
+		proposal, err = tx.GetProposalByID(ctx, proposalID)
+		if err != nil {
+			return err
+		}
+		if proposal == nil {
+			return fmt.Errorf("proposal %w", ErrNotFound)
+		}
+		if proposal.Status != model.ProposalStatusPending {
+			return fmt.Errorf("proposal is already %s: %w", proposal.Status, ErrConflict)
+		}
+
+		song, err := tx.GetSongByID(ctx, proposal.SongID)
+		if err != nil {
+			return err
+		}
+		if song == nil {
+			return fmt.Errorf("song %w", ErrNotFound)
+		}
+		song.Charts = slices.Clone(song.Charts)
+		if err := applyProposal(song, proposal); err != nil {
+			return err
+		}
+		if _, _, err := tx.UpdateSong(ctx, song.ID, song, reviewedBy); err != nil {
+			return err
+		}
+
+		ok, err := tx.ReviewProposal(ctx, proposal.ID, model.ProposalStatusAccepted, reviewedBy)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("proposal was reviewed concurrently: %w", ErrConflict)
+		}
+		proposal, err = tx.GetProposalByID(ctx, proposal.ID)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrConflict) {
+			slog.ErrorContext(ctx, "failed to accept metadata proposal", "error", err, "proposal_id", proposalID)
+		}
+		return nil, err
+	}
+	slog.InfoContext(ctx, "metadata proposal accepted",
+		"proposal_id", proposal.ID, "song_id", proposal.SongID, "field", proposal.Field)
+	s.invalidateSongRecords(ctx, proposal.SongID)
+	return proposal, nil
+}
+
+// applyProposal sets the proposed value on song after checking that the
+// field still holds the value seen at sync time.
+func applyProposal(song *model.Song, p *model.SongMetadataProposal) error {
+	stale := fmt.Errorf("%s has changed since the proposal was made, re-sync the song: %w", p.Field, ErrConflict)
+
+	if p.Field == model.ProposalFieldChartCover {
+		if p.ChartID == nil {
+			return fmt.Errorf("chart proposal without chart_id: %w", ErrConflict)
+		}
+		i := slices.IndexFunc(song.Charts, func(c model.Chart) bool { return c.ID == *p.ChartID })
+		if i < 0 {
+			return fmt.Errorf("chart %d is no longer live: %w", *p.ChartID, ErrConflict)
+		}
+		chart := &song.Charts[i]
+		current := chart.OverrideCover
+		if (current == nil) != (p.CurrentValue == nil) || current != nil && *current != *p.CurrentValue {
+			return stale
+		}
+		proposed := p.ProposedValue
+		chart.OverrideCover = &proposed
+		return nil
+	}
+
+	field := songFieldPtr(song, p.Field)
+	if field == nil {
+		return fmt.Errorf("unsupported proposal field: %s", p.Field)
+	}
+	if p.CurrentValue == nil || *field != *p.CurrentValue {
+		return stale
+	}
+	*field = p.ProposedValue
+	return nil
+}
+
+// RejectMetadataProposal marks a pending proposal as rejected without
+// touching the song.
+func (s *SongService) RejectMetadataProposal(ctx context.Context, proposalID int, reviewedBy string) (*model.SongMetadataProposal, error) {
+	ok, err := s.songRepo.ReviewProposal(ctx, proposalID, model.ProposalStatusRejected, reviewedBy)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to reject metadata proposal", "error", err, "proposal_id", proposalID)
+		return nil, err
+	}
+	proposal, err := s.songRepo.GetProposalByID(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if proposal == nil {
+		return nil, fmt.Errorf("proposal %w", ErrNotFound)
+	}
+	if !ok {
+		return nil, fmt.Errorf("proposal is already %s: %w", proposal.Status, ErrConflict)
+	}
+	slog.InfoContext(ctx, "metadata proposal rejected",
+		"proposal_id", proposal.ID, "song_id", proposal.SongID, "field", proposal.Field)
+	return proposal, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"paradigm-reboot-prober-go/internal/model"
+	"paradigm-reboot-prober-go/internal/model/request"
+	"paradigm-reboot-prober-go/internal/repository"
+	"paradigm-reboot-prober-go/internal/wiki"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenWiki is a provider whose every lookup fails like an unreachable wiki.
+type brokenWiki struct{}
+
+func (brokenWiki) GetSong(ctx context.Context, wikiID string) (*model.WikiSong, error) {
+	return nil, errors.New("connection refused")
+}
+
+func newFixtureProvider(t *testing.T, fixture string) wiki.SongMetadataProvider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "wiki.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fixture), 0o644))
+	p, err := wiki.NewFileProvider(path)
+	require.NoError(t, err)
+	return p
+}
+
+func TestSongService_Wiki(t *testing.T) {
+	db := setupTestDB(t)
+	songRepo := repository.NewSongRepository(db)
+	recordRepo := repository.NewRecordRepository(db)
+	songService := NewSongService(songRepo, recordRepo, newFixtureProvider(t, `
+songs:
+  - wiki_id: synced
+    title: Synced
+    artist: New Artist
+    illustrator: Old Illustrator
+    bpm: "200"
+    cover: Cover_new.jpg
+    charts:
+      - {difficulty: massive, level: 15.1, notes: 1001, level_design: Wiki LD, cover: Cover_massive.jpg}
+      - {difficulty: invaded, level: 12, notes: 700, cover: Cover_old.jpg}
+`))
+	ctx := context.Background()
+
+	charts, err := songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "synced", Title: "Synced", Artist: "Old Artist",
+			Illustrator: "Old Illustrator", BPM: "180", Cover: "Cover_old.jpg"},
+		Charts: []model.ChartInput{
+			{Difficulty: model.DifficultyInvaded, Level: 12.0, Notes: 700},
+			{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000, LevelDesign: "LD"},
+		},
+	})
+	require.NoError(t, err)
+	songID, massiveID := charts[0].SongID, charts[1].ID
+	_, err = songService.CreateSong(ctx, &request.CreateSongRequest{
+		SongBase: model.SongBase{WikiID: "not_on_wiki", Title: "Local", Artist: "A"},
+		Charts:   []model.ChartInput{{Difficulty: model.DifficultyMassive, Level: 13.0, Notes: 800}},
+	})
+	require.NoError(t, err)
+	localID, err := songService.ResolveSongID(ctx, "not_on_wiki")
+	require.NoError(t, err)
+
+	t.Run("GetSingleSong src=wiki", func(t *testing.T) {
+		song, err := songService.GetSingleSong(ctx, songID, "wiki")
+		require.NoError(t, err)
+		assert.Equal(t, "New Artist", song.Artist)
+		assert.Equal(t, "200", song.BPM)
+		require.Len(t, song.Charts, 2)
+		for _, c := range song.Charts {
+			if c.Difficulty == model.DifficultyMassive {
+				assert.Equal(t, 15.1, c.Level)
+				assert.Equal(t, "Wiki LD", *c.LevelDesign)
+				assert.Equal(t, "Cover_massive.jpg", *c.OverrideCover)
+			}
+		}
+
+		// The stored (and cached) song is untouched
+		stored, err := songService.GetSingleSong(ctx, songID, "prp")
+		require.NoError(t, err)
+		assert.Equal(t, "Old Artist", stored.Artist)
+		for _, c := range stored.Charts {
+			assert.Nil(t, c.OverrideCover)
+		}
+
+		_, err = songService.GetSingleSong(ctx, localID, "wiki")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	var proposals []model.SongMetadataProposal
+	t.Run("Sync files proposals once", func(t *testing.T) {
+		proposals, err = songService.SyncSongFromWiki(ctx, songID)
+		require.NoError(t, err)
+		fields := make([]string, 0, len(proposals))
+		for _, p := range proposals {
+			fields = append(fields, p.Field)
+			assert.Equal(t, model.ProposalStatusPending, p.Status)
+			assert.Equal(t, "wiki", p.Source)
+		}
+		// Illustrator matches, and the invaded chart already shows Cover_old.jpg
+		assert.Equal(t, []string{"artist", "bpm", "cover", "override_cover"}, fields)
+		assert.Equal(t, "Old Artist", *proposals[0].CurrentValue)
+		assert.Equal(t, massiveID, *proposals[3].ChartID)
+		assert.Nil(t, proposals[3].CurrentValue)
+
+		again, err := songService.SyncSongFromWiki(ctx, songID)
+		require.NoError(t, err)
+		assert.Empty(t, again)
+
+		pending, err := songService.GetMetadataProposals(ctx, model.ProposalStatusPending, songID)
+		require.NoError(t, err)
+		assert.Len(t, pending, 4)
+
+		// Nothing was applied yet
+		song, err := songService.GetSingleSong(ctx, songID, "prp")
+		require.NoError(t, err)
+		assert.Equal(t, "Old Artist", song.Artist)
+
+		_, err = songService.SyncSongFromWiki(ctx, localID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = songService.GetMetadataProposals(ctx, "bogus", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Accept applies and records history", func(t *testing.T) {
+		accepted, err := songService.AcceptMetadataProposal(ctx, proposals[0].ID, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, model.ProposalStatusAccepted, accepted.Status)
+		assert.Equal(t, "admin", *accepted.ReviewedBy)
+		assert.NotNil(t, accepted.ReviewedAt)
+
+		_, err = songService.AcceptMetadataProposal(ctx, proposals[3].ID, "admin")
+		require.NoError(t, err)
+
+		song, err := songService.GetSingleSong(ctx, songID, "prp")
+		require.NoError(t, err)
+		assert.Equal(t, "New Artist", song.Artist)
+		assert.Equal(t, "180", song.BPM)
+		for _, c := range song.Charts {
+			if c.ID == massiveID {
+				assert.Equal(t, "Cover_massive.jpg", *c.OverrideCover)
+				assert.Equal(t, 15.0, c.Level, "levels are never synced")
+			}
+		}
+
+		history, err := songService.GetSongHistory(ctx, songID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		for _, h := range history {
+			assert.Equal(t, "admin", h.ChangedBy)
+		}
+
+		_, err = songService.AcceptMetadataProposal(ctx, proposals[0].ID, "admin")
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = songService.AcceptMetadataProposal(ctx, 9999, "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Stale proposals conflict", func(t *testing.T) {
+		_, err := songService.UpdateSong(ctx, &request.UpdateSongRequest{
+			ID: songID,
+			SongBase: model.SongBase{WikiID: "synced", Title: "Synced", Artist: "New Artist",
+				Illustrator: "Old Illustrator", BPM: "190", Cover: "Cover_old.jpg"},
+			Charts: []model.ChartInput{
+				{Difficulty: model.DifficultyInvaded, Level: 12.0, Notes: 700},
+				{Difficulty: model.DifficultyMassive, Level: 15.0, Notes: 1000, LevelDesign: "LD",
+					SongBaseOverride: model.SongBaseOverride{OverrideCover: ptr("Cover_massive.jpg")}},
+			},
+		}, "editor")
+		require.NoError(t, err)
+
+		_, err = songService.AcceptMetadataProposal(ctx, proposals[1].ID, "admin")
+		assert.ErrorIs(t, err, ErrConflict)
+		pending, err := songService.GetMetadataProposals(ctx, model.ProposalStatusPending, songID)
+		require.NoError(t, err)
+		assert.Len(t, pending, 2, "a failed accept leaves the proposal pending")
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		rejected, err := songService.RejectMetadataProposal(ctx, proposals[2].ID, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, model.ProposalStatusRejected, rejected.Status)
+
+		_, err = songService.RejectMetadataProposal(ctx, proposals[2].ID, "admin")
+		assert.ErrorIs(t, err, ErrConflict)
+		_, err = songService.RejectMetadataProposal(ctx, 9999, "admin")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		song, err := songService.GetSingleSong(ctx, songID, "prp")
+		require.NoError(t, err)
+		assert.Equal(t, "Cover_old.jpg", song.Cover)
+	})
+
+	t.Run("Provider errors", func(t *testing.T) {
+		disabled := NewSongService(songRepo, recordRepo, nil)
+		_, err := disabled.GetSingleSong(ctx, songID, "wiki")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+		_, err = disabled.SyncSongFromWiki(ctx, songID)
+		assert.Error(t, err)
+
+		broken := NewSongService(songRepo, recordRepo, brokenWiki{})
+		_, err = broken.GetSingleSong(ctx, songID, "wiki")
+		assert.ErrorIs(t, err, ErrUpstream)
+		_, err = broken.SyncSongFromWiki(ctx, songID)
+		assert.ErrorIs(t, err, ErrUpstream)
+	})
+}
@@ -44,9 +44,22 @@ func InitDB() {
 		&model.Chart{},
 		&model.PlayRecord{},
 		&model.BestPlayRecord{},
-		// chart_statistics is owned by the fitting-calculator microservice (cmd/fitting);
-		// migrating it here ensures the schema exists regardless of which binary starts first.
+		&model.SongAlias{},
+		&model.ChartHistory{},
+		&model.SongMetadataProposal{},
+		&model.Season{},
+		&model.SeasonSong{},
+		&model.ChartVote{},
+		&model.ChartTag{},
+		// chart_statistics, the fitting run history and outlier reports, the player-skill snapshots and the fitting lease are owned by the fitting-calculator
+		// microservice (cmd/fitting); migrating them here ensures the schema exists regardless
+		// of which binary starts first.
 		&model.ChartStatistic{},
+		&model.FittingRun{},
+		&model.FittingRunChart{},
+		&model.FittingRunOutlier{},
+		&model.FittingPlayerSkill{},
+		&model.FittingLease{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
@@ -0,0 +1,47 @@
+package wiki
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"paradigm-reboot-prober-go/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider serves song metadata from a local fixture file. The file is a
+// YAML (or JSON) document with a top-level `songs` list, read once at startup.
+type FileProvider struct {
+	songs map[string]model.WikiSong
+}
+
+// NewFileProvider loads the fixture at path.
+func NewFileProvider(path string) (*FileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wiki fixture: %w", err)
+	}
+	var fixture struct {
+		Songs []model.WikiSong `yaml:"songs"`
+	}
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse wiki fixture %s: %w", path, err)
+	}
+
+	p := &FileProvider{songs: make(map[string]model.WikiSong, len(fixture.Songs))}
+	for i, song := range fixture.Songs {
+		if song.WikiID == "" {
+			return nil, fmt.Errorf("wiki fixture %s: song #%d has no wiki_id", path, i+1)
+		}
+		p.songs[song.WikiID] = song
+	}
+	return p, nil
+}
+
+func (p *FileProvider) GetSong(ctx context.Context, wikiID string) (*model.WikiSong, error) {
+	song, ok := p.songs[wikiID]
+	if !ok {
+		return nil, ErrSongNotFound
+	}
+	return &song, nil
+}
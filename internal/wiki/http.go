@@ -0,0 +1,62 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"paradigm-reboot-prober-go/internal/model"
+	"strings"
+	"time"
+)
+
+// maxResponseSize caps how much of a wiki response is read.
+const maxResponseSize = 1 << 20 // 1 MB
+
+// HTTPProvider fetches song metadata as JSON from the community wiki.
+type HTTPProvider struct {
+	urlTemplate string
+	client      *http.Client
+}
+
+// NewHTTPProvider returns a provider that GETs urlTemplate with "{wiki_id}"
+// replaced by the (path-escaped) wiki_id of the song.
+func NewHTTPProvider(urlTemplate string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		urlTemplate: urlTemplate,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProvider) GetSong(ctx context.Context, wikiID string) (*model.WikiSong, error) {
+	u := strings.ReplaceAll(p.urlTemplate, "{wiki_id}", url.PathEscape(wikiID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("wiki request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrSongNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("wiki returned status %d for %s", resp.StatusCode, wikiID)
+	}
+
+	var song model.WikiSong
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&song); err != nil {
+		return nil, fmt.Errorf("invalid wiki response for %s: %w", wikiID, err)
+	}
+	if song.WikiID == "" {
+		song.WikiID = wikiID
+	}
+	return &song, nil
+}
@@ -0,0 +1,38 @@
+// Package wiki fetches song metadata from the community wiki.
+//
+// The SongMetadataProvider interface hides where the data comes from: the
+// HTTP provider talks to the live wiki, while the file provider serves a
+// local fixture for tests and offline use.
+package wiki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"paradigm-reboot-prober-go/config"
+	"paradigm-reboot-prober-go/internal/model"
+)
+
+// ErrSongNotFound is returned when the wiki has no page for the requested song.
+var ErrSongNotFound = errors.New("song not found on wiki")
+
+// SongMetadataProvider looks up wiki metadata by the song's wiki_id.
+type SongMetadataProvider interface {
+	GetSong(ctx context.Context, wikiID string) (*model.WikiSong, error)
+}
+
+// NewProvider builds the provider selected by the wiki section of the global
+// config. It returns nil (and no error) when no provider is configured.
+func NewProvider() (SongMetadataProvider, error) {
+	cfg := config.GlobalConfig.Wiki
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "http":
+		return NewHTTPProvider(cfg.BaseURL, config.WikiTimeoutDuration), nil
+	case "file":
+		return NewFileProvider(cfg.FixtureFile)
+	default:
+		return nil, fmt.Errorf("unsupported wiki provider: %q", cfg.Provider)
+	}
+}
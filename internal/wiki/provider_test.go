@@ -0,0 +1,86 @@
+package wiki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"paradigm-reboot-prober-go/internal/model"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/songs/felys":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"title":"Felys","artist":"Wiki Artist","bpm":"200",
+				"charts":[{"difficulty":"massive","level":14.8,"notes":1200,"cover":"Cover_alt.jpg"}]}`))
+		case "/api/songs/a%2Fb":
+			_, _ = w.Write([]byte(`{"wiki_id":"a/b","title":"Slash"}`))
+		case "/api/songs/broken":
+			_, _ = w.Write([]byte(`{`))
+		case "/api/songs/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL+"/api/songs/{wiki_id}", time.Second)
+	ctx := context.Background()
+
+	song, err := p.GetSong(ctx, "felys")
+	require.NoError(t, err)
+	assert.Equal(t, "felys", song.WikiID, "wiki_id defaults to the requested one")
+	assert.Equal(t, "Wiki Artist", song.Artist)
+	require.Len(t, song.Charts, 1)
+	assert.Equal(t, model.DifficultyMassive, song.Charts[0].Difficulty)
+	assert.Equal(t, "Cover_alt.jpg", song.Charts[0].Cover)
+
+	song, err = p.GetSong(ctx, "a/b")
+	require.NoError(t, err, "wiki_id is path-escaped")
+	assert.Equal(t, "Slash", song.Title)
+
+	_, err = p.GetSong(ctx, "missing")
+	assert.ErrorIs(t, err, ErrSongNotFound)
+
+	_, err = p.GetSong(ctx, "broken")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSongNotFound)
+
+	_, err = p.GetSong(ctx, "down")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSongNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	p, err := NewFileProvider(filepath.Join("testdata", "songs.yaml"))
+	require.NoError(t, err)
+
+	song, err := p.GetSong(context.Background(), "felys")
+	require.NoError(t, err)
+	assert.Equal(t, "Wiki Illustrator", song.Illustrator)
+	assert.Equal(t, "200", song.BPM)
+	require.Len(t, song.Charts, 1)
+	assert.Equal(t, 14.8, song.Charts[0].Level)
+
+	_, err = p.GetSong(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrSongNotFound)
+
+	t.Run("Invalid fixtures", func(t *testing.T) {
+		_, err := NewFileProvider(filepath.Join(t.TempDir(), "absent.yaml"))
+		assert.Error(t, err)
+
+		path := filepath.Join(t.TempDir(), "no_id.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("songs:\n  - title: No ID\n"), 0o644))
+		_, err = NewFileProvider(path)
+		assert.Error(t, err)
+	})
+}
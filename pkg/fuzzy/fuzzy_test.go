@@ -0,0 +1,112 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"ASCII lower-cased, punctuation dropped", "Hello, World!", "helloworld"},
+		{"Full-width ASCII", "ＡＢＣ１２３", "abc123"},
+		{"Katakana to hiragana", "カタカナ", "かたかな"},
+		{"Half-width katakana with dakuten", "ｶﾞｷﾞ", "がぎ"},
+		{"Han characters kept", "中文 歌曲", "中文歌曲"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Normalize(tt.input))
+		})
+	}
+}
+
+func TestToRomaji(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"さくら", "sakura"},
+		{"しゃしん", "shashin"},
+		{"きょう", "kyou"},
+		{"がっこう", "gakkou"},
+		{"まっちゃ", "matcha"},
+		{"ふじ", "fuji"},
+		{"らーめん", "ramen"},
+		{"abcあ", "abca"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, toRomaji(tt.input))
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	t.Run("Kana produces romaji", func(t *testing.T) {
+		keys := Keys("サクラ")
+		assert.Equal(t, []Key{
+			{Kind: KeyNormalized, Value: "さくら"},
+			{Kind: KeyRomaji, Value: "sakura"},
+		}, keys)
+	})
+
+	t.Run("Han produces pinyin and initials", func(t *testing.T) {
+		keys := Keys("中文歌曲")
+		assert.Contains(t, keys, Key{Kind: KeyPinyin, Value: "zhongwengequ"})
+		assert.Contains(t, keys, Key{Kind: KeyInitials, Value: "zwgq"})
+	})
+
+	t.Run("Latin has only the normalised key", func(t *testing.T) {
+		assert.Equal(t, []Key{{Kind: KeyNormalized, Value: "abc"}}, Keys("ABC"))
+	})
+
+	t.Run("Empty text", func(t *testing.T) {
+		assert.Nil(t, Keys(" !? "))
+	})
+}
+
+func TestQueryScore(t *testing.T) {
+	t.Run("Ordering of match classes", func(t *testing.T) {
+		q := NewQuery("night")
+		exact := q.Score("Night")
+		prefix := q.Score("Nightmare")
+		substring := q.Score("Starry Night Sky")
+		subsequence := q.Score("No Igloo Gets Hot Tonight") // n..i..g..h..t appear in order earlier
+		none := q.Score("Daybreak")
+
+		assert.Equal(t, 1.0, exact)
+		assert.Greater(t, exact, prefix)
+		assert.Greater(t, prefix, substring)
+		assert.Greater(t, substring, subsequence)
+		assert.Greater(t, subsequence, 0.0)
+		assert.Equal(t, 0.0, none)
+	})
+
+	t.Run("Romaji query matches kana title", func(t *testing.T) {
+		assert.Greater(t, NewQuery("sakura").Score("さくら"), 0.9)
+	})
+
+	t.Run("Katakana query matches hiragana title", func(t *testing.T) {
+		assert.Equal(t, 1.0, NewQuery("サクラ").Score("さくら"))
+	})
+
+	t.Run("Pinyin and initials match Chinese title", func(t *testing.T) {
+		assert.Greater(t, NewQuery("zhongwen").Score("中文歌曲"), 0.8)
+		assert.Greater(t, NewQuery("zwgq").Score("中文歌曲"), 0.8)
+	})
+
+	t.Run("Single-letter query does not match initials", func(t *testing.T) {
+		assert.Equal(t, 0.0, NewQuery("q").ScoreKeys([]Key{{Kind: KeyInitials, Value: "zwgq"}}))
+	})
+
+	t.Run("Empty query", func(t *testing.T) {
+		q := NewQuery("  ")
+		assert.True(t, q.Empty())
+		assert.Equal(t, 0.0, q.Score("anything"))
+	})
+}
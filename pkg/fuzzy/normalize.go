@@ -0,0 +1,191 @@
+// Package fuzzy provides text normalisation and ranking for song search.
+//
+// Titles in the catalog mix Latin, Japanese kana/kanji and Chinese, and users
+// type whatever their keyboard gives them: full-width letters, half-width
+// katakana, romaji for a kana title or pinyin (or just pinyin initials) for a
+// Chinese one. Keys expands a piece of text into every searchable form so a
+// query in any of those scripts can be matched with plain string comparisons.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds text into a canonical comparable form:
+//   - NFKC (full-width ASCII → ASCII, half-width katakana → full-width, composed dakuten)
+//   - katakana → hiragana
+//   - lower case
+//   - whitespace and punctuation removed
+func Normalize(s string) string {
+	s = norm.NFKC.String(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x30A1 && r <= 0x30F6: // katakana → hiragana
+			b.WriteRune(r - 0x60)
+		case r == 'ー' || unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// KeyKind identifies which transliteration produced a search key.
+type KeyKind int
+
+const (
+	KeyNormalized KeyKind = iota // Normalize(text)
+	KeyRomaji                    // kana transliterated to Hepburn romaji
+	KeyPinyin                    // Han characters transliterated to toneless pinyin
+	KeyInitials                  // first letter of each pinyin syllable
+)
+
+// Key is one searchable form of a piece of text.
+type Key struct {
+	Kind  KeyKind
+	Value string
+}
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Keys returns all searchable forms of text. The normalised form is always
+// first; transliterations are only included when they differ from it.
+func Keys(text string) []Key {
+	n := Normalize(text)
+	if n == "" {
+		return nil
+	}
+	keys := []Key{{Kind: KeyNormalized, Value: n}}
+	add := func(kind KeyKind, v string) {
+		if v == "" {
+			return
+		}
+		for _, k := range keys {
+			if k.Value == v {
+				return
+			}
+		}
+		keys = append(keys, Key{Kind: kind, Value: v})
+	}
+
+	if hasKana(n) {
+		add(KeyRomaji, toRomaji(n))
+	}
+	if hasHan(n) {
+		var full, initials strings.Builder
+		for _, r := range n {
+			if !unicode.Is(unicode.Han, r) {
+				full.WriteRune(r)
+				initials.WriteRune(r)
+				continue
+			}
+			py := pinyin.SinglePinyin(r, pinyinArgs)
+			if len(py) == 0 || py[0] == "" {
+				full.WriteRune(r)
+				initials.WriteRune(r)
+				continue
+			}
+			full.WriteString(py[0])
+			initials.WriteByte(py[0][0])
+		}
+		add(KeyPinyin, full.String())
+		add(KeyInitials, initials.String())
+	}
+	return keys
+}
+
+func hasKana(s string) bool {
+	for _, r := range s {
+		if r >= 0x3041 && r <= 0x3096 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// kanaRomaji maps single hiragana to Hepburn romaji.
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+}
+
+// smallYouon maps the small ya/yu/yo to the vowel part used after
+// sh/ch/j, e.g. し+ゃ → "sha" rather than "shiya".
+var smallYouon = map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}
+
+// toRomaji transliterates the hiragana in s to Hepburn romaji. Characters
+// that are not hiragana are copied unchanged. Long vowel marks are dropped
+// since users rarely type them.
+func toRomaji(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	geminate := false
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		if r == 'っ' {
+			geminate = true
+			continue
+		}
+		if r == 'ー' {
+			continue
+		}
+		syl, ok := kanaRomaji[r]
+		if !ok {
+			geminate = false
+			b.WriteRune(r)
+			continue
+		}
+		if i+1 < len(rs) {
+			if v, small := smallYouon[rs[i+1]]; small && len(syl) >= 2 && strings.HasSuffix(syl, "i") {
+				stem := syl[:len(syl)-1]
+				switch stem {
+				case "sh", "ch", "j":
+					syl = stem + v
+				default:
+					syl = stem + "y" + v
+				}
+				i++
+			}
+		}
+		if geminate {
+			if strings.HasPrefix(syl, "ch") {
+				b.WriteByte('t')
+			} else if c := syl[0]; c != 'a' && c != 'i' && c != 'u' && c != 'e' && c != 'o' && c != 'n' {
+				b.WriteByte(c)
+			}
+			geminate = false
+		}
+		b.WriteString(syl)
+	}
+	return b.String()
+}
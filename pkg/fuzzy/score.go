@@ -0,0 +1,120 @@
+package fuzzy
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Match quality, from best to worst. Scores are in (0, 1]; zero means no match.
+const (
+	scoreExact       = 1.0
+	scorePrefix      = 0.85
+	scoreSubstring   = 0.7
+	scoreSubsequence = 0.4
+)
+
+// kindWeight discounts matches on transliterated keys slightly, so that a
+// query typed in the original script wins ties against a romaji/pinyin hit.
+var kindWeight = map[KeyKind]float64{
+	KeyNormalized: 1.0,
+	KeyRomaji:     0.95,
+	KeyPinyin:     0.95,
+	KeyInitials:   0.9,
+}
+
+// Query is a pre-normalised search query.
+type Query struct {
+	norm string
+}
+
+// NewQuery normalises q once so it can be scored against many candidates.
+func NewQuery(q string) Query {
+	return Query{norm: Normalize(q)}
+}
+
+// Empty reports whether the query has no searchable characters.
+func (q Query) Empty() bool { return q.norm == "" }
+
+// Score returns how well the query matches text, in [0, 1].
+func (q Query) Score(text string) float64 {
+	return q.ScoreKeys(Keys(text))
+}
+
+// ScoreKeys is Score for keys precomputed with Keys.
+func (q Query) ScoreKeys(keys []Key) float64 {
+	if q.norm == "" {
+		return 0
+	}
+	best := 0.0
+	for _, k := range keys {
+		// Initials are only meaningful for short, multi-letter queries;
+		// a single letter would match nearly everything.
+		if k.Kind == KeyInitials && utf8.RuneCountInString(q.norm) < 2 {
+			continue
+		}
+		if s := matchScore(q.norm, k.Value) * kindWeight[k.Kind]; s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// matchScore grades how q matches candidate. Within a match class, shorter
+// candidates (i.e. the query covers more of the text) score higher.
+func matchScore(q, candidate string) float64 {
+	if candidate == "" {
+		return 0
+	}
+	ql := float64(utf8.RuneCountInString(q))
+	cl := float64(utf8.RuneCountInString(candidate))
+	coverage := ql / cl
+
+	switch {
+	case q == candidate:
+		return scoreExact
+	case strings.HasPrefix(candidate, q):
+		return scorePrefix + 0.1*coverage
+	case strings.Contains(candidate, q):
+		return scoreSubstring + 0.1*coverage
+	}
+	if ql < 2 {
+		return 0
+	}
+	if span := subsequenceSpan(q, candidate); span > 0 {
+		// Tighter spans mean the query letters are closer together.
+		return scoreSubsequence * ql / float64(span)
+	}
+	return 0
+}
+
+// subsequenceSpan returns the length (in runes) of the shortest window of
+// candidate that contains q as a subsequence when matched greedily from the
+// first possible start, or 0 if q is not a subsequence of candidate.
+func subsequenceSpan(q, candidate string) int {
+	qr := []rune(q)
+	cr := []rune(candidate)
+	best := 0
+	for start := range cr {
+		if cr[start] != qr[0] {
+			continue
+		}
+		j := 0
+		end := -1
+		for i := start; i < len(cr); i++ {
+			if cr[i] == qr[j] {
+				j++
+				if j == len(qr) {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break // no later start can succeed either
+		}
+		if span := end - start + 1; best == 0 || span < best {
+			best = span
+		}
+	}
+	return best
+}
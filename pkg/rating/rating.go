@@ -53,3 +53,28 @@ func SingleRating(level float64, score int) int {
 	// int_rating: int = int(rating * 100 + EPS)
 	return int(rating*100 + EPS)
 }
+
+// MaxScore is the highest score SingleRating distinguishes; higher scores are
+// capped to it.
+const MaxScore = 1010000
+
+// InverseScore returns the lowest score whose SingleRating on a chart of the
+// given level reaches rating (in the int×100 form SingleRating returns).
+// ok is false when even MaxScore falls short of it.
+func InverseScore(level float64, rating int) (score int, ok bool) {
+	if SingleRating(level, MaxScore) < rating {
+		return 0, false
+	}
+	// SingleRating never decreases with the score: search for the first
+	// score that reaches the target.
+	lo, hi := 0, MaxScore
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if SingleRating(level, mid) >= rating {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, true
+}
@@ -77,3 +77,22 @@ func TestSingleRating(t *testing.T) {
 		})
 	}
 }
+
+func TestInverseScore(t *testing.T) {
+	for _, level := range []float64{10.0, 14.7, 16.4} {
+		for _, score := range []int{800000, 950000, 995000, 1000000, 1005000, 1009000, 1009500, 1010000} {
+			target := SingleRating(level, score)
+			got, ok := InverseScore(level, target)
+			assert.True(t, ok, "level %v score %d", level, score)
+			assert.LessOrEqual(t, got, score, "level %v score %d", level, score)
+			assert.Equal(t, target, SingleRating(level, got), "level %v score %d", level, score)
+			assert.Less(t, SingleRating(level, got-1), target, "level %v score %d", level, score)
+		}
+	}
+
+	_, ok := InverseScore(10.0, SingleRating(10.0, MaxScore)+1)
+	assert.False(t, ok, "unreachable rating")
+	got, ok := InverseScore(10.0, 0)
+	assert.True(t, ok)
+	assert.Zero(t, got)
+}